@@ -26,7 +26,7 @@ import (
 
 var Command = &cobra.Command{
 	Use:   "rules",
-	Short: "Validate, list, or search detection rules",
+	Short: "Validate, test, list, or search detection rules",
 }
 
 var validateCmd = &cobra.Command{
@@ -35,6 +35,13 @@ var validateCmd = &cobra.Command{
 	RunE:  validate,
 }
 
+var testCmd = &cobra.Command{
+	Use:   "test [file...]",
+	Short: "Test rules against synthetic events declared in test case files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  test,
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List rules",
@@ -51,6 +58,7 @@ func init() {
 	cfg.MustViperize(Command)
 
 	Command.AddCommand(validateCmd)
+	Command.AddCommand(testCmd)
 
 	listCmd.PersistentFlags().BoolVarP(&summarized, "summary", "s", false, "Show rules summary by MITRE tactics and techniques")
 	Command.AddCommand(listCmd)
@@ -60,6 +68,10 @@ func validate(cmd *cobra.Command, args []string) error {
 	return validateRules()
 }
 
+func test(cmd *cobra.Command, args []string) error {
+	return testRules(args)
+}
+
 func list(cmd *cobra.Command, args []string) error {
 	return listRules()
 }
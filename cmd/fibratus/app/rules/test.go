@@ -0,0 +1,72 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rules
+
+import (
+	"fmt"
+	"github.com/enescakir/emoji"
+	"github.com/rabbitstack/fibratus/internal/bootstrap"
+	"github.com/rabbitstack/fibratus/pkg/filter/harness"
+	"strings"
+)
+
+func testRules(paths []string) error {
+	if err := bootstrap.InitConfigAndLogger(cfg); err != nil {
+		return err
+	}
+
+	tcs, err := harness.LoadTestCases(paths...)
+	if err != nil {
+		return fmt.Errorf("%v %v", emoji.DisappointedFace, err)
+	}
+	if len(tcs) == 0 {
+		return fmt.Errorf("%v no test cases found in %s", emoji.DisappointedFace, strings.Join(paths, ","))
+	}
+
+	var failed int
+	runner := harness.NewRunner(cfg)
+	for _, tc := range tcs {
+		res := runner.Run(tc)
+		switch {
+		case res.Err != nil:
+			failed++
+			emo("%v %s [%s]: %v\n", emoji.CrossMark, tc.Name, tc.File(), res.Err)
+		case !res.Passed():
+			failed++
+			if tc.Matches {
+				emo("%v %s [%s]: expected rule %q to fire, but it didn't", emoji.CrossMark, tc.Name, tc.File(), tc.Rule)
+			} else {
+				emo("%v %s [%s]: expected rule %q not to fire, but it did", emoji.CrossMark, tc.Name, tc.File(), tc.Rule)
+			}
+			if len(res.Fired) > 0 {
+				emo(" (fired rules: %s)", strings.Join(res.Fired, ", "))
+			}
+			emo("\n")
+		default:
+			emo("%v %s\n", emoji.CheckMarkButton, tc.Name)
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", len(tcs)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%v %d test(s) failed", emoji.DisappointedFace, failed)
+	}
+	emo("%v All tests passed!", emoji.Rocket)
+	return nil
+}
@@ -23,7 +23,9 @@ import (
 	"github.com/enescakir/emoji"
 	"github.com/rabbitstack/fibratus/internal/bootstrap"
 	"github.com/rabbitstack/fibratus/pkg/filter"
+	"github.com/rabbitstack/fibratus/pkg/filter/action"
 	"github.com/rabbitstack/fibratus/pkg/filter/fields"
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
 	"path/filepath"
 	"strings"
 )
@@ -53,6 +55,22 @@ func validateRules() error {
 		return fmt.Errorf("%v %v", emoji.DisappointedFace, err)
 	}
 
+	for _, l := range cfg.Filters.Lists.FromPaths {
+		paths, err := filepath.Glob(l)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			emo("%v Loading lookup table from %s\n", emoji.Scroll, path)
+		}
+	}
+	for _, url := range cfg.Filters.Lists.FromURLs {
+		emo("%v Loading lookup table from %s\n", emoji.Scroll, url)
+	}
+	if err := lists.Load(lists.WithPaths(cfg.Filters.Lists.FromPaths), lists.WithURLs(cfg.Filters.Lists.FromURLs)); err != nil {
+		return fmt.Errorf("%v %v", emoji.DisappointedFace, err)
+	}
+
 	for _, r := range cfg.Filters.Rules.FromPaths {
 		paths, err := filepath.Glob(r)
 		if err != nil {
@@ -72,15 +90,34 @@ func validateRules() error {
 		return fmt.Errorf("%v no rules found in %s", emoji.DisappointedFace, strings.Join(cfg.Filters.Rules.FromPaths, ","))
 	}
 
+	for _, e := range cfg.Filters.Exceptions.FromPaths {
+		paths, err := filepath.Glob(e)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if !isValidExt(path) {
+				continue
+			}
+			emo("%v Loading exceptions from %s\n", emoji.Shield, path)
+		}
+	}
+	if err := cfg.Filters.LoadExceptions(); err != nil {
+		return fmt.Errorf("%v %v", emoji.DisappointedFace, err)
+	}
+
 	warnings := make([]string, 0)
 	// validate rule for every group
 	for _, group := range cfg.GetRuleGroups() {
 		for _, rule := range group.Rules {
-			f := filter.New(rule.Condition, cfg)
+			f := filter.New(rule.Condition, cfg, filter.WithExceptions(cfg.Filters.GetExceptions(rule, group)...))
 			err := f.Compile()
 			if err != nil {
 				return fmt.Errorf("%v %v", emoji.DisappointedFace, filter.ErrInvalidFilter(rule.Name, group.Name, err))
 			}
+			if _, err := action.Decode(rule); err != nil {
+				return fmt.Errorf("%v %v", emoji.DisappointedFace, filter.ErrInvalidAction(rule.Name, err))
+			}
 			for _, fld := range f.GetFields() {
 				if isDeprecated, dep := fields.IsDeprecated(fld); isDeprecated {
 					warnings = append(warnings,
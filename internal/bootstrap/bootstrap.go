@@ -27,6 +27,8 @@ import (
 	"github.com/rabbitstack/fibratus/pkg/config"
 	"github.com/rabbitstack/fibratus/pkg/filament"
 	"github.com/rabbitstack/fibratus/pkg/filter"
+	"github.com/rabbitstack/fibratus/pkg/filter/action"
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
 	"github.com/rabbitstack/fibratus/pkg/handle"
 	"github.com/rabbitstack/fibratus/pkg/kcap"
 	"github.com/rabbitstack/fibratus/pkg/kstream"
@@ -139,6 +141,9 @@ func NewApp(cfg *config.Config, options ...Option) (*App, error) {
 		res   *config.RulesCompileResult
 	)
 	if cfg.Filters.Rules.Enabled {
+		action.SetCaptureWriterFactory(func(filename string) (action.CaptureWriter, error) {
+			return kcap.NewWriter(filename, psnap, hsnap)
+		})
 		rules = filter.NewRules(psnap, cfg)
 		var err error
 		res, err = rules.Compile()
@@ -265,7 +270,10 @@ func (f *App) Run(args []string) error {
 			f.consumer.Events(),
 			f.consumer.Errors(),
 			cfg.Aggregator,
-			cfg.Output,
+			cfg.Outputs,
+			func(expr string) (aggregator.Predicate, error) {
+				return filter.NewFromCLI([]string{expr}, cfg)
+			},
 			cfg.Transformers,
 			cfg.Alertsenders,
 		)
@@ -364,7 +372,10 @@ func (f *App) ReadCapture(ctx context.Context, args []string) error {
 			evts,
 			errs,
 			f.config.Aggregator,
-			f.config.Output,
+			f.config.Outputs,
+			func(expr string) (aggregator.Predicate, error) {
+				return filter.NewFromCLIWithAllAccessors([]string{expr})
+			},
 			f.config.Transformers,
 			f.config.Alertsenders,
 		)
@@ -428,6 +439,7 @@ func (f *App) Shutdown() error {
 			errs = append(errs, err)
 		}
 	}
+	lists.Close()
 	if err := handle.CloseTimeout(); err != nil {
 		errs = append(errs, err)
 	}
@@ -34,8 +34,13 @@ import (
 	_ "github.com/rabbitstack/fibratus/pkg/outputs/console"
 	_ "github.com/rabbitstack/fibratus/pkg/outputs/elasticsearch"
 	_ "github.com/rabbitstack/fibratus/pkg/outputs/eventlog"
+	_ "github.com/rabbitstack/fibratus/pkg/outputs/file"
 	_ "github.com/rabbitstack/fibratus/pkg/outputs/http"
+	_ "github.com/rabbitstack/fibratus/pkg/outputs/kafka"
 	_ "github.com/rabbitstack/fibratus/pkg/outputs/null"
+	_ "github.com/rabbitstack/fibratus/pkg/outputs/otlp"
+	_ "github.com/rabbitstack/fibratus/pkg/outputs/splunk"
+	_ "github.com/rabbitstack/fibratus/pkg/outputs/syslog"
 
 	// initialize alert senders
 	_ "github.com/rabbitstack/fibratus/pkg/alertsender/mail"
@@ -64,7 +69,8 @@ var (
 )
 
 // BufferedAggregator collects events from the inbound channel and produces batches on regular intervals. The batches
-// are pushed to the work queue from which load-balanced configured workers consume the batches and publish to the outputs.
+// are fanned out to the work queue of each output from which load-balanced workers consume the batches and publish
+// to the output. Every output can define a filter to receive only the subset of events.
 type BufferedAggregator struct {
 	kevtsc  <-chan *kevent.Kevent
 	errsc   <-chan error
@@ -72,8 +78,7 @@ type BufferedAggregator struct {
 	flusher *time.Ticker
 	// queue of inbound kernel events
 	kevts []*kevent.Kevent
-	// work queue that forwarder passes to outputs
-	wq         queue
+	// submitter dispatches batches to outputs
 	submitter  *submitter
 	transforms []transformers.Transformer
 	c          Config
@@ -84,7 +89,8 @@ func NewBuffered(
 	evts <-chan *kevent.Kevent,
 	errs <-chan error,
 	aggConfig Config,
-	outputConfig outputs.Config,
+	outputConfigs []outputs.Config,
+	compiler PredicateCompiler,
 	transformerConfigs []transformers.Config,
 	alertsenderConfigs []alertsender.Config,
 ) (*BufferedAggregator, error) {
@@ -96,14 +102,13 @@ func NewBuffered(
 		kevtsc:  evts,
 		kevts:   make([]*kevent.Kevent, 0),
 		errsc:   errs,
-		stop:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
 		flusher: time.NewTicker(flushInterval),
-		wq:      make(chan *kevent.Batch),
 		c:       aggConfig,
 	}
 
 	var err error
-	agg.submitter, err = newSubmitter(agg.wq, outputConfig)
+	agg.submitter, err = newSubmitter(outputConfigs, aggConfig, compiler)
 	if err != nil {
 		return nil, err
 	}
@@ -127,35 +132,22 @@ func (agg *BufferedAggregator) Stop() error {
 	agg.stop <- struct{}{}
 
 	// flush enqueued events
+	var err error
 	b := kevent.NewBatch(agg.kevts...)
-	if b.Len() > 0 {
-		done := make(chan struct{}, 1)
-		go func() {
-			agg.wq <- b
-			done <- struct{}{}
-		}()
-
-		select {
-		case <-done:
-			close(agg.wq)
-		case <-time.After(agg.c.FlushTimeout):
-			return errors.New("fail to flush events after stop timed out")
-		}
+	if b.Len() > 0 && !agg.submitter.submit(b, agg.c.FlushTimeout) {
+		err = errors.New("fail to flush events after stop timed out")
 	}
 
-	// sleep a bit before closing the clients
-	time.Sleep(time.Millisecond * 150)
-
-	err := agg.submitter.shutdown()
-	if err != nil {
-		return err
+	// wait for outputs to publish queued batches before closing the clients
+	if serr := agg.submitter.shutdown(agg.c.FlushTimeout); serr != nil {
+		return serr
 	}
 
-	return nil
+	return err
 }
 
 // run starts the aggregator loop. The aggregator receives event stream from the upstream channel, buffers
-// them to intermediate queue and dispatches batches to downstream worker queue.
+// them to intermediate queue and dispatches batches to downstream output queues.
 func (agg *BufferedAggregator) run() {
 	for {
 		select {
@@ -169,9 +161,9 @@ func (agg *BufferedAggregator) run() {
 			b := kevent.NewBatch(agg.kevts...)
 			l := b.Len()
 			batchEvents.Add(l)
-			// push the batch to the work queue
+			// push the batch to the output queues
 			if l > 0 {
-				agg.wq <- b
+				agg.submitter.submit(b, agg.c.OutputQueueTimeout)
 			}
 			flushesCount.Add(1)
 			// clear the queue
@@ -38,7 +38,8 @@ func TestNewBufferedAggregator(t *testing.T) {
 		keventsc,
 		errsc,
 		Config{FlushPeriod: time.Millisecond * 200},
-		outputs.Config{Type: outputs.Console, Output: console.Config{Format: "pretty"}},
+		[]outputs.Config{{Type: outputs.Console, Output: console.Config{Format: "pretty"}}},
+		nil,
 		nil,
 		nil,
 	)
@@ -21,12 +21,21 @@ package aggregator
 import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
 	"time"
 )
 
 const (
 	flushPeriod  = "aggregator.flush-period"
 	flushTimeout = "aggregator.flush-timeout"
+	queueSize    = "aggregator.output-queue-size"
+	queueTimeout = "aggregator.output-queue-timeout"
+
+	spoolEnabled = "aggregator.spool.enabled"
+	spoolPath    = "aggregator.spool.path"
+	spoolMaxSize = "aggregator.spool.max-size"
+	spoolMaxAge  = "aggregator.spool.max-age"
 )
 
 // Config contains aggregator-specific configuration tweaks.
@@ -35,16 +44,46 @@ type Config struct {
 	FlushPeriod time.Duration `json:"aggregator.flush-period" yaml:"aggregator.flush-period"`
 	// FlushTimeout represents the max time to wait before announcing failed flushing of enqueued events
 	FlushTimeout time.Duration `json:"aggregator.flush-timeout" yaml:"aggregator.flush-timeout"`
+	// OutputQueueSize is the max number of batches waiting to be published to each output
+	OutputQueueSize int `json:"aggregator.output-queue-size" yaml:"aggregator.output-queue-size"`
+	// OutputQueueTimeout is the max time to wait for full output queues to free up
+	OutputQueueTimeout time.Duration `json:"aggregator.output-queue-timeout" yaml:"aggregator.output-queue-timeout"`
+	// Spool contains the settings of the on-disk spool for batches that failed to publish
+	Spool SpoolConfig `json:"aggregator.spool" yaml:"aggregator.spool"`
+}
+
+// SpoolConfig contains the settings of the on-disk spool.
+type SpoolConfig struct {
+	// Enabled indicates if failed batches are persisted to disk and replayed once the output recovers
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Path is the directory where spooled batches are stored
+	Path string `json:"path" yaml:"path"`
+	// MaxSize is the maximum size in megabytes of the spool for each output client
+	MaxSize int `json:"max-size" yaml:"max-size"`
+	// MaxAge determines the maximum time spooled batches are retained
+	MaxAge time.Duration `json:"max-age" yaml:"max-age"`
 }
 
 // AddFlags registers persistent aggregator flags.
 func AddFlags(flags *pflag.FlagSet) {
 	flags.Duration(flushPeriod, time.Millisecond*200, "Determines the period for flushing batches to outputs")
 	flags.Duration(flushTimeout, time.Second*4, "Represents the max time to wait before announcing failed flushing of enqueued events on aggregator shutdown")
+	flags.Bool(spoolEnabled, false, "Indicates if batches that failed to publish are persisted to disk and replayed once the output recovers")
+	flags.String(spoolPath, filepath.Join(os.Getenv("PROGRAMFILES"), "fibratus", "spool"), "Specifies the directory where spooled batches are stored")
+	flags.Int(spoolMaxSize, 512, "Specifies the maximum size in megabytes of the spool for each output client. The oldest batches are dropped when the spool is full")
+	flags.Duration(spoolMaxAge, time.Hour*24, "Determines the maximum time spooled batches are retained")
+	flags.Int(queueSize, 100, "Specifies the max number of batches waiting to be published to each output. Batches are dropped when the output queue is full")
+	flags.Duration(queueTimeout, time.Second, "Specifies the max time to wait for the full output queue to free up. Batches are spooled, if the spool is enabled, or dropped once the timeout elapses")
 }
 
 // InitFromViper initializes aggregator flags from viper.
 func (c *Config) InitFromViper(v *viper.Viper) {
 	c.FlushPeriod = v.GetDuration(flushPeriod)
 	c.FlushTimeout = v.GetDuration(flushTimeout)
+	c.OutputQueueSize = v.GetInt(queueSize)
+	c.OutputQueueTimeout = v.GetDuration(queueTimeout)
+	c.Spool.Enabled = v.GetBool(spoolEnabled)
+	c.Spool.Path = v.GetString(spoolPath)
+	c.Spool.MaxSize = v.GetInt(spoolMaxSize)
+	c.Spool.MaxAge = v.GetDuration(spoolMaxAge)
 }
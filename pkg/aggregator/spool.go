@@ -0,0 +1,318 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kcap/section"
+	kcapver "github.com/rabbitstack/fibratus/pkg/kcap/version"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"github.com/rabbitstack/fibratus/pkg/util/bytes"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// batchesSpooled counts the batches persisted to the spool per output
+	batchesSpooled = expvar.NewMap("aggregator.spool.batches.spooled")
+	// batchesReplayed counts the spooled batches successfully published per output
+	batchesReplayed = expvar.NewMap("aggregator.spool.batches.replayed")
+	// spoolBatchesDropped counts the spooled batches dropped per output either because the
+	// spool size is exceeded, the batch is expired, or the batch couldn't be read
+	spoolBatchesDropped = expvar.NewMap("aggregator.spool.batches.dropped")
+)
+
+const (
+	// spoolMagic identifies spool segment files
+	spoolMagic = "FSPL"
+	// spoolVersion is the current version of the segment format
+	spoolVersion uint16 = 1
+	// segmentExt is the file extension of spool segments
+	segmentExt = ".spool"
+)
+
+// errSpoolCorrupted signals the spool segment can't be decoded
+var errSpoolCorrupted = func(name string, err error) error { return fmt.Errorf("corrupted spool segment %s: %v", name, err) }
+
+// segment represents a single spooled batch stored on disk.
+type segment struct {
+	seq  uint64
+	size int64
+	path string
+	ts   time.Time
+}
+
+// spool is the on-disk write-ahead queue that retains the batches the output client
+// failed to publish. Each batch is stored in its own segment file. Segments are named
+// after the monotonically increasing sequence number, so they are replayed in the
+// same order they were spooled, even across restarts.
+type spool struct {
+	mu       sync.Mutex
+	output   string
+	dir      string
+	maxSize  int64
+	maxAge   time.Duration
+	size     int64
+	seq      uint64
+	segments []segment
+}
+
+// newSpool opens the spool in the specified directory and loads segments persisted
+// in previous runs. Leftovers of partially written segments are removed.
+func newSpool(output, dir string, maxSize int64, maxAge time.Duration) (*spool, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create spool directory: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &spool{output: output, dir: dir, maxSize: maxSize, maxAge: maxAge, segments: make([]segment, 0)}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if filepath.Ext(path) != segmentExt {
+			// remove segments that weren't completely written
+			if strings.HasSuffix(path, segmentExt+".tmp") {
+				_ = os.Remove(path)
+			}
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(entry.Name(), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, segment{seq: seq, size: info.Size(), path: path, ts: info.ModTime()})
+		s.size += info.Size()
+		if seq > s.seq {
+			s.seq = seq
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	if len(s.segments) > 0 {
+		log.Infof("found %d spooled batches for %s output", len(s.segments), output)
+	}
+	return s, nil
+}
+
+// len returns the number of spooled batches.
+func (s *spool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.segments)
+}
+
+// write persists the batch to a new segment file. The file is first written under
+// a temporary name and renamed when all data is flushed to disk. If the spool size
+// cap is exceeded, the oldest segments are dropped to make room for the batch.
+func (s *spool) write(b *kevent.Batch) error {
+	buf := encodeBatch(b)
+	size := int64(len(buf))
+	if s.maxSize > 0 && size > s.maxSize {
+		spoolBatchesDropped.Add(s.output, 1)
+		return fmt.Errorf("batch size %d exceeds spool capacity", size)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.maxSize > 0 && s.size+size > s.maxSize && len(s.segments) > 0 {
+		s.drop(s.segments[0])
+		s.segments = s.segments[1:]
+	}
+
+	s.seq++
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.seq, segmentExt))
+	if err := writeSegment(path, buf); err != nil {
+		return err
+	}
+	s.segments = append(s.segments, segment{seq: s.seq, size: size, path: path, ts: time.Now()})
+	s.size += size
+	batchesSpooled.Add(s.output, 1)
+
+	return nil
+}
+
+// peek returns the oldest spooled batch without removing it from the spool.
+// Expired and corrupted segments are dropped. If the spool is empty, nil is
+// returned.
+func (s *spool) peek() *kevent.Batch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.segments) > 0 {
+		seg := s.segments[0]
+		if s.maxAge > 0 && time.Since(seg.ts) > s.maxAge {
+			s.drop(seg)
+			s.segments = s.segments[1:]
+			continue
+		}
+		buf, err := os.ReadFile(seg.path)
+		if err == nil {
+			var b *kevent.Batch
+			b, err = decodeBatch(buf)
+			if err == nil {
+				return b
+			}
+			err = errSpoolCorrupted(seg.path, err)
+		}
+		log.Warnf("dropping spooled batch: %v", err)
+		s.drop(seg)
+		s.segments = s.segments[1:]
+	}
+	return nil
+}
+
+// pop removes the oldest segment from the spool.
+func (s *spool) pop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.segments) == 0 {
+		return nil
+	}
+	seg := s.segments[0]
+	s.segments = s.segments[1:]
+	s.size -= seg.size
+	return os.Remove(seg.path)
+}
+
+// drop removes the segment and accounts it as dropped.
+// The caller is responsible for removing the segment
+// from the list of segments.
+func (s *spool) drop(seg segment) {
+	s.size -= seg.size
+	spoolBatchesDropped.Add(s.output, 1)
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("unable to remove spool segment %s: %v", seg.path, err)
+	}
+}
+
+func writeSegment(path string, buf []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// encodeBatch serializes the batch to the segment format. The segment starts with
+// the magic, format version, and the number of events. Each event is stored in the
+// capture raw format followed by the optional raw process state.
+func encodeBatch(b *kevent.Batch) []byte {
+	buf := make([]byte, 0)
+	buf = append(buf, spoolMagic...)
+	buf = append(buf, bytes.WriteUint16(spoolVersion)...)
+	buf = append(buf, bytes.WriteUint32(uint32(len(b.Events)))...)
+	for _, evt := range b.Events {
+		raw := evt.MarshalRaw()
+		buf = append(buf, bytes.WriteUint32(uint32(len(raw)))...)
+		buf = append(buf, raw...)
+		if evt.PS == nil {
+			buf = append(buf, bytes.WriteUint32(0)...)
+			continue
+		}
+		ps := evt.PS.Marshal()
+		buf = append(buf, bytes.WriteUint32(uint32(len(ps)))...)
+		buf = append(buf, ps...)
+	}
+	return buf
+}
+
+// decodeBatch recovers the batch from the segment.
+func decodeBatch(buf []byte) (*kevent.Batch, error) {
+	if len(buf) < 10 || string(buf[:4]) != spoolMagic {
+		return nil, errors.New("invalid segment header")
+	}
+	if ver := bytes.ReadUint16(buf[4:]); ver != spoolVersion {
+		return nil, fmt.Errorf("unsupported segment version %d", ver)
+	}
+	n := bytes.ReadUint32(buf[6:])
+	// each event takes at least the two length prefixes
+	if int64(n)*8 > int64(len(buf)-10) {
+		return nil, errors.New("invalid number of events")
+	}
+	off := 10
+	next := func() ([]byte, error) {
+		if len(buf)-off < 4 {
+			return nil, errors.New("unexpected end of segment")
+		}
+		l := int64(bytes.ReadUint32(buf[off:]))
+		off += 4
+		if int64(len(buf)-off) < l {
+			return nil, errors.New("unexpected end of segment")
+		}
+		b := buf[off : off+int(l)]
+		off += int(l)
+		return b, nil
+	}
+	psec := section.New(section.Process, kcapver.ProcessSecV3, 0, 0)
+	evts := make([]*kevent.Kevent, 0, n)
+	for i := uint32(0); i < n; i++ {
+		raw, err := next()
+		if err != nil {
+			return nil, err
+		}
+		evt, err := kevent.NewFromKcap(raw, kcapver.KevtSecV2)
+		if err != nil {
+			return nil, err
+		}
+		raw, err = next()
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) > 0 {
+			evt.PS, err = pstypes.NewFromKcap(raw, psec)
+			if err != nil {
+				return nil, err
+			}
+		}
+		evts = append(evts, evt)
+	}
+	return kevent.NewBatch(evts...), nil
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"github.com/rabbitstack/fibratus/pkg/util/bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSpoolBatch(pids ...uint32) *kevent.Batch {
+	evts := make([]*kevent.Kevent, 0, len(pids))
+	for _, pid := range pids {
+		evts = append(evts, &kevent.Kevent{
+			Type:      ktypes.CreateFile,
+			Tid:       2484,
+			PID:       pid,
+			CPU:       1,
+			Name:      "CreateFile",
+			Category:  ktypes.File,
+			Timestamp: time.Now(),
+			Kparams: kevent.Kparams{
+				kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\system32\\user32.dll"},
+			},
+			Metadata: map[kevent.MetadataKey]any{kevent.RuleNameKey: "Suspicious DLL loaded"},
+			PS: &pstypes.PS{
+				PID:     pid,
+				Ppid:    4,
+				Name:    "cmd.exe",
+				Exe:     "C:\\Windows\\system32\\cmd.exe",
+				Cmdline: "cmd.exe /c dir",
+				Args:    []string{"/c", "dir"},
+				Envs:    map[string]string{"ProgramData": "C:\\ProgramData"},
+			},
+		})
+	}
+	return kevent.NewBatch(evts...)
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := newSpool("http", dir, 0, time.Hour)
+	require.NoError(t, err)
+	require.Nil(t, s.peek())
+
+	require.NoError(t, s.write(newSpoolBatch(100, 101)))
+	require.NoError(t, s.write(newSpoolBatch(200)))
+	require.Equal(t, 2, s.len())
+
+	// segments survive restarts and are replayed in order
+	s, err = newSpool("http", dir, 0, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 2, s.len())
+
+	b := s.peek()
+	require.NotNil(t, b)
+	require.Len(t, b.Events, 2)
+	assert.Equal(t, uint32(100), b.Events[0].PID)
+	assert.Equal(t, "C:\\Windows\\system32\\user32.dll", b.Events[0].GetParamAsString(kparams.FileName))
+	assert.Equal(t, "Suspicious DLL loaded", b.Events[0].GetMetaAsString(kevent.RuleNameKey))
+	require.NotNil(t, b.Events[0].PS)
+	assert.Equal(t, "cmd.exe /c dir", b.Events[0].PS.Cmdline)
+	require.NoError(t, s.pop())
+
+	b = s.peek()
+	require.NotNil(t, b)
+	assert.Equal(t, uint32(200), b.Events[0].PID)
+	require.NoError(t, s.pop())
+
+	require.Nil(t, s.peek())
+	require.Equal(t, 0, s.len())
+	assert.Equal(t, int64(0), s.size)
+}
+
+func TestSpoolMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	size := int64(len(encodeBatch(newSpoolBatch(100))))
+
+	s, err := newSpool("amqp", dir, size*2, time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, s.write(newSpoolBatch(100)))
+	require.NoError(t, s.write(newSpoolBatch(200)))
+	require.NoError(t, s.write(newSpoolBatch(300)))
+	require.Equal(t, 2, s.len())
+	assert.Equal(t, "1", spoolBatchesDropped.Get("amqp").String())
+
+	// the oldest batch is evicted
+	b := s.peek()
+	require.NotNil(t, b)
+	assert.Equal(t, uint32(200), b.Events[0].PID)
+
+	// batches larger than the spool are rejected
+	require.Error(t, s.write(newSpoolBatch(1, 2, 3)))
+}
+
+func TestSpoolExpiredAndCorruptedSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := newSpool("eventlog", dir, 0, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, s.write(newSpoolBatch(100)))
+	require.NoError(t, s.write(newSpoolBatch(200)))
+	require.NoError(t, s.write(newSpoolBatch(300)))
+	s.segments[0].ts = time.Now().Add(-time.Hour)
+	require.NoError(t, os.WriteFile(s.segments[1].path, []byte("garbage"), os.ModePerm))
+
+	b := s.peek()
+	require.NotNil(t, b)
+	assert.Equal(t, uint32(300), b.Events[0].PID)
+	assert.Equal(t, 1, s.len())
+	assert.Equal(t, "2", spoolBatchesDropped.Get("eventlog").String())
+
+	// partially written segments are removed on startup
+	tmp := filepath.Join(dir, "00000000000000000009.spool.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte("FSPL"), os.ModePerm))
+	s, err = newSpool("eventlog", dir, 0, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.len())
+	assert.NoFileExists(t, tmp)
+}
+
+func TestDecodeCorruptedBatch(t *testing.T) {
+	header := append([]byte(spoolMagic), bytes.WriteUint16(spoolVersion)...)
+	var tests = []struct {
+		name string
+		buf  []byte
+	}{
+		{"truncated header", []byte("FSPL")},
+		{"too many events", append(header, bytes.WriteUint32(math.MaxUint32)...)},
+		{"wrapping event length", append(append(header, bytes.WriteUint32(1)...), append(bytes.WriteUint32(math.MaxUint32), make([]byte, 8)...)...)},
+		{"truncated event", append(append(header, bytes.WriteUint32(1)...), append(bytes.WriteUint32(32), make([]byte, 8)...)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeBatch(tt.buf)
+			require.Error(t, err)
+		})
+	}
+}
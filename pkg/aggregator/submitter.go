@@ -19,38 +19,212 @@
 package aggregator
 
 import (
+	"context"
+	"expvar"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/rabbitstack/fibratus/pkg/kevent"
 	"github.com/rabbitstack/fibratus/pkg/outputs"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// batchesDropped counts the batches dropped per output due to full queue
+	batchesDropped = expvar.NewMap("aggregator.output.batches.dropped")
+	// eventsFiltered counts the events rejected per output by the output filter
+	eventsFiltered = expvar.NewMap("aggregator.output.events.filtered")
+	// batchesOverflowed counts the batches spooled per output due to full queue
+	batchesOverflowed = expvar.NewMap("aggregator.output.batches.overflowed")
 )
 
+// defaultQueueSize is the default capacity of the output queue
+const defaultQueueSize = 100
+
 // queue defines the type alias for the batch worker queue
 type queue chan *kevent.Batch
 
-// submitter initializes a group of load balanced output producers.
-type submitter struct {
-	wq      queue
+// Predicate decides whether the event is routed to the output.
+type Predicate interface {
+	Run(kevt *kevent.Kevent) bool
+}
+
+// PredicateCompiler compiles the output filter expression into the predicate.
+type PredicateCompiler func(expr string) (Predicate, error)
+
+// output keeps the work queue and a group of load balanced
+// workers that publish batches to the output clients. Each
+// output has its own queue, so a slow or unavailable output
+// doesn't stall the rest of outputs.
+type output struct {
+	name    string
+	qu      queue
+	filter  Predicate
 	workers []*worker
+	// overflow is the worker that spools batches not fitting
+	// in the full queue. It is nil if spooling is disabled
+	overflow *worker
+}
+
+// selectEvents returns the events that satisfy the output filter.
+func (o *output) selectEvents(evts []*kevent.Kevent) []*kevent.Kevent {
+	if o.filter == nil {
+		return evts
+	}
+	selected := make([]*kevent.Kevent, 0, len(evts))
+	for _, evt := range evts {
+		if o.filter.Run(evt) {
+			selected = append(selected, evt)
+		}
+	}
+	if n := len(evts) - len(selected); n > 0 {
+		eventsFiltered.Add(o.name, int64(n))
+	}
+	return selected
 }
 
-func newSubmitter(wq queue, outputConfig outputs.Config) (*submitter, error) {
-	output, err := outputs.Load(outputConfig.Type, outputConfig)
-	if err != nil {
-		return nil, err
+// enqueue pushes the batch to the output queue. If the deadline is nil, the batch
+// is dropped right away when the queue is full. Otherwise, enqueue waits for the
+// queue to free up until the deadline is reached. If the spool is enabled, the
+// batch that doesn't fit in the queue is spooled instead of being dropped.
+func (o *output) enqueue(b *kevent.Batch, deadline <-chan struct{}) bool {
+	select {
+	case o.qu <- b:
+		return true
+	default:
 	}
-	clients := output.Clients
-	workers := make([]*worker, len(clients))
+	if deadline != nil {
+		select {
+		case o.qu <- b:
+			return true
+		case <-deadline:
+		}
+	}
+	if o.overflow != nil && o.overflow.spill(b) {
+		batchesOverflowed.Add(o.name, 1)
+		b.Release()
+		return true
+	}
+	batchesDropped.Add(o.name, 1)
+	b.Release()
+	return false
+}
 
-	for i, client := range clients {
-		workers[i] = initWorker(wq, client)
+// submitter dispatches batches to all active outputs.
+type submitter struct {
+	outputs []*output
+}
+
+func newSubmitter(outputConfigs []outputs.Config, c Config, compiler PredicateCompiler) (*submitter, error) {
+	queueSize := c.OutputQueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
 	}
+	s := &submitter{outputs: make([]*output, 0, len(outputConfigs))}
+	for _, config := range outputConfigs {
+		o := &output{name: config.Type.String(), qu: make(queue, queueSize)}
+		if config.Filter != "" {
+			if compiler == nil {
+				return nil, fmt.Errorf("%s output filter can't be compiled", o.name)
+			}
+			var err error
+			o.filter, err = compiler(config.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s output filter: %v", o.name, err)
+			}
+		}
+		out, err := outputs.Load(config.Type, config)
+		if err != nil {
+			return nil, err
+		}
+		for i, client := range out.Clients {
+			var spool *spool
+			if c.Spool.Enabled {
+				dir := filepath.Join(c.Spool.Path, o.name, strconv.Itoa(i))
+				spool, err = newSpool(o.name, dir, int64(c.Spool.MaxSize)*1024*1024, c.Spool.MaxAge)
+				if err != nil {
+					return nil, err
+				}
+			}
+			o.workers = append(o.workers, initWorker(o.name, o.qu, client, spool))
+		}
+		if c.Spool.Enabled && len(o.workers) > 0 {
+			o.overflow = o.workers[0]
+		}
+		s.outputs = append(s.outputs, o)
+	}
+	return s, nil
+}
 
-	return &submitter{wq: wq, workers: workers}, nil
+// submit fans out the batch to the outputs. Each output receives
+// the events matching its filter. Events are returned to the pool
+// once all outputs are done with the batch. Outputs with full queues
+// share the same timeout, so the submission doesn't take longer than
+// the timeout regardless of the number of outputs. Returns false if
+// the batch was dropped by any of the outputs.
+func (s *submitter) submit(b *kevent.Batch, timeout time.Duration) bool {
+	targets := make([]*output, 0, len(s.outputs))
+	subsets := make([][]*kevent.Kevent, 0, len(s.outputs))
+	for _, o := range s.outputs {
+		evts := o.selectEvents(b.Events)
+		if len(evts) == 0 {
+			continue
+		}
+		targets = append(targets, o)
+		subsets = append(subsets, evts)
+	}
+	var deadline <-chan struct{}
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		deadline = ctx.Done()
+	}
+	ok := true
+	for i, batch := range b.Share(subsets...) {
+		if !targets[i].enqueue(batch, deadline) {
+			log.Warnf("%s output queue is full. Dropping batch of %d events", targets[i].name, batch.Len())
+			ok = false
+		}
+	}
+	return ok
 }
 
-func (s *submitter) shutdown() error {
-	for _, w := range s.workers {
-		if err := w.close(); err != nil {
-			return err
+// shutdown closes the output queues and waits for the workers to publish
+// queued batches before the clients are closed. Workers that don't drain
+// the queue within the timeout, for example, because the client is not
+// connected, are not waited for.
+func (s *submitter) shutdown(timeout time.Duration) error {
+	for _, o := range s.outputs {
+		close(o.qu)
+	}
+	var wg sync.WaitGroup
+	for _, o := range s.outputs {
+		for _, w := range o.workers {
+			wg.Add(1)
+			go func(w *worker) {
+				defer wg.Done()
+				<-w.done
+			}(w)
+		}
+	}
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		log.Warnf("outputs didn't publish queued batches within %v", timeout)
+	}
+	for _, o := range s.outputs {
+		for _, w := range o.workers {
+			if err := w.close(); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2019-2020 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pidPredicate uint32
+
+func (p pidPredicate) Run(kevt *kevent.Kevent) bool { return kevt.PID == uint32(p) }
+
+func TestSubmitterFanOut(t *testing.T) {
+	es := &output{name: "elasticsearch", qu: make(queue, 2)}
+	amqp := &output{name: "amqp", qu: make(queue, 2), filter: pidPredicate(859)}
+	s := &submitter{outputs: []*output{es, amqp}}
+
+	kevt1 := &kevent.Kevent{Type: ktypes.CreateProcess, Name: "CreateProcess", PID: 859}
+	kevt2 := &kevent.Kevent{Type: ktypes.CreateFile, Name: "CreateFile", PID: 4}
+
+	require.True(t, s.submit(kevent.NewBatch(kevt1, kevt2), 0))
+
+	require.Len(t, es.qu, 1)
+	require.Len(t, amqp.qu, 1)
+
+	b1 := <-es.qu
+	b2 := <-amqp.qu
+	assert.Equal(t, int64(2), b1.Len())
+	require.Equal(t, int64(1), b2.Len())
+	assert.Equal(t, uint32(859), b2.Events[0].PID)
+
+	// events are shared between outputs
+	b1.Release()
+	assert.Equal(t, "CreateProcess", b2.Events[0].Name)
+	b2.Release()
+	assert.Equal(t, "", kevt1.Name)
+
+	// no output is interested in events
+	kevt3 := &kevent.Kevent{Type: ktypes.CreateFile, Name: "CreateFile", PID: 4}
+	s.outputs = []*output{amqp}
+	require.True(t, s.submit(kevent.NewBatch(kevt3), 0))
+	require.Len(t, amqp.qu, 0)
+	assert.Equal(t, "", kevt3.Name)
+	assert.Equal(t, "2", eventsFiltered.Get("amqp").String())
+}
+
+func TestSubmitterDropBatches(t *testing.T) {
+	o := &output{name: "http", qu: make(queue, 1)}
+	s := &submitter{outputs: []*output{o}}
+
+	require.True(t, s.submit(kevent.NewBatch(&kevent.Kevent{PID: 4}), 0))
+	require.False(t, s.submit(kevent.NewBatch(&kevent.Kevent{PID: 4}), 0))
+
+	require.Len(t, o.qu, 1)
+	assert.Equal(t, "1", batchesDropped.Get("http").String())
+}
+
+func TestSubmitterSpoolOverflow(t *testing.T) {
+	spool, err := newSpool("kafka", t.TempDir(), 0, 0)
+	require.NoError(t, err)
+	w := &worker{output: "kafka", spool: spool, spilled: make(chan struct{}, 1)}
+	o := &output{name: "kafka", qu: make(queue, 1), overflow: w}
+	s := &submitter{outputs: []*output{o}}
+
+	require.True(t, s.submit(kevent.NewBatch(&kevent.Kevent{PID: 4}), 0))
+	require.True(t, s.submit(kevent.NewBatch(&kevent.Kevent{PID: 859}), time.Millisecond*10))
+
+	require.Len(t, o.qu, 1)
+	require.Equal(t, 1, spool.len())
+	require.Len(t, w.spilled, 1)
+	assert.Equal(t, "1", batchesOverflowed.Get("kafka").String())
+	assert.Nil(t, batchesDropped.Get("kafka"))
+
+	b := spool.peek()
+	require.NotNil(t, b)
+	assert.Equal(t, uint32(859), b.Events[0].PID)
+}
+
+func TestSubmitterSharedDeadline(t *testing.T) {
+	// unbuffered queues without workers are always full
+	outs := []*output{{name: "kafka", qu: make(queue)}, {name: "syslog", qu: make(queue)}, {name: "splunk", qu: make(queue)}}
+	s := &submitter{outputs: outs}
+
+	start := time.Now()
+	require.False(t, s.submit(kevent.NewBatch(&kevent.Kevent{PID: 4}), time.Millisecond*200))
+	// full outputs wait for the same deadline
+	assert.Less(t, time.Since(start), time.Millisecond*500)
+	for _, o := range outs {
+		assert.Equal(t, "1", batchesDropped.Get(o.name).String())
+	}
+}
+
+// slowClient takes a while to publish each batch.
+type slowClient struct {
+	published atomic.Int32
+	closed    atomic.Bool
+	// publishedOnClose is the number of batches published before the client was closed
+	publishedOnClose int32
+}
+
+func (c *slowClient) Connect() error { return nil }
+
+func (c *slowClient) Publish(*kevent.Batch) error {
+	if c.closed.Load() {
+		return errors.New("client is closed")
+	}
+	time.Sleep(time.Millisecond * 50)
+	c.published.Add(1)
+	return nil
+}
+
+func (c *slowClient) Close() error {
+	c.publishedOnClose = c.published.Load()
+	c.closed.Store(true)
+	return nil
+}
+
+func TestSubmitterShutdownDrainsQueues(t *testing.T) {
+	client := &slowClient{}
+	o := &output{name: "eventlog", qu: make(queue, 5)}
+	o.workers = append(o.workers, initWorker(o.name, o.qu, client, nil))
+	s := &submitter{outputs: []*output{o}}
+
+	for i := 0; i < 5; i++ {
+		require.True(t, s.submit(kevent.NewBatch(&kevent.Kevent{PID: 4}), 0))
+	}
+	require.NoError(t, s.shutdown(time.Second*5))
+	// queued batches are published before the client is closed
+	assert.Equal(t, int32(5), client.publishedOnClose)
+}
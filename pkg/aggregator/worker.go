@@ -20,6 +20,7 @@ package aggregator
 
 import (
 	"expvar"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
 	"github.com/rabbitstack/fibratus/pkg/outputs"
 	log "github.com/sirupsen/logrus"
 	"time"
@@ -28,27 +29,44 @@ import (
 // maxBackoff determines the maximum exponential backoff wait time before reconnecting the client
 const maxBackoff = time.Minute
 
-var clientPublishErrors = expvar.NewInt("aggregator.worker.client.publish.errors")
+var (
+	clientPublishErrors = expvar.NewInt("aggregator.worker.client.publish.errors")
+	// outputPublishErrors counts publish errors per output
+	outputPublishErrors = expvar.NewMap("aggregator.output.publish.errors")
+	// batchesPublished counts successfully published batches per output
+	batchesPublished = expvar.NewMap("aggregator.output.batches.published")
+)
 
 type worker struct {
+	output  string
 	qu      queue
 	client  outputs.Client
 	backoff time.Duration
+	// spool retains batches that failed to publish. It is nil if spooling is disabled
+	spool *spool
+	// retry fires when spooled batches are due for replay
+	retry        <-chan time.Time
+	retryBackoff time.Duration
+	// spilled signals batches were spooled because the queue was full
+	spilled chan struct{}
+	// done is closed when the worker drains the closed queue
+	done chan struct{}
 }
 
-func initWorker(q queue, client outputs.Client) *worker {
-	w := &worker{qu: q, client: client, backoff: time.Second * 2}
+func initWorker(output string, q queue, client outputs.Client, spool *spool) *worker {
+	w := &worker{output: output, qu: q, client: client, backoff: time.Second * 2, spool: spool, spilled: make(chan struct{}, 1), done: make(chan struct{})}
 	go w.run()
 	return w
 }
 
 func (w *worker) run() {
+	defer close(w.done)
 	for {
 		err := w.client.Connect()
 		if err != nil {
 			// schedule an exponential backoff reconnect strategy for the client
 			w.backoff *= 2
-			log.Warnf("fail to connect the client: %v. Reconnecting in %v...", err, w.backoff)
+			log.Warnf("fail to connect the %s client: %v. Reconnecting in %v...", w.output, err, w.backoff)
 			if w.backoff > maxBackoff {
 				w.backoff = maxBackoff
 			}
@@ -57,12 +75,112 @@ func (w *worker) run() {
 		}
 		break
 	}
-	for batch := range w.qu {
-		if err := w.client.Publish(batch); err != nil {
-			clientPublishErrors.Add(1)
-			log.Warnf("couldn't publish batch to client: %v", err)
+	// replay batches spooled in previous runs
+	if w.spool != nil && w.spool.len() > 0 {
+		w.replay()
+	}
+	for {
+		select {
+		case batch, ok := <-w.qu:
+			if !ok {
+				return
+			}
+			w.process(batch)
+		case <-w.retry:
+			w.retry = nil
+			w.replay()
+		case <-w.spilled:
+			// replay is already scheduled if the client is failing
+			if w.retry == nil {
+				w.replay()
+			}
+		}
+	}
+}
+
+// process publishes the batch and spools it if publishing fails. If there are
+// pending spooled batches, the batch is appended to the spool right away to
+// preserve the publishing order.
+func (w *worker) process(batch *kevent.Batch) {
+	defer batch.Release()
+	if w.spool != nil && w.spool.len() > 0 {
+		w.store(batch)
+		return
+	}
+	if err := w.publish(batch); err != nil && w.spool != nil {
+		w.store(batch)
+	}
+}
+
+func (w *worker) publish(batch *kevent.Batch) error {
+	if err := w.client.Publish(batch); err != nil {
+		clientPublishErrors.Add(1)
+		outputPublishErrors.Add(w.output, 1)
+		log.Warnf("couldn't publish batch to %s client: %v", w.output, err)
+		return err
+	}
+	batchesPublished.Add(w.output, 1)
+	return nil
+}
+
+// store writes the batch to the spool and schedules the replay.
+func (w *worker) store(batch *kevent.Batch) {
+	if err := w.spool.write(batch); err != nil {
+		log.Warnf("unable to spool batch for %s output: %v", w.output, err)
+	}
+	w.scheduleRetry()
+}
+
+// spill spools the batch that doesn't fit in the full queue and
+// wakes up the worker to replay it. Returns false if the batch
+// couldn't be spooled.
+func (w *worker) spill(batch *kevent.Batch) bool {
+	if err := w.spool.write(batch); err != nil {
+		log.Warnf("unable to spool batch for %s output: %v", w.output, err)
+		return false
+	}
+	select {
+	case w.spilled <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// replay publishes spooled batches in the order they were spooled. Replaying
+// stops on the first failure and is rescheduled with an exponential backoff.
+func (w *worker) replay() {
+	for {
+		batch := w.spool.peek()
+		if batch == nil {
+			w.retryBackoff = 0
+			return
 		}
+		err := w.publish(batch)
+		batch.Release()
+		if err != nil {
+			w.scheduleRetry()
+			return
+		}
+		if err := w.spool.pop(); err != nil {
+			log.Warnf("unable to remove spooled batch for %s output: %v", w.output, err)
+		}
+		batchesReplayed.Add(w.output, 1)
+	}
+}
+
+func (w *worker) scheduleRetry() {
+	if w.retry != nil {
+		return
+	}
+	if w.retryBackoff == 0 {
+		w.retryBackoff = time.Second * 2
+	} else {
+		w.retryBackoff *= 2
+	}
+	if w.retryBackoff > maxBackoff {
+		w.retryBackoff = maxBackoff
 	}
+	w.retry = time.After(w.retryBackoff)
 }
 
 func (w *worker) close() error {
@@ -19,8 +19,10 @@
 package aggregator
 
 import (
+	"errors"
 	"github.com/rabbitstack/fibratus/pkg/kevent"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -82,7 +84,7 @@ func TestRunWorker(t *testing.T) {
 
 	client := &httpClient{url: srv.URL, wait: make(chan struct{}, 1), expectedPublished: 2}
 
-	w := initWorker(q, client)
+	w := initWorker("http", q, client, nil)
 	defer w.close()
 
 	<-client.wait
@@ -118,10 +120,59 @@ func TestConnectClientBackoff(t *testing.T) {
 		fail = false
 	})
 
-	w := initWorker(q, client)
+	w := initWorker("http", q, client, nil)
 	defer w.close()
 
 	<-client.wait
 
 	assert.Equal(t, 2, client.published)
 }
+
+type flakyClient struct {
+	failures  int
+	published []uint32
+	wait      chan struct{}
+	expected  int
+}
+
+func (c *flakyClient) Connect() error { return nil }
+func (c *flakyClient) Close() error   { return nil }
+
+func (c *flakyClient) Publish(b *kevent.Batch) error {
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("connection refused")
+	}
+	for _, evt := range b.Events {
+		c.published = append(c.published, evt.PID)
+	}
+	if len(c.published) == c.expected {
+		c.wait <- struct{}{}
+	}
+	return nil
+}
+
+func TestWorkerReplaySpool(t *testing.T) {
+	s, err := newSpool("http", t.TempDir(), 0, time.Hour)
+	require.NoError(t, err)
+
+	q := make(chan *kevent.Batch, 3)
+	q <- newSpoolBatch(1)
+	q <- newSpoolBatch(2)
+	q <- newSpoolBatch(3)
+
+	client := &flakyClient{failures: 2, wait: make(chan struct{}, 1), expected: 3}
+
+	w := initWorker("http", q, client, s)
+	defer w.close()
+
+	select {
+	case <-client.wait:
+	case <-time.After(time.Second * 10):
+		t.Fatal("spooled batches not replayed")
+	}
+
+	// batches are replayed in the original order
+	assert.Equal(t, []uint32{1, 2, 3}, client.published)
+	assert.Eventually(t, func() bool { return s.len() == 0 }, time.Second, time.Millisecond*10)
+}
@@ -20,6 +20,8 @@ package alertsender
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
@@ -82,6 +84,11 @@ type Alert struct {
 	Tags []string
 	// Severity determines the severity of this alert.
 	Severity Severity
+	// Labels contains the labels of the rule that produced this alert.
+	Labels map[string]string
+	// Key identifies the entity the alert refers to, such as the
+	// process or the join value of the rule that produced this alert.
+	Key string
 }
 
 // String returns the alert string representation.
@@ -89,6 +96,19 @@ func (a Alert) String() string {
 	return fmt.Sprintf("Title: %s, Text: %s, Severity: %s, Tags: %v", a.Title, a.Text, a.Severity, a.Tags)
 }
 
+// DedupKey returns the key that identifies repeated alerts. If the alert refers to the
+// entity, the key is derived from the title and the entity key. Otherwise, the title and
+// text make up the key. Incident management services use it to group repeated alerts into
+// a single incident.
+func (a Alert) DedupKey() string {
+	s := a.Title + "\x00" + a.Text
+	if a.Key != "" {
+		s = a.Title + "\x01" + a.Key
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
 // MDToHTML converts alert's text Markdown elements to HTML blocks.
 func (a *Alert) MDToHTML() error {
 	md := goldmark.New(
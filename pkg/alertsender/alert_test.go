@@ -0,0 +1,39 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alertsender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertDedupKey(t *testing.T) {
+	a1 := NewAlert("Command shell spawned by Office", "winword.exe spawned cmd.exe /c whoami", nil, High)
+	a2 := NewAlert("Command shell spawned by Office", "winword.exe spawned cmd.exe /c ipconfig", nil, High)
+	assert.NotEqual(t, a1.DedupKey(), a2.DedupKey())
+
+	// alerts for the same entity are deduplicated
+	// regardless of the event-specific text
+	a1.Key, a2.Key = "4024", "4024"
+	assert.Equal(t, a1.DedupKey(), a2.DedupKey())
+
+	a2.Key = "5092"
+	assert.NotEqual(t, a1.DedupKey(), a2.DedupKey())
+}
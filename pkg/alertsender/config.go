@@ -20,6 +20,22 @@ package alertsender
 
 // Config is the container for the alert sender configuration structure.
 type Config struct {
-	Type   Type
+	Type Type
+	// Name identifies the alert sender instance. Multiple
+	// instances of the same sender type are distinguished
+	// by their names. Defaults to the sender type name.
+	Name   string
 	Sender interface{}
+	// Routes contains the conditions for dispatching
+	// alerts via this sender. The sender receives all
+	// alerts if no routes are defined.
+	Routes []Route
+}
+
+// InstanceName returns the name of the alert sender instance.
+func (c Config) InstanceName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Type.String()
 }
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opsgenie
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	enabled    = "alertsenders.opsgenie.enabled"
+	apiKey     = "alertsenders.opsgenie.api-key"
+	url        = "alertsenders.opsgenie.url"
+	responders = "alertsenders.opsgenie.responders"
+	tags       = "alertsenders.opsgenie.tags"
+	source     = "alertsenders.opsgenie.source"
+	timeout    = "alertsenders.opsgenie.timeout"
+)
+
+// defaultURL is the Opsgenie API endpoint. EU accounts use https://api.eu.opsgenie.com
+const defaultURL = "https://api.opsgenie.com"
+
+// Config stores the settings that dictate the behaviour of the Opsgenie alert sender.
+type Config struct {
+	// Enabled determines if Opsgenie alert sender is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// APIKey is the API integration key.
+	APIKey string `mapstructure:"api-key"`
+	// URL represents the Opsgenie API endpoint.
+	URL string `mapstructure:"url"`
+	// Responders contains the list of teams, users, escalations or schedules that
+	// are notified for the alert. Each responder is expressed as type:name, e.g.
+	// team:SOC. The responder is assumed to be a team if the type is omitted.
+	Responders []string `mapstructure:"responders"`
+	// Tags contains additional tags that are attached to every alert.
+	Tags []string `mapstructure:"tags"`
+	// Source identifies the alert source. Defaults to the hostname.
+	Source string `mapstructure:"source"`
+	// Timeout represents the timeout for the HTTP requests.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(enabled, false, "Determines whether Opsgenie alert sender is enabled")
+	flags.String(apiKey, "", "Specifies the API integration key")
+	flags.String(url, defaultURL, "Represents the Opsgenie API endpoint")
+	flags.StringSlice(responders, []string{}, "Contains the list of teams, users, escalations or schedules that are notified for the alert")
+	flags.StringSlice(tags, []string{}, "Contains additional tags that are attached to every alert")
+	flags.String(source, "", "Identifies the alert source. Defaults to the hostname")
+	flags.Duration(timeout, time.Second*10, "Represents the timeout for the HTTP requests")
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+)
+
+const (
+	// maxMessageLength is the maximum length of the alert message accepted by Opsgenie
+	maxMessageLength = 130
+	// maxDescriptionLength is the maximum length of the alert description accepted by Opsgenie
+	maxDescriptionLength = 15000
+)
+
+// alert represents the Opsgenie create alert request.
+type alert struct {
+	Message     string      `json:"message"`
+	Alias       string      `json:"alias"`
+	Description string      `json:"description,omitempty"`
+	Responders  []responder `json:"responders,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Source      string      `json:"source,omitempty"`
+	Priority    string      `json:"priority"`
+}
+
+type responder struct {
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+type opsgenie struct {
+	client     *http.Client
+	config     Config
+	responders []responder
+}
+
+func init() {
+	alertsender.Register(alertsender.Opsgenie, makeSender)
+}
+
+// makeSender constructs a new instance of the Opsgenie alert sender.
+func makeSender(config alertsender.Config) (alertsender.Sender, error) {
+	c, ok := config.Sender.(Config)
+	if !ok {
+		return nil, alertsender.ErrInvalidConfig(alertsender.Opsgenie)
+	}
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("opsgenie API key is required")
+	}
+	if c.URL == "" {
+		c.URL = defaultURL
+	}
+	if c.Source == "" {
+		c.Source, _ = os.Hostname()
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Second * 10
+	}
+	responders, err := parseResponders(c.Responders)
+	if err != nil {
+		return nil, err
+	}
+	return &opsgenie{config: c, responders: responders, client: &http.Client{Timeout: c.Timeout}}, nil
+}
+
+func (o opsgenie) Send(a alertsender.Alert) error {
+	body, err := json.Marshal(o.newAlert(a))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(o.config.URL, "/")+"/v2/alerts", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.config.APIKey)
+	req.Header.Set("User-Agent", version.ProductToken())
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to send alert to Opsgenie. code: %d content: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func (o opsgenie) Type() alertsender.Type { return alertsender.Opsgenie }
+func (o opsgenie) Shutdown() error        { return nil }
+func (o opsgenie) SupportsMarkdown() bool { return false }
+
+func (o opsgenie) newAlert(a alertsender.Alert) alert {
+	message := a.Title
+	if len(message) > maxMessageLength {
+		message = message[:maxMessageLength]
+	}
+	description := a.Text
+	if len(description) > maxDescriptionLength {
+		description = description[:maxDescriptionLength]
+	}
+	tags := make([]string, 0, len(a.Tags)+len(o.config.Tags))
+	tags = append(tags, a.Tags...)
+	tags = append(tags, o.config.Tags...)
+	return alert{
+		Message:     message,
+		Alias:       a.DedupKey(),
+		Description: description,
+		Responders:  o.responders,
+		Tags:        tags,
+		Source:      o.config.Source,
+		Priority:    priority(a.Severity),
+	}
+}
+
+// parseResponders parses responders expressed in the type:name notation.
+func parseResponders(responders []string) ([]responder, error) {
+	rs := make([]responder, 0, len(responders))
+	for _, r := range responders {
+		typ, name, ok := strings.Cut(r, ":")
+		if !ok {
+			typ, name = "team", r
+		}
+		switch typ {
+		case "team", "escalation", "schedule":
+			rs = append(rs, responder{Type: typ, Name: name})
+		case "user":
+			rs = append(rs, responder{Type: typ, Username: name})
+		default:
+			return nil, fmt.Errorf("invalid opsgenie responder type %q. Expected one of team, user, escalation, or schedule", typ)
+		}
+	}
+	return rs, nil
+}
+
+// priority maps the alert severity to the Opsgenie alert priority.
+func priority(s alertsender.Severity) string {
+	switch s {
+	case alertsender.Critical:
+		return "P1"
+	case alertsender.High:
+		return "P2"
+	case alertsender.Medium:
+		return "P3"
+	default:
+		return "P4"
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package opsgenie
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpsgenieSender(t *testing.T) {
+	alerts := make(chan alert, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/alerts", r.URL.Path)
+		assert.Equal(t, "GenieKey eb243592", r.Header.Get("Authorization"))
+		var a alert
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&a)) {
+			return
+		}
+		alerts <- a
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"result":"Request will be processed","took":0.302,"requestId":"43a29c5c"}`))
+	}))
+	defer srv.Close()
+
+	s, err := alertsender.Load(alertsender.Config{
+		Type: alertsender.Opsgenie,
+		Sender: Config{
+			APIKey:     "eb243592",
+			URL:        srv.URL,
+			Responders: []string{"SOC", "user:john@example.com", "schedule:On-Call"},
+			Tags:       []string{"edr"},
+			Source:     "archrabbit",
+		},
+	})
+	require.NoError(t, err)
+
+	al := alertsender.NewAlert("LSASS memory dumping", "Detected mimikatz.exe accessing LSASS", []string{"credential access"}, alertsender.Critical)
+	require.NoError(t, s.Send(al))
+
+	a := <-alerts
+	assert.Equal(t, "LSASS memory dumping", a.Message)
+	assert.Equal(t, al.DedupKey(), a.Alias)
+	assert.Equal(t, "Detected mimikatz.exe accessing LSASS", a.Description)
+	assert.Equal(t, []string{"credential access", "edr"}, a.Tags)
+	assert.Equal(t, "archrabbit", a.Source)
+	assert.Equal(t, "P1", a.Priority)
+	assert.Equal(t, []responder{
+		{Type: "team", Name: "SOC"},
+		{Type: "user", Username: "john@example.com"},
+		{Type: "schedule", Name: "On-Call"},
+	}, a.Responders)
+}
+
+func TestOpsgeniePriority(t *testing.T) {
+	var tests = []struct {
+		s   alertsender.Severity
+		exp string
+	}{
+		{alertsender.Normal, "P4"},
+		{alertsender.Medium, "P3"},
+		{alertsender.High, "P2"},
+		{alertsender.Critical, "P1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s.String(), func(t *testing.T) {
+			assert.Equal(t, tt.exp, priority(tt.s))
+		})
+	}
+}
+
+func TestOpsgenieSenderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Key format is not valid!","took":0.001,"requestId":"9d8e1d8c"}`))
+	}))
+	defer srv.Close()
+
+	s, err := makeSender(alertsender.Config{Type: alertsender.Opsgenie, Sender: Config{APIKey: "invalid", URL: srv.URL}})
+	require.NoError(t, err)
+	require.Error(t, s.Send(alertsender.NewAlert("LSASS memory dumping", "", nil, alertsender.High)))
+
+	_, err = makeSender(alertsender.Config{Type: alertsender.Opsgenie, Sender: Config{APIKey: "eb243592", Responders: []string{"channel:soc"}}})
+	require.Error(t, err)
+}
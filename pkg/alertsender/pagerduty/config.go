@@ -0,0 +1,67 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pagerduty
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	enabled    = "alertsenders.pagerduty.enabled"
+	routingKey = "alertsenders.pagerduty.routing-key"
+	url        = "alertsenders.pagerduty.url"
+	source     = "alertsenders.pagerduty.source"
+	component  = "alertsenders.pagerduty.component"
+	group      = "alertsenders.pagerduty.group"
+	timeout    = "alertsenders.pagerduty.timeout"
+)
+
+// defaultURL is the PagerDuty Events API v2 endpoint
+const defaultURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Config stores the settings that dictate the behaviour of the PagerDuty alert sender.
+type Config struct {
+	// Enabled determines if PagerDuty alert sender is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// RoutingKey is the integration key of the PagerDuty service.
+	RoutingKey string `mapstructure:"routing-key"`
+	// URL represents the Events API v2 endpoint.
+	URL string `mapstructure:"url"`
+	// Source identifies the affected system. Defaults to the hostname.
+	Source string `mapstructure:"source"`
+	// Component is the part of the source system responsible for the event.
+	Component string `mapstructure:"component"`
+	// Group is the logical grouping of the source components.
+	Group string `mapstructure:"group"`
+	// Timeout represents the timeout for the HTTP requests.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(enabled, false, "Determines whether PagerDuty alert sender is enabled")
+	flags.String(routingKey, "", "Specifies the integration key of the PagerDuty service")
+	flags.String(url, defaultURL, "Represents the Events API v2 endpoint")
+	flags.String(source, "", "Identifies the affected system. Defaults to the hostname")
+	flags.String(component, "fibratus", "Specifies the part of the source system responsible for the event")
+	flags.String(group, "", "Specifies the logical grouping of the source components")
+	flags.Duration(timeout, time.Second*10, "Represents the timeout for the HTTP requests")
+}
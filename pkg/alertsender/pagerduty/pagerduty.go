@@ -0,0 +1,163 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+)
+
+// maxSummaryLength is the maximum length of the event summary accepted by PagerDuty
+const maxSummaryLength = 1024
+
+// event represents the PagerDuty Events API v2 trigger event.
+type event struct {
+	RoutingKey  string  `json:"routing_key"`
+	EventAction string  `json:"event_action"`
+	DedupKey    string  `json:"dedup_key"`
+	Client      string  `json:"client,omitempty"`
+	Payload     payload `json:"payload"`
+}
+
+type payload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Component     string         `json:"component,omitempty"`
+	Group         string         `json:"group,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+// response is the Events API v2 response.
+type response struct {
+	Status   string   `json:"status"`
+	Message  string   `json:"message"`
+	DedupKey string   `json:"dedup_key"`
+	Errors   []string `json:"errors"`
+}
+
+type pagerduty struct {
+	client *http.Client
+	config Config
+}
+
+func init() {
+	alertsender.Register(alertsender.PagerDuty, makeSender)
+}
+
+// makeSender constructs a new instance of the PagerDuty alert sender.
+func makeSender(config alertsender.Config) (alertsender.Sender, error) {
+	c, ok := config.Sender.(Config)
+	if !ok {
+		return nil, alertsender.ErrInvalidConfig(alertsender.PagerDuty)
+	}
+	if c.RoutingKey == "" {
+		return nil, fmt.Errorf("pagerduty routing key is required")
+	}
+	if c.URL == "" {
+		c.URL = defaultURL
+	}
+	if c.Source == "" {
+		c.Source, _ = os.Hostname()
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Second * 10
+	}
+	return &pagerduty{config: c, client: &http.Client{Timeout: c.Timeout}}, nil
+}
+
+func (p pagerduty) Send(alert alertsender.Alert) error {
+	body, err := json.Marshal(p.newEvent(alert))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.ProductToken())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var r response
+		if err := json.Unmarshal(b, &r); err == nil && r.Message != "" {
+			return fmt.Errorf("failed to send alert to PagerDuty. code: %d message: %s errors: %v", resp.StatusCode, r.Message, r.Errors)
+		}
+		return fmt.Errorf("failed to send alert to PagerDuty. code: %d content: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func (p pagerduty) Type() alertsender.Type { return alertsender.PagerDuty }
+func (p pagerduty) Shutdown() error        { return nil }
+func (p pagerduty) SupportsMarkdown() bool { return false }
+
+func (p pagerduty) newEvent(alert alertsender.Alert) event {
+	summary := alert.Title
+	if len(summary) > maxSummaryLength {
+		summary = summary[:maxSummaryLength]
+	}
+	details := map[string]any{"text": alert.Text}
+	if len(alert.Tags) > 0 {
+		details["tags"] = alert.Tags
+	}
+	return event{
+		RoutingKey:  p.config.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.DedupKey(),
+		Client:      "Fibratus",
+		Payload: payload{
+			Summary:       summary,
+			Source:        p.config.Source,
+			Severity:      severity(alert.Severity),
+			Component:     p.config.Component,
+			Group:         p.config.Group,
+			CustomDetails: details,
+		},
+	}
+}
+
+// severity maps the alert severity to the PagerDuty event severity.
+func severity(s alertsender.Severity) string {
+	switch s {
+	case alertsender.Medium:
+		return "warning"
+	case alertsender.High:
+		return "error"
+	case alertsender.Critical:
+		return "critical"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pagerduty
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerDutySender(t *testing.T) {
+	events := make(chan event, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/enqueue", r.URL.Path)
+		var e event
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&e)) {
+			return
+		}
+		events <- e
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"success","message":"Event processed","dedup_key":"` + e.DedupKey + `"}`))
+	}))
+	defer srv.Close()
+
+	s, err := alertsender.Load(alertsender.Config{
+		Type:   alertsender.PagerDuty,
+		Sender: Config{RoutingKey: "R0UT1NGK3Y", URL: srv.URL + "/v2/enqueue", Source: "archrabbit", Component: "fibratus"},
+	})
+	require.NoError(t, err)
+
+	alert := alertsender.NewAlert("LSASS memory dumping", "Detected mimikatz.exe accessing LSASS", []string{"credential access"}, alertsender.High)
+	require.NoError(t, s.Send(alert))
+	require.NoError(t, s.Send(alert))
+
+	e1, e2 := <-events, <-events
+	assert.Equal(t, "R0UT1NGK3Y", e1.RoutingKey)
+	assert.Equal(t, "trigger", e1.EventAction)
+	assert.NotEmpty(t, e1.DedupKey)
+	assert.Equal(t, e1.DedupKey, e2.DedupKey)
+	assert.Equal(t, "LSASS memory dumping", e1.Payload.Summary)
+	assert.Equal(t, "archrabbit", e1.Payload.Source)
+	assert.Equal(t, "error", e1.Payload.Severity)
+	assert.Equal(t, "fibratus", e1.Payload.Component)
+	assert.Equal(t, "Detected mimikatz.exe accessing LSASS", e1.Payload.CustomDetails["text"])
+	assert.Equal(t, []any{"credential access"}, e1.Payload.CustomDetails["tags"])
+}
+
+func TestPagerDutySeverity(t *testing.T) {
+	var tests = []struct {
+		s   alertsender.Severity
+		exp string
+	}{
+		{alertsender.Normal, "info"},
+		{alertsender.Medium, "warning"},
+		{alertsender.High, "error"},
+		{alertsender.Critical, "critical"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s.String(), func(t *testing.T) {
+			assert.Equal(t, tt.exp, severity(tt.s))
+		})
+	}
+}
+
+func TestPagerDutySenderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"invalid event","message":"Event object is invalid","errors":["'routing_key' is invalid"]}`))
+	}))
+	defer srv.Close()
+
+	s, err := makeSender(alertsender.Config{Type: alertsender.PagerDuty, Sender: Config{RoutingKey: "invalid", URL: srv.URL}})
+	require.NoError(t, err)
+	err = s.Send(alertsender.NewAlert("LSASS memory dumping", "", nil, alertsender.Critical))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Event object is invalid")
+
+	_, err = makeSender(alertsender.Config{Type: alertsender.PagerDuty, Sender: Config{}})
+	require.Error(t, err)
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alertsender
+
+import (
+	"fmt"
+)
+
+// Route describes the conditions an alert must satisfy to
+// be dispatched via the alert sender. All conditions declared
+// in the route must be satisfied for the route to match.
+type Route struct {
+	// Severities contains the list of accepted alert severities.
+	Severities []string `mapstructure:"severities"`
+	// MinSeverity represents the lowest accepted alert severity.
+	MinSeverity string `mapstructure:"min-severity"`
+	// Tags contains the list of alert tags. The condition
+	// is satisfied if the alert has any of the tags.
+	Tags []string `mapstructure:"tags"`
+	// Labels contains the rule labels. The condition is satisfied
+	// if the alert carries all the labels with identical values.
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+// Matches determines if the alert satisfies the route conditions.
+func (r Route) Matches(alert Alert) bool {
+	if len(r.Severities) > 0 {
+		var ok bool
+		for _, s := range r.Severities {
+			if ParseSeverityFromString(s) == alert.Severity {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if r.MinSeverity != "" && alert.Severity < ParseSeverityFromString(r.MinSeverity) {
+		return false
+	}
+	if len(r.Tags) > 0 && !hasAnyTag(alert.Tags, r.Tags) {
+		return false
+	}
+	for k, v := range r.Labels {
+		if alert.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Route) validate() error {
+	for _, s := range r.Severities {
+		if !isValidSeverity(s) {
+			return fmt.Errorf("unknown severity %q", s)
+		}
+	}
+	if r.MinSeverity != "" && !isValidSeverity(r.MinSeverity) {
+		return fmt.Errorf("unknown min severity %q", r.MinSeverity)
+	}
+	return nil
+}
+
+func hasAnyTag(tags []string, expected []string) bool {
+	for _, tag := range tags {
+		for _, e := range expected {
+			if tag == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isValidSeverity(s string) bool {
+	switch s {
+	case "normal", "Normal", "NORMAL", "low", "LOW",
+		"medium", "Medium", "MEDIUM",
+		"high", "High", "HIGH",
+		"critical", "Critical", "CRITICAL":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alertsender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopSender struct{ name string }
+
+func (noopSender) Send(Alert) error       { return nil }
+func (noopSender) Type() Type             { return Noop }
+func (noopSender) Shutdown() error        { return nil }
+func (noopSender) SupportsMarkdown() bool { return true }
+
+func init() {
+	Register(Noop, func(config Config) (Sender, error) { return &noopSender{name: config.Name}, nil })
+}
+
+func TestRouteMatches(t *testing.T) {
+	var tests = []struct {
+		name    string
+		route   Route
+		alert   Alert
+		matches bool
+	}{
+		{"empty route", Route{}, Alert{Severity: Normal}, true},
+		{"severities", Route{Severities: []string{"high", "critical"}}, Alert{Severity: High}, true},
+		{"severities mismatch", Route{Severities: []string{"high", "critical"}}, Alert{Severity: Medium}, false},
+		{"min severity", Route{MinSeverity: "medium"}, Alert{Severity: Critical}, true},
+		{"min severity mismatch", Route{MinSeverity: "high"}, Alert{Severity: Medium}, false},
+		{"tags", Route{Tags: []string{"T1003", "credential access"}}, Alert{Tags: []string{"credential access"}}, true},
+		{"tags mismatch", Route{Tags: []string{"T1003"}}, Alert{Tags: []string{"defense evasion"}}, false},
+		{"labels", Route{Labels: map[string]string{"tactic.id": "TA0006"}}, Alert{Labels: map[string]string{"tactic.id": "TA0006", "tactic.name": "Credential Access"}}, true},
+		{"labels mismatch", Route{Labels: map[string]string{"tactic.id": "TA0006"}}, Alert{Labels: map[string]string{"tactic.id": "TA0005"}}, false},
+		{"labels missing", Route{Labels: map[string]string{"tactic.id": "TA0006"}}, Alert{}, false},
+		{"all conditions", Route{MinSeverity: "high", Labels: map[string]string{"tactic.id": "TA0006"}}, Alert{Severity: Medium, Labels: map[string]string{"tactic.id": "TA0006"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.route.Matches(tt.alert))
+		})
+	}
+}
+
+func TestFindByAlert(t *testing.T) {
+	defer func() { alertsenders = map[string]*instance{} }()
+
+	require.NoError(t, LoadAll([]Config{
+		{Type: Noop, Name: "pagerduty", Routes: []Route{{MinSeverity: "critical"}}},
+		{Type: Noop, Name: "slack"},
+		{Type: Noop, Name: "ir-team", Routes: []Route{{Labels: map[string]string{"tactic.id": "TA0006"}}, {Tags: []string{"ransomware"}}}},
+	}))
+	require.Len(t, FindAll(), 3)
+	assert.NotNil(t, FindByName("ir-team"))
+	assert.Nil(t, FindByName("mail"))
+	assert.NotNil(t, Find(Noop))
+
+	names := func(alert Alert) []string {
+		var names []string
+		for _, i := range instances() {
+			for _, s := range FindByAlert(alert) {
+				if i.Sender == s {
+					names = append(names, i.name)
+				}
+			}
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"slack"}, names(Alert{Severity: High}))
+	assert.Equal(t, []string{"pagerduty", "slack"}, names(Alert{Severity: Critical}))
+	assert.Equal(t, []string{"ir-team", "slack"}, names(Alert{Severity: Medium, Labels: map[string]string{"tactic.id": "TA0006"}}))
+	assert.Equal(t, []string{"ir-team", "pagerduty", "slack"}, names(Alert{Severity: Critical, Tags: []string{"ransomware"}}))
+
+	require.Error(t, LoadAll([]Config{{Type: Noop, Name: "invalid", Routes: []Route{{MinSeverity: "extreme"}}}}))
+}
@@ -21,13 +21,34 @@ package alertsender
 import (
 	"fmt"
 	"github.com/rabbitstack/fibratus/pkg/util/multierror"
+	"sort"
 )
 
 // ErrInvalidConfig signals an invalid sender config
 var ErrInvalidConfig = func(name Type) error { return fmt.Errorf("invalid config for %q sender", name) }
 
 var factories = map[Type]Factory{}
-var alertsenders = map[Type]Sender{}
+var alertsenders = map[string]*instance{}
+
+// instance is the loaded alert sender along with its routes.
+type instance struct {
+	Sender
+	name   string
+	routes []Route
+}
+
+// accepts determines if the alert is dispatched via this sender instance.
+func (i *instance) accepts(alert Alert) bool {
+	if len(i.routes) == 0 {
+		return true
+	}
+	for _, route := range i.routes {
+		if route.Matches(alert) {
+			return true
+		}
+	}
+	return false
+}
 
 // Factory defines the alias for the alert sender factory
 type Factory func(config Config) (Sender, error)
@@ -44,6 +65,14 @@ const (
 	Noop
 	// Systray designates the systray notification alert sender
 	Systray
+	// Webhook designates the generic webhook alert sender
+	Webhook
+	// Teams designates Microsoft Teams alert sender
+	Teams
+	// PagerDuty designates PagerDuty alert sender
+	PagerDuty
+	// Opsgenie designates Opsgenie alert sender
+	Opsgenie
 	// None is the type for unknown alert sender
 	None
 )
@@ -59,6 +88,14 @@ func (s Type) String() string {
 		return "noop"
 	case Systray:
 		return "systray"
+	case Webhook:
+		return "webhook"
+	case Teams:
+		return "teams"
+	case PagerDuty:
+		return "pagerduty"
+	case Opsgenie:
+		return "opsgenie"
 	default:
 		return "none"
 	}
@@ -89,6 +126,14 @@ func ToType(s string) Type {
 		return Noop
 	case "systray":
 		return Systray
+	case "webhook":
+		return Webhook
+	case "teams":
+		return Teams
+	case "pagerduty":
+		return PagerDuty
+	case "opsgenie":
+		return Opsgenie
 	default:
 		return None
 	}
@@ -102,16 +147,44 @@ func Register(typ Type, factory Factory) {
 	factories[typ] = factory
 }
 
-// Find locates the sender.
+// Find locates the sender by type. If there are multiple
+// instances of the same sender type, the instance with the
+// lexicographically smallest name is returned.
 func Find(typ Type) Sender {
-	return alertsenders[typ]
+	for _, i := range instances() {
+		if i.Type() == typ {
+			return i.Sender
+		}
+	}
+	return nil
+}
+
+// FindByName locates the sender instance by its name.
+func FindByName(name string) Sender {
+	i, ok := alertsenders[name]
+	if !ok {
+		return nil
+	}
+	return i.Sender
 }
 
 // FindAll returns all registered senders.
 func FindAll() []Sender {
 	senders := make([]Sender, 0, len(alertsenders))
-	for _, s := range alertsenders {
-		senders = append(senders, s)
+	for _, i := range instances() {
+		senders = append(senders, i.Sender)
+	}
+	return senders
+}
+
+// FindByAlert returns all registered senders whose routes
+// match the given alert.
+func FindByAlert(alert Alert) []Sender {
+	senders := make([]Sender, 0, len(alertsenders))
+	for _, i := range instances() {
+		if i.accepts(alert) {
+			senders = append(senders, i.Sender)
+		}
 	}
 	return senders
 }
@@ -119,8 +192,8 @@ func FindAll() []Sender {
 // ShutdownAll shutdowns all registered senders.
 func ShutdownAll() error {
 	errs := make([]error, 0)
-	for _, s := range alertsenders {
-		err := s.Shutdown()
+	for _, i := range alertsenders {
+		err := i.Shutdown()
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -128,6 +201,16 @@ func ShutdownAll() error {
 	return multierror.Wrap(errs...)
 }
 
+// instances returns loaded sender instances sorted by name.
+func instances() []*instance {
+	is := make([]*instance, 0, len(alertsenders))
+	for _, i := range alertsenders {
+		is = append(is, i)
+	}
+	sort.Slice(is, func(i, j int) bool { return is[i].name < is[j].name })
+	return is
+}
+
 // Load loads an alert sender from the registry.
 func Load(config Config) (Sender, error) {
 	typ := config.Type
@@ -139,13 +222,21 @@ func Load(config Config) (Sender, error) {
 }
 
 // LoadAll loads all alert senders from the configuration inputs.
+// Sender instances are keyed by their names, so loading the sender
+// with the same name replaces the previously loaded instance.
 func LoadAll(configs []Config) error {
 	for _, config := range configs {
 		alertsender, err := Load(config)
 		if err != nil {
-			return fmt.Errorf("fail to load %q alertsender: %v", config.Type, err)
+			return fmt.Errorf("fail to load %q alertsender: %v", config.InstanceName(), err)
+		}
+		for _, route := range config.Routes {
+			if err := route.validate(); err != nil {
+				return fmt.Errorf("invalid route in %q alertsender: %v", config.InstanceName(), err)
+			}
 		}
-		alertsenders[config.Type] = alertsender
+		name := config.InstanceName()
+		alertsenders[name] = &instance{Sender: alertsender, name: name, routes: config.Routes}
 	}
 	return nil
 }
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package teams
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	enabled = "alertsenders.teams.enabled"
+	url     = "alertsenders.teams.url"
+	timeout = "alertsenders.teams.timeout"
+)
+
+// Config stores the settings that dictate the behaviour of the Microsoft Teams alert sender.
+type Config struct {
+	// Enabled determines if Teams alert sender is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// URL represents the Teams incoming webhook or workflow URL.
+	URL string `mapstructure:"url"`
+	// Timeout represents the timeout for the HTTP requests.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(enabled, false, "Determines whether Microsoft Teams alert sender is enabled")
+	flags.String(url, "", "Represents the Teams incoming webhook or workflow URL")
+	flags.Duration(timeout, time.Second*10, "Represents the timeout for the HTTP requests")
+}
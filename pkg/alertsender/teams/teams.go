@@ -0,0 +1,169 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+)
+
+const (
+	adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+	adaptiveCardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion     = "1.4"
+)
+
+// message is the envelope that carries adaptive card attachments.
+type message struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	ContentType string `json:"contentType"`
+	Content     card   `json:"content"`
+}
+
+type card struct {
+	Schema  string    `json:"$schema"`
+	Type    string    `json:"type"`
+	Version string    `json:"version"`
+	Body    []element `json:"body"`
+	MSTeams struct {
+		Width string `json:"width"`
+	} `json:"msteams"`
+}
+
+type element struct {
+	Type   string `json:"type"`
+	Text   string `json:"text,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Weight string `json:"weight,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap,omitempty"`
+	Facts  []fact `json:"facts,omitempty"`
+}
+
+type fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teams struct {
+	client *http.Client
+	config Config
+}
+
+func init() {
+	alertsender.Register(alertsender.Teams, makeSender)
+}
+
+// makeSender constructs a new instance of the Microsoft Teams alert sender.
+func makeSender(config alertsender.Config) (alertsender.Sender, error) {
+	c, ok := config.Sender.(Config)
+	if !ok {
+		return nil, alertsender.ErrInvalidConfig(alertsender.Teams)
+	}
+	if c.URL == "" {
+		return nil, fmt.Errorf("teams webhook URL is required")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Second * 10
+	}
+	return &teams{config: c, client: &http.Client{Timeout: c.Timeout}}, nil
+}
+
+func (t teams) Send(alert alertsender.Alert) error {
+	body, err := json.Marshal(newMessage(alert))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.ProductToken())
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to send alert to Teams. code: %d content: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func (t teams) Type() alertsender.Type { return alertsender.Teams }
+func (t teams) Shutdown() error        { return nil }
+func (t teams) SupportsMarkdown() bool { return true }
+
+// newMessage builds the adaptive card from the alert.
+func newMessage(alert alertsender.Alert) message {
+	c := card{
+		Schema:  adaptiveCardSchema,
+		Type:    "AdaptiveCard",
+		Version: adaptiveCardVersion,
+	}
+	c.MSTeams.Width = "Full"
+	c.Body = append(c.Body, element{
+		Type:   "TextBlock",
+		Text:   alert.Title,
+		Size:   "Large",
+		Weight: "Bolder",
+		Color:  color(alert.Severity),
+		Wrap:   true,
+	})
+	if alert.Text != "" {
+		c.Body = append(c.Body, element{Type: "TextBlock", Text: alert.Text, Wrap: true})
+	}
+	facts := []fact{{Title: "Severity", Value: alert.Severity.String()}}
+	if len(alert.Tags) > 0 {
+		facts = append(facts, fact{Title: "Tags", Value: strings.Join(alert.Tags, ", ")})
+	}
+	c.Body = append(c.Body, element{Type: "FactSet", Facts: facts})
+
+	return message{
+		Type:        "message",
+		Attachments: []attachment{{ContentType: adaptiveCardContentType, Content: c}},
+	}
+}
+
+// color maps the alert severity to the adaptive card text color.
+func color(severity alertsender.Severity) string {
+	switch severity {
+	case alertsender.Medium:
+		return "Warning"
+	case alertsender.High, alertsender.Critical:
+		return "Attention"
+	default:
+		return "Good"
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package teams
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamsSender(t *testing.T) {
+	messages := make(chan message, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var m message
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&m)) {
+			return
+		}
+		messages <- m
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s, err := alertsender.Load(alertsender.Config{Type: alertsender.Teams, Sender: Config{URL: srv.URL}})
+	require.NoError(t, err)
+	require.True(t, s.SupportsMarkdown())
+	require.NoError(t, s.Send(alertsender.NewAlert("LSASS memory dumping", "Detected **mimikatz.exe** accessing LSASS", []string{"credential access", "T1003"}, alertsender.High)))
+
+	m := <-messages
+	assert.Equal(t, "message", m.Type)
+	require.Len(t, m.Attachments, 1)
+	assert.Equal(t, adaptiveCardContentType, m.Attachments[0].ContentType)
+
+	c := m.Attachments[0].Content
+	assert.Equal(t, "AdaptiveCard", c.Type)
+	assert.Equal(t, adaptiveCardVersion, c.Version)
+	require.Len(t, c.Body, 3)
+	assert.Equal(t, "LSASS memory dumping", c.Body[0].Text)
+	assert.Equal(t, "Attention", c.Body[0].Color)
+	assert.Equal(t, "Detected **mimikatz.exe** accessing LSASS", c.Body[1].Text)
+	assert.Equal(t, []fact{{"Severity", "high"}, {"Tags", "credential access, T1003"}}, c.Body[2].Facts)
+}
+
+func TestTeamsSenderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Webhook message delivery failed", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s, err := makeSender(alertsender.Config{Type: alertsender.Teams, Sender: Config{URL: srv.URL}})
+	require.NoError(t, err)
+	require.Error(t, s.Send(alertsender.NewAlert("LSASS memory dumping", "", nil, alertsender.Normal)))
+}
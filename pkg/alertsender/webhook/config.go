@@ -0,0 +1,67 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	enabled     = "alertsenders.webhook.enabled"
+	url         = "alertsenders.webhook.url"
+	method      = "alertsenders.webhook.method"
+	headers     = "alertsenders.webhook.headers"
+	contentType = "alertsenders.webhook.content-type"
+	tmpl        = "alertsenders.webhook.template"
+	timeout     = "alertsenders.webhook.timeout"
+)
+
+// defaultTemplate renders the alert as JSON object
+const defaultTemplate = `{"title": {{ .Title | toJson }}, "text": {{ .Text | toJson }}, "severity": {{ .Severity.String | toJson }}, "tags": {{ .Tags | toJson }}}`
+
+// Config stores the settings that dictate the behaviour of the webhook alert sender.
+type Config struct {
+	// Enabled determines if webhook alert sender is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// URL represents the endpoint where alerts are delivered.
+	URL string `mapstructure:"url"`
+	// Method is the HTTP verb used in requests.
+	Method string `mapstructure:"method"`
+	// Headers contains a list of additional headers in the HTTP request.
+	Headers map[string]string `mapstructure:"headers"`
+	// ContentType is the value of the Content-Type header.
+	ContentType string `mapstructure:"content-type"`
+	// Template is the Go template for rendering the request body.
+	Template string `mapstructure:"template"`
+	// Timeout represents the timeout for the HTTP requests.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(enabled, false, "Determines whether webhook alert sender is enabled")
+	flags.String(url, "", "Represents the endpoint where alerts are delivered")
+	flags.String(method, "POST", "Determines the HTTP verb to use in requests")
+	flags.StringToString(headers, map[string]string{}, "Contains a list of additional headers in the HTTP request")
+	flags.String(contentType, "application/json", "Specifies the value of the Content-Type header")
+	flags.String(tmpl, "", "Specifies the Go template for rendering the request body")
+	flags.Duration(timeout, time.Second*10, "Represents the timeout for the HTTP requests")
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+)
+
+type webhook struct {
+	client *http.Client
+	config Config
+	tmpl   *template.Template
+}
+
+func init() {
+	alertsender.Register(alertsender.Webhook, makeSender)
+}
+
+// makeSender constructs a new instance of the webhook alert sender.
+func makeSender(config alertsender.Config) (alertsender.Sender, error) {
+	c, ok := config.Sender.(Config)
+	if !ok {
+		return nil, alertsender.ErrInvalidConfig(alertsender.Webhook)
+	}
+	if c.URL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	if c.Method == "" {
+		c.Method = http.MethodPost
+	}
+	if c.ContentType == "" {
+		c.ContentType = "application/json"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Second * 10
+	}
+	text := c.Template
+	if text == "" {
+		text = defaultTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %v", err)
+	}
+	return &webhook{config: c, tmpl: tmpl, client: &http.Client{Timeout: c.Timeout}}, nil
+}
+
+func (w webhook) Send(alert alertsender.Alert) error {
+	var body bytes.Buffer
+	if err := w.tmpl.Execute(&body, alert); err != nil {
+		return fmt.Errorf("unable to render webhook body: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, w.config.Method, w.config.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.config.ContentType)
+	req.Header.Set("User-Agent", version.ProductToken())
+	for k, v := range w.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to send alert to webhook. code: %d content: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
+
+func (w webhook) Type() alertsender.Type { return alertsender.Webhook }
+func (w webhook) Shutdown() error        { return nil }
+func (w webhook) SupportsMarkdown() bool { return false }
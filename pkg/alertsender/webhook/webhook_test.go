@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSender(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer 3c5ef", r.Header.Get("Authorization"))
+		b, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		bodies <- b
+	}))
+	defer srv.Close()
+
+	s, err := alertsender.Load(alertsender.Config{
+		Type: alertsender.Webhook,
+		Sender: Config{
+			URL:     srv.URL,
+			Method:  http.MethodPut,
+			Headers: map[string]string{"Authorization": "Bearer 3c5ef"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Send(alertsender.NewAlert("LSASS memory dumping", `Detected "mimikatz.exe" accessing LSASS`, []string{"credential access"}, alertsender.Critical)))
+
+	var alert map[string]any
+	require.NoError(t, json.Unmarshal(<-bodies, &alert))
+	assert.Equal(t, "LSASS memory dumping", alert["title"])
+	assert.Equal(t, `Detected "mimikatz.exe" accessing LSASS`, alert["text"])
+	assert.Equal(t, "critical", alert["severity"])
+	assert.Equal(t, []any{"credential access"}, alert["tags"])
+}
+
+func TestWebhookSenderCustomTemplate(t *testing.T) {
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	s, err := makeSender(alertsender.Config{
+		Type:   alertsender.Webhook,
+		Sender: Config{URL: srv.URL, ContentType: "text/plain", Template: `[{{ .Severity.String | upper }}] {{ .Title }}`},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Send(alertsender.NewAlert("LSASS memory dumping", "", nil, alertsender.High)))
+	assert.Equal(t, "[HIGH] LSASS memory dumping", <-bodies)
+}
+
+func TestWebhookSenderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	s, err := makeSender(alertsender.Config{Type: alertsender.Webhook, Sender: Config{URL: srv.URL}})
+	require.NoError(t, err)
+	require.Error(t, s.Send(alertsender.NewAlert("LSASS memory dumping", "", nil, alertsender.High)))
+
+	_, err = makeSender(alertsender.Config{Type: alertsender.Webhook, Sender: Config{URL: srv.URL, Template: "{{ .Title "}})
+	require.Error(t, err)
+}
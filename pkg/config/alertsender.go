@@ -23,8 +23,12 @@ import (
 	"fmt"
 	"github.com/rabbitstack/fibratus/pkg/alertsender"
 	"github.com/rabbitstack/fibratus/pkg/alertsender/mail"
+	"github.com/rabbitstack/fibratus/pkg/alertsender/opsgenie"
+	"github.com/rabbitstack/fibratus/pkg/alertsender/pagerduty"
 	"github.com/rabbitstack/fibratus/pkg/alertsender/slack"
 	"github.com/rabbitstack/fibratus/pkg/alertsender/systray"
+	"github.com/rabbitstack/fibratus/pkg/alertsender/teams"
+	"github.com/rabbitstack/fibratus/pkg/alertsender/webhook"
 	"reflect"
 )
 
@@ -46,47 +50,33 @@ func (c *Config) tryLoadAlertSenders() error {
 		return fmt.Errorf("expected map[string]interface{} type for alertsenders but found %s", reflect.TypeOf(alertsenders))
 	}
 
+	names := make(map[string]bool)
 	for typ, config := range mapping {
-		switch typ {
-		case "mail":
-			var mailConfig mail.Config
-			if err := decode(config, &mailConfig); err != nil {
-				return errAlertsenderConfig(typ, err)
+		// multiple instances of the same alert
+		// sender type are declared as a list
+		instances, ok := config.([]interface{})
+		if !ok {
+			instances = []interface{}{config}
+		}
+		for _, instance := range instances {
+			senderConfig, err := decodeAlertsender(typ, instance)
+			if err != nil {
+				return err
 			}
-			if !mailConfig.Enabled {
+			if senderConfig == nil {
 				continue
 			}
-			config := alertsender.Config{
-				Type:   alertsender.Mail,
-				Sender: mailConfig,
-			}
-			configs = append(configs, config)
-		case "slack":
-			var slackConfig slack.Config
-			if err := decode(config, &slackConfig); err != nil {
+			var opts alertsenderOpts
+			if err := decode(instance, &opts); err != nil {
 				return errAlertsenderConfig(typ, err)
 			}
-			if !slackConfig.Enabled {
-				continue
-			}
-			config := alertsender.Config{
-				Type:   alertsender.Slack,
-				Sender: slackConfig,
-			}
-			configs = append(configs, config)
-		case "systray":
-			var systrayConfig systray.Config
-			if err := decode(config, &systrayConfig); err != nil {
-				return errAlertsenderConfig(typ, err)
+			senderConfig.Name, senderConfig.Routes = opts.Name, opts.Routes
+			name := senderConfig.InstanceName()
+			if names[name] {
+				return errAlertsenderConfig(typ, fmt.Errorf("duplicate alert sender name %q. Each instance must have a unique name", name))
 			}
-			if !systrayConfig.Enabled {
-				continue
-			}
-			config := alertsender.Config{
-				Type:   alertsender.Systray,
-				Sender: systrayConfig,
-			}
-			configs = append(configs, config)
+			names[name] = true
+			configs = append(configs, *senderConfig)
 		}
 	}
 
@@ -94,3 +84,101 @@ func (c *Config) tryLoadAlertSenders() error {
 
 	return nil
 }
+
+// alertsenderOpts contains the options common to all alert senders.
+type alertsenderOpts struct {
+	Name   string              `mapstructure:"name"`
+	Routes []alertsender.Route `mapstructure:"routes"`
+}
+
+// decodeAlertsender decodes the alert sender config of the given
+// type. Nil config is returned if the alert sender is disabled.
+func decodeAlertsender(typ string, config interface{}) (*alertsender.Config, error) {
+	switch typ {
+	case "mail":
+		var mailConfig mail.Config
+		if err := decode(config, &mailConfig); err != nil {
+			return nil, errAlertsenderConfig(typ, err)
+		}
+		if !mailConfig.Enabled {
+			return nil, nil
+		}
+		return &alertsender.Config{
+			Type:   alertsender.Mail,
+			Sender: mailConfig,
+		}, nil
+	case "slack":
+		var slackConfig slack.Config
+		if err := decode(config, &slackConfig); err != nil {
+			return nil, errAlertsenderConfig(typ, err)
+		}
+		if !slackConfig.Enabled {
+			return nil, nil
+		}
+		return &alertsender.Config{
+			Type:   alertsender.Slack,
+			Sender: slackConfig,
+		}, nil
+	case "systray":
+		var systrayConfig systray.Config
+		if err := decode(config, &systrayConfig); err != nil {
+			return nil, errAlertsenderConfig(typ, err)
+		}
+		if !systrayConfig.Enabled {
+			return nil, nil
+		}
+		return &alertsender.Config{
+			Type:   alertsender.Systray,
+			Sender: systrayConfig,
+		}, nil
+	case "webhook":
+		var webhookConfig webhook.Config
+		if err := decode(config, &webhookConfig); err != nil {
+			return nil, errAlertsenderConfig(typ, err)
+		}
+		if !webhookConfig.Enabled {
+			return nil, nil
+		}
+		return &alertsender.Config{
+			Type:   alertsender.Webhook,
+			Sender: webhookConfig,
+		}, nil
+	case "teams":
+		var teamsConfig teams.Config
+		if err := decode(config, &teamsConfig); err != nil {
+			return nil, errAlertsenderConfig(typ, err)
+		}
+		if !teamsConfig.Enabled {
+			return nil, nil
+		}
+		return &alertsender.Config{
+			Type:   alertsender.Teams,
+			Sender: teamsConfig,
+		}, nil
+	case "pagerduty":
+		var pagerdutyConfig pagerduty.Config
+		if err := decode(config, &pagerdutyConfig); err != nil {
+			return nil, errAlertsenderConfig(typ, err)
+		}
+		if !pagerdutyConfig.Enabled {
+			return nil, nil
+		}
+		return &alertsender.Config{
+			Type:   alertsender.PagerDuty,
+			Sender: pagerdutyConfig,
+		}, nil
+	case "opsgenie":
+		var opsgenieConfig opsgenie.Config
+		if err := decode(config, &opsgenieConfig); err != nil {
+			return nil, errAlertsenderConfig(typ, err)
+		}
+		if !opsgenieConfig.Enabled {
+			return nil, nil
+		}
+		return &alertsender.Config{
+			Type:   alertsender.Opsgenie,
+			Sender: opsgenieConfig,
+		}, nil
+	}
+	return nil, nil
+}
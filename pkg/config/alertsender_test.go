@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/rabbitstack/fibratus/pkg/alertsender/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"testing"
+)
+
+func TestAlertsenders(t *testing.T) {
+	c := NewWithOpts(WithRun())
+
+	err := c.flags.Parse([]string{"--config-file=_fixtures/alertsenders.yml"})
+	require.NoError(t, c.viper.BindPFlags(c.flags))
+	require.NoError(t, err)
+	require.NoError(t, c.TryLoadFile(c.GetConfigFile()))
+
+	require.NoError(t, c.Init())
+	require.Empty(t, c.Validate())
+
+	require.Len(t, c.Alertsenders, 4)
+	sort.Slice(c.Alertsenders, func(i, j int) bool {
+		return c.Alertsenders[i].InstanceName() < c.Alertsenders[j].InstanceName()
+	})
+
+	irTeam := c.Alertsenders[0]
+	assert.Equal(t, "ir-team", irTeam.Name)
+	assert.Equal(t, alertsender.Mail, irTeam.Type)
+	require.IsType(t, mail.Config{}, irTeam.Sender)
+	assert.Equal(t, []string{"ir@gmail.com"}, irTeam.Sender.(mail.Config).To)
+	assert.Equal(t, []alertsender.Route{{Labels: map[string]string{"tactic.id": "TA0006"}}}, irTeam.Routes)
+
+	pagerduty := c.Alertsenders[1]
+	assert.Equal(t, "pagerduty", pagerduty.InstanceName())
+	assert.Equal(t, []alertsender.Route{{MinSeverity: "critical"}}, pagerduty.Routes)
+
+	slack := c.Alertsenders[2]
+	assert.Equal(t, "slack", slack.InstanceName())
+	assert.Empty(t, slack.Routes)
+
+	soc := c.Alertsenders[3]
+	assert.Equal(t, "soc", soc.Name)
+	assert.Equal(t, []string{"soc@gmail.com"}, soc.Sender.(mail.Config).To)
+}
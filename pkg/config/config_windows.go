@@ -25,8 +25,13 @@ import (
 	"time"
 
 	"github.com/rabbitstack/fibratus/pkg/outputs/eventlog"
+	"github.com/rabbitstack/fibratus/pkg/outputs/file"
 
 	"github.com/rabbitstack/fibratus/pkg/outputs/http"
+	"github.com/rabbitstack/fibratus/pkg/outputs/kafka"
+	"github.com/rabbitstack/fibratus/pkg/outputs/otlp"
+	"github.com/rabbitstack/fibratus/pkg/outputs/splunk"
+	"github.com/rabbitstack/fibratus/pkg/outputs/syslog"
 
 	"github.com/rabbitstack/fibratus/pkg/aggregator"
 	"github.com/rabbitstack/fibratus/pkg/aggregator/transformers"
@@ -50,8 +55,12 @@ import (
 
 	"github.com/rabbitstack/fibratus/pkg/alertsender"
 	mailsender "github.com/rabbitstack/fibratus/pkg/alertsender/mail"
+	opsgeniesender "github.com/rabbitstack/fibratus/pkg/alertsender/opsgenie"
+	pagerdutysender "github.com/rabbitstack/fibratus/pkg/alertsender/pagerduty"
 	slacksender "github.com/rabbitstack/fibratus/pkg/alertsender/slack"
 	systraysender "github.com/rabbitstack/fibratus/pkg/alertsender/systray"
+	teamssender "github.com/rabbitstack/fibratus/pkg/alertsender/teams"
+	webhooksender "github.com/rabbitstack/fibratus/pkg/alertsender/webhook"
 	"github.com/rabbitstack/fibratus/pkg/outputs"
 	"github.com/rabbitstack/fibratus/pkg/outputs/console"
 	"github.com/rabbitstack/fibratus/pkg/pe"
@@ -84,8 +93,8 @@ type Config struct {
 	Filament FilamentConfig `json:"filament" yaml:"filament"`
 	// PE contains the settings that influences the behaviour of the PE (Portable Executable) reader.
 	PE pe.Config `json:"pe" yaml:"pe"`
-	// Output stores the currently active output config
-	Output outputs.Config
+	// Outputs stores the configs of all active outputs
+	Outputs []outputs.Config
 	// InitHandleSnapshot indicates whether initial handle snapshot is built
 	InitHandleSnapshot bool `json:"init-handle-snapshot" yaml:"init-handle-snapshot"`
 	// EnumerateHandles indicates if process handles are collected during startup or
@@ -215,6 +224,11 @@ func NewWithOpts(options ...Option) *Config {
 		amqp.AddFlags(flagSet)
 		elasticsearch.AddFlags(flagSet)
 		http.AddFlags(flagSet)
+		kafka.AddFlags(flagSet)
+		syslog.AddFlags(flagSet)
+		file.AddFlags(flagSet)
+		otlp.AddFlags(flagSet)
+		splunk.AddFlags(flagSet)
 		eventlog.AddFlags(flagSet)
 		removet.AddFlags(flagSet)
 		replacet.AddFlags(flagSet)
@@ -224,6 +238,10 @@ func NewWithOpts(options ...Option) *Config {
 		mailsender.AddFlags(flagSet)
 		slacksender.AddFlags(flagSet)
 		systraysender.AddFlags(flagSet)
+		webhooksender.AddFlags(flagSet)
+		teamssender.AddFlags(flagSet)
+		pagerdutysender.AddFlags(flagSet)
+		opsgeniesender.AddFlags(flagSet)
 		yara.AddFlags(flagSet)
 	}
 
@@ -371,6 +389,21 @@ func (c *Config) addFlags() {
 		c.flags.StringSlice(rulesFromPaths, []string{filepath.Join(dir, "*")}, "Comma-separated list of rules files")
 		c.flags.StringSlice(macrosFromPaths, []string{filepath.Join(dir, "Macros", "*")}, "Comma-separated list of macro files")
 		c.flags.StringSlice(rulesFromURLs, []string{}, "Comma-separated list of rules URL resources")
+		c.flags.StringSlice(exceptionsFromPaths, []string{filepath.Join(dir, "Exceptions", "*")}, "Comma-separated list of rule exception files")
+		c.flags.StringSlice(listsFromPaths, []string{filepath.Join(dir, "Lists", "*")}, "Comma-separated list of lookup table files")
+		c.flags.StringSlice(listsFromURLs, []string{}, "Comma-separated list of lookup table URL resources")
+		c.flags.Duration(listsRefreshInterval, DefaultListsRefreshInterval, "Specifies how often lookup table sources are checked for changes")
+		c.flags.Int(maxSequenceExpressions, DefaultMaxSequenceExpressions, "Specifies the maximum number of expressions in a sequence rule")
+		c.flags.Duration(maxSequenceSpan, DefaultMaxSequenceSpan, "Specifies the largest permitted max span in sequence rules")
+		c.flags.Int(maxSequencePartials, DefaultMaxSequencePartials, "Specifies the maximum number of partial matches per sequence expression")
+		c.flags.Bool(compactPartials, false, "Indicates if partial matches only retain the event state required to evaluate the sequence")
+		c.flags.Bool(riskEnabled, false, "Indicates if entity risk scoring is enabled")
+		c.flags.Float64(riskThreshold, DefaultRiskThreshold, "Specifies the entity risk score that triggers the risk alert")
+		c.flags.Int(riskTactics, DefaultRiskTactics, "Specifies the number of distinct MITRE tactics that trigger the risk alert")
+		c.flags.Duration(riskHalfLife, DefaultRiskHalfLife, "Specifies the time after which the entity risk score is halved")
+		c.flags.Duration(riskTacticsWindow, DefaultRiskTacticsWindow, "Specifies the time window for counting distinct MITRE tactics")
+		c.flags.Int(riskProcessDepth, 2, "Specifies the number of ancestors the process risk score propagates to")
+		c.flags.StringSlice(riskEntities, []string{"process", "user"}, "Comma-separated list of entity kinds risk scores are accumulated for")
 	}
 	if c.opts.capture {
 		c.flags.StringP(kcapFile, "o", "", "The path of the output kcap file")
@@ -36,3 +36,22 @@ func decode(input, output interface{}) error {
 	}
 	return decoder.Decode(input)
 }
+
+// decodeStrict is like decode, but fails if the input
+// contains keys that don't map to output fields.
+func decodeStrict(input, output interface{}) error {
+	var decoderConfig = &mapstructure.DecoderConfig{
+		Result:           output,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	}
+	decoder, err := mapstructure.NewDecoder(decoderConfig)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
@@ -38,6 +38,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 )
 
 // FilterConfig is the descriptor of a single filter.
@@ -50,8 +51,48 @@ type FilterConfig struct {
 	Severity         string            `json:"severity" yaml:"severity"`
 	Labels           map[string]string `json:"labels" yaml:"labels"`
 	MinEngineVersion string            `json:"min-engine-version" yaml:"min-engine-version"`
+	Threshold        *ThresholdConfig  `json:"threshold" yaml:"threshold"`
+	Suppress         *SuppressConfig   `json:"suppress" yaml:"suppress"`
 }
 
+// ThresholdConfig describes the count-based constraints of the rule.
+// When present, the rule fires only after its condition matches the
+// given number of times within the sliding time window. Matches can
+// be grouped by field values, and optionally, only the distinct values
+// of a field are counted.
+type ThresholdConfig struct {
+	// Count is the number of matches required to fire the rule. It must be at least 1
+	Count int `json:"count" yaml:"count"`
+	// Distinct designates the field whose unique values are counted
+	Distinct string `json:"distinct" yaml:"distinct"`
+	// Window represents the sliding time window duration
+	Window time.Duration `json:"window" yaml:"window"`
+	// By contains fields for grouping the matches
+	By []string `json:"by" yaml:"by"`
+}
+
+// HasThreshold determines if the rule has count-based constraints.
+func (f FilterConfig) HasThreshold() bool { return f.Threshold != nil }
+
+// SuppressConfig describes how alerts of noisy rules are throttled.
+// Alerts sharing the same deduplication key are emitted at most the
+// given number of times within the time window. The rest of alerts
+// are suppressed and summarized when the window ends.
+type SuppressConfig struct {
+	// By contains field modifiers whose values make up the deduplication
+	// key. Modifiers may be prefixed with the sequence event ordinal, e.g.
+	// 2.file.name
+	By []string `json:"by" yaml:"by"`
+	// Window represents the suppression time window duration
+	Window time.Duration `json:"window" yaml:"window"`
+	// Max is the number of alerts emitted per key within the window.
+	// Zero, which is the value when the attribute is omitted, means 1
+	Max int `json:"max" yaml:"max"`
+}
+
+// HasSuppress determines if the rule alerts are throttled.
+func (f FilterConfig) HasSuppress() bool { return f.Suppress != nil }
+
 // FilterGroup represents the container for filters.
 type FilterGroup struct {
 	Name        string            `json:"group" yaml:"group"`
@@ -73,6 +114,9 @@ type KillAction struct {
 	Pid string `json:"pid" yaml:"pid"`
 }
 
+// Name returns the action name.
+func (KillAction) Name() string { return "kill" }
+
 func (a KillAction) PidToInt(pid string) uint32 {
 	n, err := strconv.Atoi(pid)
 	if err != nil {
@@ -81,15 +125,97 @@ func (a KillAction) PidToInt(pid string) uint32 {
 	return uint32(n)
 }
 
+// SuspendAction defines an action for suspending
+// the process indicated by the filter field expression.
+type SuspendAction struct {
+	// Pid indicates the field for which
+	// the process id is resolved
+	Pid string `json:"pid" yaml:"pid"`
+}
+
+// Name returns the action name.
+func (SuspendAction) Name() string { return "suspend" }
+
+// ExecAction defines an action for running an external
+// command. Command arguments can reference event fields,
+// e.g. %ps.pid.
+type ExecAction struct {
+	// Command is the path of the executable.
+	Command string `json:"command" yaml:"command"`
+	// Args contains command line arguments.
+	Args []string `json:"args" yaml:"args"`
+	// Timeout specifies the maximum command execution time.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// Name returns the action name.
+func (ExecAction) Name() string { return "exec" }
+
+// WebhookAction defines an action for calling the HTTP
+// endpoint. The URL, header values, and the request body
+// can reference event fields.
+type WebhookAction struct {
+	// URL is the webhook endpoint.
+	URL string `json:"url" yaml:"url"`
+	// Method is the HTTP request method.
+	Method string `json:"method" yaml:"method"`
+	// Headers contains additional HTTP request headers.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+	// Body is the request body. If empty, the JSON
+	// representation of matched events is sent.
+	Body string `json:"body" yaml:"body"`
+	// Timeout specifies the request timeout.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// Name returns the action name.
+func (WebhookAction) Name() string { return "webhook" }
+
+// TagAction defines an action for adding metadata to
+// events that triggered the rule. Tag values can reference
+// event fields.
+type TagAction struct {
+	// Tags contains metadata keys and values.
+	Tags map[string]string `json:"tags" yaml:"tags"`
+}
+
+// Name returns the action name.
+func (TagAction) Name() string { return "tag" }
+
+// CaptureAction defines an action for writing events
+// that surround the rule match to the capture file.
+type CaptureAction struct {
+	// Before designates the time span of events captured
+	// before the rule match.
+	Before time.Duration `json:"before" yaml:"before"`
+	// After designates the time span of events captured
+	// after the rule match.
+	After time.Duration `json:"after" yaml:"after"`
+	// Path is the directory where capture files are written.
+	Path string `json:"path" yaml:"path"`
+}
+
+// Name returns the action name.
+func (CaptureAction) Name() string { return "capture" }
+
 // DecodeActions converts raw YAML map to
 // typed action structures.
 func (f FilterConfig) DecodeActions() ([]any, error) {
 	actions := make([]any, 0, len(f.Action))
 
 	dec := func(m map[string]any, o any) error {
-		err := decode(m, &o)
+		// the name key identifies the action
+		// and has no counterpart in the action
+		// structure
+		in := make(map[string]any, len(m))
+		for k, v := range m {
+			if k != "name" {
+				in[k] = v
+			}
+		}
+		err := decodeStrict(in, &o)
 		if err != nil {
-			return err
+			return fmt.Errorf("invalid %q action: %v", m["name"], err)
 		}
 		actions = append(actions, o)
 		return nil
@@ -100,12 +226,25 @@ func (f FilterConfig) DecodeActions() ([]any, error) {
 		if !ok {
 			continue
 		}
+		var err error
 		switch m["name"] {
 		case "kill":
-			var kill KillAction
-			if err := dec(m, kill); err != nil {
-				return nil, err
-			}
+			err = dec(m, KillAction{})
+		case "suspend":
+			err = dec(m, SuspendAction{})
+		case "exec":
+			err = dec(m, ExecAction{})
+		case "webhook":
+			err = dec(m, WebhookAction{})
+		case "tag":
+			err = dec(m, TagAction{})
+		case "capture":
+			err = dec(m, CaptureAction{})
+		default:
+			err = fmt.Errorf("unknown %q action", m["name"])
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
 	return actions, nil
@@ -123,10 +262,15 @@ func (g FilterGroup) Hash() uint32 {
 // Each filter group can contain multiple filter expressions which
 // represent the rules.
 type Filters struct {
-	Rules  Rules  `json:"rules" yaml:"rules"`
-	Macros Macros `json:"macros" yaml:"macros"`
-	macros map[string]*Macro
-	groups []FilterGroup
+	Rules      Rules      `json:"rules" yaml:"rules"`
+	Macros     Macros     `json:"macros" yaml:"macros"`
+	Sequences  Sequences  `json:"sequences" yaml:"sequences"`
+	Exceptions Exceptions `json:"exceptions" yaml:"exceptions"`
+	Risk       Risk       `json:"risk" yaml:"risk"`
+	Lists      Lists      `json:"lists" yaml:"lists"`
+	macros     map[string]*Macro
+	groups     []FilterGroup
+	exceptions []Exception
 }
 
 // FiltersWithMacros builds the filter config with the map of
@@ -149,6 +293,187 @@ type Macros struct {
 	FromPaths []string `json:"from-paths" yaml:"from-paths"`
 }
 
+const (
+	// DefaultMaxSequenceExpressions is the default maximum number of sequence expressions
+	DefaultMaxSequenceExpressions = 5
+	// DefaultMaxSequenceSpan is the default upper bound of the sequence max span
+	DefaultMaxSequenceSpan = time.Hour * 4
+	// DefaultMaxSequencePartials is the default maximum number of partials per sequence slot
+	DefaultMaxSequencePartials = 1000
+)
+
+// Sequences contains attributes that control the limits
+// and the state of sequence rules.
+type Sequences struct {
+	// MaxExpressions is the maximum number of expressions in a sequence
+	MaxExpressions int `json:"max-expressions" yaml:"max-expressions"`
+	// MaxSpan is the largest permitted sequence max span
+	MaxSpan time.Duration `json:"max-span" yaml:"max-span"`
+	// MaxPartials is the maximum number of partials stored per sequence slot
+	MaxPartials int `json:"max-partials" yaml:"max-partials"`
+	// CompactPartials indicates if partials only retain the
+	// event state required to evaluate the sequence
+	CompactPartials bool `json:"compact-partials" yaml:"compact-partials"`
+}
+
+// GetMaxExpressions returns the maximum number of sequence expressions.
+func (s Sequences) GetMaxExpressions() int {
+	if s.MaxExpressions <= 0 {
+		return DefaultMaxSequenceExpressions
+	}
+	return s.MaxExpressions
+}
+
+// GetMaxSpan returns the largest permitted sequence max span.
+func (s Sequences) GetMaxSpan() time.Duration {
+	if s.MaxSpan <= 0 {
+		return DefaultMaxSequenceSpan
+	}
+	return s.MaxSpan
+}
+
+// GetMaxPartials returns the maximum number of partials per sequence slot.
+func (s Sequences) GetMaxPartials() int {
+	if s.MaxPartials <= 0 {
+		return DefaultMaxSequencePartials
+	}
+	return s.MaxPartials
+}
+
+const (
+	// DefaultRiskThreshold is the default entity risk score that triggers the risk alert
+	DefaultRiskThreshold = 100
+	// DefaultRiskTactics is the default number of distinct tactics that trigger the risk alert
+	DefaultRiskTactics = 3
+	// DefaultRiskHalfLife is the default time after which the entity risk score is halved
+	DefaultRiskHalfLife = time.Hour
+	// DefaultRiskTacticsWindow is the default window for counting distinct tactics
+	DefaultRiskTacticsWindow = time.Hour * 4
+)
+
+// RiskEntities are the entity kinds risk scores are accumulated for.
+var RiskEntities = []string{"process", "user", "host"}
+
+// Risk contains attributes that control the entity risk
+// scoring. Rule matches add the score derived from the rule
+// severity to the entities referenced by the matched event.
+type Risk struct {
+	// Enabled indicates if risk scoring is enabled
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Threshold is the risk score that triggers the risk alert
+	Threshold float64 `json:"threshold" yaml:"threshold"`
+	// Tactics is the number of distinct MITRE tactics that trigger the risk alert
+	Tactics int `json:"tactics" yaml:"tactics"`
+	// HalfLife is the time after which the entity risk score is halved
+	HalfLife time.Duration `json:"half-life" yaml:"half-life"`
+	// TacticsWindow is the time window for counting distinct tactics
+	TacticsWindow time.Duration `json:"tactics-window" yaml:"tactics-window"`
+	// ProcessDepth is the number of ancestors the process risk score propagates to
+	ProcessDepth int `json:"process-depth" yaml:"process-depth"`
+	// Entities contains the entity kinds risk scores are accumulated for
+	Entities []string `json:"entities" yaml:"entities"`
+	// Scores maps rule severities to risk scores
+	Scores map[string]float64 `json:"scores" yaml:"scores"`
+}
+
+// defaultRiskScores maps rule severities to default risk scores
+var defaultRiskScores = map[string]float64{
+	"low":      5,
+	"medium":   20,
+	"high":     40,
+	"critical": 80,
+}
+
+// GetThreshold returns the risk score that triggers the risk alert.
+func (r Risk) GetThreshold() float64 {
+	if r.Threshold <= 0 {
+		return DefaultRiskThreshold
+	}
+	return r.Threshold
+}
+
+// GetTactics returns the number of distinct tactics that trigger the risk alert.
+func (r Risk) GetTactics() int {
+	if r.Tactics <= 0 {
+		return DefaultRiskTactics
+	}
+	return r.Tactics
+}
+
+// GetHalfLife returns the risk score half-life.
+func (r Risk) GetHalfLife() time.Duration {
+	if r.HalfLife <= 0 {
+		return DefaultRiskHalfLife
+	}
+	return r.HalfLife
+}
+
+// GetTacticsWindow returns the window for counting distinct tactics.
+func (r Risk) GetTacticsWindow() time.Duration {
+	if r.TacticsWindow <= 0 {
+		return DefaultRiskTacticsWindow
+	}
+	return r.TacticsWindow
+}
+
+// GetEntities returns the entity kinds risk scores are accumulated for.
+func (r Risk) GetEntities() []string {
+	if len(r.Entities) == 0 {
+		return []string{"process", "user"}
+	}
+	return r.Entities
+}
+
+// GetScore returns the risk score of the rule severity. Unknown
+// or missing severities are scored as low severity rules.
+func (r Risk) GetScore(severity string) float64 {
+	severity = strings.ToLower(severity)
+	if score, ok := r.Scores[severity]; ok {
+		return score
+	}
+	if score, ok := defaultRiskScores[severity]; ok {
+		return score
+	}
+	if score, ok := r.Scores["low"]; ok {
+		return score
+	}
+	return defaultRiskScores["low"]
+}
+
+// Exceptions contains attributes that describe the location of
+// exception resources.
+type Exceptions struct {
+	FromPaths []string `json:"from-paths" yaml:"from-paths"`
+}
+
+// Lists contains attributes that describe the location of lookup
+// tables and determine how often the tables are refreshed.
+type Lists struct {
+	FromPaths       []string      `json:"from-paths" yaml:"from-paths"`
+	FromURLs        []string      `json:"from-urls" yaml:"from-urls"`
+	RefreshInterval time.Duration `json:"refresh-interval" yaml:"refresh-interval"`
+}
+
+// DefaultListsRefreshInterval is the default interval for checking lookup table sources for changes
+const DefaultListsRefreshInterval = time.Minute
+
+// Exception excludes the benign activity from the rules. The exception
+// targets rules by their names or the names of the groups they pertain
+// to. The exception condition is negated and combined with the rule
+// condition, so the rule doesn't fire if the exception matches.
+type Exception struct {
+	Name        string   `json:"exception" yaml:"exception"`
+	Description string   `json:"description" yaml:"description"`
+	Rules       []string `json:"rules" yaml:"rules"`
+	Groups      []string `json:"groups" yaml:"groups"`
+	Condition   string   `json:"condition" yaml:"condition"`
+}
+
+// Applies determines if the exception targets the given rule.
+func (e Exception) Applies(rule *FilterConfig, group FilterGroup) bool {
+	return slices.Contains(e.Rules, rule.Name) || slices.Contains(e.Groups, group.Name)
+}
+
 // Macro represents the state of the rule macro. Macros
 // either expand to expressions or lists.
 type Macro struct {
@@ -171,6 +496,10 @@ type ActionContext struct {
 	Filter *FilterConfig
 	// Group represents the group where the filter is declared
 	Group FilterGroup
+	// Key identifies the entity the filter matched. It is
+	// the join value for sequence policies or the process
+	// of the event that triggered the filter
+	Key string
 }
 
 // RulesCompileResult contains the stats of the
@@ -247,6 +576,26 @@ const (
 	rulesFromPaths  = "filters.rules.from-paths"
 	rulesFromURLs   = "filters.rules.from-urls"
 	macrosFromPaths = "filters.macros.from-paths"
+
+	maxSequenceExpressions = "filters.sequences.max-expressions"
+	maxSequenceSpan        = "filters.sequences.max-span"
+	maxSequencePartials    = "filters.sequences.max-partials"
+	compactPartials        = "filters.sequences.compact-partials"
+
+	exceptionsFromPaths = "filters.exceptions.from-paths"
+
+	listsFromPaths       = "filters.lists.from-paths"
+	listsFromURLs        = "filters.lists.from-urls"
+	listsRefreshInterval = "filters.lists.refresh-interval"
+
+	riskEnabled       = "filters.risk.enabled"
+	riskThreshold     = "filters.risk.threshold"
+	riskTactics       = "filters.risk.tactics"
+	riskHalfLife      = "filters.risk.half-life"
+	riskTacticsWindow = "filters.risk.tactics-window"
+	riskProcessDepth  = "filters.risk.process-depth"
+	riskEntities      = "filters.risk.entities"
+	riskScores        = "filters.risk.scores"
 )
 
 func (f *Filters) initFromViper(v *viper.Viper) {
@@ -254,6 +603,25 @@ func (f *Filters) initFromViper(v *viper.Viper) {
 	f.Rules.FromPaths = v.GetStringSlice(rulesFromPaths)
 	f.Rules.FromURLs = v.GetStringSlice(rulesFromURLs)
 	f.Macros.FromPaths = v.GetStringSlice(macrosFromPaths)
+	f.Sequences.MaxExpressions = v.GetInt(maxSequenceExpressions)
+	f.Sequences.MaxSpan = v.GetDuration(maxSequenceSpan)
+	f.Sequences.MaxPartials = v.GetInt(maxSequencePartials)
+	f.Sequences.CompactPartials = v.GetBool(compactPartials)
+	f.Exceptions.FromPaths = v.GetStringSlice(exceptionsFromPaths)
+	f.Lists.FromPaths = v.GetStringSlice(listsFromPaths)
+	f.Lists.FromURLs = v.GetStringSlice(listsFromURLs)
+	f.Lists.RefreshInterval = v.GetDuration(listsRefreshInterval)
+	f.Risk.Enabled = v.GetBool(riskEnabled)
+	f.Risk.Threshold = v.GetFloat64(riskThreshold)
+	f.Risk.Tactics = v.GetInt(riskTactics)
+	f.Risk.HalfLife = v.GetDuration(riskHalfLife)
+	f.Risk.TacticsWindow = v.GetDuration(riskTacticsWindow)
+	f.Risk.ProcessDepth = v.GetInt(riskProcessDepth)
+	f.Risk.Entities = v.GetStringSlice(riskEntities)
+	f.Risk.Scores = make(map[string]float64)
+	for severity := range v.GetStringMap(riskScores) {
+		f.Risk.Scores[severity] = v.GetFloat64(riskScores + "." + severity)
+	}
 }
 
 func (f Filters) HasMacros() bool           { return len(f.macros) > 0 }
@@ -321,6 +689,87 @@ func (f *Filters) LoadMacros() error {
 	return nil
 }
 
+// LoadExceptions loads exceptions from all exception files. Rule
+// groups must be loaded prior to calling this method, as every
+// exception is validated to target existing rules or groups.
+func (f *Filters) LoadExceptions() error {
+	f.exceptions = make([]Exception, 0)
+	for _, p := range f.Exceptions.FromPaths {
+		paths, err := filepath.Glob(p)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if !isValidExt(path) {
+				continue
+			}
+			log.Infof("loading exceptions from file %s", path)
+			buf, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("couldn't load exceptions from file: %v", err)
+			}
+			// validate exception yaml structure
+			var out interface{}
+			err = yaml.Unmarshal(buf, &out)
+			if err != nil {
+				return fmt.Errorf("%q is invalid exception yaml file: %v", path, err)
+			}
+			valid, errs := validate(exceptionsSchema, out)
+			if !valid || len(errs) > 0 {
+				b, err := yaml.Marshal(&out)
+				if err == nil {
+					out = string(b)
+				}
+				return fmt.Errorf("invalid exception definition: \n\n"+
+					"%v in %s: %v", out, path, multierror.Wrap(errs...))
+			}
+			buf, err = renderTmpl(path, buf)
+			if err != nil {
+				return err
+			}
+			var exceptions []Exception
+			if err := yaml.Unmarshal(buf, &exceptions); err != nil {
+				return err
+			}
+			f.exceptions = append(f.exceptions, exceptions...)
+		}
+	}
+
+	// check the exceptions target existing rules and groups
+	rules := make(map[string]bool)
+	groups := make(map[string]bool)
+	for _, group := range f.groups {
+		groups[group.Name] = true
+		for _, rule := range group.Rules {
+			rules[rule.Name] = true
+		}
+	}
+	for _, e := range f.exceptions {
+		for _, rule := range e.Rules {
+			if !rules[rule] {
+				return fmt.Errorf("%q exception targets unknown %q rule", e.Name, rule)
+			}
+		}
+		for _, group := range e.Groups {
+			if !groups[group] {
+				return fmt.Errorf("%q exception targets unknown %q group", e.Name, group)
+			}
+		}
+	}
+	return nil
+}
+
+// GetExceptions returns all exceptions that target the given rule.
+func (f Filters) GetExceptions(rule *FilterConfig, group FilterGroup) []Exception {
+	exceptions := make([]Exception, 0)
+	for _, e := range f.exceptions {
+		if e.Applies(rule, group) {
+			exceptions = append(exceptions, e)
+		}
+	}
+	return exceptions
+}
+
 func isValidExt(path string) bool {
 	return filepath.Ext(path) == ".yml" || filepath.Ext(path) == ".yaml"
 }
@@ -28,6 +28,41 @@ import (
 	"testing"
 )
 
+func TestLoadExceptions(t *testing.T) {
+	filters := Filters{
+		Rules: Rules{
+			FromPaths: []string{
+				"_fixtures/filters/default.yml",
+			},
+		},
+		Exceptions: Exceptions{
+			FromPaths: []string{
+				"_fixtures/exceptions/default.yml",
+			},
+		},
+	}
+	require.NoError(t, filters.LoadGroups())
+	require.NoError(t, filters.LoadExceptions())
+	require.Len(t, filters.exceptions, 2)
+
+	e1 := filters.exceptions[0]
+	assert.Equal(t, "Trusted Java processes", e1.Name)
+	assert.Equal(t, "Java processes from the trusted location", e1.Description)
+	assert.Equal(t, []string{"suspicious network ACTIVITY"}, e1.Rules)
+	assert.Equal(t, "ps.exe imatches 'C:\\\\Program Files\\\\Java\\\\*'", e1.Condition)
+
+	g1, g2 := filters.groups[0], filters.groups[1]
+	exceptions := filters.GetExceptions(g1.Rules[0], g1)
+	require.Len(t, exceptions, 1)
+	assert.Equal(t, "Local network traffic", exceptions[0].Name)
+	exceptions = filters.GetExceptions(g2.Rules[0], g2)
+	require.Len(t, exceptions, 1)
+	assert.Equal(t, "Trusted Java processes", exceptions[0].Name)
+
+	filters.Exceptions.FromPaths = []string{"_fixtures/exceptions/unknown-rule.yml"}
+	require.EqualError(t, filters.LoadExceptions(), `"Trusted Java processes" exception targets unknown "suspicious network connections" rule`)
+}
+
 func TestLoadGroupsFromPaths(t *testing.T) {
 	filters := Filters{
 		Rules{
@@ -36,8 +71,13 @@ func TestLoadGroupsFromPaths(t *testing.T) {
 			},
 		},
 		Macros{FromPaths: nil},
+		Sequences{},
+		Exceptions{},
+		Risk{},
+		Lists{},
 		map[string]*Macro{},
 		[]FilterGroup{},
+		[]Exception{},
 	}
 	err := filters.LoadGroups()
 	require.NoError(t, err)
@@ -78,8 +118,13 @@ func TestLoadGroupsFromPathsNewAttributes(t *testing.T) {
 			},
 		},
 		Macros{FromPaths: nil},
+		Sequences{},
+		Exceptions{},
+		Risk{},
+		Lists{},
 		map[string]*Macro{},
 		[]FilterGroup{},
+		[]Exception{},
 	}
 	err := filters.LoadGroups()
 	require.NoError(t, err)
@@ -128,8 +173,13 @@ func TestLoadGroupsFromURLs(t *testing.T) {
 			},
 		},
 		Macros{FromPaths: nil},
+		Sequences{},
+		Exceptions{},
+		Risk{},
+		Lists{},
 		map[string]*Macro{},
 		[]FilterGroup{},
+		[]Exception{},
 	}
 	err = filters.LoadGroups()
 	require.NoError(t, err)
@@ -22,16 +22,21 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
+	"sort"
 
 	"github.com/rabbitstack/fibratus/pkg/outputs/eventlog"
+	"github.com/rabbitstack/fibratus/pkg/outputs/file"
 
 	"github.com/rabbitstack/fibratus/pkg/outputs"
 	"github.com/rabbitstack/fibratus/pkg/outputs/amqp"
 	"github.com/rabbitstack/fibratus/pkg/outputs/console"
 	"github.com/rabbitstack/fibratus/pkg/outputs/elasticsearch"
 	"github.com/rabbitstack/fibratus/pkg/outputs/http"
+	"github.com/rabbitstack/fibratus/pkg/outputs/kafka"
 	"github.com/rabbitstack/fibratus/pkg/outputs/null"
+	"github.com/rabbitstack/fibratus/pkg/outputs/otlp"
+	"github.com/rabbitstack/fibratus/pkg/outputs/splunk"
+	"github.com/rabbitstack/fibratus/pkg/outputs/syslog"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/windows/svc"
 )
@@ -50,29 +55,7 @@ func (c *Config) tryLoadOutput() error {
 		return fmt.Errorf("expected map[string]interface{} type for output but found %s", reflect.TypeOf(output))
 	}
 
-	humNum := func(n int) string {
-		switch n {
-		case 2:
-			return "two"
-		case 3:
-			return "three"
-		case 4:
-			return "four"
-		case 5:
-			return "five"
-		case 6:
-			return "six"
-		case 7:
-			return "seven"
-		default:
-			return strconv.Itoa(n)
-		}
-	}
-	// don't permit if there are various outputs enabled at a time
-	activeOutputs := findActiveOutputs(mapping)
-	if len(activeOutputs) > 1 {
-		return fmt.Errorf("expected one but found %s active outputs: %s", humNum(len(activeOutputs)), activeOutputs)
-	}
+	c.Outputs = make([]outputs.Config, 0)
 
 	for typ, config := range mapping {
 		switch outputs.TypeFromString(typ) {
@@ -84,7 +67,15 @@ func (c *Config) tryLoadOutput() error {
 			if !consoleConfig.Enabled {
 				continue
 			}
-			c.Output.Type, c.Output.Output = outputs.Console, consoleConfig
+			// if it is not an interactive session but the console output is enabled
+			// we default to null output and warn about that
+			if isWindowsService() {
+				log.Warn("running in non-interactive session with console output. " +
+					"Please configure a different output type. Defaulting to null output")
+				c.addOutput(outputs.Null, &null.Config{}, config)
+				continue
+			}
+			c.addOutput(outputs.Console, consoleConfig, config)
 
 		case outputs.AMQP:
 			var amqpConfig amqp.Config
@@ -94,7 +85,7 @@ func (c *Config) tryLoadOutput() error {
 			if !amqpConfig.Enabled {
 				continue
 			}
-			c.Output.Type, c.Output.Output = outputs.AMQP, amqpConfig
+			c.addOutput(outputs.AMQP, amqpConfig, config)
 
 		case outputs.Elasticsearch:
 			var esConfig elasticsearch.Config
@@ -104,7 +95,7 @@ func (c *Config) tryLoadOutput() error {
 			if !esConfig.Enabled {
 				continue
 			}
-			c.Output.Type, c.Output.Output = outputs.Elasticsearch, esConfig
+			c.addOutput(outputs.Elasticsearch, esConfig, config)
 
 		case outputs.HTTP:
 			var httpConfig http.Config
@@ -114,7 +105,7 @@ func (c *Config) tryLoadOutput() error {
 			if !httpConfig.Enabled {
 				continue
 			}
-			c.Output.Type, c.Output.Output = outputs.HTTP, httpConfig
+			c.addOutput(outputs.HTTP, httpConfig, config)
 
 		case outputs.Eventlog:
 			var eventlogConfig eventlog.Config
@@ -124,39 +115,76 @@ func (c *Config) tryLoadOutput() error {
 			if !eventlogConfig.Enabled {
 				continue
 			}
-			c.Output.Type, c.Output.Output = outputs.Eventlog, eventlogConfig
-		}
-	}
+			c.addOutput(outputs.Eventlog, eventlogConfig, config)
 
-	// if it is not an interactive session but the console output is enabled
-	// we default to null output and warn about that
-	if isWindowsService() && c.Output.Output != nil {
-		if c.Output.Type == outputs.Console {
-			log.Warn("running in non-interactive session with console output. " +
-				"Please configure a different output type. Defaulting to null output")
-			c.Output.Type, c.Output.Output = outputs.Null, &null.Config{}
-			return nil
+		case outputs.Kafka:
+			var kafkaConfig kafka.Config
+			if err := decode(config, &kafkaConfig); err != nil {
+				return errOutputConfig(typ, err)
+			}
+			if !kafkaConfig.Enabled {
+				continue
+			}
+			c.addOutput(outputs.Kafka, kafkaConfig, config)
+		case outputs.Syslog:
+			var syslogConfig syslog.Config
+			if err := decode(config, &syslogConfig); err != nil {
+				return errOutputConfig(typ, err)
+			}
+			if !syslogConfig.Enabled {
+				continue
+			}
+			c.addOutput(outputs.Syslog, syslogConfig, config)
+		case outputs.File:
+			var fileConfig file.Config
+			if err := decode(config, &fileConfig); err != nil {
+				return errOutputConfig(typ, err)
+			}
+			if !fileConfig.Enabled {
+				continue
+			}
+			c.addOutput(outputs.File, fileConfig, config)
+		case outputs.OTLP:
+			var otlpConfig otlp.Config
+			if err := decode(config, &otlpConfig); err != nil {
+				return errOutputConfig(typ, err)
+			}
+			if !otlpConfig.Enabled {
+				continue
+			}
+			c.addOutput(outputs.OTLP, otlpConfig, config)
+		case outputs.Splunk:
+			var splunkConfig splunk.Config
+			if err := decode(config, &splunkConfig); err != nil {
+				return errOutputConfig(typ, err)
+			}
+			if !splunkConfig.Enabled {
+				continue
+			}
+			c.addOutput(outputs.Splunk, splunkConfig, config)
 		}
 	}
 
 	// default to null output
-	if c.Output.Output == nil {
+	if len(c.Outputs) == 0 {
 		log.Warn("all outputs disabled. Defaulting to null output")
-		c.Output.Type, c.Output.Output = outputs.Null, &null.Config{}
+		c.Outputs = append(c.Outputs, outputs.Config{Type: outputs.Null, Output: &null.Config{}})
+		return nil
 	}
 
+	// keep a stable order of outputs as the settings map is randomly iterated
+	sort.Slice(c.Outputs, func(i, j int) bool { return c.Outputs[i].Type < c.Outputs[j].Type })
+
 	return nil
 }
 
-func findActiveOutputs(outputs map[string]interface{}) []string {
-	outputTypes := make([]string, 0)
-	for typ, rawConfig := range outputs {
-		enabled, ok := rawConfig.(map[string]interface{})["enabled"].(bool)
-		if ok && enabled {
-			outputTypes = append(outputTypes, typ)
-		}
+// addOutput appends the active output along with its optional routing filter expression.
+func (c *Config) addOutput(typ outputs.Type, output interface{}, rawConfig interface{}) {
+	var filter string
+	if m, ok := rawConfig.(map[string]interface{}); ok {
+		filter, _ = m["filter"].(string)
 	}
-	return outputTypes
+	c.Outputs = append(c.Outputs, outputs.Config{Type: typ, Output: output, Filter: filter})
 }
 
 // isWindowsService returns true if the process is running inside Windows Service.
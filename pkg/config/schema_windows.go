@@ -26,7 +26,162 @@ import (
 var schema = `
 {
 	"$schema": "http://json-schema.org/draft-07/schema#",
-	"definitions": {"yara": {"$id": "#yara", "type": "object", "properties": {"enabled": {"type": "boolean"}}}},
+	"definitions": {
+		"yara": {"$id": "#yara", "type": "object", "properties": {"enabled": {"type": "boolean"}}},
+		"alertsender-route": {
+			"$id": "#alertsender-route",
+			"type": "object",
+			"properties": {
+				"severities":	{"type": "array", "items": {"type": "string", "enum": ["low", "normal", "medium", "high", "critical"]}},
+				"min-severity":	{"type": "string", "enum": ["low", "normal", "medium", "high", "critical"]},
+				"tags":			{"type": "array", "items": {"type": "string", "minLength": 1}},
+				"labels":		{"type": "object", "additionalProperties": {"type": "string"}}
+			},
+			"additionalProperties": false
+		},
+		"mail-alertsender": {
+			"$id": "#mail-alertsender",
+			"type": "object",
+			"properties": {
+				"name": 		{"type": "string", "minLength": 1},
+				"routes": 		{"type": "array", "items": {"$ref": "#alertsender-route"}},
+				"enabled": 		{"type": "boolean"},
+				"host": 		{"type": "string"},
+				"port": 		{"type": "number"},
+				"user": 		{"type": "string"},
+				"password": 	{"type": "string"},
+				"from": 		{"type": "string"},
+				"to": 			{"type": "array", "items": {"type": "string", "format": "email"}},
+				"content-type": {"type": "string"}
+			},
+			"if": {
+				"properties": {"enabled": { "const": true }}
+			},
+			"then": {
+				"properties": {
+					"from": {"type": "string", "format": "email"},
+					"to": 	{"type": "array", "minItems": 1, "items": {"type": "string", "format": "email"}}
+				}
+			},
+			"additionalProperties": false
+		},
+		"slack-alertsender": {
+			"$id": "#slack-alertsender",
+			"type": "object",
+			"properties": {
+				"name": 		{"type": "string", "minLength": 1},
+				"routes": 		{"type": "array", "items": {"$ref": "#alertsender-route"}},
+				"enabled": 		{"type": "boolean"},
+				"url": 			{"type": "string"},
+				"workspace": 	{"type": "string"},
+				"channel": 		{"type": "string"},
+				"emoji": 		{"type": "string"}
+			},
+			"if": {
+				"properties": {"enabled": { "const": true }}
+			},
+			"then": {
+				"properties": {"url": {"type": "string", "format": "uri", "minLength": 1, "pattern": "^(https?|http?)://"}}
+			},
+			"additionalProperties": false
+		},
+		"systray-alertsender": {
+			"$id": "#systray-alertsender",
+			"type": "object",
+			"properties": {
+				"name": 		{"type": "string", "minLength": 1},
+				"routes": 		{"type": "array", "items": {"$ref": "#alertsender-route"}},
+				"enabled": 		{"type": "boolean"},
+				"sound": 		{"type": "boolean"},
+				"quiet-mode": 	{"type": "boolean"}
+			},
+			"additionalProperties": false
+		},
+		"webhook-alertsender": {
+			"$id": "#webhook-alertsender",
+			"type": "object",
+			"properties": {
+				"name": 		{"type": "string", "minLength": 1},
+				"routes": 		{"type": "array", "items": {"$ref": "#alertsender-route"}},
+				"enabled": 		{"type": "boolean"},
+				"url": 			{"type": "string"},
+				"method": 		{"type": "string", "enum": ["GET", "POST", "PUT", "PATCH"]},
+				"headers": 		{"type": "object", "additionalProperties": {"type": "string"}},
+				"content-type": {"type": "string"},
+				"template": 	{"type": "string"},
+				"timeout": 		{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m|h"}
+			},
+			"if": {
+				"properties": {"enabled": { "const": true }}
+			},
+			"then": {
+				"properties": {"url": {"type": "string", "format": "uri", "minLength": 1, "pattern": "^(https?|http?)://"}}
+			},
+			"additionalProperties": false
+		},
+		"teams-alertsender": {
+			"$id": "#teams-alertsender",
+			"type": "object",
+			"properties": {
+				"name": 		{"type": "string", "minLength": 1},
+				"routes": 		{"type": "array", "items": {"$ref": "#alertsender-route"}},
+				"enabled": 		{"type": "boolean"},
+				"url": 			{"type": "string"},
+				"timeout": 		{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m|h"}
+			},
+			"if": {
+				"properties": {"enabled": { "const": true }}
+			},
+			"then": {
+				"properties": {"url": {"type": "string", "format": "uri", "minLength": 1, "pattern": "^(https?|http?)://"}}
+			},
+			"additionalProperties": false
+		},
+		"pagerduty-alertsender": {
+			"$id": "#pagerduty-alertsender",
+			"type": "object",
+			"properties": {
+				"name": 		{"type": "string", "minLength": 1},
+				"routes": 		{"type": "array", "items": {"$ref": "#alertsender-route"}},
+				"enabled": 		{"type": "boolean"},
+				"routing-key": 	{"type": "string"},
+				"url": 			{"type": "string"},
+				"source": 		{"type": "string"},
+				"component": 	{"type": "string"},
+				"group": 		{"type": "string"},
+				"timeout": 		{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m|h"}
+			},
+			"if": {
+				"properties": {"enabled": { "const": true }}
+			},
+			"then": {
+				"properties": {"routing-key": {"type": "string", "minLength": 1}}
+			},
+			"additionalProperties": false
+		},
+		"opsgenie-alertsender": {
+			"$id": "#opsgenie-alertsender",
+			"type": "object",
+			"properties": {
+				"name": 		{"type": "string", "minLength": 1},
+				"routes": 		{"type": "array", "items": {"$ref": "#alertsender-route"}},
+				"enabled": 		{"type": "boolean"},
+				"api-key": 		{"type": "string"},
+				"url": 			{"type": "string"},
+				"responders": 	{"type": "array", "items": {"type": "string", "minLength": 1}},
+				"tags": 		{"type": "array", "items": {"type": "string"}},
+				"source": 		{"type": "string"},
+				"timeout": 		{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m|h"}
+			},
+			"if": {
+				"properties": {"enabled": { "const": true }}
+			},
+			"then": {
+				"properties": {"api-key": {"type": "string", "minLength": 1}}
+			},
+			"additionalProperties": false
+		}
+	},
 
 	"type": "object",
 	"properties": {
@@ -34,7 +189,19 @@ var schema = `
 			"type": "object",
 			"properties": {
 				"flush-period":		{"type": "string", "minLength": 2, "pattern": "[0-9]+ms|s"},
-				"flush-timeout":	{"type": "string", "minLength": 2, "pattern": "[0-9]+s"}
+				"flush-timeout":	{"type": "string", "minLength": 2, "pattern": "[0-9]+s"},
+				"output-queue-size":	{"type": "integer", "minimum": 1},
+				"output-queue-timeout":	{"type": "string", "minLength": 2, "pattern": "[0-9]+ms|s"},
+				"spool": {
+					"type": "object",
+					"properties": {
+						"enabled":		{"type": "boolean"},
+						"path":			{"type": "string"},
+						"max-size":		{"type": "integer", "minimum": 1},
+						"max-age":		{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m|h"}
+					},
+					"additionalProperties": false
+				}
 			},
 			"additionalProperties": false
 		},
@@ -42,55 +209,13 @@ var schema = `
 			"type": "object",
 			"anyOf": [{
 					"properties": {
-						"mail": {
-							"type": "object",
-							"properties": {
-								"enabled": 		{"type": "boolean"},
-								"host": 		{"type": "string"},
-								"port": 		{"type": "number"},
-								"user": 		{"type": "string"},
-								"password": 	{"type": "string"},
-								"from": 		{"type": "string"},
-								"to": 			{"type": "array", "items": {"type": "string", "format": "email"}},
-								"content-type": {"type": "string"}
-							},
-							"if": {
-								"properties": {"enabled": { "const": true }}
-							},
-							"then": {
-								"properties": {
-									"from": {"type": "string", "format": "email"},
-									"to": 	{"type": "array", "minItems": 1, "items": {"type": "string", "format": "email"}}
-								}
-							},
-							"additionalProperties": false
-						},
-						"slack": {
-							"type": "object",
-							"properties": {
-								"enabled": 		{"type": "boolean"},
-								"url": 			{"type": "string"},
-								"workspace": 	{"type": "string"},
-								"channel": 		{"type": "string"},
-								"emoji": 		{"type": "string"}
-							},
-							"if": {
-								"properties": {"enabled": { "const": true }}
-							},
-							"then": {
-								"properties": {"url": {"type": "string", "format": "uri", "minLength": 1, "pattern": "^(https?|http?)://"}}
-							},
-							"additionalProperties": false
-						},
-						"systray": {
-							"type": "object",
-							"properties": {
-								"enabled": 		{"type": "boolean"},
-								"sound": 		{"type": "boolean"},
-								"quiet-mode": 	{"type": "boolean"}
-							},
-							"additionalProperties": false
-						}
+						"mail": {"anyOf": [{"$ref": "#mail-alertsender"}, {"type": "array", "items": {"$ref": "#mail-alertsender"}}]},
+						"slack": {"anyOf": [{"$ref": "#slack-alertsender"}, {"type": "array", "items": {"$ref": "#slack-alertsender"}}]},
+						"systray": {"anyOf": [{"$ref": "#systray-alertsender"}, {"type": "array", "items": {"$ref": "#systray-alertsender"}}]},
+						"webhook": {"anyOf": [{"$ref": "#webhook-alertsender"}, {"type": "array", "items": {"$ref": "#webhook-alertsender"}}]},
+						"teams": {"anyOf": [{"$ref": "#teams-alertsender"}, {"type": "array", "items": {"$ref": "#teams-alertsender"}}]},
+						"pagerduty": {"anyOf": [{"$ref": "#pagerduty-alertsender"}, {"type": "array", "items": {"$ref": "#pagerduty-alertsender"}}]},
+						"opsgenie": {"anyOf": [{"$ref": "#opsgenie-alertsender"}, {"type": "array", "items": {"$ref": "#opsgenie-alertsender"}}]}
 					},
 					"additionalProperties": false
 				}
@@ -150,7 +275,56 @@ var schema = `
                         "from-paths": 	{"type": ["array", "null"], "items": [{"type": "string", "minLength": 4}]}
                     },
                     "additionalProperties": false
-                }
+                },
+				"exceptions": {
+					"type": "object",
+					"properties": {
+						"from-paths": 	{"type": ["array", "null"], "items": [{"type": "string", "minLength": 4}]}
+					},
+					"additionalProperties": false
+				},
+				"lists": {
+					"type": "object",
+					"properties": {
+						"from-paths": 		{"type": ["array", "null"], "items": [{"type": "string", "minLength": 4}]},
+						"from-urls": 		{"type": ["array", "null"], "items": [{"type": "string", "minLength": 8}]},
+						"refresh-interval":	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"}
+					},
+					"additionalProperties": false
+				},
+				"sequences": {
+					"type": "object",
+					"properties": {
+						"max-expressions":	{"type": "integer", "minimum": 2},
+						"max-span":			{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+						"max-partials":		{"type": "integer", "minimum": 1},
+						"compact-partials":	{"type": "boolean"}
+					},
+					"additionalProperties": false
+				},
+				"risk": {
+					"type": "object",
+					"properties": {
+						"enabled":			{"type": "boolean"},
+						"threshold":		{"type": "number", "exclusiveMinimum": 0},
+						"tactics":			{"type": "integer", "minimum": 1},
+						"half-life":		{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+						"tactics-window":	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+						"process-depth":	{"type": "integer", "minimum": 0},
+						"entities":			{"type": "array", "items": {"type": "string", "enum": ["process", "user", "host"]}},
+						"scores":			{
+							"type": "object",
+							"properties": {
+								"low":		{"type": "number", "minimum": 0},
+								"medium":	{"type": "number", "minimum": 0},
+								"high":		{"type": "number", "minimum": 0},
+								"critical":	{"type": "number", "minimum": 0}
+							},
+							"additionalProperties": false
+						}
+					},
+					"additionalProperties": false
+				}
 			},
 			"additionalProperties": false
 		},
@@ -215,6 +389,7 @@ var schema = `
 							"type": "object",
 							"properties": {
 								"enabled":		{"type": "boolean"},
+								"filter":		{"type": "string"},
 								"format": 		{"type": "string", "enum": ["json", "pretty"]},
 								"template": 	{"type": "string"},
 								"kv-delimiter": {"type": "string"}
@@ -225,6 +400,7 @@ var schema = `
 							"type": "object",
 							"properties": {
 								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
 								"servers": 					{"type": "array", "items": [{"type": "string", "minItems": 1, "format": "uri", "minLength": 1, "maxLength": 255, "pattern": "^(https?|http?)://"}]},
 								"timeout": 					{"type": "string"},
 								"index-name":				{"type": "string", "minLength": 1},
@@ -235,6 +411,14 @@ var schema = `
 								"sniff": 					{"type": "boolean"},
 								"trace-log": 				{"type": "boolean"},
 								"gzip-compression": 		{"type": "boolean"},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs"]},
+								"data-stream": 				{"type": "boolean"},
+								"data-stream-name": 		{"type": "string", "minLength": 1},
+								"ilm-policy-name": 			{"type": "string"},
+								"ilm-policy-config": 		{"type": "string"},
+								"ilm-rollover-max-age": 	{"type": "string"},
+								"ilm-rollover-max-size": 	{"type": "string"},
+								"ilm-delete-after": 		{"type": "string"},
 								"healthcheck-interval":		{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m}"},
 								"healthcheck-timeout":		{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m}"},
 								"flush-period":				{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m}"},
@@ -251,6 +435,7 @@ var schema = `
 							"type": "object",
 							"properties": {
 								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
 								"url": 						{"type": "string", "format": "uri", "minLength": 1, "maxLength": 255, "pattern": "^(amqps?|amqp?)://"},
 								"timeout": 					{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m}"},
 								"exchange": 				{"type": "string", "minLength": 1},
@@ -260,6 +445,7 @@ var schema = `
 								"vhost": 					{"type": "string", "minLength": 1},
 								"passive": 					{"type": "boolean"},
 								"durable": 					{"type": "boolean"},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs", "protobuf"]},
 								"username": 				{"type": "string"},
 								"password": 				{"type": "string"},
 								"tls-key": 					{"type": "string"},
@@ -274,10 +460,11 @@ var schema = `
 							"type": "object",
 							"properties": {
 								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
 								"endpoints": 				{"type": "array", "items": [{"type": "string", "minItems": 1, "format": "uri", "minLength": 1, "maxLength": 255, "pattern": "^(https?|http?)://"}]},
 								"timeout": 					{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m}"},
 								"method": 					{"type": "string", "enum": ["POST", "PUT"]},
-								"serializer": 				{"type": "string", "enum": ["json"]},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs", "protobuf"]},
 								"enable-gzip": 				{"type": "boolean"},
 								"proxy-url": 				{"type": "string"},
 								"proxy-username": 			{"type": "string"},
@@ -296,11 +483,130 @@ var schema = `
 							"type": "object",
 							"properties": {
 								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
 								"level": 					{"type": "string", "enum": ["INFO", "info", "warn", "warning", "WARN", "WARNING", "error", "erro", "ERROR", "ERRO"]},
 								"remote-host": 				{"type": "string"},
 								"template": 				{"type": "string"}
 							},
 							"additionalProperties": false
+						},
+						"kafka": {
+							"type": "object",
+							"properties": {
+								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
+								"brokers": 					{"type": "array", "items": [{"type": "string", "minLength": 1}]},
+								"topic": 					{"type": "string", "minLength": 1},
+								"partition-key": 			{"type": "string"},
+								"compression": 				{"type": "string", "enum": ["none", "gzip", "snappy", "lz4", "zstd"]},
+								"acks": 					{"type": "string", "enum": ["none", "leader", "all"]},
+								"timeout": 					{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m}"},
+								"client-id": 				{"type": "string"},
+								"sasl-mechanism": 			{"type": "string", "enum": ["", "plain", "scram-sha-256", "scram-sha-512"]},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs", "protobuf"]},
+								"username": 				{"type": "string"},
+								"password": 				{"type": "string"},
+								"tls-key": 					{"type": "string"},
+								"tls-cert": 				{"type": "string"},
+								"tls-ca": 					{"type": "string"},
+								"tls-insecure-skip-verify": {"type": "boolean"}
+							},
+							"additionalProperties": false
+						},
+						"syslog": {
+							"type": "object",
+							"properties": {
+								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
+								"network": 					{"type": "string", "enum": ["udp", "tcp", "tls"]},
+								"address": 					{"type": "string", "minLength": 1},
+								"format": 					{"type": "string", "enum": ["rfc5424", "rfc3164"]},
+								"framing": 					{"type": "string", "enum": ["octet-counting", "newline"]},
+								"body": 					{"type": "string", "enum": ["template", "json", "cef", "leef"]},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs"]},
+								"template": 				{"type": "string"},
+								"facility": 				{"type": "string", "enum": ["kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp", "local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7"]},
+								"severity": 				{"type": "string", "enum": ["emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"]},
+								"app-name": 				{"type": "string"},
+								"hostname": 				{"type": "string"},
+								"timeout": 					{"type": "string", "minLength": 2, "pattern": "[0-9]+s|m}"},
+								"tls-key": 					{"type": "string"},
+								"tls-cert": 				{"type": "string"},
+								"tls-ca": 					{"type": "string"},
+								"tls-insecure-skip-verify": {"type": "boolean"}
+							},
+							"additionalProperties": false
+						},
+						"file": {
+							"type": "object",
+							"properties": {
+								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
+								"path": 					{"type": "string", "minLength": 1},
+								"format": 					{"type": "string", "enum": ["json", "template"]},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs"]},
+								"template": 				{"type": "string"},
+								"max-size": 				{"type": "integer", "minimum": 1},
+								"rotation-interval": 		{"type": "string", "pattern": "[0-9]+(ms|s|m|h)"},
+								"max-backups": 				{"type": "integer", "minimum": 0},
+								"compression": 				{"type": "string", "enum": ["none", "gzip", "zstd"]},
+								"fsync": 					{"type": "string", "enum": ["batch", "interval", "never"]},
+								"fsync-interval": 			{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"}
+							},
+							"additionalProperties": false
+						},
+						"otlp": {
+							"type": "object",
+							"properties": {
+								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
+								"protocol": 				{"type": "string", "enum": ["grpc", "http"]},
+								"endpoint": 				{"type": "string"},
+								"headers":					{"type": "object", "additionalProperties": {"type": "string"}},
+								"timeout": 					{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+								"compression": 				{"type": "string", "enum": ["none", "gzip"]},
+								"insecure": 				{"type": "boolean"},
+								"service-name": 			{"type": "string"},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs"]},
+								"template": 				{"type": "string"},
+								"batch-size": 				{"type": "integer", "minimum": 1},
+								"max-retries": 				{"type": "integer", "minimum": 0},
+								"retry-initial-backoff": 	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+								"retry-max-backoff": 		{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+								"tls-key": 					{"type": "string"},
+								"tls-cert": 				{"type": "string"},
+								"tls-ca": 					{"type": "string"},
+								"tls-insecure-skip-verify": {"type": "boolean"}
+							},
+							"additionalProperties": false
+						},
+						"splunk": {
+							"type": "object",
+							"properties": {
+								"enabled":					{"type": "boolean"},
+								"filter":					{"type": "string"},
+								"endpoint": 				{"type": "string", "minLength": 1},
+								"token": 					{"type": "string"},
+								"index": 					{"type": "string"},
+								"rule-index": 				{"type": "string"},
+								"source": 					{"type": "string"},
+								"sourcetype": 				{"type": "string"},
+								"serializer": 				{"type": "string", "enum": ["json", "ecs"]},
+								"enable-gzip": 				{"type": "boolean"},
+								"timeout": 					{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+								"ack": 						{"type": "boolean"},
+								"ack-timeout": 				{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+								"ack-poll-interval": 		{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+								"channel": 					{"type": "string"},
+								"proxy-url": 				{"type": "string"},
+								"proxy-username": 			{"type": "string"},
+								"proxy-password": 			{"type": "string"},
+								"tls-key": 					{"type": "string"},
+								"tls-cert": 				{"type": "string"},
+								"tls-ca": 					{"type": "string"},
+								"tls-insecure-skip-verify": {"type": "boolean"}
+							},
+							"additionalProperties": false
 						}
 					},
 					"additionalProperties": false
@@ -473,7 +779,7 @@ var schema = `
 						"additionalProperties": false
 					}]
 				},
-				"alert-via":		{"type": "string", "enum": ["slack", "mail"]},
+				"alert-via":		{"type": "string", "minLength": 1},
 				"alert-template":   {
 						"type": 		"object",
 						"properties": {
@@ -518,12 +824,44 @@ var rulesSchema = `
 									"items": {
 										"type": "object",
 										"properties": {
-											"name": 	{"type": "string", "enum": ["kill"]},
-											"pid": 		{"type": "string", "minLength": 5}
+											"name": 	{"type": "string", "enum": ["kill", "suspend", "exec", "webhook", "tag", "capture"]},
+											"pid": 		{"type": "string", "minLength": 5},
+											"command": 	{"type": "string", "minLength": 1},
+											"args": 	{"type": "array", "items": {"type": "string"}},
+											"timeout": 	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+											"url": 		{"type": "string", "minLength": 8},
+											"method": 	{"type": "string", "enum": ["GET", "POST", "PUT", "PATCH"]},
+											"headers": 	{"type": "object", "additionalProperties": {"type": "string"}},
+											"body": 	{"type": "string"},
+											"tags": 	{"type": "object", "minProperties": 1, "additionalProperties": {"type": "string"}},
+											"before": 	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+											"after": 	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+											"path": 	{"type": "string", "minLength": 1}
 										},
 										"required": ["name"],
 										"additionalProperties": false
 									}
+								},
+								"threshold":			{
+									"type": "object",
+									"properties": {
+										"count": 	{"type": "integer", "minimum": 1},
+										"distinct": {"type": "string", "minLength": 3},
+										"window": 	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+										"by": 		{"type": "array", "items": {"type": "string", "minLength": 3}}
+									},
+									"required": ["count", "window"],
+									"additionalProperties": false
+								},
+								"suppress":			{
+									"type": "object",
+									"properties": {
+										"by": 		{"type": "array", "items": {"type": "string", "minLength": 3}},
+										"window": 	{"type": "string", "minLength": 2, "pattern": "[0-9]+(ms|s|m|h)"},
+										"max": 		{"type": "integer", "minimum": 1}
+									},
+									"required": ["window"],
+									"additionalProperties": false
 								}
 							},
 							"required": ["name", "condition", "min-engine-version"],
@@ -563,6 +901,31 @@ var macrosSchema = `
 }
 `
 
+var exceptionsSchema = `
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "type": "array",
+    "items":
+    {
+        "type": "object",
+        "properties": {
+            "exception": 	{"type": "string", "minLength": 3},
+            "description":  {"type": "string"},
+            "rules":		{"type": "array", "items": {"type": "string", "minLength": 3}, "minItems": 1},
+            "groups":		{"type": "array", "items": {"type": "string", "minLength": 1}, "minItems": 1},
+            "condition":  	{"type": "string", "minLength": 3}
+        },
+        "required": ["exception", "condition"],
+        "anyOf": [
+            {"required": ["rules"]},
+            {"required": ["groups"]}
+        ],
+        "additionalProperties": false
+    },
+    "additionalProperties": false
+}
+`
+
 type schemaConfig struct {
 	MaxBuffers    uint32
 	MinBuffers    uint32
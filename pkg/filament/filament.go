@@ -508,21 +508,20 @@ var keywords = []string{"", "", "severity", "tags"}
 func (f *filament) emitAlertFn(_, args cpython.PyArgs, kwargs cpython.PyKwargs) cpython.PyRawObject {
 	f.gil.Lock()
 	defer f.gil.Unlock()
-	senders := alertsender.FindAll()
-	if len(senders) == 0 {
+	if len(alertsender.FindAll()) == 0 {
 		log.Warn("no alertsenders registered. Alert won't be sent")
 		return cpython.NewPyNone()
 	}
 
 	title, text, sever, tags := cpython.PyArgsParseKeywords(args, kwargs, keywords)
-
-	for _, s := range senders {
-		alert := alertsender.NewAlert(
-			title,
-			text,
-			tags,
-			alertsender.ParseSeverityFromString(sever),
-		)
+	alert := alertsender.NewAlert(
+		title,
+		text,
+		tags,
+		alertsender.ParseSeverityFromString(sever),
+	)
+
+	for _, s := range alertsender.FindByAlert(alert) {
 		if err := s.Send(alert); err != nil {
 			log.Warnf("unable to emit alert from filament: %v", err)
 		}
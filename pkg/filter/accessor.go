@@ -20,6 +20,7 @@ package filter
 
 import (
 	"errors"
+	kerrors "github.com/rabbitstack/fibratus/pkg/errors"
 	"github.com/rabbitstack/fibratus/pkg/filter/fields"
 	"github.com/rabbitstack/fibratus/pkg/kevent"
 	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
@@ -111,10 +112,32 @@ func (k *kevtAccessor) get(f fields.Field, kevt *kevent.Kevent) (kparams.Value,
 				return kevt.GetParamAsString(name), nil
 			}
 		}
+		if f.IsKevtMetaMap() {
+			key, _ := captureInBrackets(f.String())
+			return kevt.GetMetaAsString(kevent.MetadataKey(key)), nil
+		}
 		return nil, nil
 	}
 }
 
+// getValue extracts the field value from the event by
+// consulting the given accessors. The first accessor that
+// yields a non-nil value wins. If no accessor is able to
+// produce the value, nil is returned.
+func getValue(accessors []accessor, f fields.Field, kevt *kevent.Kevent) kparams.Value {
+	for _, accessor := range accessors {
+		v, err := accessor.get(f, kevt)
+		if err != nil && !kerrors.IsKparamNotFound(err) {
+			accessorErrors.Add(err.Error(), 1)
+			continue
+		}
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
 // narrowAccessors dynamically disables filter accessors by walking
 // the fields declared in the expression. The field can be expressed
 // as a regular LHS/RHS component, used as a function parameter or
@@ -926,7 +926,7 @@ func (pa *peAccessor) parserOpts() []pe.Option {
 		if f.IsPeSymbol() {
 			opts = append(opts, pe.WithSymbols())
 		}
-		if f.IsPeSectionEntropy() {
+		if f.IsPeSectionEntropy() || f == fields.PeSections {
 			opts = append(opts, pe.WithSections(), pe.WithSectionEntropy())
 		}
 		if f.IsPeVersionResource() || f.IsPeResourcesMap() {
@@ -1021,6 +1021,17 @@ func (pa *peAccessor) get(f fields.Field, kevt *kevent.Kevent) (kparams.Value, e
 		return p.ImageBase, nil
 	case fields.PeNumSections:
 		return p.NumberOfSections, nil
+	case fields.PeSections:
+		sections := make([]map[string]interface{}, 0, len(p.Sections))
+		for _, sec := range p.Sections {
+			sections = append(sections, map[string]interface{}{
+				"name":                        sec.Name,
+				string(fields.SectionEntropy): sec.Entropy,
+				string(fields.SectionMD5Hash): sec.Md5,
+				string(fields.SectionSize):    sec.Size,
+			})
+		}
+		return sections, nil
 	case fields.PeNumSymbols:
 		return p.NumberOfSymbols, nil
 	case fields.PeSymbols:
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"expvar"
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"time"
+)
+
+var (
+	// actionExecutions counts the number of executions per action
+	actionExecutions = expvar.NewMap("action.executions")
+	// actionErrors counts the number of failed executions per action
+	actionErrors = expvar.NewMap("action.errors")
+)
+
+// ErrUnknownAction signals the action with the given name is not registered
+var ErrUnknownAction = func(name string) error { return fmt.Errorf("%q action is not registered", name) }
+
+// Interpolator replaces event field references, such as %ps.pid,
+// with the values extracted from the events that triggered the rule.
+// If the escape function is given, it is applied to each field value
+// before the value is substituted in the string.
+type Interpolator func(s string, escape ...func(string) string) string
+
+// Action is the minimal interface all rule actions have to implement.
+type Action interface {
+	// Name returns the name of the action as declared in the rule.
+	Name() string
+	// Execute runs the action on behalf of the rule match. Actions
+	// that take long to complete should run asynchronously, since
+	// they are executed inside the event processing path.
+	Execute(ctx *config.ActionContext, interpolate Interpolator) error
+}
+
+// Recording is implemented by actions that need
+// recent events retained by the rule engine.
+type Recording interface {
+	// RecordSpan returns the time span of events
+	// that have to be retained before the rule match.
+	RecordSpan() time.Duration
+}
+
+// Factory defines the alias for the action factory. The factory
+// receives the typed action configuration decoded from the rule.
+type Factory func(config any) (Action, error)
+
+var factories = map[string]Factory{}
+
+// Register registers a new action factory.
+func Register(name string, factory Factory) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("%q action is already registered", name))
+	}
+	factories[name] = factory
+}
+
+// Decode builds the actions declared in the rule. It fails
+// if the action configuration is invalid, or the action is
+// not registered.
+func Decode(f *config.FilterConfig) ([]Action, error) {
+	configs, err := f.DecodeActions()
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]Action, 0, len(configs))
+	for _, c := range configs {
+		named, ok := c.(interface{ Name() string })
+		if !ok {
+			return nil, fmt.Errorf("unnamed action config %T", c)
+		}
+		factory, ok := factories[named.Name()]
+		if !ok {
+			return nil, ErrUnknownAction(named.Name())
+		}
+		act, err := factory(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q action: %v", named.Name(), err)
+		}
+		actions = append(actions, act)
+	}
+	return actions, nil
+}
+
+// Run executes the action and accounts for its
+// execution in action metrics.
+func Run(act Action, ctx *config.ActionContext, interpolate Interpolator) error {
+	actionExecutions.Add(act.Name(), 1)
+	if err := act.Execute(ctx, interpolate); err != nil {
+		actionErrors.Add(act.Name(), 1)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/rabbitstack/fibratus/pkg/alertsender"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	f := &config.FilterConfig{
+		Name: "Suspicious process",
+		Action: []config.FilterAction{
+			map[string]any{"name": "kill"},
+			map[string]any{"name": "suspend", "pid": "ps.parent.pid"},
+			map[string]any{"name": "exec", "command": "isolate.exe", "args": []any{"%ps.pid"}},
+			map[string]any{"name": "webhook", "url": "https://edr.local/isolate"},
+			map[string]any{"name": "tag", "tags": map[string]any{"triage": "isolate"}},
+			map[string]any{"name": "capture", "before": "5s"},
+		},
+	}
+	actions, err := Decode(f)
+	require.NoError(t, err)
+	require.Len(t, actions, 6)
+
+	names := make([]string, 0, len(actions))
+	for _, act := range actions {
+		names = append(names, act.Name())
+	}
+	assert.Equal(t, []string{"kill", "suspend", "exec", "webhook", "tag", "capture"}, names)
+
+	assert.Equal(t, "ps.pid", actions[0].(kill).Pid)
+	assert.Equal(t, "ps.parent.pid", actions[1].(suspend).Pid)
+	assert.Equal(t, defaultExecTimeout, actions[2].(execAction).Timeout)
+	assert.Equal(t, "POST", actions[3].(webhook).Method)
+	assert.Equal(t, time.Second*5, actions[5].(capture).Before)
+	assert.Equal(t, defaultCaptureSpan, actions[5].(capture).After)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	var tests = []struct {
+		action map[string]any
+		err    string
+	}{
+		{map[string]any{"name": "exec"}, `invalid "exec" action: missing command`},
+		{map[string]any{"name": "exec", "command": "isolate.exe", "url": "https://edr.local"}, "invalid \"exec\" action: 1 error(s) decoding:\n\n* '' has invalid keys: url"},
+		{map[string]any{"name": "webhook", "url": "edr"}, `invalid "webhook" action: invalid URL: parse "edr": invalid URI for request`},
+		{map[string]any{"name": "tag"}, `invalid "tag" action: no tags given`},
+		{map[string]any{"name": "capture", "after": "1h"}, `invalid "capture" action: capture span can't exceed 5m0s`},
+		{map[string]any{"name": "isolate"}, `unknown "isolate" action`},
+	}
+
+	for _, tt := range tests {
+		_, err := Decode(&config.FilterConfig{Action: []config.FilterAction{tt.action}})
+		require.EqualError(t, err, tt.err)
+	}
+}
+
+// routedSender records sent alerts and fails if configured so.
+type routedSender struct {
+	fail   bool
+	alerts []alertsender.Alert
+}
+
+func (s *routedSender) Send(a alertsender.Alert) error {
+	if s.fail {
+		return errors.New("connection refused")
+	}
+	s.alerts = append(s.alerts, a)
+	return nil
+}
+func (*routedSender) Type() alertsender.Type { return alertsender.Noop }
+func (*routedSender) Shutdown() error        { return nil }
+func (*routedSender) SupportsMarkdown() bool { return true }
+
+func TestEmitSendsToAllRoutedSenders(t *testing.T) {
+	senders := map[string]*routedSender{
+		"pagerduty": {},
+		"slack":     {fail: true},
+		"teams":     {fail: true},
+	}
+	alertsender.Register(alertsender.Noop, func(c alertsender.Config) (alertsender.Sender, error) { return senders[c.Name], nil })
+	require.NoError(t, alertsender.LoadAll([]alertsender.Config{
+		{Type: alertsender.Noop, Name: "pagerduty"},
+		{Type: alertsender.Noop, Name: "slack"},
+		{Type: alertsender.Noop, Name: "teams"},
+	}))
+
+	err := Emit(&config.ActionContext{}, "LSASS memory dumping", "LSASS memory dumped", "critical", nil)
+	require.Error(t, err)
+	assert.Equal(t, "unable to emit alert from rule via [noop] sender: connection refused, unable to emit alert from rule via [noop] sender: connection refused", err.Error())
+	// the failure of the other senders doesn't prevent the alert delivery
+	require.Len(t, senders["pagerduty"].alerts, 1)
+	assert.Equal(t, "LSASS memory dumping", senders["pagerduty"].alerts[0].Title)
+}
+
+func TestTag(t *testing.T) {
+	acts, err := Decode(&config.FilterConfig{Action: []config.FilterAction{
+		map[string]any{"name": "tag", "tags": map[string]any{"triage": "isolate", "image": "%ps.exe"}},
+	}})
+	require.NoError(t, err)
+
+	evt := &kevent.Kevent{Metadata: make(map[kevent.MetadataKey]any)}
+	ctx := &config.ActionContext{Events: []*kevent.Kevent{evt}, Filter: &config.FilterConfig{Name: "Suspicious process"}}
+	interpolate := func(s string, _ ...func(string) string) string {
+		if s == "%ps.exe" {
+			return `C:\Windows\System32\cmd.exe`
+		}
+		return s
+	}
+	require.NoError(t, Run(acts[0], ctx, interpolate))
+	assert.Equal(t, "isolate", evt.GetMetaAsString("triage"))
+	assert.Equal(t, `C:\Windows\System32\cmd.exe`, evt.GetMetaAsString("image"))
+}
+
+func TestWebhookBodyEscaping(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- b
+	}))
+	defer srv.Close()
+
+	acts, err := Decode(&config.FilterConfig{Action: []config.FilterAction{
+		map[string]any{"name": "webhook", "url": srv.URL, "body": `{"exe": "%ps.exe", "pid": %ps.pid}`},
+	}})
+	require.NoError(t, err)
+
+	exe := `C:\Temp\a", "isolate": "false`
+	interpolate := func(s string, escape ...func(string) string) string {
+		esc := func(v string) string { return v }
+		if len(escape) > 0 {
+			esc = escape[0]
+		}
+		return strings.NewReplacer("%ps.exe", esc(exe), "%ps.pid", esc("1024")).Replace(s)
+	}
+	ctx := &config.ActionContext{Filter: &config.FilterConfig{Name: "Suspicious process"}}
+	require.NoError(t, Run(acts[0], ctx, interpolate))
+
+	select {
+	case b := <-bodies:
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(b, &body))
+		assert.Equal(t, map[string]any{"exe": exe, "pid": float64(1024)}, body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook request not received")
+	}
+}
+
+func TestWebhookURLAndHeaderEscaping(t *testing.T) {
+	type request struct {
+		path, name, header string
+	}
+	requests := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- request{path: r.URL.Path, name: r.URL.Query().Get("name"), header: r.Header.Get("X-Process")}
+	}))
+	defer srv.Close()
+
+	acts, err := Decode(&config.FilterConfig{Action: []config.FilterAction{
+		map[string]any{"name": "webhook", "url": srv.URL + "/isolate/%ps.exe?name=%ps.exe&force=false", "headers": map[string]any{"X-Process": "%ps.exe"}},
+	}})
+	require.NoError(t, err)
+
+	exe := "C:\\Temp\\a.exe?force=true&x=#frag\r\nX-Injected: 1"
+	interpolate := func(s string, escape ...func(string) string) string {
+		esc := func(v string) string { return v }
+		if len(escape) > 0 {
+			esc = escape[0]
+		}
+		return strings.ReplaceAll(s, "%ps.exe", esc(exe))
+	}
+	ctx := &config.ActionContext{Filter: &config.FilterConfig{Name: "Suspicious process"}}
+	require.NoError(t, Run(acts[0], ctx, interpolate))
+
+	select {
+	case r := <-requests:
+		assert.Equal(t, "/isolate/"+exe, r.path)
+		assert.Equal(t, exe, r.name)
+		assert.Equal(t, "C:\\Temp\\a.exe?force=true&x=#fragX-Injected: 1", r.header)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook request not received")
+	}
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	kcapver "github.com/rabbitstack/fibratus/pkg/kcap/version"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCaptureSpan is the time span captured before and after the rule match if not specified in the action
+	defaultCaptureSpan = time.Second * 10
+	// maxCaptureSpan is the maximum time span captured before or after the rule match
+	maxCaptureSpan = time.Minute * 5
+	// maxRecordedBytes is the maximum size of serialized events retained for captures
+	maxRecordedBytes = 64 * 1024 * 1024
+)
+
+// capturedEventsDropped counts events not written to capture files because the capture queue was full
+var capturedEventsDropped = expvar.NewInt("action.capture.events.dropped")
+
+// ErrCaptureUnsupported signals the capture writer is not available
+var ErrCaptureUnsupported = errors.New("capture writer is not available")
+
+// CaptureWriter writes events to the capture file.
+type CaptureWriter interface {
+	// Write consumes events from the channel and writes them to the capture file.
+	Write(<-chan *kevent.Kevent, <-chan error) chan error
+	// Close flushes and closes the capture file.
+	Close() error
+}
+
+// CaptureWriterFactory creates the capture writer for the given file name.
+type CaptureWriterFactory func(filename string) (CaptureWriter, error)
+
+var newCaptureWriter CaptureWriterFactory
+
+// SetCaptureWriterFactory sets the factory for creating capture writers.
+// Capture actions fail if the factory is not set.
+func SetCaptureWriterFactory(factory CaptureWriterFactory) { newCaptureWriter = factory }
+
+// unsafeChars matches characters not allowed in capture file names
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_\-]+`)
+
+func init() {
+	Register("capture", newCapture)
+}
+
+// capture writes events surrounding the rule match
+// to the capture file. Events preceding the match are
+// taken from the recorder buffer, while subsequent
+// events are written until the after span elapses.
+type capture struct {
+	config.CaptureAction
+}
+
+func newCapture(c any) (Action, error) {
+	act := c.(config.CaptureAction)
+	if act.Before == 0 {
+		act.Before = defaultCaptureSpan
+	}
+	if act.After == 0 {
+		act.After = defaultCaptureSpan
+	}
+	if act.Before > maxCaptureSpan || act.After > maxCaptureSpan {
+		return nil, fmt.Errorf("capture span can't exceed %v", maxCaptureSpan)
+	}
+	if act.Path == "" {
+		act.Path = filepath.Join(os.TempDir(), "fibratus", "captures")
+	}
+	return capture{act}, nil
+}
+
+// RecordSpan returns the time span of events captured before the rule match.
+func (c capture) RecordSpan() time.Duration { return c.Before }
+
+func (c capture) Execute(ctx *config.ActionContext, interpolate Interpolator) error {
+	if newCaptureWriter == nil {
+		return ErrCaptureUnsupported
+	}
+	if err := os.MkdirAll(c.Path, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%d.kcap", unsafeChars.ReplaceAllString(ctx.Filter.Name, "_"), time.Now().UnixNano())
+	filename := filepath.Join(c.Path, name)
+	w, err := newCaptureWriter(filename)
+	if err != nil {
+		return err
+	}
+	log.Infof("executing capture action: file=%s rule=%s", filename, ctx.Filter.Name)
+	rec.start(w, c.Before, c.After)
+	return nil
+}
+
+// Record stores the event in the recorder buffer, so it can be written to
+// capture files triggered by subsequent rule matches. It is a no-op if the
+// recorder span is zero.
+func Record(kevt *kevent.Kevent) { rec.record(kevt) }
+
+// ResetRecorder discards the recorded events and sets the time span
+// of retained events. Zero span disables recording.
+func ResetRecorder(span time.Duration) { rec.reset(span) }
+
+var rec = &recorder{}
+
+// recordedEvent is the serialized event retained in the recorder buffer.
+type recordedEvent struct {
+	ts  time.Time
+	buf []byte
+}
+
+// activeCapture is the capture file that receives events
+// until the deadline is reached.
+type activeCapture struct {
+	w     CaptureWriter
+	evts  chan *kevent.Kevent
+	errs  chan error
+	close *time.Timer
+}
+
+// recorder keeps serialized copies of recent events. Events
+// are serialized because the original events are returned to
+// the pool once they reach outputs.
+type recorder struct {
+	mu   sync.Mutex
+	span time.Duration
+	evts []recordedEvent
+	// size is the total size of retained events
+	size     int
+	captures []*activeCapture
+}
+
+func (r *recorder) reset(span time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.span = span
+	r.evts = nil
+	r.size = 0
+}
+
+func (r *recorder) record(kevt *kevent.Kevent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.span == 0 {
+		return
+	}
+	b := kevt.MarshalRaw()
+	r.evts = append(r.evts, recordedEvent{ts: kevt.Timestamp, buf: b})
+	r.size += len(b)
+	// evict events outside the time span
+	var n int
+	for n < len(r.evts) && (kevt.Timestamp.Sub(r.evts[n].ts) > r.span || r.size > maxRecordedBytes) {
+		r.size -= len(r.evts[n].buf)
+		n++
+	}
+	if n > 0 {
+		r.evts = r.evts[n:]
+	}
+	for _, c := range r.captures {
+		c.push(b)
+	}
+}
+
+func (r *recorder) start(w CaptureWriter, before, after time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := &activeCapture{
+		w:    w,
+		evts: make(chan *kevent.Kevent, 1000),
+		errs: make(chan error),
+	}
+	errs := w.Write(c.evts, c.errs)
+	go func() {
+		for err := range errs {
+			log.Warnf("unable to write captured event: %v", err)
+		}
+	}()
+	if len(r.evts) > 0 {
+		last := r.evts[len(r.evts)-1].ts
+		for _, evt := range r.evts {
+			if last.Sub(evt.ts) <= before {
+				c.push(evt.buf)
+			}
+		}
+	}
+	r.captures = append(r.captures, c)
+	c.close = time.AfterFunc(after, func() { r.stop(c) })
+}
+
+func (r *recorder) stop(c *activeCapture) {
+	r.mu.Lock()
+	for i, capture := range r.captures {
+		if capture == c {
+			r.captures = append(r.captures[:i], r.captures[i+1:]...)
+			break
+		}
+	}
+	r.mu.Unlock()
+	// give the writer a chance to
+	// drain the queued events
+	for i := 0; i < 10 && len(c.evts) > 0; i++ {
+		time.Sleep(time.Millisecond * 100)
+	}
+	if err := c.w.Close(); err != nil {
+		log.Warnf("unable to close capture file: %v", err)
+	}
+}
+
+func (c *activeCapture) push(b []byte) {
+	evt, err := kevent.NewFromKcap(b, kcapver.KevtSecV2)
+	if err != nil {
+		return
+	}
+	select {
+	case c.evts <- evt:
+	default:
+		capturedEventsDropped.Add(1)
+	}
+}
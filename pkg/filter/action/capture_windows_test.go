@@ -0,0 +1,72 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	r := &recorder{}
+	now := time.Now()
+	newEvent := func(ts time.Time, size int) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateFile,
+			Category:  ktypes.File,
+			Name:      "CreateFile",
+			Timestamp: ts,
+			Kparams: kevent.Kparams{
+				kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: strings.Repeat("a", size)},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	// recording is disabled until the span is set
+	r.record(newEvent(now, 10))
+	assert.Len(t, r.evts, 0)
+
+	r.reset(time.Second)
+	r.record(newEvent(now, 10))
+	r.record(newEvent(now.Add(time.Millisecond*500), 10))
+	require.Len(t, r.evts, 2)
+	// events outside the span are evicted
+	r.record(newEvent(now.Add(time.Millisecond*1200), 10))
+	assert.Len(t, r.evts, 2)
+
+	// the retained events don't exceed the size cap
+	for i := 0; i < 40; i++ {
+		r.record(newEvent(now.Add(time.Millisecond*1200), 1024*1024*2))
+	}
+	assert.LessOrEqual(t, r.size, maxRecordedBytes)
+	assert.Less(t, len(r.evts), 40)
+
+	// reset discards the recorded events
+	r.reset(0)
+	assert.Len(t, r.evts, 0)
+	assert.Equal(t, 0, r.size)
+}
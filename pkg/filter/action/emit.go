@@ -24,25 +24,40 @@ import (
 	"github.com/rabbitstack/fibratus/pkg/alertsender/renderer"
 	"github.com/rabbitstack/fibratus/pkg/config"
 	"github.com/rabbitstack/fibratus/pkg/util/markdown"
+	"github.com/rabbitstack/fibratus/pkg/util/multierror"
 	log "github.com/sirupsen/logrus"
 )
 
-// Emit sends the rule alert via all configured alert senders.
+// Emit sends the rule alert via all configured alert senders
+// whose routes match the alert. The failure of one sender doesn't
+// prevent the alert from being sent via the remaining senders.
 func Emit(ctx *config.ActionContext, title string, text string, severity string, tags []string) error {
 	log.Infof("sending alert: [%s]. Text: %s", title, text)
 
-	senders := alertsender.FindAll()
-	if len(senders) == 0 {
+	if len(alertsender.FindAll()) == 0 {
 		return fmt.Errorf("no alertsenders registered. Alert won't be sent")
 	}
 
+	a := alertsender.NewAlert(
+		title,
+		text,
+		tags,
+		alertsender.ParseSeverityFromString(severity),
+	)
+	a.Labels = labels(ctx)
+	if ctx != nil {
+		a.Key = ctx.Key
+	}
+
+	senders := alertsender.FindByAlert(a)
+	if len(senders) == 0 {
+		log.Debugf("no alertsenders routes matched alert [%s]", title)
+		return nil
+	}
+
+	errs := make([]error, 0)
 	for _, sender := range senders {
-		alert := alertsender.NewAlert(
-			title,
-			text,
-			tags,
-			alertsender.ParseSeverityFromString(severity),
-		)
+		alert := a
 		// strip markdown
 		if !sender.SupportsMarkdown() {
 			alert.Text = markdown.Strip(alert.Text)
@@ -52,13 +67,32 @@ func Emit(ctx *config.ActionContext, title string, text string, severity string,
 			var err error
 			alert.Text, err = renderer.RenderHTMLRuleAlert(ctx, alert)
 			if err != nil {
-				return err
+				errs = append(errs, err)
+				continue
 			}
 		}
 		err := sender.Send(alert)
 		if err != nil {
-			return fmt.Errorf("unable to emit alert from rule via [%s] sender: %v", sender.Type(), err)
+			errs = append(errs, fmt.Errorf("unable to emit alert from rule via [%s] sender: %v", sender.Type(), err))
+		}
+	}
+	return multierror.Wrap(errs...)
+}
+
+// labels merges the group and rule labels. Rule
+// labels take precedence over group labels.
+func labels(ctx *config.ActionContext) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(ctx.Group.Labels))
+	for k, v := range ctx.Group.Labels {
+		labels[k] = v
+	}
+	if ctx.Filter != nil {
+		for k, v := range ctx.Filter.Labels {
+			labels[k] = v
 		}
 	}
-	return nil
+	return labels
 }
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"os/exec"
+	"time"
+)
+
+// defaultExecTimeout is the maximum command execution time if not specified in the action
+const defaultExecTimeout = time.Second * 30
+
+func init() {
+	Register("exec", newExec)
+}
+
+// execAction runs the external command, such as a
+// script that isolates the host from the network.
+type execAction struct {
+	config.ExecAction
+}
+
+func newExec(c any) (Action, error) {
+	act := c.(config.ExecAction)
+	if act.Command == "" {
+		return nil, fmt.Errorf("missing command")
+	}
+	if act.Timeout == 0 {
+		act.Timeout = defaultExecTimeout
+	}
+	return execAction{act}, nil
+}
+
+// Execute starts the command in the background. Command
+// failures are logged and accounted in action metrics.
+func (e execAction) Execute(ctx *config.ActionContext, interpolate Interpolator) error {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = interpolate(arg)
+	}
+	log.Infof("executing exec action: command=%s args=%v rule=%s", e.Command, args, ctx.Filter.Name)
+	go func() {
+		c, cancel := context.WithTimeout(context.Background(), e.Timeout)
+		defer cancel()
+		out, err := exec.CommandContext(c, e.Command, args...).CombinedOutput()
+		if err != nil {
+			actionErrors.Add(e.Name(), 1)
+			log.Warnf("%s command failed for rule %s: %v: %s", e.Command, ctx.Filter.Name, err, out)
+		}
+	}()
+	return nil
+}
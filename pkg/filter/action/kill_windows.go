@@ -20,9 +20,34 @@ package action
 
 import (
 	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	log "github.com/sirupsen/logrus"
 	"syscall"
 )
 
+func init() {
+	Register("kill", newKill)
+}
+
+// kill terminates the process that triggered the rule.
+type kill struct {
+	config.KillAction
+}
+
+func newKill(c any) (Action, error) {
+	act := c.(config.KillAction)
+	if act.Pid == "" {
+		act.Pid = "ps.pid"
+	}
+	return kill{act}, nil
+}
+
+func (k kill) Execute(ctx *config.ActionContext, interpolate Interpolator) error {
+	pid := k.PidToInt(interpolate("%" + k.Pid))
+	log.Infof("executing kill action: pid=%d rule=%s", pid, ctx.Filter.Name)
+	return Kill(pid)
+}
+
 // Kill terminates a process with specified pid.
 func Kill(pid uint32) error {
 	h, err := syscall.OpenProcess(syscall.PROCESS_TERMINATE, false, pid)
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/sys"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+	"strconv"
+)
+
+func init() {
+	Register("suspend", newSuspend)
+}
+
+// suspend suspends all threads of the process that triggered the rule.
+type suspend struct {
+	config.SuspendAction
+}
+
+func newSuspend(c any) (Action, error) {
+	act := c.(config.SuspendAction)
+	if act.Pid == "" {
+		act.Pid = "ps.pid"
+	}
+	return suspend{act}, nil
+}
+
+func (s suspend) Execute(ctx *config.ActionContext, interpolate Interpolator) error {
+	pid, err := strconv.ParseUint(interpolate("%"+s.Pid), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid pid: %v", err)
+	}
+	log.Infof("executing suspend action: pid=%d rule=%s", pid, ctx.Filter.Name)
+	return Suspend(uint32(pid))
+}
+
+// Suspend suspends all threads of the process with specified pid.
+func Suspend(pid uint32) error {
+	h, err := windows.OpenProcess(windows.PROCESS_SUSPEND_RESUME, false, pid)
+	if err != nil {
+		return fmt.Errorf("couldn't open pid %d for suspension: %v", pid, err)
+	}
+	defer func() {
+		_ = windows.CloseHandle(h)
+	}()
+	if err := sys.NtSuspendProcess(h); err != nil {
+		return fmt.Errorf("fail to suspend pid %d: %v", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+)
+
+func init() {
+	Register("tag", newTag)
+}
+
+// tag adds metadata to the events that triggered the
+// rule, so they reach outputs enriched with the tags.
+type tag struct {
+	config.TagAction
+}
+
+func newTag(c any) (Action, error) {
+	act := c.(config.TagAction)
+	if len(act.Tags) == 0 {
+		return nil, fmt.Errorf("no tags given")
+	}
+	return tag{act}, nil
+}
+
+func (t tag) Execute(ctx *config.ActionContext, interpolate Interpolator) error {
+	for k, v := range t.Tags {
+		v = interpolate(v)
+		for _, evt := range ctx.Events {
+			evt.AddMeta(kevent.MetadataKey(k), v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// defaultWebhookTimeout is the request timeout if not specified in the action
+const defaultWebhookTimeout = time.Second * 10
+
+func init() {
+	Register("webhook", newWebhook)
+}
+
+// webhook calls the HTTP endpoint, for example, to
+// request host isolation from the EDR platform.
+type webhook struct {
+	config.WebhookAction
+	client *http.Client
+}
+
+// webhookPayload is the default request body.
+type webhookPayload struct {
+	Rule     string            `json:"rule"`
+	Group    string            `json:"group"`
+	Severity string            `json:"severity"`
+	Events   []json.RawMessage `json:"events"`
+}
+
+func newWebhook(c any) (Action, error) {
+	act := c.(config.WebhookAction)
+	if _, err := url.ParseRequestURI(act.URL); err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	if act.Method == "" {
+		act.Method = http.MethodPost
+	}
+	if act.Timeout == 0 {
+		act.Timeout = defaultWebhookTimeout
+	}
+	return webhook{WebhookAction: act, client: &http.Client{Timeout: act.Timeout}}, nil
+}
+
+// Execute sends the request in the background. Request
+// failures are logged and accounted in action metrics.
+func (w webhook) Execute(ctx *config.ActionContext, interpolate Interpolator) error {
+	var body []byte
+	if w.Body != "" {
+		body = []byte(interpolate(w.Body, escapeJSON))
+	} else {
+		payload := webhookPayload{
+			Rule:     ctx.Filter.Name,
+			Group:    ctx.Group.Name,
+			Severity: ctx.Filter.Severity,
+			Events:   make([]json.RawMessage, 0, len(ctx.Events)),
+		}
+		for _, evt := range ctx.Events {
+			payload.Events = append(payload.Events, evt.MarshalJSON())
+		}
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(w.Method, interpolateURL(w.URL, interpolate), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, interpolate(v, stripControl))
+	}
+	log.Infof("executing webhook action: url=%s rule=%s", req.URL, ctx.Filter.Name)
+	go func() {
+		resp, err := w.client.Do(req)
+		if err != nil {
+			actionErrors.Add(w.Name(), 1)
+			log.Warnf("webhook request failed for rule %s: %v", ctx.Filter.Name, err)
+			return
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode >= http.StatusBadRequest {
+			actionErrors.Add(w.Name(), 1)
+			log.Warnf("webhook for rule %s returned %s", ctx.Filter.Name, resp.Status)
+		}
+	}()
+	return nil
+}
+
+// interpolateURL replaces field references in the URL. Values
+// are escaped according to the URL component they appear in,
+// so they can't inject query parameters or alter the path.
+func interpolateURL(u string, interpolate Interpolator) string {
+	path, query, ok := strings.Cut(u, "?")
+	path = interpolate(path, url.PathEscape)
+	if !ok {
+		return path
+	}
+	return path + "?" + interpolate(query, url.QueryEscape)
+}
+
+// stripControl removes control characters, such as
+// CR and LF, that would break the header value.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// escapeJSON escapes the field value, so it can be safely
+// embedded in the JSON string of the user-supplied body.
+func escapeJSON(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return string(b[1 : len(b)-1])
+}
@@ -42,6 +42,7 @@ func TestLookup(t *testing.T) {
 	assert.Equal(t, Field("ps.ancestor[2].sid"), Lookup("ps.ancestor[2].sid"))
 	assert.Empty(t, Lookup("ps.ancestor[ro].name"))
 	assert.Equal(t, Field("kevt.arg[exe]"), Lookup("kevt.arg[exe]"))
+	assert.Equal(t, Field("kevt.meta[rule.name]"), Lookup("kevt.meta[rule.name]"))
 	assert.Empty(t, Lookup("kevt.arg"))
 	assert.Equal(t, Field("thread.callstack[0].address"), Lookup("thread.callstack[0].address"))
 	assert.Equal(t, Field("thread.callstack[ustart].address"), Lookup("thread.callstack[ustart].address"))
@@ -29,6 +29,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var (
@@ -36,6 +37,8 @@ var (
 	ErrNoFields = errors.New("expected at least one field or operator but zero found")
 	// accessorErrors counts the errors produced by the field accessors
 	accessorErrors = expvar.NewMap("filter.accessor.errors")
+
+	fieldsReplRegexp = regexp.MustCompile(`%([1-9]?)\.?([a-z0-9A-Z\[\].]+)`)
 )
 
 // Filter is the main interface for the filter engine implementors. Filter can either
@@ -49,8 +52,13 @@ type Filter interface {
 	Run(kevt *kevent.Kevent) bool
 	// RunSequence runs a filter with sequence expressions. Sequence rules depend
 	// on the state machine transitions and partial matches to decide whether the
-	// rule is fired.
-	RunSequence(kevt *kevent.Kevent, seqID uint16, partials map[uint16][]*kevent.Kevent, rawMatch bool) bool
+	// rule is fired. If the expression matches, the value of the join field is
+	// returned, so the event can be joined with the downstream partials.
+	RunSequence(kevt *kevent.Kevent, seqID uint16, partials map[uint16][]*Partial, rawMatch bool) (bool, any)
+	// RunUntil evaluates the until expression of the sequence. If the expression
+	// matches, the join value is returned, so the partials that share the same
+	// join value can be discarded.
+	RunUntil(kevt *kevent.Kevent) (bool, any)
 	// GetStringFields returns field names mapped to their string values.
 	GetStringFields() map[fields.Field][]string
 	// GetFields returns all field used in the filter expression.
@@ -61,6 +69,31 @@ type Filter interface {
 	IsSequence() bool
 }
 
+// Partial is the event matched by the sequence expression. The
+// join value is kept along with the partial instead of the event
+// metadata, since the same event is evaluated by many sequences.
+type Partial struct {
+	*kevent.Kevent
+	// By is the value of the join field the event was matched by
+	By any
+
+	// key is the partial key of the original event
+	key uint64
+	// values contains the field values captured from
+	// the original event when the partial is compacted
+	values    map[fields.Field]any
+	compacted bool
+}
+
+// value returns the field value captured from the compacted
+// partial or extracts the value from the partial event.
+func (p *Partial) value(f *filter, field fields.Field) any {
+	if v, ok := p.values[field]; ok {
+		return v
+	}
+	return f.getField(field, p.Kevent)
+}
+
 type filter struct {
 	expr        ql.Expr
 	seq         *ql.Sequence
@@ -71,6 +104,22 @@ type filter struct {
 	// stringFields contains filter field names mapped to their string values
 	stringFields map[fields.Field][]string
 	hasFunctions bool
+	// exceptions exclude benign activity from the filter
+	exceptions []exception
+
+	// prog, seqProgs, and untilProg are the compiled
+	// versions of the expression, sequence expressions,
+	// and the until expression respectively
+	prog      *ql.Program
+	seqProgs  []*ql.Program
+	untilProg *ql.Program
+	valuers   sync.Pool
+}
+
+// exception contains the parser of the exception expression.
+type exception struct {
+	name   string
+	parser *ql.Parser
 }
 
 // Compile parsers the filter expression and builds a binary expression tree
@@ -79,8 +128,9 @@ type filter struct {
 // expressions are replaced with respective event parameters via map valuer.
 // For functions call we grab all the arguments that are evaluated as field
 // literals.
-// Matching the filter involves descending the binary expression tree recursively
-// until all nodes are visited.
+// Finally, the expression tree is compiled into the program of type-specialized
+// closures that are executed for each event. Field values are extracted lazily,
+// only when the evaluation reaches the field.
 func (f *filter) Compile() error {
 	var err error
 	if f.parser.IsSequence() {
@@ -91,6 +141,10 @@ func (f *filter) Compile() error {
 	if err != nil {
 		return err
 	}
+	if err := f.applyExceptions(); err != nil {
+		return err
+	}
+	f.compilePrograms()
 
 	// traverse the expression tree
 	walk := func(n ql.Node) {
@@ -122,6 +176,13 @@ func (f *filter) Compile() error {
 					f.addBoundField(field)
 				}
 			}
+		case *ql.Quantifier:
+			if list, ok := expr.List.(*ql.FieldLiteral); ok {
+				f.addField(fields.Field(list.Value))
+			}
+			if list, ok := expr.List.(*ql.BoundFieldLiteral); ok {
+				f.addBoundField(list)
+			}
 		case *ql.FieldLiteral:
 			field := fields.Field(expr.Value)
 			if fields.IsBoolean(field) {
@@ -141,6 +202,12 @@ func (f *filter) Compile() error {
 				f.addField(expr.By)
 			}
 		}
+		if until := f.seq.Until; until != nil {
+			ql.WalkFunc(until.Expr, walk)
+			if !until.By.IsEmpty() {
+				f.addField(until.By)
+			}
+		}
 	}
 	if len(f.fields) == 0 && !f.hasFunctions {
 		return ErrNoFields
@@ -150,35 +217,80 @@ func (f *filter) Compile() error {
 	return f.checkBoundRefs()
 }
 
+// applyExceptions parses the exception expressions and combines
+// them with the filter expression, so the filter doesn't match if
+// any of the exceptions is satisfied.
+func (f *filter) applyExceptions() error {
+	if len(f.exceptions) == 0 {
+		return nil
+	}
+	exclusions := make([]ql.Expr, 0, len(f.exceptions))
+	for _, e := range f.exceptions {
+		expr, err := e.parser.ParseExpr()
+		if err != nil {
+			return fmt.Errorf("invalid %q exception: %v", e.name, err)
+		}
+		exclusions = append(exclusions, expr)
+	}
+	if f.expr != nil {
+		f.expr = ql.Exclude(f.expr, exclusions...)
+	} else {
+		f.seq.Exclude(exclusions...)
+	}
+	return nil
+}
+
+// compilePrograms compiles the filter or sequence expressions into programs.
+func (f *filter) compilePrograms() {
+	if f.expr != nil {
+		f.prog = ql.Compile(f.expr)
+		return
+	}
+	f.seqProgs = make([]*ql.Program, len(f.seq.Expressions))
+	for i, expr := range f.seq.Expressions {
+		f.seqProgs[i] = ql.Compile(expr.Expr)
+	}
+	if f.seq.Until != nil {
+		f.untilProg = ql.Compile(f.seq.Until.Expr)
+	}
+}
+
 func (f *filter) Run(kevt *kevent.Kevent) bool {
-	if f.expr == nil {
+	if f.prog == nil {
 		return false
 	}
-	return ql.Eval(f.expr, f.mapValuer(kevt), f.hasFunctions)
+	valuer := f.newValuer(kevt)
+	defer f.releaseValuer(valuer)
+	return f.prog.Eval(valuer)
 }
 
-func (f *filter) RunSequence(kevt *kevent.Kevent, seqID uint16, partials map[uint16][]*kevent.Kevent, rawMatch bool) bool {
+func (f *filter) RunSequence(kevt *kevent.Kevent, seqID uint16, partials map[uint16][]*Partial, rawMatch bool) (bool, any) {
 	if f.seq == nil {
-		return false
+		return false, nil
 	}
 	nseqs := uint16(len(f.seq.Expressions))
 	if seqID > nseqs-1 {
-		return false
+		return false, nil
 	}
-	valuer := f.mapValuer(kevt)
+	valuer := f.newValuer(kevt)
+	defer f.releaseValuer(valuer)
 	expr := f.seq.Expressions[seqID]
+	prog := f.seqProgs[seqID]
 
 	if rawMatch {
 		// only check if the condition matches
 		// without evaluating joins/bound fields
-		return ql.Eval(expr.Expr, valuer, f.hasFunctions)
+		return prog.Eval(valuer), nil
 	}
-	var match bool
+	var (
+		match bool
+		joinv any
+	)
 	if seqID >= 1 && expr.HasBoundFields() {
 		// if a sequence expression contains references to
 		// bound fields we map all partials to their sequence
 		// aliases
-		p := make(map[string][]*kevent.Kevent)
+		p := make(map[string][]*Partial)
 		nslots := len(partials[seqID])
 		for i := uint16(0); i < seqID; i++ {
 			alias := f.seq.Expressions[i].Alias
@@ -196,7 +308,7 @@ func (f *filter) RunSequence(kevt *kevent.Kevent, seqID uint16, partials map[uin
 			nslots--
 			for _, field := range expr.BoundFields {
 				evts := p[field.Alias()]
-				var evt *kevent.Kevent
+				var evt *Partial
 				if n > len(evts)-1 {
 					// pick the latest event if all
 					// events for this slot are consumed
@@ -204,20 +316,12 @@ func (f *filter) RunSequence(kevt *kevent.Kevent, seqID uint16, partials map[uin
 				} else {
 					evt = evts[n]
 				}
-				for _, accessor := range f.accessors {
-					v, err := accessor.get(field.Field(), evt)
-					if err != nil && !kerrors.IsKparamNotFound(err) {
-						accessorErrors.Add(err.Error(), 1)
-						continue
-					}
-					if v != nil {
-						valuer[field.String()] = v
-						break
-					}
+				if v := evt.value(f, field.Field()); v != nil {
+					valuer.bind(field.String(), v)
 				}
 			}
 			n++
-			match = ql.Eval(expr.Expr, valuer, f.hasFunctions)
+			match = prog.Eval(valuer)
 			if match {
 				break
 			}
@@ -230,27 +334,56 @@ func (f *filter) RunSequence(kevt *kevent.Kevent, seqID uint16, partials map[uin
 		if seqID >= 1 && !by.IsEmpty() {
 			// traverse upstream partials for join equality
 			joins := make([]bool, seqID)
-			joinID := valuer[by.String()]
+			joinID, _ := valuer.Value(by.String())
 		outer:
 			for i := uint16(0); i < seqID; i++ {
+				// absence expressions don't store partials
+				if f.seq.Expressions[i].Negated {
+					joins[i] = true
+					continue
+				}
 				for _, p := range partials[i+1] {
-					if compareSeqJoin(joinID, p.SequenceBy()) {
+					if compareSeqJoin(joinID, p.By) {
 						joins[i] = true
 						continue outer
 					}
 				}
 			}
-			match = joinsEqual(joins) && ql.Eval(expr.Expr, valuer, f.hasFunctions)
+			match = joinsEqual(joins) && prog.Eval(valuer)
 		} else {
-			match = ql.Eval(expr.Expr, valuer, f.hasFunctions)
+			match = prog.Eval(valuer)
 		}
 		if match && !by.IsEmpty() {
-			if v := valuer[by.String()]; v != nil {
-				kevt.AddMeta(kevent.RuleSequenceByKey, v)
-			}
+			joinv, _ = valuer.Value(by.String())
 		}
 	}
-	return match
+	return match, joinv
+}
+
+func (f *filter) RunUntil(kevt *kevent.Kevent) (bool, any) {
+	if f.seq == nil || f.seq.Until == nil {
+		return false, nil
+	}
+	valuer := f.newValuer(kevt)
+	defer f.releaseValuer(valuer)
+	until := f.seq.Until
+	if !f.untilProg.Eval(valuer) {
+		return false, nil
+	}
+	by := until.By
+	if by.IsEmpty() {
+		by = f.seq.By
+	}
+	if by.IsEmpty() {
+		return true, nil
+	}
+	// the until expression can't terminate
+	// partials if the join value is missing
+	v, ok := valuer.Value(by.String())
+	if !ok {
+		return false, nil
+	}
+	return true, v
 }
 
 func joinsEqual(joins []bool) bool {
@@ -273,7 +406,14 @@ func (f *filter) GetSequence() *ql.Sequence { return f.seq }
 // which refers to the event in particular sequence stage. Otherwise, the modifier is
 // a well-known field name prepended with the `%` symbol.
 func InterpolateFields(s string, evts []*kevent.Kevent) string {
-	var fieldsReplRegexp = regexp.MustCompile(`%([1-9]?)\.?([a-z0-9A-Z\[\].]+)`)
+	return interpolateFields(s, evts, nil, nil)
+}
+
+// interpolateFields replaces field modifiers with values extracted from
+// the events. If given, the field values captured from compacted partials
+// take precedence over the values extracted from the events. The optional
+// escape function is applied to each value before it is substituted.
+func interpolateFields(s string, evts []*kevent.Kevent, values []map[fields.Field]any, escape func(string) string) string {
 	matches := fieldsReplRegexp.FindAllStringSubmatch(s, -1)
 	r := s
 	if len(matches) == 0 {
@@ -298,18 +438,25 @@ func InterpolateFields(s string, evts []*kevent.Kevent) string {
 			kevt := evts[i-1]
 			// extract field value from the event and replace in string
 			var val any
+			if i-1 < len(values) {
+				val = values[i-1][fields.Field(m[2])]
+			}
 			for _, accessor := range getAccessors() {
+				if val != nil {
+					break
+				}
 				var err error
 				val, err = accessor.get(fields.Field(m[2]), kevt)
 				if err != nil {
-					continue
-				}
-				if val != nil {
-					break
+					val = nil
 				}
 			}
 			if val != nil {
-				r = strings.ReplaceAll(r, m[0], fmt.Sprintf("%v", val))
+				v := fmt.Sprintf("%v", val)
+				if escape != nil {
+					v = escape(v)
+				}
+				r = strings.ReplaceAll(r, m[0], v)
 			} else {
 				r = strings.ReplaceAll(r, m[0], "N/A")
 			}
@@ -320,26 +467,92 @@ func InterpolateFields(s string, evts []*kevent.Kevent) string {
 	return r
 }
 
-// mapValuer for each field present in the AST, we run the
-// accessors and extract the field values that are
-// supplied to the valuer. The valuer feeds the
-// expression with correct values.
-func (f *filter) mapValuer(kevt *kevent.Kevent) map[string]interface{} {
-	valuer := make(map[string]interface{}, len(f.fields))
-	for _, field := range f.fields {
-		for _, accessor := range f.accessors {
-			v, err := accessor.get(field, kevt)
-			if err != nil && !kerrors.IsKparamNotFound(err) {
-				accessorErrors.Add(err.Error(), 1)
-				continue
-			}
-			if v != nil {
-				valuer[field.String()] = v
-				break
-			}
+// getField runs the accessors to extract the field value from the event.
+// The value of the first accessor that recognizes the field is returned.
+func (f *filter) getField(field fields.Field, kevt *kevent.Kevent) interface{} {
+	for _, accessor := range f.accessors {
+		v, err := accessor.get(field, kevt)
+		if err != nil && !kerrors.IsKparamNotFound(err) {
+			accessorErrors.Add(err.Error(), 1)
+			continue
+		}
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// eventValuer feeds the compiled programs with field values. The
+// values are extracted from the event on the first access and cached
+// for the remaining evaluation, so fields that are never reached due
+// to short-circuiting don't incur the cost of running the accessors.
+type eventValuer struct {
+	f        *filter
+	kevt     *kevent.Kevent
+	values   []interface{}
+	resolved []bool
+	// bound contains bound field values from partial sequence matches
+	bound []boundValue
+}
+
+type boundValue struct {
+	key string
+	val interface{}
+}
+
+// newValuer obtains the valuer for the given event from the pool.
+func (f *filter) newValuer(kevt *kevent.Kevent) *eventValuer {
+	v, ok := f.valuers.Get().(*eventValuer)
+	if !ok {
+		v = &eventValuer{
+			f:        f,
+			values:   make([]interface{}, len(f.fields)),
+			resolved: make([]bool, len(f.fields)),
+		}
+	}
+	v.kevt = kevt
+	return v
+}
+
+// releaseValuer resets the valuer state and returns it to the pool.
+func (f *filter) releaseValuer(v *eventValuer) {
+	clear(v.values)
+	clear(v.resolved)
+	v.kevt = nil
+	v.bound = v.bound[:0]
+	f.valuers.Put(v)
+}
+
+// Value returns the value of the filter field or the bound field.
+func (v *eventValuer) Value(key string) (interface{}, bool) {
+	for i, field := range v.f.fields {
+		if field.String() != key {
+			continue
+		}
+		if !v.resolved[i] {
+			v.values[i] = v.f.getField(field, v.kevt)
+			v.resolved[i] = true
+		}
+		return v.values[i], v.values[i] != nil
+	}
+	for _, b := range v.bound {
+		if b.key == key {
+			return b.val, true
+		}
+	}
+	return nil, false
+}
+
+// bind sets the value of the bound field.
+func (v *eventValuer) bind(key string, val interface{}) {
+	for i := range v.bound {
+		if v.bound[i].key == key {
+			v.bound[i].val = val
+			return
 		}
 	}
-	return valuer
+	v.bound = append(v.bound, boundValue{key: key, val: val})
 }
 
 // addField appends a new field to the filter fields list.
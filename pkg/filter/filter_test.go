@@ -19,8 +19,10 @@
 package filter
 
 import (
+	"fmt"
 	"github.com/rabbitstack/fibratus/pkg/config"
 	"github.com/rabbitstack/fibratus/pkg/filter/fields"
+	"github.com/rabbitstack/fibratus/pkg/filter/ql"
 	"github.com/rabbitstack/fibratus/pkg/kevent"
 	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
 	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
@@ -35,6 +37,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -212,6 +215,9 @@ func TestProcFilter(t *testing.T) {
 		{`ps.modules[kernel32.dll].address.base = 'fff23fff'`, true},
 		{`ps.modules[kernel32.dll].location = 'C:\\Windows\\System32'`, true},
 		{`ps.modules[xul.dll].size = 12354`, false},
+		{`any(ps.modules, m -> m ~= 'USER32.dll')`, true},
+		{`all(ps.modules, m -> m iendswith '32.dll')`, true},
+		{`none(ps.modules, m -> m = 'kernel32.dll')`, false},
 		{`kevt.name = 'CreateProcess' and kevt.pid != ps.ppid`, true},
 		{`ps.parent.name = 'wininit.exe'`, true},
 		{`ps.ancestor[1].name = 'wininit.exe'`, true},
@@ -477,6 +483,8 @@ func TestKeventFilter(t *testing.T) {
 		{`kevt.arg[file_name] = '\\Device\\HarddiskVolume2\\Windows\\system32\\user32.dll'`, true},
 		{`kevt.arg[type] = 'file'`, true},
 		{`kevt.arg[pid] = 3434`, true},
+		{`kevt.meta[foo] = 'bar'`, true},
+		{`kevt.meta[rule.name] != ''`, false},
 
 		{`kevt.desc contains 'Creates or opens a new file'`, true},
 
@@ -643,6 +651,10 @@ func TestPEFilter(t *testing.T) {
 		{`pe.nsymbols = 10 AND pe.nsections = 2`, true},
 		{`pe.nsections > 1`, true},
 		{`pe.address.base = '140000000' AND pe.address.entrypoint = '20110'`, true},
+		{`any(pe.sections, s -> s.name = '.text' and s.entropy > 6)`, true},
+		{`any(pe.sections, s -> s.name = '.rdata' and s.entropy > 6)`, false},
+		{`all(pe.sections, s -> s.size > 1024 and s.md5 != '')`, true},
+		{`none(pe.imports, i -> i imatches 'ws2_32.dll')`, true},
 	}
 
 	for i, tt := range tests {
@@ -939,3 +951,64 @@ func BenchmarkFilterRun(b *testing.B) {
 		f.Run(kevt)
 	}
 }
+
+// BenchmarkFilterCompiledRun compares the compiled filter
+// programs with the AST evaluator fed by the map valuer.
+func BenchmarkFilterCompiledRun(b *testing.B) {
+	kpars := kevent.Kparams{
+		kparams.Cmdline:         {Name: kparams.Cmdline, Type: kparams.UnicodeString, Value: "C:\\Windows\\system32\\svchost.exe -k RPCSS"},
+		kparams.ProcessName:     {Name: kparams.ProcessName, Type: kparams.AnsiString, Value: "svchost.exe"},
+		kparams.ProcessID:       {Name: kparams.ProcessID, Type: kparams.Uint32, Value: uint32(1234)},
+		kparams.ProcessParentID: {Name: kparams.ProcessParentID, Type: kparams.Uint32, Value: uint32(345)},
+	}
+
+	kevt := &kevent.Kevent{
+		Type:     ktypes.CreateProcess,
+		Kparams:  kpars,
+		Name:     "CreateProcess",
+		Category: ktypes.Process,
+	}
+
+	pats := make([]string, 0, 300)
+	for i := 0; i < 300; i++ {
+		pats = append(pats, fmt.Sprintf("'?:\\\\program files\\\\vendor%d\\\\*\\\\updater*.exe'", i))
+	}
+	patterns := strings.Join(pats, ", ")
+
+	var benches = []struct {
+		name string
+		expr string
+	}{
+		{"eq", `ps.name = 'svchost.exe'`},
+		{"short-circuit", `kevt.name = 'CreateThread' and ps.name = 'svchost.exe' and ps.cmdline icontains 'rpcss'`},
+		{"iin", `kevt.name = 'CreateProcess' and ps.name iin ('cmd.exe', 'powershell.exe', 'pwsh.exe', 'rundll32.exe', 'regsvr32.exe', 'mshta.exe', 'wscript.exe', 'cscript.exe', 'svchost.exe')`},
+		{"imatches", `kevt.name = 'CreateProcess' and ps.cmdline imatches ('*\\svchost.exe -k rpc*', '*\\rundll32.exe *,#*')`},
+		{"icontains", `ps.cmdline icontains ('-enc', 'downloadstring', 'rpcss') or ps.name iendswith ('.scr', '.pif')`},
+		{"numeric", `ps.pid > 1000 and ps.pid != 4 and ps.ppid >= 345`},
+		{"large-list", `ps.cmdline imatches (` + patterns + `)`},
+	}
+
+	for _, bench := range benches {
+		f := New(bench.expr, cfg).(*filter)
+		require.NoError(b, f.Compile())
+
+		b.Run(bench.name+"/interpreted", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m := make(map[string]interface{}, len(f.fields))
+				for _, field := range f.fields {
+					if v := f.getField(field, kevt); v != nil {
+						m[field.String()] = v
+					}
+				}
+				ql.Eval(f.expr, m, f.hasFunctions)
+			}
+		})
+		b.Run(bench.name+"/compiled", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				f.Run(kevt)
+			}
+		})
+	}
+}
@@ -28,7 +28,8 @@ import (
 )
 
 type opts struct {
-	psnap ps.Snapshotter
+	psnap      ps.Snapshotter
+	exceptions []config.Exception
 }
 
 // Option defines the option supplied to the filter
@@ -41,6 +42,13 @@ func WithPSnapshotter(psnap ps.Snapshotter) Option {
 	}
 }
 
+// WithExceptions passes the exceptions that are combined with the filter expression.
+func WithExceptions(exceptions ...config.Exception) Option {
+	return func(o *opts) {
+		o.exceptions = exceptions
+	}
+}
+
 // New creates a new filter with the specified filter expression. The consumers must ensure
 // the expression is correctly parsed before executing the filter. This is achieved by calling the
 // `Compile` method after constructing the filter.
@@ -85,11 +93,13 @@ func New(expr string, config *config.Config, options ...Option) Filter {
 		accessors = append(accessors, newDNSAccessor())
 	}
 
-	var parser *ql.Parser
-	if fconfig.HasMacros() {
-		parser = ql.NewParserWithConfig(expr, fconfig)
-	} else {
-		parser = ql.NewParser(expr)
+	// the parser expands macros and enforces
+	// the sequence limits from the config
+	parser := ql.NewParserWithConfig(expr, fconfig)
+
+	exceptions := make([]exception, 0, len(opts.exceptions))
+	for _, e := range opts.exceptions {
+		exceptions = append(exceptions, exception{name: e.Name, parser: ql.NewParserWithConfig(e.Condition, fconfig)})
 	}
 
 	return &filter{
@@ -98,6 +108,7 @@ func New(expr string, config *config.Config, options ...Option) Filter {
 		fields:       make([]fields.Field, 0),
 		stringFields: make(map[fields.Field][]string),
 		boundFields:  make([]*ql.BoundFieldLiteral, 0),
+		exceptions:   exceptions,
 	}
 }
 
@@ -0,0 +1,285 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package harness
+
+import (
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"math"
+	"net"
+	"time"
+)
+
+// paramTypes maps the type names that can be used
+// in explicitly typed parameters to parameter types.
+var paramTypes = map[string]kparams.Type{
+	"unicode":   kparams.UnicodeString,
+	"string":    kparams.UnicodeString,
+	"ansi":      kparams.AnsiString,
+	"int8":      kparams.Int8,
+	"uint8":     kparams.Uint8,
+	"int16":     kparams.Int16,
+	"uint16":    kparams.Uint16,
+	"int32":     kparams.Int32,
+	"uint32":    kparams.Uint32,
+	"int64":     kparams.Int64,
+	"uint64":    kparams.Uint64,
+	"bool":      kparams.Bool,
+	"pid":       kparams.PID,
+	"tid":       kparams.TID,
+	"port":      kparams.Port,
+	"ip":        kparams.IP,
+	"ipv4":      kparams.IPv4,
+	"ipv6":      kparams.IPv6,
+	"time":      kparams.Time,
+	"slice":     kparams.Slice,
+	"enum":      kparams.Enum,
+	"flags":     kparams.Flags,
+	"flags64":   kparams.Flags64,
+	"address":   kparams.Address,
+	"file_path": kparams.FilePath,
+	"key":       kparams.Key,
+	"sid":       kparams.WbemSID,
+}
+
+// wellKnownParams contains the types of parameters
+// that are inferred from the parameter name rather
+// than from the scalar value.
+var wellKnownParams = map[string]kparams.Type{
+	kparams.ProcessID:       kparams.PID,
+	kparams.ProcessParentID: kparams.PID,
+	kparams.ThreadID:        kparams.TID,
+	kparams.NetDport:        kparams.Port,
+	kparams.NetSport:        kparams.Port,
+	kparams.NetSIP:          kparams.IP,
+	kparams.NetDIP:          kparams.IP,
+}
+
+// newEvent builds the event from the specification. The
+// event timestamp is given by the caller.
+func newEvent(seq uint64, e Event, ts time.Time) (*kevent.Kevent, error) {
+	ktype := ktypes.KeventNameToKtype(e.Name)
+	if ktype == ktypes.UnknownKtype {
+		// network events are mapped to multiple types
+		if types := ktypes.KeventNameToKtypes(e.Name); len(types) > 0 {
+			ktype = types[0]
+		}
+	}
+	if ktype == ktypes.UnknownKtype {
+		return nil, fmt.Errorf("unknown event name %q", e.Name)
+	}
+	info := ktypes.KtypeToKeventInfo(ktype)
+	evt := &kevent.Kevent{
+		Seq:         seq,
+		PID:         e.PID,
+		Tid:         e.Tid,
+		Type:        ktype,
+		Name:        info.Name,
+		Category:    info.Category,
+		Description: info.Description,
+		Timestamp:   ts,
+		Kparams:     make(kevent.Kparams),
+		Metadata:    make(map[kevent.MetadataKey]any),
+	}
+	if e.PS != nil {
+		evt.PS = newProcess(e.PS)
+		if evt.PID == 0 {
+			evt.PID = evt.PS.PID
+		}
+	}
+	for name, v := range e.Params {
+		kpar, err := newParam(name, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q parameter in %s event: %v", name, e.Name, err)
+		}
+		evt.Kparams[name] = kpar
+	}
+	return evt, nil
+}
+
+// newProcess builds the process state from the specification.
+func newProcess(p *Process) *pstypes.PS {
+	ps := &pstypes.PS{
+		PID:          p.PID,
+		Ppid:         p.Ppid,
+		Name:         p.Name,
+		Exe:          p.Exe,
+		Cmdline:      p.Cmdline,
+		Cwd:          p.Cwd,
+		SID:          p.SID,
+		Username:     p.Username,
+		Domain:       p.Domain,
+		SessionID:    p.SessionID,
+		Args:         p.Args,
+		Envs:         p.Envs,
+		Threads:      make(map[uint32]pstypes.Thread),
+		Modules:      make([]pstypes.Module, 0),
+		FileMappings: make([]pstypes.Mmap, 0),
+	}
+	if ps.Args == nil {
+		ps.Args = make([]string, 0)
+	}
+	if ps.Envs == nil {
+		ps.Envs = make(map[string]string)
+	}
+	if p.Parent != nil {
+		ps.Parent = newProcess(p.Parent)
+		if ps.Ppid == 0 {
+			ps.Ppid = ps.Parent.PID
+		}
+	}
+	return ps
+}
+
+// newParam builds the event parameter. The value is either
+// a scalar or a mapping with explicit type and value keys.
+func newParam(name string, v any) (*kevent.Kparam, error) {
+	if m, ok := v.(map[string]any); ok {
+		typ, ok := m["type"].(string)
+		if !ok {
+			return nil, fmt.Errorf("missing parameter type")
+		}
+		t, ok := paramTypes[typ]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter type %q", typ)
+		}
+		return typedParam(name, t, m["value"])
+	}
+	if t, ok := wellKnownParams[name]; ok {
+		return typedParam(name, t, v)
+	}
+	var t kparams.Type
+	switch val := v.(type) {
+	case string:
+		t = kparams.UnicodeString
+	case bool:
+		t = kparams.Bool
+	case int:
+		if val < 0 || val > math.MaxUint32 {
+			t = kparams.Int64
+		} else {
+			t = kparams.Uint32
+		}
+	case float64:
+		t = kparams.Double
+	case []any:
+		t = kparams.Slice
+	case time.Time:
+		t = kparams.Time
+	default:
+		return nil, fmt.Errorf("unsupported value %v", v)
+	}
+	return typedParam(name, t, v)
+}
+
+// typedParam builds the parameter of the given type. Generic
+// IP addresses are resolved to either IPv4 or IPv6 types.
+func typedParam(name string, t kparams.Type, v any) (*kevent.Kparam, error) {
+	val, err := convertValue(t, v)
+	if err != nil {
+		return nil, err
+	}
+	if ip, ok := val.(net.IP); ok && t == kparams.IP {
+		if ip4 := ip.To4(); ip4 != nil {
+			t, val = kparams.IPv4, ip4
+		} else {
+			t = kparams.IPv6
+		}
+	}
+	return &kevent.Kparam{Name: name, Type: t, Value: val}, nil
+}
+
+// convertValue converts the decoded value to
+// the representation of the parameter type.
+func convertValue(t kparams.Type, v any) (kparams.Value, error) {
+	switch t {
+	case kparams.UnicodeString, kparams.AnsiString, kparams.FilePath, kparams.Key, kparams.WbemSID:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string value, got %v", v)
+		}
+		return s, nil
+	case kparams.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool value, got %v", v)
+		}
+		return b, nil
+	case kparams.Double:
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected float value, got %v", v)
+		}
+		return f, nil
+	case kparams.IP, kparams.IPv4, kparams.IPv6:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected IP address, got %v", v)
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", s)
+		}
+		return ip, nil
+	case kparams.Time:
+		switch ts := v.(type) {
+		case time.Time:
+			return ts, nil
+		case string:
+			return time.Parse(time.RFC3339, ts)
+		}
+		return nil, fmt.Errorf("expected timestamp, got %v", v)
+	case kparams.Slice:
+		items, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected list value, got %v", v)
+		}
+		s := make([]string, 0, len(items))
+		for _, item := range items {
+			s = append(s, fmt.Sprintf("%v", item))
+		}
+		return s, nil
+	}
+	n, ok := v.(int)
+	if !ok {
+		return nil, fmt.Errorf("expected numeric value, got %v", v)
+	}
+	switch t {
+	case kparams.Int8:
+		return int8(n), nil
+	case kparams.Uint8:
+		return uint8(n), nil
+	case kparams.Int16:
+		return int16(n), nil
+	case kparams.Uint16, kparams.Port:
+		return uint16(n), nil
+	case kparams.Int32:
+		return int32(n), nil
+	case kparams.Uint32, kparams.PID, kparams.TID, kparams.Enum, kparams.Flags:
+		return uint32(n), nil
+	case kparams.Int64:
+		return int64(n), nil
+	case kparams.Uint64, kparams.Flags64, kparams.Address:
+		return uint64(n), nil
+	}
+	return nil, fmt.Errorf("unsupported parameter type %v", t)
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package harness
+
+import (
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+func TestNewParam(t *testing.T) {
+	var tests = []struct {
+		name  string
+		v     any
+		typ   kparams.Type
+		value kparams.Value
+	}{
+		{"file_name", `C:\Windows\notepad.exe`, kparams.UnicodeString, `C:\Windows\notepad.exe`},
+		{"pid", 1234, kparams.PID, uint32(1234)},
+		{"tid", 4321, kparams.TID, uint32(4321)},
+		{"dport", 443, kparams.Port, uint16(443)},
+		{"dip", "10.0.0.1", kparams.IPv4, net.ParseIP("10.0.0.1").To4()},
+		{"sip", "fe80::1", kparams.IPv6, net.ParseIP("fe80::1")},
+		{"status", 5, kparams.Uint32, uint32(5)},
+		{"offset", -1, kparams.Int64, int64(-1)},
+		{"is_exec", true, kparams.Bool, true},
+		{"args", []any{"-c", 1}, kparams.Slice, []string{"-c", "1"}},
+		{"base_address", map[string]any{"type": "address", "value": 0x7ffe0000}, kparams.Address, uint64(0x7ffe0000)},
+		{"sid", map[string]any{"type": "ansi", "value": "S-1-5-18"}, kparams.AnsiString, "S-1-5-18"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kpar, err := newParam(tt.name, tt.v)
+			require.NoError(t, err)
+			assert.Equal(t, tt.typ, kpar.Type)
+			assert.Equal(t, tt.value, kpar.Value)
+		})
+	}
+}
+
+func TestNewParamErrors(t *testing.T) {
+	_, err := newParam("dport", "https")
+	require.Error(t, err)
+	_, err = newParam("dip", "10.0.0")
+	require.Error(t, err)
+	_, err = newParam("status", map[string]any{"type": "nonsense", "value": 1})
+	require.Error(t, err)
+	_, err = newParam("status", map[string]any{"value": 1})
+	require.Error(t, err)
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package harness
+
+import (
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/filter"
+	"time"
+)
+
+// ErrUnknownRule signals the test case references a rule that is not in the ruleset.
+var ErrUnknownRule = func(rule string) error { return fmt.Errorf("rule %q not found in the ruleset", rule) }
+
+// Result is the outcome of the test case evaluation.
+type Result struct {
+	// TestCase is the evaluated test case.
+	TestCase TestCase
+	// Fired contains the names of all rules that fired
+	// while the test case events were processed.
+	Fired []string
+	// Err is set if the test case couldn't be evaluated.
+	Err error
+}
+
+// Matched determines if the rule under test fired.
+func (r Result) Matched() bool {
+	for _, rule := range r.Fired {
+		if rule == r.TestCase.Rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Passed determines if the test case outcome is the expected one.
+func (r Result) Passed() bool { return r.Err == nil && r.Matched() == r.TestCase.Matches }
+
+// Runner feeds test case events through the rule engine. Every test
+// case is evaluated by a fresh rule engine instance, so sequence and
+// threshold states never leak between test cases. Rule actions, such
+// as alerts or killing processes, are never executed. All rules that
+// match an event are reported, and the deadlines of sequences ending
+// with absence expressions elapse once all test case events are
+// processed.
+type Runner struct {
+	config *config.Config
+}
+
+// NewRunner creates a new test case runner for the ruleset in the given configuration.
+func NewRunner(config *config.Config) *Runner {
+	return &Runner{config: config}
+}
+
+// Run evaluates the test case. Events without an absolute timestamp are
+// placed relatively to the previous event by the given delay. The first
+// event is timestamped with the current time unless stated otherwise.
+func (r *Runner) Run(tc TestCase) Result {
+	res := Result{TestCase: tc, Fired: make([]string, 0)}
+
+	psnap := newSnapshotter()
+	rules := filter.NewRules(psnap, r.config)
+	defer rules.Close()
+	rules.EnableDryRun()
+	rules.OnMatch(func(ctx *config.ActionContext) {
+		res.Fired = append(res.Fired, ctx.Filter.Name)
+	})
+	if _, err := rules.Compile(); err != nil {
+		res.Err = err
+		return res
+	}
+	if !r.hasRule(tc.Rule) {
+		res.Err = ErrUnknownRule(tc.Rule)
+		return res
+	}
+
+	ts := time.Now()
+	for i, e := range tc.Events {
+		if !e.Timestamp.IsZero() {
+			ts = e.Timestamp
+		} else {
+			ts = ts.Add(e.Delay)
+		}
+		evt, err := newEvent(uint64(i+1), e, ts)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		// events that don't declare the process
+		// state inherit the state declared by
+		// preceding events of the same process
+		if evt.PS == nil {
+			_, evt.PS = psnap.Find(evt.PID)
+		} else {
+			psnap.Put(evt.PS)
+		}
+		if _, err := rules.ProcessEvent(evt); err != nil {
+			res.Err = err
+			return res
+		}
+	}
+	rules.ExpireAbsences(ts.Add(r.config.Filters.Sequences.GetMaxSpan()))
+	return res
+}
+
+func (r *Runner) hasRule(name string) bool {
+	for _, g := range r.config.GetRuleGroups() {
+		for _, rule := range g.Rules {
+			if rule.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
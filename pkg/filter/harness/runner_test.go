@@ -0,0 +1,89 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package harness
+
+import (
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newConfig(fromFiles ...string) *config.Config {
+	return &config.Config{
+		Filters: &config.Filters{
+			Rules: config.Rules{
+				FromPaths: fromFiles,
+			},
+		},
+	}
+}
+
+func TestRunner(t *testing.T) {
+	tcs, err := LoadTestCases("_fixtures/tests.yml")
+	require.NoError(t, err)
+	require.Len(t, tcs, 8)
+
+	runner := NewRunner(newConfig("_fixtures/rules.yml"))
+
+	var tests = []struct {
+		test  string
+		fired []string
+	}{
+		{"script dropped by Word", []string{"Office process dropped a script file", "Script file created in temp directory"}},
+		{"script dropped by Explorer", []string{"Script file created in temp directory"}},
+		{"connection within maxspan", []string{"Executable dropped and connected to suspicious port"}},
+		{"connection after maxspan", []string{}},
+		{"connection from another process", []string{}},
+		{"shell without loaded modules", []string{"Command shell spawned and never loaded a module"}},
+		{"shell loaded a module", []string{}},
+		{"shell loaded a module after maxspan", []string{"Command shell spawned and never loaded a module"}},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			tc := tcs[i]
+			assert.Equal(t, tt.test, tc.Name)
+			res := runner.Run(tc)
+			require.NoError(t, res.Err)
+			assert.Equal(t, tt.fired, res.Fired)
+			assert.True(t, res.Passed())
+		})
+	}
+}
+
+func TestRunnerUnknownRule(t *testing.T) {
+	runner := NewRunner(newConfig("_fixtures/rules.yml"))
+	res := runner.Run(TestCase{
+		Name:   "unknown rule",
+		Rule:   "Nonexistent rule",
+		Events: []Event{{Name: "CreateFile"}},
+	})
+	require.Error(t, res.Err)
+	assert.False(t, res.Passed())
+}
+
+func TestRunnerUnknownEvent(t *testing.T) {
+	tcs, err := LoadTestCases("_fixtures/invalid.yml")
+	require.NoError(t, err)
+	require.Len(t, tcs, 1)
+
+	res := NewRunner(newConfig("_fixtures/rules.yml")).Run(tcs[0])
+	require.EqualError(t, res.Err, `unknown event name "CreateFileX"`)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package harness
+
+import (
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/ps"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"github.com/rabbitstack/fibratus/pkg/util/va"
+	"sync"
+)
+
+// snapshotter is the in-memory process snapshotter populated
+// from the process state declared in test case events. It never
+// queries the operating system for processes it doesn't know of.
+type snapshotter struct {
+	mu    sync.RWMutex
+	procs map[uint32]*pstypes.PS
+}
+
+var _ ps.Snapshotter = (*snapshotter)(nil)
+
+func newSnapshotter() *snapshotter {
+	return &snapshotter{procs: make(map[uint32]*pstypes.PS)}
+}
+
+func (s *snapshotter) Write(kevt *kevent.Kevent) error {
+	if kevt.PS != nil {
+		s.Put(kevt.PS)
+	}
+	return nil
+}
+
+func (s *snapshotter) AddThread(*kevent.Kevent) error                { return nil }
+func (s *snapshotter) AddModule(*kevent.Kevent) error                { return nil }
+func (s *snapshotter) RemoveThread(uint32, uint32) error             { return nil }
+func (s *snapshotter) RemoveModule(uint32, string) error             { return nil }
+func (s *snapshotter) AddFileMapping(*kevent.Kevent) error           { return nil }
+func (s *snapshotter) RemoveFileMapping(uint32, va.Address) error    { return nil }
+func (s *snapshotter) WriteFromKcap(kevt *kevent.Kevent) error       { return s.Write(kevt) }
+func (s *snapshotter) FindModule(va.Address) (bool, *pstypes.Module) { return false, nil }
+func (s *snapshotter) Close() error                                  { return nil }
+
+func (s *snapshotter) Remove(kevt *kevent.Kevent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.procs, kevt.PID)
+	return nil
+}
+
+func (s *snapshotter) Find(pid uint32) (bool, *pstypes.PS) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	proc, ok := s.procs[pid]
+	return ok, proc
+}
+
+func (s *snapshotter) FindAndPut(pid uint32) *pstypes.PS {
+	_, proc := s.Find(pid)
+	return proc
+}
+
+func (s *snapshotter) Put(proc *pstypes.PS) {
+	if proc == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.procs[proc.PID] = proc
+	if proc.Parent != nil {
+		if _, ok := s.procs[proc.Parent.PID]; !ok {
+			s.procs[proc.Parent.PID] = proc.Parent
+		}
+	}
+}
+
+func (s *snapshotter) Size() uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return uint32(len(s.procs))
+}
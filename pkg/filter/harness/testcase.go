@@ -0,0 +1,142 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package harness evaluates detection rules against synthetic events
+// described in YAML test cases. Each test case declares the rule under
+// test, the sequence of events to feed into the rule engine, and the
+// expected outcome.
+package harness
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TestCase describes a series of synthetic events and the
+// expected outcome of the rule evaluation.
+type TestCase struct {
+	// Name is the short description of the test case.
+	Name string `yaml:"test"`
+	// Rule is the name of the rule under test.
+	Rule string `yaml:"rule"`
+	// Matches indicates whether the rule is expected to fire.
+	Matches bool `yaml:"matches"`
+	// Events contains the events fed to the rule engine in order.
+	Events []Event `yaml:"events"`
+	// file is the path of the file the test case was loaded from.
+	file string
+}
+
+// File returns the path of the file the test case was loaded from.
+func (tc TestCase) File() string { return tc.file }
+
+// Event is the synthetic event specification.
+type Event struct {
+	// Name is the event name, e.g. CreateProcess or RegSetValue.
+	Name string `yaml:"name"`
+	// Timestamp is the absolute event timestamp.
+	Timestamp time.Time `yaml:"timestamp"`
+	// Delay is the offset from the previous event timestamp. It
+	// is ignored if the absolute timestamp is given.
+	Delay time.Duration `yaml:"delay"`
+	// PID is the identifier of the process that produced the event.
+	PID uint32 `yaml:"pid"`
+	// Tid is the identifier of the thread that produced the event.
+	Tid uint32 `yaml:"tid"`
+	// Params contains event parameters. The value is either a scalar,
+	// in which case the parameter type is inferred, or a mapping with
+	// explicit type and value keys.
+	Params map[string]any `yaml:"params"`
+	// PS is the state of the process that produced the event.
+	PS *Process `yaml:"ps"`
+}
+
+// Process describes the synthetic process state.
+type Process struct {
+	PID       uint32            `yaml:"pid"`
+	Ppid      uint32            `yaml:"ppid"`
+	Name      string            `yaml:"name"`
+	Exe       string            `yaml:"exe"`
+	Cmdline   string            `yaml:"cmdline"`
+	Cwd       string            `yaml:"cwd"`
+	SID       string            `yaml:"sid"`
+	Username  string            `yaml:"username"`
+	Domain    string            `yaml:"domain"`
+	SessionID uint32            `yaml:"session_id"`
+	Args      []string          `yaml:"args"`
+	Envs      map[string]string `yaml:"envs"`
+	Parent    *Process          `yaml:"parent"`
+}
+
+// LoadTestCases reads test cases from the given file paths. Paths
+// can contain glob patterns. Only files with yml or yaml extensions
+// are considered.
+func LoadTestCases(paths ...string) ([]TestCase, error) {
+	testCases := make([]TestCase, 0)
+	for _, p := range paths {
+		files, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if filepath.Ext(file) != ".yml" && filepath.Ext(file) != ".yaml" {
+				continue
+			}
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't read test cases from %s: %v", file, err)
+			}
+			var tcs []TestCase
+			dec := yaml.NewDecoder(bytes.NewReader(b))
+			dec.KnownFields(true)
+			if err := dec.Decode(&tcs); err != nil {
+				return nil, fmt.Errorf("couldn't decode test cases from %s: %v", file, err)
+			}
+			for i, tc := range tcs {
+				if err := tc.validate(); err != nil {
+					return nil, fmt.Errorf("invalid test case #%d in %s: %v", i+1, file, err)
+				}
+				tc.file = file
+				testCases = append(testCases, tc)
+			}
+		}
+	}
+	return testCases, nil
+}
+
+func (tc TestCase) validate() error {
+	if tc.Name == "" {
+		return fmt.Errorf("missing test name")
+	}
+	if tc.Rule == "" {
+		return fmt.Errorf("missing rule name in %q test", tc.Name)
+	}
+	if len(tc.Events) == 0 {
+		return fmt.Errorf("%q test has no events", tc.Name)
+	}
+	for i, e := range tc.Events {
+		if e.Name == "" {
+			return fmt.Errorf("missing name for event #%d in %q test", i+1, tc.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lists
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errInvalidTableName = func(name, src string) error {
+		return fmt.Errorf("invalid lookup table name %q derived from %s: only letters, digits and underscores are allowed", name, src)
+	}
+	errDuplicateTable = func(name, src string) error {
+		return fmt.Errorf("lookup table %s from %s is already defined", name, src)
+	}
+)
+
+type opts struct {
+	paths           []string
+	urls            []string
+	refreshInterval time.Duration
+}
+
+// Option represents the option for the lookup table loader.
+type Option func(o *opts)
+
+// WithPaths sets the file system paths of the lookup table files.
+// Paths may contain glob expressions.
+func WithPaths(paths []string) Option {
+	return func(o *opts) {
+		o.paths = paths
+	}
+}
+
+// WithURLs sets the URLs of the lookup table resources.
+func WithURLs(urls []string) Option {
+	return func(o *opts) {
+		o.urls = urls
+	}
+}
+
+func (o opts) equal(other opts) bool {
+	return slices.Equal(o.paths, other.paths) && slices.Equal(o.urls, other.urls) && o.refreshInterval == other.refreshInterval
+}
+
+// WithRefresh sets the interval for checking the lookup table
+// sources for changes. Zero interval disables the refresh.
+func WithRefresh(interval time.Duration) Option {
+	return func(o *opts) {
+		o.refreshInterval = interval
+	}
+}
+
+// source represents the file or the URL the table is loaded from.
+type source struct {
+	name   string
+	path   string
+	url    string
+	format string
+
+	// modTime and size identify the version of the table file
+	modTime time.Time
+	size    int64
+	// etag and lastModified are used to issue conditional requests
+	etag         string
+	lastModified string
+}
+
+func (s *source) String() string {
+	if s.url != "" {
+		return s.url
+	}
+	return s.path
+}
+
+// load fetches the table contents and registers the table. If the
+// source hasn't changed since the last load, the table is left
+// intact. It returns true if the table was reloaded.
+func (s *source) load() (bool, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if s.url != "" {
+		data, err = s.download()
+	} else {
+		data, err = s.read()
+	}
+	if err != nil || data == nil {
+		return false, err
+	}
+	rows, err := Parse(s.format, data)
+	if err != nil {
+		return false, fmt.Errorf("couldn't parse lookup table %s from %s: %v", s.name, s, err)
+	}
+	Register(s.name, rows)
+	tableRefreshes.Add(s.name, 1)
+	return true, nil
+}
+
+func (s *source) read() ([]byte, error) {
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.ModTime().Equal(s.modTime) && fi.Size() == s.size {
+		return nil, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.modTime, s.size = fi.ModTime(), fi.Size()
+	return data, nil
+}
+
+func (s *source) download() ([]byte, error) {
+	client := http.Client{
+		Timeout: time.Second * 30,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("got non-ok status code for %s: %s", s.url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	s.etag, s.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	return data, nil
+}
+
+// loader keeps lookup tables up to date by periodically
+// checking their sources for changes.
+type loader struct {
+	sources []*source
+	tick    *time.Ticker
+	quit    chan struct{}
+}
+
+var (
+	l *loader
+	// loaded contains the options of the last successful load
+	loaded *opts
+	// names contains the names of the tables registered by the last load
+	names []string
+	mu    sync.Mutex
+)
+
+// Load loads lookup tables from files and URLs given in options and
+// registers them under the names derived from the file or the URL path
+// base name without the extension. If the source can't be loaded, the
+// table is registered empty, or retains the entries of the previous load,
+// until the source is successfully refreshed. Subsequent calls replace the
+// tables, unregister the tables whose sources are no longer given, and stop
+// refreshing the sources of the previous call, unless they are given the
+// same options. In that case, the tables are left intact.
+func Load(options ...Option) error {
+	var opts opts
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if loaded != nil && loaded.equal(opts) {
+		return nil
+	}
+	loaded = nil
+	if l != nil {
+		l.stop()
+		l = nil
+	}
+
+	sources, err := resolveSources(opts)
+	if err != nil {
+		return err
+	}
+	configured := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		configured[s.name] = true
+	}
+	for _, name := range names {
+		if !configured[name] {
+			log.Infof("unregistering lookup table %s", name)
+			Unregister(name)
+		}
+	}
+	names = make([]string, 0, len(sources))
+
+	failed := false
+	for _, s := range sources {
+		names = append(names, s.name)
+		log.Infof("loading lookup table %s from %s", s.name, s)
+		if _, err := s.load(); err != nil {
+			failed = true
+			tableRefreshErrors.Add(s.name, 1)
+			log.Warnf("couldn't load lookup table %s: %v", s.name, err)
+			// rules referencing the table still compile
+			if Get(s.name) == nil {
+				Register(s.name, nil)
+			}
+		}
+	}
+	// retry failed sources on the next load
+	if !failed {
+		loaded = &opts
+	}
+	if len(sources) == 0 || opts.refreshInterval <= 0 {
+		return nil
+	}
+
+	l = &loader{
+		sources: sources,
+		tick:    time.NewTicker(opts.refreshInterval),
+		quit:    make(chan struct{}),
+	}
+	go l.refresh()
+
+	return nil
+}
+
+// Close stops refreshing lookup tables.
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	loaded = nil
+	if l != nil {
+		l.stop()
+		l = nil
+	}
+}
+
+func (l *loader) refresh() {
+	for {
+		select {
+		case <-l.tick.C:
+			for _, s := range l.sources {
+				ok, err := s.load()
+				if err != nil {
+					tableRefreshErrors.Add(s.name, 1)
+					log.Warnf("unable to refresh lookup table %s: %v", s.name, err)
+					continue
+				}
+				if ok {
+					log.Infof("lookup table %s refreshed from %s", s.name, s)
+				}
+			}
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+func (l *loader) stop() {
+	l.tick.Stop()
+	close(l.quit)
+}
+
+func resolveSources(opts opts) ([]*source, error) {
+	sources := make([]*source, 0)
+	names := make(map[string]bool)
+	add := func(s *source) error {
+		if !isValidName(s.name) {
+			return errInvalidTableName(s.name, s.String())
+		}
+		if names[s.name] {
+			return errDuplicateTable(s.name, s.String())
+		}
+		names[s.name] = true
+		sources = append(sources, s)
+		return nil
+	}
+
+	for _, p := range opts.paths {
+		paths, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			fi, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if fi.IsDir() {
+				continue
+			}
+			ext := filepath.Ext(path)
+			s := &source{
+				name:   strings.TrimSuffix(filepath.Base(path), ext),
+				path:   path,
+				format: formatFromExt(ext),
+			}
+			if err := add(s); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, rawURL := range opts.urls {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		ext := path.Ext(u.Path)
+		s := &source{
+			name:   strings.TrimSuffix(path.Base(u.Path), ext),
+			url:    rawURL,
+			format: formatFromExt(ext),
+		}
+		if err := add(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return sources, nil
+}
+
+// isValidName checks if the table name can be
+// referenced with the $lists.<name> expression.
+func isValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lists
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromPaths(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ioc_hashes.txt"), []byte("44d88612fea8a8f36de82e1278abb02f\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "signers.csv"), []byte("signer,trusted\nMicrosoft Windows,true\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c2.json"), []byte(`["10.0.0.1"]`), 0644))
+
+	require.NoError(t, Load(WithPaths([]string{filepath.Join(dir, "*")}), WithRefresh(time.Millisecond*20)))
+	defer Close()
+
+	hashes := Get("ioc_hashes")
+	require.NotNil(t, hashes)
+	assert.True(t, hashes.Contains("44d88612fea8a8f36de82e1278abb02f"))
+	signers := Get("signers")
+	require.NotNil(t, signers)
+	row, ok := signers.Row("microsoft windows")
+	require.True(t, ok)
+	assert.Equal(t, true, row["trusted"])
+	require.NotNil(t, Get("c2"))
+	assert.True(t, Get("c2").Contains("10.0.0.1"))
+
+	// the table is reloaded when the file changes
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ioc_hashes.txt"), []byte("44d88612fea8a8f36de82e1278abb02f\nd41d8cd98f00b204e9800998ecf8427e\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return hashes.Len() == 2 && hashes.Contains("d41d8cd98f00b204e9800998ecf8427e")
+	}, time.Second*5, time.Millisecond*10)
+
+	// the table retains the entries if the refresh fails
+	require.NoError(t, os.Remove(filepath.Join(dir, "signers.csv")))
+	assert.Eventually(t, func() bool {
+		return tableRefreshErrors.Get("signers") != nil
+	}, time.Second*5, time.Millisecond*10)
+	assert.True(t, signers.Contains("Microsoft Windows"))
+}
+
+func TestLoadFromURLs(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("evil.com\nmalware.net\n"))
+	}))
+	defer srv.Close()
+
+	require.NoError(t, Load(WithURLs([]string{srv.URL + "/feeds/bad_domains.txt"}), WithRefresh(time.Millisecond*20)))
+	defer Close()
+
+	domains := Get("bad_domains")
+	require.NotNil(t, domains)
+	assert.Equal(t, 2, domains.Len())
+	assert.True(t, domains.Contains("malware.net"))
+
+	refreshes := tableRefreshes.Get("bad_domains").String()
+	// loading the same sources leaves the tables intact
+	require.NoError(t, Load(WithURLs([]string{srv.URL + "/feeds/bad_domains.txt"}), WithRefresh(time.Millisecond*20)))
+	assert.Same(t, domains, Get("bad_domains"))
+	assert.Equal(t, refreshes, tableRefreshes.Get("bad_domains").String())
+	// unmodified resources don't reload the table
+	assert.Eventually(t, func() bool { return requests.Load() > 3 }, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, refreshes, tableRefreshes.Get("bad_domains").String())
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad-domains.txt"), []byte("evil.com\n"), 0644))
+	require.Error(t, Load(WithPaths([]string{filepath.Join(dir, "*")})))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "bad-domains.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "domains.txt"), []byte("evil.com\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "domains.csv"), []byte("domain\nevil.com\n"), 0644))
+	require.Error(t, Load(WithPaths([]string{filepath.Join(dir, "*")})))
+
+	// unreachable sources leave empty tables
+	var available atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("evil.com\n"))
+	}))
+	defer srv.Close()
+	require.NoError(t, Load(WithURLs([]string{srv.URL + "/domains.txt"}), WithRefresh(time.Millisecond*20)))
+	defer Close()
+	domains := Get("domains")
+	require.NotNil(t, domains)
+	assert.Equal(t, 0, domains.Len())
+	assert.NotNil(t, tableRefreshErrors.Get("domains"))
+
+	// and are populated once the source becomes available
+	available.Store(true)
+	assert.Eventually(t, func() bool { return domains.Contains("evil.com") }, time.Second*5, time.Millisecond*10)
+}
+
+func TestLoadUnregistersTables(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c2_servers.txt"), []byte("10.0.0.1\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "signers.txt"), []byte("Microsoft Windows\n"), 0644))
+
+	require.NoError(t, Load(WithPaths([]string{filepath.Join(dir, "c2_servers.txt"), filepath.Join(dir, "signers.txt")})))
+	defer Close()
+	require.NotNil(t, Get("c2_servers"))
+	require.NotNil(t, Get("signers"))
+
+	require.NoError(t, Load(WithPaths([]string{filepath.Join(dir, "signers.txt")})))
+	assert.Nil(t, Get("c2_servers"))
+	assert.Nil(t, tableSize.Get("c2_servers"))
+	require.NotNil(t, Get("signers"))
+	assert.True(t, Get("signers").Contains("Microsoft Windows"))
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lists
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CSV designates the comma-separated table format. The header
+	// row declares column names and the first column holds keys.
+	CSV = "csv"
+	// JSON designates the table given as the array of keys or the
+	// object mapping each key to the object with column values.
+	JSON = "json"
+	// Text designates the newline-separated list of keys. Empty
+	// lines and lines starting with # are ignored.
+	Text = "text"
+)
+
+var errMissingHeader = errors.New("missing csv header")
+
+// formatFromExt resolves the table format from the file extension.
+// Files with unrecognized extensions are parsed as newline-separated
+// lists.
+func formatFromExt(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".csv":
+		return CSV
+	case ".json":
+		return JSON
+	default:
+		return Text
+	}
+}
+
+// Parse reads table entries from the data in the given format.
+func Parse(format string, data []byte) (map[string]Row, error) {
+	switch format {
+	case CSV:
+		return parseCSV(data)
+	case JSON:
+		return parseJSON(data)
+	default:
+		return parseText(data)
+	}
+}
+
+func parseText(data []byte) (map[string]Row, error) {
+	rows := make(map[string]Row)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		key := strings.TrimSpace(scanner.Text())
+		if key == "" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		rows[key] = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func parseCSV(data []byte) (map[string]Row, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errMissingHeader
+		}
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	rows := make(map[string]Row)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		key := strings.TrimSpace(rec[0])
+		if key == "" {
+			continue
+		}
+		var row Row
+		if len(header) > 1 {
+			row = make(Row, len(header)-1)
+		}
+		for i := 1; i < len(header); i++ {
+			row[header[i]] = parseCell(strings.TrimSpace(rec[i]))
+		}
+		rows[key] = row
+	}
+	return rows, nil
+}
+
+// parseCell converts the CSV cell to the boolean or numeric
+// value, so the column can be compared with filter fields
+// and literals of the same type.
+func parseCell(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(n, 10) == s {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.ContainsRune(s, '.') {
+		return f
+	}
+	return s
+}
+
+func parseJSON(data []byte) (map[string]Row, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	rows := make(map[string]Row)
+	switch v := v.(type) {
+	case []interface{}:
+		for _, key := range v {
+			s, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string key but got %v", key)
+			}
+			rows[s] = nil
+		}
+	case map[string]interface{}:
+		for key, cols := range v {
+			m, ok := cols.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected object with columns for %s key", key)
+			}
+			row := make(Row, len(m))
+			for col, val := range m {
+				row[col] = jsonValue(val)
+			}
+			rows[key] = row
+		}
+	default:
+		return nil, errors.New("expected array of keys or object with rows")
+	}
+	return rows, nil
+}
+
+func jsonValue(v interface{}) interface{} {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return n.String()
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lists
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		format string
+		data   string
+		rows   map[string]Row
+		err    bool
+	}{
+		{
+			Text,
+			"# suspicious domains\nevil.com\n\n  malware.net  \n",
+			map[string]Row{"evil.com": nil, "malware.net": nil},
+			false,
+		},
+		{
+			CSV,
+			"vendor,trusted,score,ratio\n# comment\nMicrosoft Corporation, true, 10, 0.5\nEvil Corp,false,90,1.5\n,true,0,0\n",
+			map[string]Row{
+				"Microsoft Corporation": {"trusted": true, "score": int64(10), "ratio": 0.5},
+				"Evil Corp":             {"trusted": false, "score": int64(90), "ratio": 1.5},
+			},
+			false,
+		},
+		{
+			CSV,
+			"hash\n44d88612fea8a8f36de82e1278abb02f\n0123\n",
+			map[string]Row{"44d88612fea8a8f36de82e1278abb02f": nil, "0123": nil},
+			false,
+		},
+		{
+			CSV,
+			"vendor,trusted\nEvil Corp\n",
+			nil,
+			true,
+		},
+		{
+			CSV,
+			"",
+			nil,
+			true,
+		},
+		{
+			JSON,
+			`["evil.com", "malware.net"]`,
+			map[string]Row{"evil.com": nil, "malware.net": nil},
+			false,
+		},
+		{
+			JSON,
+			`{"Microsoft Corporation": {"trusted": true, "score": 10, "ratio": 0.5, "country": "US"}}`,
+			map[string]Row{
+				"Microsoft Corporation": {"trusted": true, "score": int64(10), "ratio": 0.5, "country": "US"},
+			},
+			false,
+		},
+		{
+			JSON,
+			`["evil.com", 1]`,
+			nil,
+			true,
+		},
+		{
+			JSON,
+			`{"evil.com": true}`,
+			nil,
+			true,
+		},
+		{
+			JSON,
+			`"evil.com"`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			rows, err := Parse(tt.format, []byte(tt.data))
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.rows, rows)
+		})
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	assert.Equal(t, true, parseCell("true"))
+	assert.Equal(t, false, parseCell("false"))
+	assert.Equal(t, int64(-5), parseCell("-5"))
+	assert.Equal(t, 2.25, parseCell("2.25"))
+	assert.Equal(t, "007", parseCell("007"))
+	assert.Equal(t, "1e10", parseCell("1e10"))
+	assert.Equal(t, "True", parseCell("True"))
+}
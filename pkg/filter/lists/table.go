@@ -0,0 +1,220 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lists implements the lookup tables that rules consult through
+// the $lists.<name> references and the lookup function. Tables are loaded
+// from CSV, JSON or newline-separated files residing in the local file
+// system or served over HTTP/S, and are kept up to date by reloading them
+// when the file changes or on the refresh interval.
+package lists
+
+import (
+	"expvar"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// tableSize counts the number of entries in each lookup table
+	tableSize = expvar.NewMap("lists.table.size")
+	// tableRefreshes counts the number of successful lookup table refreshes
+	tableRefreshes = expvar.NewMap("lists.table.refreshes")
+	// tableRefreshErrors counts the number of failed lookup table refreshes
+	tableRefreshErrors = expvar.NewMap("lists.table.refresh.errors")
+	// tableLastRefresh stores the Unix timestamp of the last lookup table refresh
+	tableLastRefresh = expvar.NewMap("lists.table.last.refresh")
+)
+
+// Row contains the column values of the lookup table entry keyed
+// by column names. Entries of the tables without columns, such as
+// newline-separated lists, have nil rows.
+type Row map[string]interface{}
+
+// Table is the named collection of entries indexed by their keys. Both the
+// exact and case-insensitive membership tests are resolved in constant time.
+// The table contents are replaced as a whole when the table is refreshed, so
+// the evaluation never observes partially loaded entries.
+type Table struct {
+	name string
+	mu   sync.RWMutex
+	idx  *index
+}
+
+type index struct {
+	rows map[string]Row
+	// fold maps lowercase keys to original keys
+	fold map[string]string
+	// keys contains sorted table keys. The slice is built
+	// on the first use and shared until the table is refreshed
+	keys []string
+	once sync.Once
+}
+
+func newIndex(rows map[string]Row) *index {
+	idx := &index{rows: rows, fold: make(map[string]string, len(rows))}
+	for key := range rows {
+		idx.fold[strings.ToLower(key)] = key
+	}
+	return idx
+}
+
+// NewTable creates a new lookup table with the given entries.
+func NewTable(name string, rows map[string]Row) *Table {
+	t := &Table{name: name}
+	t.Set(rows)
+	return t
+}
+
+// Name returns the table name.
+func (t *Table) Name() string { return t.name }
+
+// Len returns the number of table entries.
+func (t *Table) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.idx.rows)
+}
+
+// Contains determines if the table has the entry with the given key.
+func (t *Table) Contains(key string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.idx.rows[key]
+	return ok
+}
+
+// ContainsFold determines if the table has the entry
+// with the key that is equal to the given key under
+// case-insensitive comparison.
+func (t *Table) ContainsFold(key string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if _, ok := t.idx.rows[key]; ok {
+		return true
+	}
+	_, ok := t.idx.fold[strings.ToLower(key)]
+	return ok
+}
+
+// Row returns the entry with the given key. If the table doesn't
+// have the entry with the exact key, the key is matched under
+// case-insensitive comparison.
+func (t *Table) Row(key string) (Row, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if row, ok := t.idx.rows[key]; ok {
+		return row, true
+	}
+	if key, ok := t.idx.fold[strings.ToLower(key)]; ok {
+		return t.idx.rows[key], true
+	}
+	return nil, false
+}
+
+// Keys returns the sorted list of table keys. The returned
+// slice is shared and must not be modified by the caller.
+func (t *Table) Keys() []string {
+	t.mu.RLock()
+	idx := t.idx
+	t.mu.RUnlock()
+	idx.once.Do(func() {
+		idx.keys = make([]string, 0, len(idx.rows))
+		for key := range idx.rows {
+			idx.keys = append(idx.keys, key)
+		}
+		sort.Strings(idx.keys)
+	})
+	return idx.keys
+}
+
+// Set replaces the table entries.
+func (t *Table) Set(rows map[string]Row) {
+	if rows == nil {
+		rows = make(map[string]Row)
+	}
+	idx := newIndex(rows)
+	t.mu.Lock()
+	t.idx = idx
+	t.mu.Unlock()
+	tableSize.Set(t.name, intVar(len(rows)))
+	tableLastRefresh.Set(t.name, intVar(int(time.Now().Unix())))
+}
+
+// String returns the table reference as it appears in filter expressions.
+func (t *Table) String() string {
+	return "$lists." + t.name
+}
+
+func intVar(n int) *expvar.Int {
+	v := new(expvar.Int)
+	v.Set(int64(n))
+	return v
+}
+
+// tables is the registry of lookup tables indexed by table names
+var tables = struct {
+	sync.RWMutex
+	m map[string]*Table
+}{m: make(map[string]*Table)}
+
+// Register stores the table entries under the given name. If the table
+// with the same name is already registered, its entries are replaced,
+// so the filters holding the table reference observe the new entries.
+func Register(name string, rows map[string]Row) *Table {
+	tables.Lock()
+	defer tables.Unlock()
+	if t, ok := tables.m[name]; ok {
+		t.Set(rows)
+		return t
+	}
+	t := NewTable(name, rows)
+	tables.m[name] = t
+	return t
+}
+
+// Unregister removes the table with the given name. Filters
+// holding the table reference keep observing its last entries.
+func Unregister(name string) {
+	tables.Lock()
+	defer tables.Unlock()
+	delete(tables.m, name)
+	tableSize.Delete(name)
+	tableLastRefresh.Delete(name)
+}
+
+// Get returns the table with the given name or nil
+// if the table is not registered.
+func Get(name string) *Table {
+	tables.RLock()
+	defer tables.RUnlock()
+	return tables.m[name]
+}
+
+// Names returns the sorted names of all registered tables.
+func Names() []string {
+	tables.RLock()
+	defer tables.RUnlock()
+	names := make([]string, 0, len(tables.m))
+	for name := range tables.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lists
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable(t *testing.T) {
+	tbl := NewTable("vendors", map[string]Row{
+		"Microsoft Corporation": {"trusted": true, "score": int64(10)},
+		"Evil Corp":             {"trusted": false, "score": int64(90)},
+	})
+
+	assert.Equal(t, "vendors", tbl.Name())
+	assert.Equal(t, "$lists.vendors", tbl.String())
+	assert.Equal(t, 2, tbl.Len())
+
+	assert.True(t, tbl.Contains("Evil Corp"))
+	assert.False(t, tbl.Contains("evil corp"))
+	assert.True(t, tbl.ContainsFold("evil corp"))
+	assert.False(t, tbl.ContainsFold("Good Corp"))
+
+	row, ok := tbl.Row("MICROSOFT CORPORATION")
+	require.True(t, ok)
+	assert.Equal(t, true, row["trusted"])
+	assert.Equal(t, int64(10), row["score"])
+	_, ok = tbl.Row("Good Corp")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"Evil Corp", "Microsoft Corporation"}, tbl.Keys())
+
+	tbl.Set(map[string]Row{"Good Corp": nil})
+	assert.Equal(t, 1, tbl.Len())
+	assert.False(t, tbl.Contains("Evil Corp"))
+	assert.Equal(t, []string{"Good Corp"}, tbl.Keys())
+	assert.Equal(t, "1", tableSize.Get("vendors").String())
+}
+
+func TestRegister(t *testing.T) {
+	tbl := Register("bad_domains", map[string]Row{"evil.com": nil})
+	require.Equal(t, tbl, Get("bad_domains"))
+	assert.True(t, tbl.Contains("evil.com"))
+	assert.Contains(t, Names(), "bad_domains")
+
+	// registering the table with the same name
+	// replaces the entries of the existing table
+	assert.Equal(t, tbl, Register("bad_domains", map[string]Row{"malware.net": nil}))
+	assert.False(t, tbl.Contains("evil.com"))
+	assert.True(t, tbl.Contains("malware.net"))
+
+	assert.Nil(t, Get("good_domains"))
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// evalArithmetic applies the arithmetic or bitwise operator to the operands.
+// If either of the operands is a float, the operation yields a float. If
+// either of the operands doesn't fit into the signed integer, the operation
+// is carried out on unsigned integers. Otherwise, the operation is performed
+// on signed integers, so the difference of two unsigned field values can be
+// negative. Nil is returned for non-numeric operands, division by zero, and
+// bitwise operations on floats.
+func evalArithmetic(op token, lhs, rhs interface{}) interface{} {
+	lhs, rhs = toNumber(lhs), toNumber(rhs)
+	if lhs == nil || rhs == nil {
+		return nil
+	}
+	switch lhs.(type) {
+	case float64:
+		return evalFloat(op, toFloat(lhs), toFloat(rhs))
+	case uint64:
+		return evalInteger(op, lhs.(uint64), toUnsigned(rhs))
+	}
+	switch rhs := rhs.(type) {
+	case float64:
+		return evalFloat(op, toFloat(lhs), rhs)
+	case uint64:
+		return evalInteger(op, toUnsigned(lhs), rhs)
+	}
+	return evalInteger(op, lhs.(int64), rhs.(int64))
+}
+
+func evalInteger[T int64 | uint64](op token, lhs, rhs T) interface{} {
+	switch op {
+	case Add:
+		return lhs + rhs
+	case Sub:
+		return lhs - rhs
+	case Mul:
+		return lhs * rhs
+	case Div:
+		if rhs == 0 {
+			return nil
+		}
+		return lhs / rhs
+	case Mod:
+		if rhs == 0 {
+			return nil
+		}
+		return lhs % rhs
+	case BitAnd:
+		return lhs & rhs
+	case BitOr:
+		return lhs | rhs
+	case BitXor:
+		return lhs ^ rhs
+	}
+	return nil
+}
+
+func evalFloat(op token, lhs, rhs float64) interface{} {
+	switch op {
+	case Add:
+		return lhs + rhs
+	case Sub:
+		return lhs - rhs
+	case Mul:
+		return lhs * rhs
+	case Div:
+		if rhs == 0 {
+			return nil
+		}
+		return lhs / rhs
+	case Mod:
+		if rhs == 0 {
+			return nil
+		}
+		return math.Mod(lhs, rhs)
+	}
+	return nil
+}
+
+// toNumber converts the operand of the arithmetic expression to int64, uint64
+// or float64. Strings are parsed as decimal or hexadecimal integers, because
+// some of the parameters, such as access masks, are represented as hex strings.
+// Nil is returned if the value can't be converted to a number.
+func toNumber(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return widen(v)
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n, err := strconv.ParseUint(s[2:], 16, 64)
+		if err != nil {
+			return nil
+		}
+		return widen(n)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return n
+}
+
+// widen converts the numeric value to int64, uint64 or float64. Unsigned
+// integers are only kept unsigned if they overflow the signed integer.
+// Nil is returned if the value is not numeric.
+func widen(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case uint8:
+		return int64(n)
+	case uint16:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	case uint:
+		return widen(uint64(n))
+	case uint64:
+		if n > math.MaxInt64 {
+			return n
+		}
+		return int64(n)
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return nil
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+func toUnsigned(v interface{}) uint64 {
+	switch n := v.(type) {
+	case int64:
+		return uint64(n)
+	case uint64:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArithmetic(t *testing.T) {
+	m := map[string]interface{}{
+		"ps.name":            "svchost.exe",
+		"ps.pid":             uint32(1234),
+		"ps.parent.pid":      uint32(1234),
+		"kevt.pid":           uint32(1234),
+		"ps.access.mask":     "0x1410",
+		"thread.access.mask": "0x1fffff",
+		"image.size":         uint32(0x1000),
+		"mem.size":           uint64(0x3000),
+		"mem.address":        uint64(0xffff800000001234),
+		"file.io.size":       uint64(600 * 1024),
+	}
+
+	var tests = []struct {
+		expr    string
+		matches bool
+	}{
+		{`file.io.size / 1024 > 500`, true},
+		{`file.io.size / 1024 > 600`, false},
+		{`kevt.pid != ps.parent.pid + 0`, false},
+		{`kevt.pid = ps.parent.pid + 0`, true},
+		{`mem.size - image.size > 0x1000`, true},
+		{`image.size - mem.size < 0`, true},
+		{`image.size - mem.size = -0x2000`, true},
+		{`ps.access.mask & 0x10 != 0`, true},
+		{`ps.access.mask & 0x20 != 0`, false},
+		{`(ps.access.mask & 0x1400) = 0x1400`, true},
+		{`thread.access.mask ^ 0x1fffff = 0`, true},
+		{`ps.pid & 0xff | 0x100 = 0x1d2`, true},
+		{`ps.pid + 2 * 3 = 1240`, true},
+		{`(ps.pid + 2) * 3 = 3708`, true},
+		{`ps.pid - 200 - 34 = 1000`, true},
+		{`ps.pid % 1000 = 234`, true},
+		{`ps.pid / 2.0 = 617.0`, true},
+		{`ps.pid / 0 = 0`, false},
+		{`ps.pid % 0 = 0`, false},
+		{`ps.pid & 1.5 = 0`, false},
+		{`mem.address & 0xffff = 0x1234`, true},
+		{`mem.address - 1 > 0x7fffffffffffffff`, true},
+		{`ps.name + 1 = 1`, false},
+		{`length(ps.name) * 2 = 22`, true},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.expr)
+		e, err := p.ParseExpr()
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.matches, Eval(e, m, true), tt.expr)
+		assert.Equal(t, tt.matches, Compile(e).Eval(MapValuer(m)), tt.expr)
+	}
+}
+
+func TestEvalArithmetic(t *testing.T) {
+	var tests = []struct {
+		op       token
+		lhs, rhs interface{}
+		res      interface{}
+	}{
+		{Add, uint32(1), uint16(2), int64(3)},
+		{Sub, uint64(1), uint64(2), int64(-1)},
+		{Mul, int8(-2), uint8(3), int64(-6)},
+		{Div, uint64(7), int64(2), int64(3)},
+		{Div, uint64(7), 2.0, 3.5},
+		{Div, uint64(7), int64(0), nil},
+		{Mod, 7.5, int64(2), 1.5},
+		{BitAnd, "0x1410", int64(0x10), int64(0x10)},
+		{BitOr, "16", uint16(1), int64(17)},
+		{BitXor, uint64(1 << 63), int64(1), uint64(1<<63 + 1)},
+		{BitAnd, 1.0, int64(1), nil},
+		{Add, "svchost.exe", int64(1), nil},
+		{Add, nil, int64(1), nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.res, evalArithmetic(tt.op, tt.lhs, tt.rhs), "%v %s %v", tt.lhs, tt.op, tt.rhs)
+	}
+
+	// numbers of distinct types are widened before comparing them
+	assert.Equal(t, true, evalBinary(Eq, int32(5), int64(5)))
+	assert.Equal(t, true, evalBinary(Lt, float32(1.5), uint32(2)))
+	assert.Equal(t, false, evalBinary(Eq, "5", int64(5)))
+}
@@ -22,6 +22,7 @@ package ql
 
 import (
 	fuzzysearch "github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
 	"github.com/rabbitstack/fibratus/pkg/util/wildcard"
 	"net"
 	"strconv"
@@ -139,8 +140,8 @@ func (v *ValuerEval) Eval(expr Expr) interface{} {
 				return nil
 			}
 			return nil
-		case *ParenExpr:
-			v := v.Eval(expr1.Expr)
+		case *ParenExpr, *Quantifier:
+			v := v.Eval(expr1)
 			if v == nil {
 				return nil
 			}
@@ -177,6 +178,16 @@ func (v *ValuerEval) Eval(expr Expr) interface{} {
 			return nil
 		}
 		return val
+	case *VarLiteral:
+		val, ok := v.Valuer.Value(expr.Value)
+		if !ok {
+			return nil
+		}
+		return val
+	case *Quantifier:
+		return v.evalQuantifier(expr)
+	case *TableLiteral:
+		return expr.Table
 	case *IPLiteral:
 		return expr.Value
 	case *Function:
@@ -197,6 +208,16 @@ func (v *ValuerEval) Eval(expr Expr) interface{} {
 	}
 }
 
+func (v *ValuerEval) evalQuantifier(expr *Quantifier) interface{} {
+	elem := &elementValuer{Valuer: v.Valuer, name: expr.Var}
+	eval := ValuerEval{Valuer: elem, IntegerFloatDivision: v.IntegerFloatDivision}
+	return quantify(expr.Name, v.Eval(expr.List), func(e interface{}) bool {
+		elem.elem = e
+		val, ok := eval.Eval(expr.Expr).(bool)
+		return ok && val
+	})
+}
+
 func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 	lhs := v.Eval(expr.LHS)
 	// lazy evaluation for the AND/OR operators
@@ -211,6 +232,17 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		}
 	}
 	rhs := v.Eval(expr.RHS)
+	return evalBinary(expr.Op, lhs, rhs)
+}
+
+// evalBinary applies the operator to the evaluated operands.
+func evalBinary(op token, lhs, rhs interface{}) interface{} {
+	if op.isArithmetic() {
+		return evalArithmetic(op, lhs, rhs)
+	}
+	if table, ok := rhs.(*lists.Table); ok {
+		return evalTable(op, lhs, table)
+	}
 	if lhs == nil && rhs != nil {
 		// when the LHS is nil and the RHS is a boolean, implicitly cast the
 		// nil to false.
@@ -227,7 +259,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 	switch lhs := lhs.(type) {
 	case bool:
 		rhs, ok := rhs.(bool)
-		switch expr.Op {
+		switch op {
 		case And:
 			return ok && (lhs && rhs)
 		case Or:
@@ -241,7 +273,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		switch rhs := rhs.(type) {
 		case float64:
 			lhs := float64(lhs)
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -256,7 +288,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case int64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return int64(lhs) == rhs
 			case Neq:
@@ -271,7 +303,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return int64(lhs) >= rhs
 			}
 		case uint64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return uint64(lhs) == rhs
 			case Neq:
@@ -298,7 +330,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return uint64(lhs) >= rhs
 			}
 		case []uint16:
-			switch expr.Op {
+			switch op {
 			case In:
 				for _, i := range rhs {
 					if int(i) == lhs {
@@ -312,7 +344,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		switch rhs := rhs.(type) {
 		case float64:
 			lhs := float64(lhs)
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -327,7 +359,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case int64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return int64(lhs) == rhs
 			case Neq:
@@ -342,7 +374,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return int64(lhs) >= rhs
 			}
 		case uint64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return uint64(lhs) == rhs
 			case Neq:
@@ -370,7 +402,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		}
 
 		rhs := rhsf
-		switch expr.Op {
+		switch op {
 		case Eq:
 			return ok && (lhs == rhs)
 		case Neq:
@@ -389,7 +421,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		switch rhs := rhs.(type) {
 		case float64:
 			lhs := float64(lhs)
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -404,7 +436,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case int64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -419,7 +451,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case uint64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return uint64(lhs) == rhs
 			case Neq:
@@ -451,7 +483,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		switch rhs := rhs.(type) {
 		case float64:
 			lhs := float64(lhs)
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -466,7 +498,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case int64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == uint64(rhs)
 			case Neq:
@@ -493,7 +525,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= uint64(rhs)
 			}
 		case uint64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -511,7 +543,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 	case []uint64:
 		switch rhs := rhs.(type) {
 		case uint64:
-			switch expr.Op {
+			switch op {
 			case Gt:
 				for _, i := range lhs {
 					if i > rhs {
@@ -528,7 +560,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return false
 			}
 		case int64:
-			switch expr.Op {
+			switch op {
 			case Gt:
 				for _, i := range lhs {
 					if i > uint64(rhs) {
@@ -549,7 +581,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		switch rhs := rhs.(type) {
 		case float64:
 			lhs := float64(lhs)
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -564,7 +596,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case int32:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == uint32(rhs)
 			case Neq:
@@ -591,7 +623,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= uint32(rhs)
 			}
 		case int64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == uint32(rhs)
 			case Neq:
@@ -618,7 +650,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= uint32(rhs)
 			}
 		case uint32:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -637,7 +669,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		switch rhs := rhs.(type) {
 		case float64:
 			lhs := float64(lhs)
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -652,7 +684,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case int32:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == uint16(rhs)
 			case Neq:
@@ -679,7 +711,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= uint16(rhs)
 			}
 		case int64:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == uint16(rhs)
 			case Neq:
@@ -706,7 +738,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= uint16(rhs)
 			}
 		case uint16:
-			switch expr.Op {
+			switch op {
 			case Eq:
 				return lhs == rhs
 			case Neq:
@@ -721,7 +753,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 				return lhs >= rhs
 			}
 		case []string:
-			switch expr.Op {
+			switch op {
 			case In:
 				for _, s := range rhs {
 					n, err := strconv.Atoi(s)
@@ -736,7 +768,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 			}
 		}
 	case string:
-		switch expr.Op {
+		switch op {
 		case Eq:
 			rhs, ok := rhs.(string)
 			if !ok {
@@ -947,7 +979,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 			}
 		}
 	case net.IP:
-		switch expr.Op {
+		switch op {
 		case Eq:
 			rhs, ok := rhs.(net.IP)
 			if !ok {
@@ -995,7 +1027,7 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 			return strings.HasSuffix(lhs.String(), rhs)
 		}
 	case []string:
-		switch expr.Op {
+		switch op {
 		case Contains:
 			s, ok := rhs.(string)
 			if !ok {
@@ -1155,9 +1187,18 @@ func (v *ValuerEval) evalBinaryExpr(expr *BinaryExpr) interface{} {
 		}
 	}
 
+	// the numbers of distinct types are compared
+	// after widening them to the common type
+	switch op {
+	case Eq, Neq, Lt, Lte, Gt, Gte:
+		if l, r := widen(lhs), widen(rhs); l != nil && r != nil && (l != lhs || r != rhs) {
+			return evalBinary(op, l, r)
+		}
+	}
+
 	// the types were not comparable. If our operation was an equality operation,
 	// return false instead of true.
-	switch expr.Op {
+	switch op {
 	case Eq, IEq, Neq, Lt, Lte, Gt, Gte:
 		return false
 	}
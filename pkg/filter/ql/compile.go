@@ -0,0 +1,657 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"net"
+	"strings"
+
+	fuzzysearch "github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/rabbitstack/fibratus/pkg/filter/ql/functions"
+	"github.com/rabbitstack/fibratus/pkg/util/wildcard"
+)
+
+// truth represents the outcome of the compiled predicate. Apart
+// from true/false, it tracks whether the expression produced nil
+// or a non-boolean value, since the logical operators treat them
+// differently.
+type truth uint8
+
+const (
+	unknown truth = iota
+	falsy
+	truthy
+	nonbool
+)
+
+func truthOf(v interface{}) truth {
+	switch v := v.(type) {
+	case nil:
+		return unknown
+	case bool:
+		return boolTruth(v)
+	}
+	return nonbool
+}
+
+func boolTruth(b bool) truth {
+	if b {
+		return truthy
+	}
+	return falsy
+}
+
+// value converts the truth to the value produced by the evaluator.
+// Predicates are only derived from binary and unary expressions
+// which always yield booleans or nil.
+func (t truth) value() interface{} {
+	switch t {
+	case truthy:
+		return true
+	case falsy:
+		return false
+	}
+	return nil
+}
+
+type (
+	valueFunc     func(Valuer) interface{}
+	predicateFunc func(Valuer) truth
+)
+
+// Program is the expression compiled into a tree of closures. Literals
+// are converted to their native representations ahead of time, and the
+// comparisons between fields and literals are specialized for the most
+// common operand types, so the evaluation doesn't have to walk the AST
+// and dispatch on every operator/type combination for each event. The
+// program produces the same outcome as the ValuerEval evaluator.
+type Program struct {
+	expr Expr
+	eval predicateFunc
+}
+
+// Compile compiles the expression into the program.
+func Compile(expr Expr) *Program {
+	return &Program{expr: expr, eval: compilePredicate(expr)}
+}
+
+// Eval evaluates the program against the valuer. It returns true
+// if the expression is satisfied.
+func (p *Program) Eval(v Valuer) bool {
+	return p.eval(v) == truthy
+}
+
+// String returns the source expression of the program.
+func (p *Program) String() string {
+	if p.expr == nil {
+		return ""
+	}
+	return p.expr.String()
+}
+
+func constValue(v interface{}) valueFunc {
+	return func(Valuer) interface{} { return v }
+}
+
+func constTruth(t truth) predicateFunc {
+	return func(Valuer) truth { return t }
+}
+
+func compileValue(expr Expr) valueFunc {
+	switch expr := expr.(type) {
+	case *BinaryExpr:
+		if expr.Op.isArithmetic() {
+			return compileArithmetic(expr)
+		}
+		pred := compileBinary(expr)
+		return func(v Valuer) interface{} { return pred(v).value() }
+	case *NotExpr, *Quantifier:
+		pred := compilePredicate(expr)
+		return func(v Valuer) interface{} { return pred(v).value() }
+	case *ParenExpr:
+		return compileValue(expr.Expr)
+	case *IntegerLiteral:
+		return constValue(expr.Value)
+	case *UnsignedLiteral:
+		return constValue(expr.Value)
+	case *DecimalLiteral:
+		return constValue(expr.Value)
+	case *StringLiteral:
+		return constValue(expr.Value)
+	case *ListLiteral:
+		return constValue(expr.Values)
+	case *BoolLiteral:
+		return constValue(expr.Value)
+	case *IPLiteral:
+		return constValue(expr.Value)
+	case *FieldLiteral:
+		return fieldValue(expr.Value)
+	case *BoundFieldLiteral:
+		return fieldValue(expr.Value)
+	case *VarLiteral:
+		return fieldValue(expr.Value)
+	case *TableLiteral:
+		return constValue(expr.Table)
+	case *Function:
+		return compileFunction(expr)
+	}
+	return constValue(nil)
+}
+
+func fieldValue(key string) valueFunc {
+	return func(v Valuer) interface{} {
+		val, ok := v.Value(key)
+		if !ok {
+			return nil
+		}
+		return val
+	}
+}
+
+func compileFunction(expr *Function) valueFunc {
+	fn, ok := funcs[strings.ToUpper(expr.Name)]
+	if !ok {
+		return constValue(nil)
+	}
+	if fn.Name() == functions.CIDRContainsFn {
+		if call := compileCIDRContains(expr); call != nil {
+			return call
+		}
+	}
+	args := make([]valueFunc, len(expr.Args))
+	for i, arg := range expr.Args {
+		args[i] = compileValue(arg)
+	}
+	return func(v Valuer) interface{} {
+		var vals []interface{}
+		if len(args) > 0 {
+			vals = make([]interface{}, len(args))
+			for i, arg := range args {
+				vals[i] = arg(v)
+			}
+		}
+		val, ok := fn.Call(vals)
+		if !ok {
+			return nil
+		}
+		return val
+	}
+}
+
+// compileCIDRContains parses the CIDR ranges ahead of time if all
+// of them are given as string literals.
+func compileCIDRContains(expr *Function) valueFunc {
+	if len(expr.Args) < 2 {
+		return nil
+	}
+	nets := make([]*net.IPNet, 0, len(expr.Args)-1)
+	for _, arg := range expr.Args[1:] {
+		cidr, ok := arg.(*StringLiteral)
+		if !ok {
+			return nil
+		}
+		_, ipnet, err := net.ParseCIDR(cidr.Value)
+		if err != nil {
+			continue
+		}
+		nets = append(nets, ipnet)
+	}
+	addr := compileValue(expr.Args[0])
+	return func(v Valuer) interface{} {
+		var ip net.IP
+		switch addr := addr(v).(type) {
+		case net.IP:
+			ip = addr
+		case string:
+			ip = net.ParseIP(addr)
+		}
+		for _, ipnet := range nets {
+			if ipnet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func compilePredicate(expr Expr) predicateFunc {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		return compilePredicate(expr.Expr)
+	case *BinaryExpr:
+		return compileBinary(expr)
+	case *NotExpr:
+		switch expr.Expr.(type) {
+		case *BinaryExpr, *Function, *ParenExpr, *Quantifier:
+		default:
+			return constTruth(unknown)
+		}
+		pred := compilePredicate(expr.Expr)
+		return func(v Valuer) truth {
+			switch pred(v) {
+			case truthy:
+				return falsy
+			case falsy:
+				return truthy
+			}
+			return unknown
+		}
+	case *Quantifier:
+		return compileQuantifier(expr)
+	case *BoolLiteral:
+		return constTruth(boolTruth(expr.Value))
+	case nil:
+		return constTruth(unknown)
+	}
+	val := compileValue(expr)
+	return func(v Valuer) truth { return truthOf(val(v)) }
+}
+
+func compileBinary(expr *BinaryExpr) predicateFunc {
+	switch expr.Op {
+	case And:
+		lhs, rhs := compilePredicate(expr.LHS), compilePredicate(expr.RHS)
+		return func(v Valuer) truth {
+			switch lhs(v) {
+			case falsy:
+				return falsy
+			case truthy:
+				if rhs(v) == truthy {
+					return truthy
+				}
+				return falsy
+			case unknown:
+				// nil is implicitly cast to false when the other operand is a boolean
+				switch rhs(v) {
+				case truthy, falsy:
+					return falsy
+				}
+			}
+			return unknown
+		}
+	case Or:
+		lhs, rhs := compilePredicate(expr.LHS), compilePredicate(expr.RHS)
+		return func(v Valuer) truth {
+			switch lhs(v) {
+			case truthy:
+				return truthy
+			case falsy:
+				if rhs(v) == truthy {
+					return truthy
+				}
+				return falsy
+			case unknown:
+				switch t := rhs(v); t {
+				case truthy, falsy:
+					return t
+				}
+			}
+			return unknown
+		}
+	}
+	if expr.Op.isArithmetic() {
+		val := compileArithmetic(expr)
+		return func(v Valuer) truth { return truthOf(val(v)) }
+	}
+	if pred := specialize(expr); pred != nil {
+		return pred
+	}
+	op := expr.Op
+	lhs, rhs := compileValue(expr.LHS), compileValue(expr.RHS)
+	return func(v Valuer) truth { return truthOf(evalBinary(op, lhs(v), rhs(v))) }
+}
+
+// compileQuantifier compiles the quantifier to the predicate
+// that binds each list element to the quantifier variable.
+func compileQuantifier(expr *Quantifier) predicateFunc {
+	name, key := expr.Name, expr.Var
+	list, pred := compileValue(expr.List), compilePredicate(expr.Expr)
+	return func(v Valuer) truth {
+		elem := &elementValuer{Valuer: v, name: key}
+		return truthOf(quantify(name, list(v), func(e interface{}) bool {
+			elem.elem = e
+			return pred(elem) == truthy
+		}))
+	}
+}
+
+// compileArithmetic compiles the arithmetic or bitwise
+// expression to the function producing the number.
+func compileArithmetic(expr *BinaryExpr) valueFunc {
+	op := expr.Op
+	lhs, rhs := compileValue(expr.LHS), compileValue(expr.RHS)
+	return func(v Valuer) interface{} { return evalArithmetic(op, lhs(v), rhs(v)) }
+}
+
+// matchers contains the comparison functions specialized for
+// the field value type. If the field value type has no matcher,
+// the comparison is delegated to the evaluator.
+type matchers struct {
+	str func(string) bool
+	ip  func(net.IP) bool
+	u16 func(uint16) bool
+	u32 func(uint32) bool
+	u64 func(uint64) bool
+	u8  func(uint8) bool
+	i   func(int) bool
+	i64 func(int64) bool
+}
+
+// specialize builds the predicate for the comparison between the field
+// and the literal. It returns nil if the operands are not eligible for
+// the specialization.
+func specialize(expr *BinaryExpr) predicateFunc {
+	var key string
+	switch lhs := expr.LHS.(type) {
+	case *FieldLiteral:
+		key = lhs.Value
+	case *BoundFieldLiteral:
+		key = lhs.Value
+	case *VarLiteral:
+		key = lhs.Value
+	default:
+		return nil
+	}
+
+	var (
+		m   matchers
+		lit interface{}
+	)
+	switch rhs := expr.RHS.(type) {
+	case *StringLiteral:
+		lit = rhs.Value
+		m.str = stringMatcher(expr.Op, rhs.Value)
+	case *ListLiteral:
+		lit = rhs.Values
+		m.str = stringListMatcher(expr.Op, rhs.Values)
+		m.ip = ipListMatcher(expr.Op, rhs.Values)
+	case *IntegerLiteral:
+		lit = rhs.Value
+		m.u16 = unsignedMatcher[uint16](expr.Op, rhs.Value)
+		m.u32 = unsignedMatcher[uint32](expr.Op, rhs.Value)
+		m.u64 = unsignedMatcher[uint64](expr.Op, rhs.Value)
+		m.u8 = signedMatcher[uint8](expr.Op, rhs.Value)
+		m.i = signedMatcher[int](expr.Op, rhs.Value)
+		m.i64 = signedMatcher[int64](expr.Op, rhs.Value)
+	case *IPLiteral:
+		lit = rhs.Value
+		m.ip = ipMatcher(expr.Op, rhs.Value)
+	case *TableLiteral:
+		lit = rhs.Table
+		if match := tableMatcher(expr.Op, rhs.Table); match != nil {
+			m.str = match
+			m.ip = func(ip net.IP) bool { return match(ip.String()) }
+		}
+	default:
+		return nil
+	}
+
+	op := expr.Op
+	return func(v Valuer) truth {
+		val, ok := v.Value(key)
+		if !ok {
+			val = nil
+		}
+		switch lhs := val.(type) {
+		case string:
+			if m.str != nil {
+				return boolTruth(m.str(lhs))
+			}
+		case uint32:
+			if m.u32 != nil {
+				return boolTruth(m.u32(lhs))
+			}
+		case uint16:
+			if m.u16 != nil {
+				return boolTruth(m.u16(lhs))
+			}
+		case uint64:
+			if m.u64 != nil {
+				return boolTruth(m.u64(lhs))
+			}
+		case uint8:
+			if m.u8 != nil {
+				return boolTruth(m.u8(lhs))
+			}
+		case int:
+			if m.i != nil {
+				return boolTruth(m.i(lhs))
+			}
+		case int64:
+			if m.i64 != nil {
+				return boolTruth(m.i64(lhs))
+			}
+		case net.IP:
+			if m.ip != nil {
+				return boolTruth(m.ip(lhs))
+			}
+		}
+		return truthOf(evalBinary(op, val, lit))
+	}
+}
+
+func stringMatcher(op token, s string) func(string) bool {
+	switch op {
+	case Eq:
+		return func(v string) bool { return v == s }
+	case IEq:
+		return func(v string) bool { return strings.EqualFold(v, s) }
+	case Neq:
+		return func(v string) bool { return v != s }
+	case Contains:
+		return func(v string) bool { return strings.Contains(v, s) }
+	case IContains:
+		s := strings.ToLower(s)
+		return func(v string) bool { return strings.Contains(strings.ToLower(v), s) }
+	case Startswith:
+		return func(v string) bool { return strings.HasPrefix(v, s) }
+	case IStartswith:
+		s := strings.ToLower(s)
+		return func(v string) bool { return strings.HasPrefix(strings.ToLower(v), s) }
+	case Endswith:
+		return func(v string) bool { return strings.HasSuffix(v, s) }
+	case IEndswith:
+		s := strings.ToLower(s)
+		return func(v string) bool { return strings.HasSuffix(strings.ToLower(v), s) }
+	case Matches:
+		pat := wildcard.Compile(s)
+		return pat.Match
+	case IMatches:
+		pat := wildcard.Compile(strings.ToLower(s))
+		return func(v string) bool { return pat.Match(strings.ToLower(v)) }
+	case Fuzzy:
+		return func(v string) bool { return fuzzysearch.Match(s, v) }
+	case IFuzzy:
+		return func(v string) bool { return fuzzysearch.MatchFold(s, v) }
+	case Fuzzynorm:
+		return func(v string) bool { return fuzzysearch.MatchNormalized(s, v) }
+	case IFuzzynorm:
+		return func(v string) bool { return fuzzysearch.MatchNormalizedFold(s, v) }
+	}
+	return nil
+}
+
+func stringListMatcher(op token, list []string) func(string) bool {
+	if len(list) >= listThreshold {
+		if match := sharedListMatcher(op, list); match != nil {
+			return match
+		}
+	}
+	switch op {
+	case In:
+		return func(v string) bool {
+			for _, s := range list {
+				if v == s {
+					return true
+				}
+			}
+			return false
+		}
+	case IIn:
+		return func(v string) bool {
+			for _, s := range list {
+				if strings.EqualFold(s, v) {
+					return true
+				}
+			}
+			return false
+		}
+	case Contains:
+		return anyOf(list, strings.Contains)
+	case IContains:
+		return anyOfLower(list, strings.Contains)
+	case Startswith:
+		return anyOf(list, strings.HasPrefix)
+	case IStartswith:
+		return anyOfLower(list, strings.HasPrefix)
+	case Endswith:
+		return anyOf(list, strings.HasSuffix)
+	case IEndswith:
+		return anyOfLower(list, strings.HasSuffix)
+	case Matches, IMatches:
+		pats := make([]wildcard.Pattern, len(list))
+		for i, s := range list {
+			if op == IMatches {
+				s = strings.ToLower(s)
+			}
+			pats[i] = wildcard.Compile(s)
+		}
+		return func(v string) bool {
+			if op == IMatches {
+				v = strings.ToLower(v)
+			}
+			for _, pat := range pats {
+				if pat.Match(v) {
+					return true
+				}
+			}
+			return false
+		}
+	case Fuzzy:
+		return anyOf(list, func(v, s string) bool { return fuzzysearch.Match(s, v) })
+	case IFuzzy:
+		return anyOf(list, func(v, s string) bool { return fuzzysearch.MatchFold(s, v) })
+	case Fuzzynorm:
+		return anyOf(list, func(v, s string) bool { return fuzzysearch.MatchNormalized(s, v) })
+	case IFuzzynorm:
+		return anyOf(list, func(v, s string) bool { return fuzzysearch.MatchNormalizedFold(s, v) })
+	}
+	return nil
+}
+
+// anyOf returns a matcher that is satisfied if the
+// function holds for any of the list values.
+func anyOf(list []string, fn func(v, s string) bool) func(string) bool {
+	return func(v string) bool {
+		for _, s := range list {
+			if fn(v, s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// anyOfLower is like anyOf, but the function is applied to
+// the lowercase versions of the value and list elements.
+func anyOfLower(list []string, fn func(v, s string) bool) func(string) bool {
+	lower := make([]string, len(list))
+	for i, s := range list {
+		lower[i] = strings.ToLower(s)
+	}
+	return func(v string) bool {
+		v = strings.ToLower(v)
+		for _, s := range lower {
+			if fn(v, s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func ipMatcher(op token, ip net.IP) func(net.IP) bool {
+	switch op {
+	case Eq:
+		return func(v net.IP) bool { return v.Equal(ip) }
+	case Neq:
+		return func(v net.IP) bool { return !v.Equal(ip) }
+	}
+	return nil
+}
+
+func ipListMatcher(op token, list []string) func(net.IP) bool {
+	if op != In {
+		return nil
+	}
+	ips := make([]net.IP, len(list))
+	for i, s := range list {
+		ips[i] = net.ParseIP(s)
+	}
+	return func(v net.IP) bool {
+		for _, ip := range ips {
+			if ip.Equal(v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// unsignedMatcher compares unsigned field values with the signed
+// integer literal. Negative literals are always smaller than the
+// field value.
+func unsignedMatcher[T uint16 | uint32 | uint64](op token, n int64) func(T) bool {
+	c, neg := T(n), n < 0
+	switch op {
+	case Eq:
+		return func(v T) bool { return v == c }
+	case Neq:
+		return func(v T) bool { return v != c }
+	case Lt:
+		return func(v T) bool { return !neg && v < c }
+	case Lte:
+		return func(v T) bool { return !neg && v <= c }
+	case Gt:
+		return func(v T) bool { return neg || v > c }
+	case Gte:
+		return func(v T) bool { return neg || v >= c }
+	}
+	return nil
+}
+
+// signedMatcher compares field values that fit into
+// the signed integer with the integer literal.
+func signedMatcher[T uint8 | int | int64](op token, n int64) func(T) bool {
+	switch op {
+	case Eq:
+		return func(v T) bool { return int64(v) == n }
+	case Neq:
+		return func(v T) bool { return int64(v) != n }
+	case Lt:
+		return func(v T) bool { return int64(v) < n }
+	case Lte:
+		return func(v T) bool { return int64(v) <= n }
+	case Gt:
+		return func(v T) bool { return int64(v) > n }
+	case Gte:
+		return func(v T) bool { return int64(v) >= n }
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	valuers := []map[string]interface{}{
+		{
+			"ps.name":        "svchost.exe",
+			"ps.parent.name": "Services.exe",
+			"ps.exe":         "C:\\Windows\\System32\\svchost.exe",
+			"ps.cmdline":     "C:\\Windows\\system32\\svchost.exe -k RPCSS",
+			"ps.args":        []string{"-k", "RPCSS"},
+			"ps.pid":         uint32(1234),
+			"kevt.pid":       uint32(4),
+			"kevt.time.h":    uint8(12),
+			"net.dport":      uint16(443),
+			"net.dip":        net.ParseIP("10.0.1.12"),
+			"image.size":     uint32(4096),
+			"file.name":      "C:\\Users\\admin\\AppData\\Local\\Temp\\\u212Aey.tmp",
+		},
+		{
+			"ps.name":    "CMD.EXE",
+			"ps.exe":     "c:\\windows\\system32\\cmd.exe",
+			"ps.pid":     uint32(0),
+			"net.dport":  uint16(53),
+			"net.dip":    net.ParseIP("fe80::1"),
+			"image.size": uint32(0),
+			"file.name":  "",
+		},
+		{},
+	}
+
+	var tests = []string{
+		`ps.name = 'svchost.exe'`,
+		`ps.name != 'svchost.exe'`,
+		`ps.name ~= 'SVCHOST.exe'`,
+		`ps.name contains 'host'`,
+		`ps.name icontains 'HOST'`,
+		`ps.name startswith 'svc'`,
+		`ps.name istartswith 'CMD'`,
+		`ps.name endswith '.exe'`,
+		`ps.name iendswith '.EXE'`,
+		`ps.exe matches 'C:\\Windows\\*\\svchost.exe'`,
+		`ps.exe imatches 'c:\\windows\\system32\\*.EXE'`,
+		`ps.exe imatches ('?:\\windows\\*\\cmd.exe', '*\\svchost.exe')`,
+		`ps.name fuzzy 'svch'`,
+		`ps.name ifuzzy 'SVCH'`,
+		`ps.name fuzzynorm 'svch'`,
+		`ps.name ifuzzynorm 'SVCH'`,
+		`ps.name in ('cmd.exe', 'svchost.exe')`,
+		`ps.name in ('a.exe', 'b.exe', 'c.exe', 'd.exe', 'e.exe', 'f.exe', 'g.exe', 'svchost.exe')`,
+		`ps.name iin ('a.exe', 'b.exe', 'c.exe', 'd.exe', 'e.exe', 'f.exe', 'g.exe', 'cmd.exe')`,
+		`ps.name iin ('cmd.exe', 'powershell.exe')`,
+		`file.name iin ('a', 'b', 'c', 'd', 'e', 'f', 'g', 'c:\\users\\admin\\appdata\\local\\temp\\key.tmp')`,
+		`ps.exe icontains ('\\temp\\', '\\appdata\\', '\\users\\public\\', '\\perflogs\\', '\\system32\\', '\\syswow64\\', '\\tasks\\', '\\fonts\\')`,
+		`ps.exe startswith ('C:\\Windows\\', 'C:\\Program Files\\', 'C:\\ProgramData\\', 'D:\\', 'E:\\', 'F:\\', '\\\\', 'C:\\Users\\')`,
+		`ps.exe iendswith ('.EXE', '.com', '.scr', '.pif', '.cpl', '.hta', '.js', '.vbs')`,
+		`ps.exe imatches ('?:\\windows\\*\\cmd.exe', '*\\svchost.exe', '*\\temp\\*', '*.scr', '?:\\users\\*\\*.exe', '*\\tasks\\*', '*\\fonts\\*.exe', '*\\perflogs\\*')`,
+		`ps.name contains ('svc', 'cmd')`,
+		`ps.name icontains ('SVC', 'CMD')`,
+		`ps.name startswith ('svc', 'cmd')`,
+		`ps.name istartswith ('SVC', 'CMD')`,
+		`ps.name endswith ('.exe', '.dll')`,
+		`ps.name iendswith ('.EXE', '.DLL')`,
+		`ps.name in 'svchost.exe'`,
+		`ps.args in ('RPCSS')`,
+		`ps.args icontains ('rpc')`,
+		`ps.pid = 1234`,
+		`ps.pid != 1234`,
+		`ps.pid > 1000 and ps.pid < 2000`,
+		`ps.pid >= 1234 and ps.pid <= 1234`,
+		`kevt.time.h >= 12`,
+		`net.dport = 443`,
+		`net.dport in ('443', '80')`,
+		`net.dip = 10.0.1.12`,
+		`net.dip != 10.0.1.12`,
+		`net.dip in ('10.0.1.12', 'fe80::1')`,
+		`net.dip startswith '10.0'`,
+		`cidr_contains(net.dip, '10.0.0.0/8', '172.16.0.0/12')`,
+		`cidr_contains(net.dip, 'invalid', 'fe80::/10')`,
+		`ps.pid > 0 and not cidr_contains(net.dip, '10.0.0.0/8')`,
+		`length(ps.name) > 5`,
+		`lower(ps.name) = 'cmd.exe'`,
+		`concat(ps.name, ':', ps.pid) = 'svchost.exe:1234'`,
+		`image.size > 0 or ps.name = 'cmd.exe'`,
+		`image.size > 0 and not ps.name = 'cmd.exe'`,
+		`ps.pid >= 0 and not (ps.name = 'cmd.exe' or ps.name = 'svchost.exe')`,
+		`(ps.name = 'svchost.exe' and ps.pid = 1234) or (ps.name = 'cmd.exe' and ps.pid = 0)`,
+		`ps.parent.name ~= 'services.exe' and ps.name = 'svchost.exe'`,
+		`ps.parent.name = 'services.exe' or ps.parent.name = 'wininit.exe'`,
+		`ps.name = 'svchost.exe' and ps.parent.name = 'Services.exe' and ps.args not in ('-s')`,
+	}
+
+	for _, expr := range tests {
+		p := NewParser(expr)
+		e, err := p.ParseExpr()
+		require.NoError(t, err, expr)
+		prog := Compile(e)
+		for i, m := range valuers {
+			assert.Equal(t, Eval(e, m, true), prog.Eval(MapValuer(m)), "%s (valuer %d)", expr, i)
+		}
+	}
+}
+
+func TestCompileSharesListMatchers(t *testing.T) {
+	list := `('a.exe', 'b.exe', 'c.exe', 'd.exe', 'e.exe', 'f.exe', 'g.exe', 'h.exe')`
+	compile := func(expr string) {
+		e, err := NewParser(expr).ParseExpr()
+		require.NoError(t, err)
+		Compile(e)
+	}
+
+	compile(`ps.name iin ` + list)
+	n := len(listMatchers.m)
+	compile(`ps.parent.name iin ` + list + ` and ps.name iin ` + list)
+	assert.Len(t, listMatchers.m, n)
+	compile(`ps.name in ` + list)
+	assert.Len(t, listMatchers.m, n+1)
+}
+
+func TestCompileLogicalOperators(t *testing.T) {
+	// nil operands are implicitly cast to false
+	// when the other operand is a boolean value
+	var tests = []struct {
+		expr Expr
+		m    map[string]interface{}
+	}{
+		{&BinaryExpr{Op: And, LHS: &FieldLiteral{Value: "a"}, RHS: &BoolLiteral{Value: true}}, map[string]interface{}{}},
+		{&BinaryExpr{Op: Or, LHS: &FieldLiteral{Value: "a"}, RHS: &BoolLiteral{Value: true}}, map[string]interface{}{}},
+		{&BinaryExpr{Op: Or, LHS: &FieldLiteral{Value: "a"}, RHS: &FieldLiteral{Value: "b"}}, map[string]interface{}{"b": true}},
+		{&BinaryExpr{Op: Or, LHS: &FieldLiteral{Value: "a"}, RHS: &FieldLiteral{Value: "b"}}, map[string]interface{}{"a": "str", "b": true}},
+		{&BinaryExpr{Op: And, LHS: &FieldLiteral{Value: "a"}, RHS: &FieldLiteral{Value: "b"}}, map[string]interface{}{"a": true, "b": "str"}},
+		{&NotExpr{Expr: &BinaryExpr{Op: And, LHS: &FieldLiteral{Value: "a"}, RHS: &FieldLiteral{Value: "b"}}}, map[string]interface{}{"b": "str"}},
+		{&NotExpr{Expr: &BinaryExpr{Op: Or, LHS: &FieldLiteral{Value: "a"}, RHS: &FieldLiteral{Value: "b"}}}, map[string]interface{}{"a": false}},
+		{&NotExpr{Expr: &ParenExpr{Expr: &FieldLiteral{Value: "a"}}}, map[string]interface{}{"a": false}},
+		{&NotExpr{Expr: &FieldLiteral{Value: "a"}}, map[string]interface{}{"a": false}},
+		{&BinaryExpr{Op: Eq, LHS: &ParenExpr{Expr: &BinaryExpr{Op: Eq, LHS: &FieldLiteral{Value: "a"}, RHS: &IntegerLiteral{Value: 1}}}, RHS: &BoolLiteral{Value: false}}, map[string]interface{}{"a": uint32(2)}},
+		{&BinaryExpr{Op: Gt, LHS: &FieldLiteral{Value: "a"}, RHS: &IntegerLiteral{Value: -1}}, map[string]interface{}{"a": uint32(2)}},
+		{&BinaryExpr{Op: Lt, LHS: &FieldLiteral{Value: "a"}, RHS: &IntegerLiteral{Value: -1}}, map[string]interface{}{"a": uint16(2)}},
+	}
+
+	for i, tt := range tests {
+		assert.Equal(t, Eval(tt.expr, tt.m, false), Compile(tt.expr).Eval(MapValuer(tt.m)), "%d. %s", i, tt.expr)
+	}
+}
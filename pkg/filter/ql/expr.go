@@ -50,6 +50,15 @@ func (e *BinaryExpr) String() string {
 	return fmt.Sprintf("%s %s %s", e.LHS.String(), e.Op.String(), e.RHS.String())
 }
 
+// Exclude combines the expression with the negated exclusions. The resulting
+// expression evaluates to true only if none of the exclusions are satisfied.
+func Exclude(expr Expr, exclusions ...Expr) Expr {
+	for _, e := range exclusions {
+		expr = &BinaryExpr{Op: And, LHS: &ParenExpr{Expr: expr}, RHS: &NotExpr{Expr: &ParenExpr{Expr: e}}}
+	}
+	return expr
+}
+
 // NotExpr represents an unary not expression.
 type NotExpr struct {
 	Expr Expr
@@ -70,6 +70,7 @@ var funcs = map[string]FunctionDef{
 	functions.VolumeFn.String():       &functions.Volume{},
 	functions.GetRegValueFn.String():  &functions.GetRegValue{},
 	functions.YaraFn.String():         &functions.Yara{},
+	functions.LookupFn.String():       &functions.Lookup{},
 }
 
 // FunctionDef is the interface that all function definitions have to satisfy.
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package functions
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
+)
+
+// Lookup consults the lookup table. Given the key, it determines if
+// the table contains the entry. If the column is supplied, it returns
+// the column value of the entry with the given key. Keys are matched
+// under case-insensitive comparison when the exact key is not found.
+type Lookup struct{}
+
+func (f Lookup) Call(args []interface{}) (interface{}, bool) {
+	if len(args) < 2 {
+		return nil, false
+	}
+	table := lists.Get(parseString(0, args))
+	if table == nil {
+		return nil, false
+	}
+	var key string
+	switch v := args[1].(type) {
+	case nil:
+		return nil, false
+	case string:
+		key = v
+	case net.IP:
+		key = v.String()
+	default:
+		key = fmt.Sprint(v)
+	}
+	row, ok := table.Row(key)
+	if len(args) == 2 {
+		return ok, true
+	}
+	if !ok {
+		return nil, false
+	}
+	val, ok := row[parseString(2, args)]
+	return val, ok
+}
+
+func (f Lookup) Desc() FunctionDesc {
+	desc := FunctionDesc{
+		Name: LookupFn,
+		Args: []FunctionArgDesc{
+			{Keyword: "table", Types: []ArgType{String}, Required: true},
+			{Keyword: "key", Types: []ArgType{Field, Func, String}, Required: true},
+			{Keyword: "column", Types: []ArgType{String}},
+		},
+		ArgsValidationFunc: func(args []string) error {
+			if len(args) > 0 && lists.Get(args[0]) == nil {
+				return fmt.Errorf("%s lookup table is undefined. Available tables: %v", args[0], lists.Names())
+			}
+			return nil
+		},
+	}
+	return desc
+}
+
+func (f Lookup) Name() Fn { return LookupFn }
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package functions
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	lists.Register("vendors", map[string]lists.Row{
+		"Microsoft Corporation": {"trusted": true, "score": int64(10)},
+	})
+	lists.Register("c2_servers", map[string]lists.Row{"10.0.0.1": nil, "443": nil})
+
+	var tests = []struct {
+		args     []interface{}
+		expected interface{}
+	}{
+		{
+			[]interface{}{"vendors", "Microsoft Corporation"},
+			true,
+		},
+		{
+			[]interface{}{"vendors", "Evil Corp"},
+			false,
+		},
+		{
+			[]interface{}{"vendors", "microsoft corporation", "trusted"},
+			true,
+		},
+		{
+			[]interface{}{"vendors", "Microsoft Corporation", "score"},
+			int64(10),
+		},
+		{
+			[]interface{}{"vendors", "Microsoft Corporation", "country"},
+			nil,
+		},
+		{
+			[]interface{}{"vendors", "Evil Corp", "trusted"},
+			nil,
+		},
+		{
+			[]interface{}{"c2_servers", net.ParseIP("10.0.0.1")},
+			true,
+		},
+		{
+			[]interface{}{"c2_servers", uint16(443)},
+			true,
+		},
+		{
+			[]interface{}{"c2_servers", nil},
+			nil,
+		},
+		{
+			[]interface{}{"signers", "Microsoft Corporation"},
+			nil,
+		},
+	}
+
+	for i, tt := range tests {
+		f := Lookup{}
+		res, _ := f.Call(tt.args)
+		assert.Equal(t, tt.expected, res, fmt.Sprintf("%d. result mismatch: exp=%v got=%v", i, tt.expected, res))
+	}
+
+	assert.NoError(t, Lookup{}.Desc().ArgsValidationFunc([]string{"vendors", "pe.company"}))
+	assert.Error(t, Lookup{}.Desc().ArgsValidationFunc([]string{"signers", "pe.company"}))
+}
@@ -72,6 +72,8 @@ const (
 	GetRegValueFn
 	// YaraFn represents the YARA function
 	YaraFn
+	// LookupFn represents the LOOKUP function
+	LookupFn
 )
 
 // ArgType is the type alias for the argument value type.
@@ -208,6 +210,8 @@ func (f Fn) String() string {
 		return "GET_REG_VALUE"
 	case YaraFn:
 		return "YARA"
+	case LookupFn:
+		return "LOOKUP"
 	default:
 		return "UNDEFINED"
 	}
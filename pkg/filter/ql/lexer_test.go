@@ -50,6 +50,15 @@ func TestScanner(t *testing.T) {
 		{s: `<=`, tok: Lte},
 		{s: `>`, tok: Gt},
 		{s: `>=`, tok: Gte},
+
+		// arithmetic and bitwise operators
+		{s: `+`, tok: Add},
+		{s: `-`, tok: Sub},
+		{s: `*`, tok: Mul},
+		{s: `/`, tok: Div},
+		{s: `%`, tok: Mod},
+		{s: `&`, tok: BitAnd},
+		{s: `^`, tok: BitXor},
 		{s: `IN`, tok: In},
 		{s: `in`, tok: In},
 
@@ -58,6 +67,7 @@ func TestScanner(t *testing.T) {
 		{s: `)`, tok: Rparen},
 		{s: `,`, tok: Comma},
 		{s: `|`, tok: Pipe},
+		{s: `->`, tok: Arrow},
 
 		// fields
 		{s: `ps.name`, tok: Field, lit: "ps.name"},
@@ -84,6 +94,9 @@ func TestScanner(t *testing.T) {
 
 		// numbers
 		{s: "6.2323", tok: Decimal, lit: "6.2323"},
+		{s: "1024", tok: Integer, lit: "1024"},
+		{s: "0x1400", tok: Integer, lit: "0x1400"},
+		{s: "0X1fFFff", tok: Integer, lit: "0X1fFFff"},
 	}
 
 	for i, tt := range tests {
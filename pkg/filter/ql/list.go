@@ -0,0 +1,163 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rabbitstack/fibratus/pkg/util/ahocorasick"
+	"github.com/rabbitstack/fibratus/pkg/util/wildcard"
+)
+
+// listThreshold is the minimum number of list literal values
+// for which the list is matched via hash set or automaton
+// instead of comparing the value with each list element.
+const listThreshold = 8
+
+// listMatchers caches matchers built for large list literals. Rules
+// often reference the same lists, either directly or through macro
+// lists, so the matcher is built once and shared among all of them.
+var listMatchers = struct {
+	sync.Mutex
+	m map[listKey]func(string) bool
+}{m: make(map[listKey]func(string) bool)}
+
+type listKey struct {
+	op   token
+	list string
+}
+
+func newListKey(op token, list []string) listKey {
+	var b strings.Builder
+	for _, s := range list {
+		b.WriteString(strconv.Itoa(len(s)))
+		b.WriteByte(':')
+		b.WriteString(s)
+	}
+	return listKey{op: op, list: b.String()}
+}
+
+// sharedListMatcher returns the cached matcher for the operator and the
+// list, building it on the first use. It returns nil if the operator has
+// no dedicated list matcher.
+func sharedListMatcher(op token, list []string) func(string) bool {
+	key := newListKey(op, list)
+	listMatchers.Lock()
+	defer listMatchers.Unlock()
+	if match, ok := listMatchers.m[key]; ok {
+		return match
+	}
+	match := newListMatcher(op, list)
+	if match != nil {
+		listMatchers.m[key] = match
+	}
+	return match
+}
+
+// newListMatcher builds the matcher that is satisfied if the value matches
+// any of the list elements under the given operator. Membership tests are
+// resolved via hash sets, substring, prefix and suffix tests via the
+// Aho-Corasick automaton, and wildcard tests via the pattern set.
+func newListMatcher(op token, list []string) func(string) bool {
+	switch op {
+	case In:
+		set := make(map[string]struct{}, len(list))
+		for _, s := range list {
+			set[s] = struct{}{}
+		}
+		return func(v string) bool {
+			_, ok := set[v]
+			return ok
+		}
+	case IIn:
+		set := make(map[string]struct{}, len(list))
+		for _, s := range list {
+			set[string(appendFoldKey(nil, s))] = struct{}{}
+		}
+		return func(v string) bool {
+			var buf [128]byte
+			_, ok := set[string(appendFoldKey(buf[:0], v))]
+			return ok
+		}
+	case Contains:
+		return ahocorasick.New(list).Contains
+	case Startswith:
+		return ahocorasick.New(list).HasPrefix
+	case Endswith:
+		return ahocorasick.New(list).HasSuffix
+	case IContains:
+		return lowered(ahocorasick.New(lowerList(list)).Contains)
+	case IStartswith:
+		return lowered(ahocorasick.New(lowerList(list)).HasPrefix)
+	case IEndswith:
+		return lowered(ahocorasick.New(lowerList(list)).HasSuffix)
+	case Matches:
+		return wildcard.CompileSet(list).Match
+	case IMatches:
+		return lowered(wildcard.CompileSet(lowerList(list)).Match)
+	}
+	return nil
+}
+
+func lowerList(list []string) []string {
+	lower := make([]string, len(list))
+	for i, s := range list {
+		lower[i] = strings.ToLower(s)
+	}
+	return lower
+}
+
+// lowered returns the matcher that lowercases the value
+// before it is passed to the underlying matcher.
+func lowered(match func(string) bool) func(string) bool {
+	return func(v string) bool { return match(strings.ToLower(v)) }
+}
+
+// appendFoldKey appends the case-folded form of the string to
+// the buffer. Each rune is replaced with the canonical member
+// of its simple folding orbit, so two strings have identical
+// keys if and only if they are equal under strings.EqualFold.
+func appendFoldKey(buf []byte, s string) []byte {
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			if 'a' <= r && r <= 'z' {
+				r -= 'a' - 'A'
+			}
+			buf = append(buf, byte(r))
+			continue
+		}
+		buf = utf8.AppendRune(buf, foldRune(r))
+	}
+	return buf
+}
+
+// foldRune returns the smallest rune in the simple folding orbit.
+func foldRune(r rune) rune {
+	fold := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < fold {
+			fold = f
+		}
+	}
+	return fold
+}
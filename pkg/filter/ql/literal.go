@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/rabbitstack/fibratus/pkg/filter/fields"
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
 	"github.com/rabbitstack/fibratus/pkg/kevent"
 	"github.com/rabbitstack/fibratus/pkg/util/hashers"
 	"net"
@@ -72,6 +73,22 @@ type BoundFieldLiteral struct {
 	Value string
 }
 
+// VarLiteral represents the reference to the quantifier variable. The
+// reference can also designate the sub-field of the structured list
+// element, e.g. s.entropy.
+type VarLiteral struct {
+	Value string
+}
+
+// TableLiteral represents the reference to the lookup table, e.g.
+// $lists.bad_domains. The table is resolved when the expression is
+// parsed. Table refreshes replace the entries of the same table, so
+// the reference remains valid for the lifetime of the expression.
+type TableLiteral struct {
+	Name  string
+	Table *lists.Table
+}
+
 func (i IPLiteral) String() string {
 	return i.Value.String()
 }
@@ -104,6 +121,14 @@ func (b BoundFieldLiteral) String() string {
 	return b.Value
 }
 
+func (v VarLiteral) String() string {
+	return v.Value
+}
+
+func (t TableLiteral) String() string {
+	return tablePrefix + t.Name
+}
+
 func (b BoundFieldLiteral) Field() fields.Field {
 	n := strings.Index(b.Value, ".")
 	if n > 0 {
@@ -166,6 +191,24 @@ func (f *Function) String() string {
 	return fmt.Sprintf("%s(%s)", f.Name, strings.Join(str, ", "))
 }
 
+// Quantifier represents the predicate applied to each element of the
+// list, e.g. any(ps.modules, m -> m imatches '*\\temp\\*'). The `any`
+// quantifier is satisfied if at least one element matches the predicate,
+// `all` requires every element to match, and `none` is satisfied if no
+// element matches. The predicate refers to the current element through
+// the variable.
+type Quantifier struct {
+	Name string
+	List Expr
+	Var  string
+	Expr Expr
+}
+
+// String returns a string representation of the quantifier.
+func (q *Quantifier) String() string {
+	return fmt.Sprintf("%s(%s, %s -> %s)", q.Name, q.List.String(), q.Var, q.Expr.String())
+}
+
 // validate ensures that the function name obtained
 // from the parser exists within the internal functions
 // catalog. It also validates the function signature to
@@ -193,7 +236,7 @@ func (f *Function) validate() error {
 		arg := fn.Desc().Args[i]
 		typ := functions.Unknown
 		switch reflect.TypeOf(expr) {
-		case reflect.TypeOf(&FieldLiteral{}), reflect.TypeOf(&BoundFieldLiteral{}):
+		case reflect.TypeOf(&FieldLiteral{}), reflect.TypeOf(&BoundFieldLiteral{}), reflect.TypeOf(&VarLiteral{}):
 			typ = functions.Field
 		case reflect.TypeOf(&IPLiteral{}):
 			typ = functions.IP
@@ -221,6 +264,12 @@ type SequenceExpr struct {
 	By          fields.Field
 	BoundFields []*BoundFieldLiteral
 	Alias       string
+	// Negated indicates the absence expression. The sequence
+	// only matches if no event satisfies the absence expression
+	// between the upstream and downstream expressions, or within
+	// the max span if the absence expression is the last in the
+	// sequence.
+	Negated bool
 
 	buckets map[uint32]bool
 }
@@ -302,6 +351,15 @@ type Sequence struct {
 	MaxSpan     time.Duration
 	By          fields.Field
 	Expressions []SequenceExpr
+	// Until is the optional expression that terminates
+	// in-flight sequences when it matches
+	Until *SequenceExpr
+}
+
+// HasTrailingAbsence determines if the sequence ends with absence expressions.
+// Such sequences can only match when the max span deadline is reached.
+func (s Sequence) HasTrailingAbsence() bool {
+	return len(s.Expressions) > 0 && s.Expressions[len(s.Expressions)-1].Negated
 }
 
 // IsConstrained determines if the sequence has the global or per-expression `BY` statement.
@@ -309,6 +367,20 @@ func (s Sequence) IsConstrained() bool {
 	return !s.By.IsEmpty() || !s.Expressions[0].By.IsEmpty()
 }
 
+// Exclude combines the negated exclusions with all sequence expressions
+// except the absence expressions.
+func (s *Sequence) Exclude(exclusions ...Expr) {
+	for i := range s.Expressions {
+		expr := &s.Expressions[i]
+		if expr.Negated {
+			continue
+		}
+		expr.Expr = Exclude(expr.Expr, exclusions...)
+		expr.init()
+		expr.walk()
+	}
+}
+
 func (s Sequence) impairBy() bool {
 	b := make(map[bool]int, len(s.Expressions))
 	for _, expr := range s.Expressions {
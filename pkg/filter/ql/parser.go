@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"github.com/rabbitstack/fibratus/pkg/config"
 	"github.com/rabbitstack/fibratus/pkg/filter/fields"
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
 	"github.com/rabbitstack/fibratus/pkg/util/multierror"
 	"net"
 	"strconv"
@@ -37,6 +38,12 @@ type Parser struct {
 	s    *bufScanner
 	c    *config.Filters
 	expr string
+	// seq indicates if the parser is parsing the sequence
+	seq bool
+	// depth is the nesting level of grouped expressions and function calls
+	depth int
+	// vars contains the variables of the enclosing quantifiers
+	vars []string
 }
 
 // NewParser builds a new parser instance from the expression string.
@@ -55,6 +62,7 @@ func NewParserWithConfig(expr string, config *config.Filters) *Parser {
 func (p *Parser) ParseSequence() (*Sequence, error) {
 	seq := &Sequence{}
 	var exprs []SequenceExpr
+	p.seq = true
 
 	// parse optional max span
 	tok, _, _ := p.scanIgnoreWhitespace()
@@ -64,8 +72,8 @@ func (p *Parser) ParseSequence() (*Sequence, error) {
 		if err != nil {
 			return nil, err
 		}
-		if seq.MaxSpan > time.Hour*4 {
-			return nil, fmt.Errorf("maximum span %v cannot be greater than 4h", seq.MaxSpan)
+		if maxSpan := p.sequences().GetMaxSpan(); seq.MaxSpan > maxSpan {
+			return nil, fmt.Errorf("maximum span %v cannot be greater than %v", seq.MaxSpan, maxSpan)
 		}
 	} else {
 		p.unscan()
@@ -85,23 +93,53 @@ func (p *Parser) ParseSequence() (*Sequence, error) {
 
 	// parse sequence expressions
 	for {
-		if tok, _, _ := p.scanIgnoreWhitespace(); tok == EOF {
+		tok, _, _ := p.scanIgnoreWhitespace()
+		// parse optional until expression that
+		// terminates the sequence
+		if tok == Until && len(exprs) > 0 {
+			until, err := p.parseUntil()
+			if err != nil {
+				return nil, err
+			}
+			seq.Until = until
+			var pos int
+			var lit string
+			tok, pos, lit = p.scanIgnoreWhitespace()
+			if tok != EOF {
+				return nil, newParseError(tokstr(tok, lit), []string{"EOF"}, pos, p.expr)
+			}
+		}
+		if tok == EOF {
 			if len(exprs) < 1 {
 				return nil, fmt.Errorf("%s: sequences require at least two expressions", p.expr)
 			}
-			const maxExpressions = 5
-			if len(exprs) > maxExpressions {
+			if maxExpressions := p.sequences().GetMaxExpressions(); len(exprs) > maxExpressions {
 				return nil, fmt.Errorf("%s: maximum number of expressions reached", p.expr)
 			}
 			seq.Expressions = exprs
 			if seq.impairBy() {
 				return nil, fmt.Errorf("%s: all expressions require the 'by' statement", p.expr)
 			}
+			if exprs[0].Negated {
+				return nil, fmt.Errorf("%s: sequences can't start with the absence expression", p.expr)
+			}
+			if exprs[len(exprs)-1].Negated && seq.MaxSpan == 0 {
+				return nil, fmt.Errorf("%s: trailing absence expressions require the 'maxspan' statement", p.expr)
+			}
+			if seq.Until != nil && seq.By.IsEmpty() && !exprs[0].By.IsEmpty() && seq.Until.By.IsEmpty() {
+				return nil, fmt.Errorf("%s: until expression requires the 'by' statement", p.expr)
+			}
 			return seq, nil
 		}
 		p.unscan()
 
+		// the absence expression is prefixed with
+		// the negation operator, e.g. not |kevt.name = 'LoadImage'|
 		tok, posStart, lit := p.scanIgnoreWhitespace()
+		negated := tok == Not
+		if negated {
+			tok, posStart, lit = p.scanIgnoreWhitespace()
+		}
 		if tok != Pipe {
 			return nil, newParseError(tokstr(tok, lit), []string{"|"}, posStart, p.expr)
 		}
@@ -128,17 +166,63 @@ func (p *Parser) ParseSequence() (*Sequence, error) {
 			if tok != Ident {
 				return nil, newParseError(tokstr(tok, lit), []string{"identifier"}, pos, p.expr)
 			}
+			if negated {
+				return nil, fmt.Errorf("%s: absence expressions can't be aliased", p.expr)
+			}
 			seqexpr = SequenceExpr{Expr: expr, Alias: lit}
 		default:
 			seqexpr = SequenceExpr{Expr: expr}
 			p.unscan()
 		}
+		seqexpr.Negated = negated
 		seqexpr.init()
 		seqexpr.walk()
 		exprs = append(exprs, seqexpr)
 	}
 }
 
+// sequences returns the sequence limits from the filters config
+// or the default limits if the parser is not given the config.
+func (p *Parser) sequences() config.Sequences {
+	if p.c == nil {
+		return config.Sequences{}
+	}
+	return p.c.Sequences
+}
+
+// parseUntil parses the expression that terminates in-flight sequences.
+// This method assumes the UNTIL token has already been consumed.
+func (p *Parser) parseUntil() (*SequenceExpr, error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != Pipe {
+		return nil, newParseError(tokstr(tok, lit), []string{"|"}, pos, p.expr)
+	}
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+	tok, pos, lit = p.scanIgnoreWhitespace()
+	if tok != Pipe {
+		return nil, newParseError(tokstr(tok, lit), []string{"|"}, pos, p.expr)
+	}
+	until := &SequenceExpr{Expr: expr}
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == By {
+		tok, pos, lit := p.scanIgnoreWhitespace()
+		if tok != Field {
+			return nil, newParseError(tokstr(tok, lit), []string{"field"}, pos, p.expr)
+		}
+		until.By = fields.Field(lit)
+	} else {
+		p.unscan()
+	}
+	until.init()
+	until.walk()
+	if until.HasBoundFields() {
+		return nil, fmt.Errorf("%s: until expression can't reference bound fields", p.expr)
+	}
+	return until, nil
+}
+
 // IsSequence checks whether the expression given to the parser is a sequence.
 func (p *Parser) IsSequence() bool {
 	tok, _, _ := p.scanIgnoreWhitespace()
@@ -164,6 +248,12 @@ func (p *Parser) ParseExpr() (Expr, error) {
 	for {
 		// if the next token is NOT an operator then return the expression.
 		op, pos, lit := p.scanIgnoreWhitespace()
+		// the pipe delimits sequence expressions, so it is
+		// only interpreted as the bitwise OR operator outside
+		// sequences or within grouped expressions
+		if op == Pipe && (!p.seq || p.depth > 0) {
+			op = BitOr
+		}
 		if !op.isOperator() {
 			p.unscan()
 			if op != EOF && op != Rparen && op != Comma && op != Pipe {
@@ -176,7 +266,7 @@ func (p *Parser) ParseExpr() (Expr, error) {
 			// expect LPAREN after in
 			tok, pos, lit := p.scanIgnoreWhitespace()
 			p.unscan()
-			if tok != Lparen && (p.c != nil && !p.c.IsMacroList(lit)) {
+			if tok != Lparen && !isTableRef(tok, lit) && (p.c != nil && !p.c.IsMacroList(lit)) {
 				return nil, newParseError(tokstr(op, lit), []string{"'('"}, pos, p.expr)
 			}
 		}
@@ -251,7 +341,9 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 		if err != nil {
 			p.unscan()
 			// if it fails, try to parse the grouped expression
+			p.depth++
 			expr, err := p.ParseExpr()
+			p.depth--
 			if err != nil {
 				return nil, err
 			}
@@ -273,14 +365,29 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 	p.unscan()
 
 	tok, pos, lit := p.scanIgnoreWhitespace()
+	// the minus sign preceding the number negates it
+	negative := tok == Sub
+	if negative {
+		tok, pos, lit = p.scan()
+		if tok != Integer && tok != Decimal {
+			return nil, newParseError(tokstr(tok, lit), []string{"number"}, pos, p.expr)
+		}
+	}
 	switch tok {
 	case Ident:
 		if tok0, _, _ := p.scan(); tok0 == Lparen {
+			if isQuantifier(lit) {
+				return p.parseQuantifier(lit)
+			}
 			return p.parseFunction(lit)
 		}
 		// unscan lparen token
 		p.unscan()
 
+		if p.isVar(lit) {
+			return &VarLiteral{Value: lit}, nil
+		}
+
 		// expand macros
 		if p.c != nil {
 			macro := p.c.GetMacro(lit)
@@ -303,8 +410,19 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 	case Str:
 		return &StringLiteral{Value: lit}, nil
 	case Field:
+		if p.isVar(lit) {
+			return &VarLiteral{Value: lit}, nil
+		}
 		return &FieldLiteral{Value: lit}, nil
 	case BoundField:
+		if isTableRef(tok, lit) {
+			name := lit[len(tablePrefix):]
+			table := lists.Get(name)
+			if table == nil {
+				return nil, &ParseError{Message: fmt.Sprintf("%s lookup table is undefined", name), Pos: pos}
+			}
+			return &TableLiteral{Name: name, Table: table}, nil
+		}
 		n := strings.Index(lit, ".")
 		if n > 0 && fields.Lookup(lit[n+1:]) == "" {
 			return nil, newParseError(tokstr(tok, lit), []string{"field after bound ref"}, pos+n, p.expr)
@@ -313,11 +431,18 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 	case True, False:
 		return &BoolLiteral{Value: tok == True}, nil
 	case Integer:
-		v, err := strconv.ParseInt(lit, 10, 64)
+		base := 10
+		if strings.HasPrefix(lit, "0x") || strings.HasPrefix(lit, "0X") {
+			base = 16
+			lit = lit[2:]
+		}
+		if negative {
+			lit = "-" + lit
+		}
+		v, err := strconv.ParseInt(lit, base, 64)
 		if err != nil {
 			// The literal may be too large to fit into an int64. If it is, use an unsigned integer.
-			// The check for negative numbers is handled somewhere else so this should always be a positive number.
-			if v, err := strconv.ParseUint(lit, 10, 64); err == nil {
+			if v, err := strconv.ParseUint(lit, base, 64); err == nil {
 				return &UnsignedLiteral{Value: v}, nil
 			}
 			return nil, &ParseError{Message: "unable to parse integer", Pos: pos}
@@ -328,6 +453,9 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 		if err != nil {
 			return nil, &ParseError{Message: "unable to parse decimal", Pos: pos}
 		}
+		if negative {
+			v = -v
+		}
 		return &DecimalLiteral{Value: v}, nil
 	}
 
@@ -349,6 +477,23 @@ func (p *Parser) parseList() ([]string, error) {
 	}
 	idents := []string{lit}
 
+	// the literal followed by the operator starts
+	// the grouped expression instead of the list,
+	// e.g. (ps.access.mask & 0x10). The tokens are
+	// pushed back, so the caller can parse it again
+	tok, _, _ = p.scan()
+	n := 1
+	if tok == WS {
+		tok, _, _ = p.scan()
+		n++
+	}
+	for i := 0; i < n; i++ {
+		p.unscan()
+	}
+	if tok != Comma && tok != Rparen {
+		return []string{}, newParseError(tokstr(tok, lit), []string{"',' or ')'"}, pos, p.expr)
+	}
+
 	// parse remaining identifiers
 	for {
 		if tok, _, _ := p.scanIgnoreWhitespace(); tok != Comma {
@@ -382,6 +527,9 @@ func (p *Parser) parseFunction(name string) (*Function, error) {
 	}
 	p.unscan()
 
+	p.depth++
+	defer func() { p.depth-- }()
+
 	arg, err := p.ParseExpr()
 	if err != nil {
 		return nil, err
@@ -418,6 +566,56 @@ func (p *Parser) parseFunction(name string) (*Function, error) {
 	return fn, nil
 }
 
+// parseQuantifier parses the quantified predicate. This function
+// assumes the quantifier name and LPAREN have been consumed.
+func (p *Parser) parseQuantifier(name string) (*Quantifier, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+
+	list, err := p.parseUnaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != Comma {
+		return nil, newParseError(tokstr(tok, lit), []string{"','"}, pos, p.expr)
+	}
+
+	// the variable is a plain identifier followed by the arrow
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != Ident || strings.Contains(lit, ".") {
+		return nil, newParseError(tokstr(tok, lit), []string{"variable"}, pos, p.expr)
+	}
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != Arrow {
+		return nil, newParseError(tokstr(tok, lit), []string{"'->'"}, pos, p.expr)
+	}
+	q := &Quantifier{Name: strings.ToLower(name), List: list, Var: lit}
+
+	p.vars = append(p.vars, q.Var)
+	q.Expr, err = p.ParseExpr()
+	p.vars = p.vars[:len(p.vars)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != Rparen {
+		return nil, newParseError(tokstr(tok, lit), []string{"')'"}, pos, p.expr)
+	}
+
+	return q, nil
+}
+
+// isVar determines if the identifier references the variable
+// of the enclosing quantifier or the sub-field of the element.
+func (p *Parser) isVar(id string) bool {
+	name, _, _ := strings.Cut(id, ".")
+	for _, v := range p.vars {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
 // parseDuration parses a string and returns a duration literal.
 func (p *Parser) parseDuration() (time.Duration, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -21,6 +21,7 @@ package ql
 import (
 	"errors"
 	"github.com/rabbitstack/fibratus/pkg/config"
+	"strings"
 	"testing"
 	"time"
 )
@@ -64,6 +65,17 @@ func TestParser(t *testing.T) {
 		{expr: "ip_cidr(net.dip) = '24'", err: errors.New("ip_cidr function is undefined. Did you mean one of CIDR_CONTAINS|MD5?")},
 
 		{expr: "ps.name = 'cmd.exe' and not cidr_contains(net.sip, '172.14.0.0')"},
+
+		{expr: "file.io.size / 1024 > 500"},
+		{expr: "kevt.pid != ps.parent.pid + 0"},
+		{expr: "mem.size - image.size > 0x1000"},
+		{expr: "ps.access.mask & 0x10 != 0"},
+		{expr: "(ps.access.mask & 0x1400) = 0x1400 and (thread.access.mask | 0x10) != 0"},
+		{expr: "ps.pid % 4 = 0 and ps.pid ^ 0xff > -1"},
+		{expr: "ps.pid > -ps.ppid", err: errors.New("ps.pid > -ps.ppid\n" +
+			"           ^ expected number")},
+		{expr: "ps.pid * > 2", err: errors.New("ps.pid * > 2\n" +
+			"         ^ expected field, string, number, bool, ip")},
 	}
 
 	for i, tt := range tests {
@@ -163,6 +175,41 @@ func TestExpandMacros(t *testing.T) {
 	}
 }
 
+func TestParseSequenceLimits(t *testing.T) {
+	expr := `maxspan 24h
+			 by ps.uuid
+			 |kevt.name = 'CreateProcess'|
+			 |kevt.name = 'CreateFile'|
+			 |kevt.name = 'RegSetValue'|
+			 |kevt.name = 'LoadImage'|
+			 |kevt.name = 'Connect'|
+			 |kevt.name = 'CreateThread'|
+			`
+
+	_, err := NewParser(expr).ParseSequence()
+	if err == nil || err.Error() != "maximum span 24h0m0s cannot be greater than 4h0m0s" {
+		t.Fatalf("expected max span error, got %v", err)
+	}
+
+	c := &config.Filters{Sequences: config.Sequences{MaxSpan: time.Hour * 24}}
+	_, err = NewParserWithConfig(expr, c).ParseSequence()
+	if err == nil || !strings.Contains(err.Error(), "maximum number of expressions reached") {
+		t.Fatalf("expected max expressions error, got %v", err)
+	}
+
+	c.Sequences.MaxExpressions = 6
+	seq, err := NewParserWithConfig(expr, c).ParseSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seq.Expressions) != 6 {
+		t.Errorf("expected 6 expressions, got %d", len(seq.Expressions))
+	}
+	if seq.MaxSpan != time.Hour*24 {
+		t.Errorf("expected 24h max span, got %v", seq.MaxSpan)
+	}
+}
+
 func TestParseSequence(t *testing.T) {
 	var tests = []struct {
 		expr          string
@@ -260,6 +307,24 @@ func TestParseSequence(t *testing.T) {
 			time.Second * 30,
 			false,
 		},
+		{
+
+			`|kevt.name = 'OpenProcess' and (ps.access.mask | 0x10) != 0|
+			 |kevt.name = 'CreateFile' and file.io.size / 1024 > 1|
+			`,
+			nil,
+			time.Duration(0),
+			false,
+		},
+		{
+
+			`|kevt.name = 'OpenProcess' and ps.access.mask | 0x10 != 0|
+			 |kevt.name = 'CreateFile'|
+			`,
+			errors.New("expected |"),
+			time.Duration(0),
+			false,
+		},
 		{
 
 			`maxspan 30s
@@ -286,10 +351,106 @@ func TestParseSequence(t *testing.T) {
 			 |kevt.name = 'CreateProcess'| as e1
 			 |kevt.name = 'CreateFile' and $e1.ps.ame = file.name |
 			`,
-			errors.New("maximum span 40h0m0s cannot be greater than 4h"),
+			errors.New("maximum span 40h0m0s cannot be greater than 4h0m0s"),
 			time.Hour * 40,
 			false,
 		},
+		{
+
+			`maxspan 1m
+			 by ps.uuid
+			 |kevt.name = 'CreateProcess'|
+			 not |kevt.name = 'TerminateProcess'|
+			 |kevt.name = 'CreateFile'|
+			`,
+			nil,
+			time.Minute,
+			true,
+		},
+		{
+
+			`maxspan 1m
+			 |kevt.name = 'CreateProcess'|
+			 not |kevt.name = 'LoadImage'|
+			`,
+			nil,
+			time.Minute,
+			false,
+		},
+		{
+
+			`|kevt.name = 'CreateProcess'|
+			 not |kevt.name = 'LoadImage'|
+			`,
+			errors.New("trailing absence expressions require the 'maxspan' statement"),
+			time.Duration(0),
+			false,
+		},
+		{
+
+			`maxspan 1m
+			 not |kevt.name = 'CreateProcess'|
+			 |kevt.name = 'LoadImage'|
+			`,
+			errors.New("sequences can't start with the absence expression"),
+			time.Minute,
+			false,
+		},
+		{
+
+			`maxspan 1m
+			 |kevt.name = 'CreateProcess'|
+			 not |kevt.name = 'LoadImage'| as e1
+			`,
+			errors.New("absence expressions can't be aliased"),
+			time.Minute,
+			false,
+		},
+		{
+
+			`maxspan 1m
+			 by ps.uuid
+			 |kevt.name = 'CreateProcess'|
+			 |kevt.name = 'LoadImage'|
+			 until |kevt.name = 'TerminateProcess'|
+			`,
+			nil,
+			time.Minute,
+			true,
+		},
+		{
+
+			`maxspan 1m
+			 |kevt.name = 'CreateProcess'| by ps.uuid
+			 |kevt.name = 'LoadImage'| by ps.uuid
+			 until |kevt.name = 'TerminateProcess'| by ps.uuid
+			`,
+			nil,
+			time.Minute,
+			true,
+		},
+		{
+
+			`maxspan 1m
+			 |kevt.name = 'CreateProcess'| by ps.uuid
+			 |kevt.name = 'LoadImage'| by ps.uuid
+			 until |kevt.name = 'TerminateProcess'|
+			`,
+			errors.New("until expression requires the 'by' statement"),
+			time.Minute,
+			true,
+		},
+		{
+
+			`maxspan 1m
+			 |kevt.name = 'CreateProcess'|
+			 until |kevt.name = 'TerminateProcess'|
+			 |kevt.name = 'LoadImage'|
+			`,
+			errors.New("expected EOF"),
+			time.Minute,
+			false,
+		},
 	}
 
 	for i, tt := range tests {
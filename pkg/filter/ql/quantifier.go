@@ -0,0 +1,144 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"net"
+	"strings"
+)
+
+const (
+	anyQuantifier  = "any"
+	allQuantifier  = "all"
+	noneQuantifier = "none"
+)
+
+// isQuantifier determines if the identifier names the list quantifier.
+func isQuantifier(id string) bool {
+	switch strings.ToLower(id) {
+	case anyQuantifier, allQuantifier, noneQuantifier:
+		return true
+	}
+	return false
+}
+
+// quantify applies the predicate to the list elements. The evaluation
+// stops as soon as the outcome of the quantifier is known. Nil is returned
+// if the value is not a list. Empty lists satisfy the `all` and `none`
+// quantifiers.
+func quantify(name string, list interface{}, pred func(elem interface{}) bool) interface{} {
+	// any and none quantifiers are decided by the first
+	// matching element, while the all quantifier is decided
+	// by the first element that doesn't match
+	decisive := name != allQuantifier
+	decided := false
+	ok := forEach(list, func(elem interface{}) bool {
+		if pred(elem) == decisive {
+			decided = true
+			return false
+		}
+		return true
+	})
+	if !ok {
+		return nil
+	}
+	switch name {
+	case anyQuantifier:
+		return decided
+	case allQuantifier, noneQuantifier:
+		return !decided
+	}
+	return nil
+}
+
+// forEach calls the function for each list element until the function
+// returns false. It returns false if the value is not a list.
+func forEach(list interface{}, fn func(elem interface{}) bool) bool {
+	switch list := list.(type) {
+	case []string:
+		each(list, fn)
+	case []uint16:
+		each(list, fn)
+	case []uint32:
+		each(list, fn)
+	case []uint64:
+		each(list, fn)
+	case []int64:
+		each(list, fn)
+	case []float64:
+		each(list, fn)
+	case []net.IP:
+		each(list, fn)
+	case []map[string]interface{}:
+		each(list, fn)
+	case []interface{}:
+		each(list, fn)
+	default:
+		return false
+	}
+	return true
+}
+
+func each[T any](list []T, fn func(elem interface{}) bool) {
+	for _, elem := range list {
+		if !fn(elem) {
+			return
+		}
+	}
+}
+
+// elementValuer binds the quantifier variable to the current list element.
+// The variable resolves to the element, and the variable sub-fields resolve
+// to the element fields if the element is structured. The rest of the values
+// are delegated to the enclosing valuer.
+type elementValuer struct {
+	Valuer
+	name string
+	elem interface{}
+}
+
+// Value returns the element or the element field for the variable reference.
+func (e *elementValuer) Value(key string) (interface{}, bool) {
+	if !strings.HasPrefix(key, e.name) {
+		return e.Valuer.Value(key)
+	}
+	if len(key) == len(e.name) {
+		return e.elem, true
+	}
+	if key[len(e.name)] != '.' {
+		return e.Valuer.Value(key)
+	}
+	key = key[len(e.name)+1:]
+	switch elem := e.elem.(type) {
+	case map[string]interface{}:
+		v, ok := elem[key]
+		return v, ok
+	case Valuer:
+		return elem.Value(key)
+	}
+	return nil, false
+}
+
+// Call delegates the function call to the enclosing valuer.
+func (e *elementValuer) Call(name string, args []interface{}) (interface{}, bool) {
+	if valuer, ok := e.Valuer.(CallValuer); ok {
+		return valuer.Call(name, args)
+	}
+	return nil, false
+}
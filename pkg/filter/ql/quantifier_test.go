@@ -0,0 +1,110 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuantifiers(t *testing.T) {
+	m := map[string]interface{}{
+		"ps.name":                      "svchost.exe",
+		"ps.modules":                   []string{"C:\\Windows\\System32\\kernel32.dll", "C:\\Users\\admin\\AppData\\Local\\Temp\\inject.dll"},
+		"ps.args":                      []string{"-k", "RPCSS"},
+		"ps.envs":                      []string{},
+		"thread.callstack.protections": []string{"RX", "RX", "RWX"},
+		"pe.symbols":                   []string{"GetProcAddress", "LoadLibraryA"},
+		"pe.sections": []map[string]interface{}{
+			{"name": ".text", "entropy": 6.36, "size": uint32(132608)},
+			{"name": ".rsrc", "entropy": 7.91, "size": uint32(512)},
+		},
+	}
+
+	var tests = []struct {
+		expr    string
+		matches bool
+	}{
+		{`any(ps.modules, m -> m imatches '*\\temp\\*')`, true},
+		{`any(ps.modules, m -> m imatches '*\\downloads\\*')`, false},
+		{`ANY(ps.modules, m -> m iendswith '.dll')`, true},
+		{`all(ps.modules, m -> m iendswith '.dll')`, true},
+		{`all(ps.modules, m -> m istartswith 'c:\\windows\\')`, false},
+		{`none(ps.modules, m -> m istartswith 'c:\\windows\\')`, false},
+		{`none(ps.args, a -> a = '-s')`, true},
+		{`all(thread.callstack.protections, p -> p != 'RWX')`, false},
+		{`any(thread.callstack.protections, p -> p = 'RWX')`, true},
+		{`any(pe.sections, s -> s.name = '.text' and s.entropy > 6)`, true},
+		{`any(pe.sections, s -> s.name = '.text' and s.entropy > 7)`, false},
+		{`any(pe.sections, s -> s.entropy > 7.5 and s.size < 1024)`, true},
+		{`all(pe.sections, s -> s.name startswith '.')`, true},
+		{`any(pe.sections, s -> s.missing = 1)`, false},
+		{`any(ps.modules, m -> lower(m) contains ps.name)`, false},
+		{`any(ps.modules, m -> length(m) > 40)`, true},
+		{`any(ps.args, a -> any(pe.symbols, s -> s icontains 'load' and a = 'RPCSS'))`, true},
+		{`any(ps.args, a -> a = 'RPCSS') and ps.name = 'svchost.exe'`, true},
+		{`ps.name = 'svchost.exe' and not any(ps.args, a -> a = '-s')`, true},
+		{`ps.name = 'svchost.exe' and not all(ps.args, a -> a = '-k')`, true},
+		{`any(ps.envs, e -> e = 'PATH')`, false},
+		{`all(ps.envs, e -> e = 'PATH')`, true},
+		{`none(ps.envs, e -> e = 'PATH')`, true},
+		{`any(ps.name, c -> c = 's')`, false},
+		{`any(ps.handles, h -> h = 'x')`, false},
+		{`ps.name = 'svchost.exe' and not any(ps.handles, h -> h = 'x')`, false},
+		{`any(('cmd.exe', 'svchost.exe'), n -> n = ps.name)`, true},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.expr)
+		e, err := p.ParseExpr()
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.matches, Eval(e, m, true), tt.expr)
+		assert.Equal(t, tt.matches, Compile(e).Eval(MapValuer(m)), tt.expr)
+	}
+}
+
+func TestParseQuantifier(t *testing.T) {
+	var tests = []struct {
+		expr string
+		str  string
+		err  bool
+	}{
+		{expr: `any(ps.modules, m -> m imatches '*\\temp\\*')`, str: `any(ps.modules, m -> m IMATCHES *\temp\*)`},
+		{expr: `all(pe.sections, s -> s.entropy < 7.2)`, str: `all(pe.sections, s -> s.entropy < 7.2e+00)`},
+		{expr: `none(ps.args, ps -> ps.name = 'x')`, str: `none(ps.args, ps -> ps.name = x)`},
+		{expr: `any(ps.modules, m -> m = 'a.dll') and m = 'a.dll'`, err: true},
+		{expr: `any(ps.modules m -> m = 'a.dll')`, err: true},
+		{expr: `any(ps.modules, m.x -> m = 'a.dll')`, err: true},
+		{expr: `any(ps.modules, m => m = 'a.dll')`, err: true},
+		{expr: `any(ps.modules, m -> m = 'a.dll'`, err: true},
+		{expr: `any(ps.modules, m -> x = 'a.dll')`, err: true},
+	}
+
+	for _, tt := range tests {
+		expr, err := NewParser(tt.expr).ParseExpr()
+		if tt.err {
+			assert.Error(t, err, tt.expr)
+			continue
+		}
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.str, expr.String())
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
+)
+
+// tablePrefix is the prefix of lookup table references
+const tablePrefix = "$lists."
+
+// isTableRef determines if the token references the lookup table.
+func isTableRef(tok token, lit string) bool {
+	return tok == BoundField && strings.HasPrefix(lit, tablePrefix)
+}
+
+// tableMatcher returns the membership test resolved via the
+// table index. It returns nil if the operator is not the
+// membership operator.
+func tableMatcher(op token, table *lists.Table) func(string) bool {
+	switch op {
+	case In:
+		return table.Contains
+	case IIn:
+		return table.ContainsFold
+	}
+	return nil
+}
+
+// evalTable evaluates the operator with the lookup table on the
+// right-hand side. Membership tests are resolved via the table
+// index, while the rest of operators are evaluated against the
+// list of table keys.
+func evalTable(op token, lhs interface{}, table *lists.Table) interface{} {
+	contains := tableMatcher(op, table)
+	if contains == nil {
+		return evalBinary(op, lhs, table.Keys())
+	}
+	switch lhs := lhs.(type) {
+	case nil:
+		return nil
+	case string:
+		return contains(lhs)
+	case []string:
+		for _, s := range lhs {
+			if contains(s) {
+				return true
+			}
+		}
+		return false
+	case net.IP:
+		return contains(lhs.String())
+	case uint8, uint16, uint32, uint64, int, int32, int64:
+		return contains(fmt.Sprint(lhs))
+	}
+	return false
+}
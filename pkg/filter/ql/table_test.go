@@ -0,0 +1,124 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ql
+
+import (
+	"net"
+	"testing"
+
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTables(t *testing.T) {
+	lists.Register("bad_domains", map[string]lists.Row{"evil.com": nil, "malware.net": nil})
+	lists.Register("c2_servers", map[string]lists.Row{"10.0.0.1": nil})
+	lists.Register("pids", map[string]lists.Row{"4": nil})
+	lists.Register("vendors", map[string]lists.Row{
+		"Microsoft Corporation": {"trusted": true, "score": int64(10)},
+		"Evil Corp":             {"trusted": false, "score": int64(90)},
+	})
+
+	m := map[string]interface{}{
+		"dns.name":   "evil.com",
+		"file.name":  "EVIL.COM",
+		"net.dip":    net.ParseIP("10.0.0.1"),
+		"ps.pid":     uint32(4),
+		"ps.args":    []string{"-k", "malware.net"},
+		"pe.company": "Microsoft Corporation",
+	}
+
+	var tests = []struct {
+		expr    string
+		matches bool
+	}{
+		{`dns.name in $lists.bad_domains`, true},
+		{`dns.name not in $lists.bad_domains`, false},
+		{`file.name in $lists.bad_domains`, false},
+		{`file.name iin $lists.bad_domains`, true},
+		{`net.dip in $lists.c2_servers`, true},
+		{`ps.pid in $lists.pids`, true},
+		{`ps.args in $lists.bad_domains`, true},
+		{`pe.company in $lists.bad_domains`, false},
+		{`dns.name iendswith $lists.bad_domains`, true},
+		{`dns.name contains $lists.bad_domains`, true},
+		{`dns.name startswith $lists.c2_servers`, false},
+		{`any(ps.args, a -> a in $lists.bad_domains)`, true},
+		{`ps.name in $lists.bad_domains`, false},
+		{`lookup('vendors', pe.company, 'trusted') = true`, true},
+		{`lookup('vendors', pe.company, 'score') < 50`, true},
+		{`lookup('vendors', 'evil corp', 'score') > 50`, true},
+		{`lookup('vendors', pe.company, 'missing') = 1`, false},
+		{`lookup('vendors', dns.name, 'trusted') = true`, false},
+		{`lookup('vendors', pe.company)`, true},
+		{`lookup('bad_domains', upper(dns.name))`, true},
+		{`lookup('bad_domains', pe.company)`, false},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.expr)
+		e, err := p.ParseExpr()
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.matches, Eval(e, m, true), tt.expr)
+		assert.Equal(t, tt.matches, Compile(e).Eval(MapValuer(m)), tt.expr)
+	}
+
+	// refreshing the table is visible to parsed expressions
+	e, err := NewParser(`dns.name in $lists.bad_domains`).ParseExpr()
+	require.NoError(t, err)
+	prog := Compile(e)
+	lists.Register("bad_domains", map[string]lists.Row{"malware.net": nil})
+	assert.False(t, Eval(e, m, true))
+	assert.False(t, prog.Eval(MapValuer(m)))
+}
+
+func TestParseTable(t *testing.T) {
+	lists.Register("bad_domains", map[string]lists.Row{"evil.com": nil})
+
+	var tests = []struct {
+		expr string
+		str  string
+		err  bool
+	}{
+		{expr: `dns.name in $lists.bad_domains`, str: `dns.name IN $lists.bad_domains`},
+		{expr: `dns.name iin $lists.bad_domains or ps.name = 'cmd.exe'`, str: `dns.name IIN $lists.bad_domains OR ps.name = cmd.exe`},
+		{expr: `lookup('bad_domains', dns.name)`, str: `lookup(bad_domains, dns.name)`},
+		{expr: `dns.name in $lists.good_domains`, err: true},
+		{expr: `lookup('good_domains', dns.name)`, err: true},
+		{expr: `lookup(dns.name, 'bad_domains')`, err: true},
+		{expr: `lookup('bad_domains')`, err: true},
+	}
+
+	for _, tt := range tests {
+		expr, err := NewParser(tt.expr).ParseExpr()
+		if tt.err {
+			assert.Error(t, err, tt.expr)
+			continue
+		}
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.str, expr.String())
+	}
+
+	// the table reference is accepted where the list macro is expected
+	c := config.FiltersWithMacros(map[string]*config.Macro{"domains": {List: []string{"evil.com"}}})
+	_, err := NewParserWithConfig(`dns.name in $lists.bad_domains or dns.name in domains`, c).ParseExpr()
+	require.NoError(t, err)
+}
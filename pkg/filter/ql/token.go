@@ -70,6 +70,14 @@ const (
 	Lte         // <=
 	Gt          // >
 	Gte         // >=
+	Add         // +
+	Sub         // -
+	Mul         // *
+	Div         // /
+	Mod         // %
+	BitAnd      // &
+	BitOr       // |
+	BitXor      // ^
 	opEnd
 
 	Lparen // (
@@ -77,11 +85,13 @@ const (
 	Comma  // ,
 	Dot    // .
 	Pipe   // |
+	Arrow  // ->
 
 	Seq     // SEQUENCE
 	MaxSpan // MAXSPAN
 	By      // BY
 	As      // AS
+	Until   // UNTIL
 )
 
 var keywords map[string]token
@@ -91,7 +101,7 @@ func init() {
 	for _, tok := range []token{And, Or, Contains, IContains, In,
 		IIn, Not, Startswith, IStartswith, Endswith, IEndswith,
 		Matches, IMatches, Fuzzy, IFuzzy, Fuzzynorm, IFuzzynorm,
-		Seq, MaxSpan, By, As} {
+		Seq, MaxSpan, By, As, Until} {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
 	keywords["true"] = True
@@ -143,21 +153,35 @@ var tokens = [...]string{
 	Gt:  ">",
 	Gte: ">=",
 
+	Add:    "+",
+	Sub:    "-",
+	Mul:    "*",
+	Div:    "/",
+	Mod:    "%",
+	BitAnd: "&",
+	BitOr:  "|",
+	BitXor: "^",
+
 	Lparen: "(",
 	Rparen: ")",
 	Comma:  ",",
 	Dot:    ".",
 	Pipe:   "|",
+	Arrow:  "->",
 
 	Seq:     "SEQUENCE",
 	MaxSpan: "MAXSPAN",
 	By:      "BY",
 	As:      "AS",
+	Until:   "UNTIL",
 }
 
 // isOperator determines whether the current token is an operator.
 func (tok token) isOperator() bool { return tok > opBeg && tok < opEnd }
 
+// isArithmetic determines whether the current token is an arithmetic or bitwise operator.
+func (tok token) isArithmetic() bool { return tok >= Add && tok <= BitXor }
+
 // String returns the string representation of the token.
 func (tok token) String() string {
 	if tok >= 0 && tok < token(len(tokens)) {
@@ -180,6 +204,10 @@ func (tok token) precedence() int {
 	case In, IIn, Contains, IContains, Startswith, IStartswith, Endswith, IEndswith,
 		Matches, IMatches, Fuzzy, IFuzzy, Fuzzynorm, IFuzzynorm:
 		return 5
+	case Add, Sub, BitOr, BitXor:
+		return 6
+	case Mul, Div, Mod, BitAnd:
+		return 7
 	}
 	return 0
 }
@@ -44,6 +44,9 @@ func Walk(v Visitor, node Node) {
 		}
 	case *ParenExpr:
 		Walk(v, n.Expr)
+	case *Quantifier:
+		Walk(v, n.List)
+		Walk(v, n.Expr)
 	}
 }
 
@@ -0,0 +1,289 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"expvar"
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/util/hostname"
+	log "github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRiskEntities determines the maximum number of tracked risk entities
+	maxRiskEntities = 50000
+	// maxRiskEntityRules determines the maximum number of contributing rules retained per entity
+	maxRiskEntityRules = 10
+	// minRiskScore is the score under which entities without recent tactics are discarded
+	minRiskScore = 1
+)
+
+var (
+	riskAlerts         = expvar.NewMap("risk.alerts.emitted")
+	riskEntitiesCount  = expvar.NewInt("risk.entities.count")
+	riskEntityBreaches = expvar.NewInt("risk.entity.breaches")
+)
+
+// riskEntity keeps the accumulated risk of the process, user, or host.
+type riskEntity struct {
+	kind string
+	name string
+	// score is the decayed risk score as of the last update
+	score float64
+	// updated is the timestamp of the last score update
+	updated time.Time
+	// tactics contains the last occurrence of each MITRE tactic indexed by the tactic id
+	tactics map[string]time.Time
+	// tacticNames maps tactic ids to their display names
+	tacticNames map[string]string
+	// rules contains the names of rules that contributed to the score
+	rules []string
+	// alerted indicates if the risk alert was emitted for the entity. It
+	// is reset when the entity drops below the alert conditions
+	alerted bool
+}
+
+// decay halves the entity score for every half-life elapsed since
+// the last update and discards tactics outside the tactics window.
+func (e *riskEntity) decay(ts time.Time, halfLife, window time.Duration) {
+	if elapsed := ts.Sub(e.updated); elapsed > 0 {
+		e.score *= math.Exp2(-float64(elapsed) / float64(halfLife))
+		e.updated = ts
+	}
+	for tactic, seen := range e.tactics {
+		if ts.Sub(seen) > window {
+			delete(e.tactics, tactic)
+			delete(e.tacticNames, tactic)
+		}
+	}
+}
+
+func (e *riskEntity) addRule(name string) {
+	for _, r := range e.rules {
+		if r == name {
+			return
+		}
+	}
+	if len(e.rules) >= maxRiskEntityRules {
+		e.rules = e.rules[1:]
+	}
+	e.rules = append(e.rules, name)
+}
+
+// riskAlert describes the entity that crossed the risk threshold.
+type riskAlert struct {
+	kind  string
+	name  string
+	score float64
+	// tactics contains the ids of tactics the entity is implicated in
+	tactics []string
+	// tacticNames maps tactic ids to their display names
+	tacticNames map[string]string
+	rules       []string
+}
+
+func (a riskAlert) title() string {
+	return fmt.Sprintf("Elevated risk for %s %s", a.kind, a.name)
+}
+
+func (a riskAlert) text() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s accumulated the risk score of %.0f", a.kind, a.name, a.score))
+	if len(a.tactics) > 0 {
+		tactics := make([]string, len(a.tactics))
+		for i, id := range a.tactics {
+			tactics[i] = id
+			if name := a.tacticNames[id]; name != "" {
+				tactics[i] = fmt.Sprintf("%s (%s)", name, id)
+			}
+		}
+		b.WriteString(fmt.Sprintf(" across %d tactic(s): %s", len(tactics), strings.Join(tactics, ", ")))
+	}
+	b.WriteString(fmt.Sprintf(". Contributing rules: %s", strings.Join(a.rules, ", ")))
+	return b.String()
+}
+
+// riskScorer accumulates risk scores of entities implicated in rule
+// matches. The score is derived from the rule severity and decays
+// over time. When the entity score crosses the threshold, or the
+// entity is implicated in enough distinct tactics, the risk alert
+// is emitted.
+type riskScorer struct {
+	config   config.Risk
+	entities map[string]*riskEntity
+	kinds    map[string]bool
+	// mu guards the entities map
+	mu sync.Mutex
+
+	// alert is called when the entity crosses the risk threshold
+	alert func(ctx *config.ActionContext, a riskAlert)
+}
+
+func newRiskScorer(c config.Risk, alert func(*config.ActionContext, riskAlert)) *riskScorer {
+	r := &riskScorer{
+		config:   c,
+		entities: make(map[string]*riskEntity),
+		kinds:    make(map[string]bool),
+		alert:    alert,
+	}
+	for _, kind := range c.GetEntities() {
+		r.kinds[kind] = true
+	}
+	return r
+}
+
+// entitiesOf returns the kinds and names of entities referenced by the event.
+func (r *riskScorer) entitiesOf(evt *kevent.Kevent) [][2]string {
+	entities := make([][2]string, 0)
+	if r.kinds["process"] && evt.PS != nil {
+		ps := evt.PS
+		for depth := 0; ps != nil && depth <= r.config.ProcessDepth; depth++ {
+			entities = append(entities, [2]string{"process", fmt.Sprintf("%s (%d)", ps.Name, ps.PID)})
+			ps = ps.Parent
+		}
+	}
+	if r.kinds["user"] && evt.PS != nil {
+		switch {
+		case evt.PS.Username != "" && evt.PS.Domain != "":
+			entities = append(entities, [2]string{"user", evt.PS.Domain + "\\" + evt.PS.Username})
+		case evt.PS.Username != "":
+			entities = append(entities, [2]string{"user", evt.PS.Username})
+		case evt.PS.SID != "":
+			entities = append(entities, [2]string{"user", evt.PS.SID})
+		}
+	}
+	if r.kinds["host"] {
+		host := evt.Host
+		if host == "" {
+			host = hostname.Get()
+		}
+		entities = append(entities, [2]string{"host", host})
+	}
+	return entities
+}
+
+// add accumulates the risk of the rule match in all entities
+// referenced by the event that triggered the rule.
+func (r *riskScorer) add(ctx *config.ActionContext) {
+	if len(ctx.Events) == 0 {
+		return
+	}
+	evt := ctx.Events[len(ctx.Events)-1]
+	ts := evt.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	score := r.config.GetScore(ctx.Filter.Severity)
+	tactic := ctx.Group.Labels["tactic.id"]
+	tacticName := ctx.Group.Labels["tactic.name"]
+
+	alerts := make([]riskAlert, 0)
+
+	r.mu.Lock()
+	for _, ent := range r.entitiesOf(evt) {
+		key := ent[0] + ":" + strings.ToLower(ent[1])
+		e, ok := r.entities[key]
+		if !ok {
+			if len(r.entities) >= maxRiskEntities {
+				riskEntityBreaches.Add(1)
+				continue
+			}
+			e = &riskEntity{kind: ent[0], name: ent[1], updated: ts, tactics: make(map[string]time.Time), tacticNames: make(map[string]string)}
+			r.entities[key] = e
+			riskEntitiesCount.Add(1)
+		}
+		e.decay(ts, r.config.GetHalfLife(), r.config.GetTacticsWindow())
+		if e.alerted && !r.exceeds(e) {
+			e.alerted = false
+		}
+		e.score += score
+		if tactic != "" {
+			e.tactics[tactic] = ts
+			if tacticName != "" {
+				e.tacticNames[tactic] = tacticName
+			}
+		}
+		e.addRule(ctx.Filter.Name)
+		if !e.alerted && r.exceeds(e) {
+			e.alerted = true
+			alerts = append(alerts, r.alertFor(e))
+		}
+	}
+	r.mu.Unlock()
+
+	for _, a := range alerts {
+		log.Infof("%s %s crossed the risk threshold with score %.0f", a.kind, a.name, a.score)
+		riskAlerts.Add(a.kind, 1)
+		if r.alert != nil {
+			r.alert(ctx, a)
+		}
+	}
+}
+
+// exceeds determines if the entity meets the risk alert conditions.
+func (r *riskScorer) exceeds(e *riskEntity) bool {
+	return e.score >= r.config.GetThreshold() || len(e.tactics) >= r.config.GetTactics()
+}
+
+func (r *riskScorer) alertFor(e *riskEntity) riskAlert {
+	tactics := make([]string, 0, len(e.tactics))
+	for tactic := range e.tactics {
+		tactics = append(tactics, tactic)
+	}
+	sort.Strings(tactics)
+	names := make(map[string]string, len(e.tacticNames))
+	for id, name := range e.tacticNames {
+		names[id] = name
+	}
+	rules := make([]string, len(e.rules))
+	copy(rules, e.rules)
+	return riskAlert{kind: e.kind, name: e.name, score: e.score, tactics: tactics, tacticNames: names, rules: rules}
+}
+
+// score returns the decayed score of the entity as of the given time.
+func (r *riskScorer) score(kind, name string, ts time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entities[kind+":"+strings.ToLower(name)]
+	if !ok {
+		return 0
+	}
+	e.decay(ts, r.config.GetHalfLife(), r.config.GetTacticsWindow())
+	return e.score
+}
+
+// gc discards entities with negligible scores and no recent tactics.
+func (r *riskScorer) gc(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, e := range r.entities {
+		e.decay(now, r.config.GetHalfLife(), r.config.GetTacticsWindow())
+		if e.score < minRiskScore && len(e.tactics) == 0 {
+			delete(r.entities, key)
+			riskEntitiesCount.Add(-1)
+		}
+	}
+}
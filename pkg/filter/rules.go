@@ -25,6 +25,7 @@ import (
 	fsm "github.com/qmuntal/stateless"
 	"github.com/rabbitstack/fibratus/pkg/filter/action"
 	"github.com/rabbitstack/fibratus/pkg/filter/fields"
+	"github.com/rabbitstack/fibratus/pkg/filter/lists"
 	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
 	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
 	"github.com/rabbitstack/fibratus/pkg/ps"
@@ -43,11 +44,6 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	// maxOutstandingPartials determines the maximum number of partials per sequence index
-	maxOutstandingPartials = 1000
-)
-
 var (
 	filterMatches     = expvar.NewMap("filter.matches")
 	filterGroupsCount = expvar.NewInt("filter.groups.count")
@@ -57,6 +53,8 @@ var (
 	partialsPerSequence   = expvar.NewMap("sequence.partials.count")
 	partialExpirations    = expvar.NewMap("sequence.partial.expirations")
 	partialBreaches       = expvar.NewMap("sequence.partial.breaches")
+	partialRetractions    = expvar.NewMap("sequence.partial.retractions")
+	partialTerminations   = expvar.NewMap("sequence.partial.terminations")
 
 	ErrInvalidFilter = func(rule, group string, err error) error {
 		return fmt.Errorf("syntax error in rule %q located in %q group: \n%v", rule, group, err)
@@ -64,6 +62,15 @@ var (
 	ErrRuleAction = func(rule string, err error) error {
 		return fmt.Errorf("fail to execute action for %q rule: %v", rule, err)
 	}
+	ErrInvalidAction = func(rule string, err error) error {
+		return fmt.Errorf("invalid action in %q rule: %v", rule, err)
+	}
+	// ErrTagCompactedPartials is raised when the tag action is declared in
+	// the sequence rule whose partials are compacted. Compacted events are
+	// copies restored on rule match, so tags added to them never reach outputs
+	ErrTagCompactedPartials = func(rule string) error {
+		return fmt.Errorf("tag action is not supported in %q sequence rule when partials are compacted", rule)
+	}
 	ErrIncompatibleFilter = func(rule, v string) error {
 		return fmt.Errorf("rule %q needs engine version [%s] but current version is [%s]", rule, v, version.Get())
 	}
@@ -110,14 +117,34 @@ type Rules struct {
 	config *config.Config
 	psnap  ps.Snapshotter
 
-	matches   []*ruleMatch
-	sequences []*sequenceState
+	matches    []*ruleMatch
+	sequences  []*sequenceState
+	thresholds []*thresholdState
+	// suppressors throttle alerts of noisy rules
+	suppressors map[*config.FilterConfig]*suppressor
+	// actions contains the actions declared in rules
+	actions map[*config.FilterConfig][]action.Action
+	// recorded contains the event type and category hashes
+	// of rules with actions that need recent events retained
+	recorded map[uint32]bool
+	// risk accumulates entity risk scores from rule matches
+	risk *riskScorer
+	// dryRun indicates if rule actions are skipped
+	dryRun bool
+	// onMatch is invoked for every rule match
+	onMatch func(ctx *config.ActionContext)
+	// mu guards the rule matches and action execution
+	mu sync.Mutex
 
 	scavenger *time.Ticker
+	quit      chan struct{}
 }
 
 type ruleMatch struct {
 	ctx *config.ActionContext
+	// values contains the field values captured
+	// from compacted partials of each event
+	values []map[fields.Field]any
 }
 
 type filterGroup struct {
@@ -128,6 +155,7 @@ type filterGroup struct {
 type compiledFilter struct {
 	filter Filter
 	ss     *sequenceState
+	ts     *thresholdState
 	config *config.FilterConfig
 }
 
@@ -139,16 +167,23 @@ type compiledFilter struct {
 type sequenceState struct {
 	name    string
 	maxSpan time.Duration
+	// maxPartials determines the maximum number of partials per sequence index
+	maxPartials int
+	// lifetime is the maximum time partials of unbounded sequences are retained
+	lifetime time.Duration
+	// compactor, if set, reduces the partial to the state
+	// required to join it with the downstream partials
+	compactor *partialCompactor
 
 	// partials keeps the state of all matched events per expression
-	partials map[uint16][]*kevent.Kevent
+	partials map[uint16][]*Partial
 	// mu guards the partials map
 	mu sync.RWMutex
 
-	// matches stores only the event that matched
-	// the upstream partials. These events will be propagated
+	// matches stores only the partials that matched
+	// the upstream partials. Their events will be propagated
 	// in the rule action context
-	matches map[uint16]*kevent.Kevent
+	matches map[uint16]*Partial
 	// mmu guards the matches map
 	mmu sync.RWMutex
 
@@ -166,19 +201,57 @@ type sequenceState struct {
 	matchedRules map[uint16]bool
 	// mrm guards the matchedRules map
 	mrm sync.RWMutex
+
+	// slots contains the indices of all expressions
+	// except the absence expressions
+	slots []uint16
+	// negated designates the indices of absence expressions
+	negated map[uint16]bool
+	// absenceState is the state to which the last expression
+	// transitions if the sequence ends with absence expressions
+	absenceState fsm.State
+	// absences keeps the max span deadlines of the join
+	// keys whose partials await the trailing absence expressions.
+	// The absences are guarded by the partials lock
+	absences []*absence
+	// onAbsenceDeadline is invoked when the max span of the
+	// join key is reached and none of the trailing absence
+	// expressions matched
+	onAbsenceDeadline func(by any)
+	// eventTime indicates if absence deadlines are driven
+	// by event timestamps instead of wall clock timers
+	eventTime bool
+}
+
+// absence represents the max span deadline of
+// the partials joined by the same key that reached
+// the trailing absence expressions of the sequence.
+type absence struct {
+	by       any
+	deadline time.Time
+	timer    *time.Timer
+}
+
+func (a *absence) stop() {
+	if a.timer != nil {
+		a.timer.Stop()
+	}
 }
 
 func newSequenceState(name, initialState string, maxSpan time.Duration) *sequenceState {
 	ss := &sequenceState{
 		name:          name,
 		maxSpan:       maxSpan,
-		partials:      make(map[uint16][]*kevent.Kevent),
+		maxPartials:   config.DefaultMaxSequencePartials,
+		partials:      make(map[uint16][]*Partial),
 		matchedRules:  make(map[uint16]bool),
-		matches:       make(map[uint16]*kevent.Kevent),
+		matches:       make(map[uint16]*Partial),
 		idxs:          make(map[fsm.State]uint16),
 		spanDeadlines: make(map[fsm.State]*time.Timer),
 		initialState:  fsm.State(initialState),
 		inDeadline:    atomic.MakeBool(false),
+		slots:         make([]uint16, 0),
+		negated:       make(map[uint16]bool),
 	}
 
 	ss.initFSM(initialState)
@@ -186,10 +259,10 @@ func newSequenceState(name, initialState string, maxSpan time.Duration) *sequenc
 	return ss
 }
 
-func (s *sequenceState) events() []*kevent.Kevent {
+func (s *sequenceState) events() []*Partial {
 	s.mmu.RLock()
 	defer s.mmu.RUnlock()
-	events := make([]*kevent.Kevent, 0, len(s.matches))
+	events := make([]*Partial, 0, len(s.matches))
 	for _, e := range s.matches {
 		events = append(events, e)
 	}
@@ -197,7 +270,43 @@ func (s *sequenceState) events() []*kevent.Kevent {
 	return events
 }
 
+// event returns the event of the partial that is handed
+// to rule actions. Compacted partials are restored with
+// the process state resolved from the snapshotter.
+func (s *sequenceState) event(p *Partial) *kevent.Kevent {
+	if !p.compacted || s.compactor == nil {
+		return p.Kevent
+	}
+	return s.compactor.restore(p)
+}
+
+// upstream returns the index of the closest
+// upstream expression which is not negated.
+func (s *sequenceState) upstream(idx uint16) uint16 {
+	for i := idx - 1; i > 0; i-- {
+		if !s.negated[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+// downstream returns the index of the closest downstream expression
+// which is not negated. Zero is returned if no such expression exists.
+func (s *sequenceState) downstream(idx uint16) uint16 {
+	for _, i := range s.slots {
+		if i > idx {
+			return i
+		}
+	}
+	return 0
+}
+
 func (s *sequenceState) isStateSchedulable(state fsm.State) bool {
+	// deadlines of the absence state are scheduled per join key
+	if s.absenceState != nil && state == s.absenceState {
+		return false
+	}
 	return state != s.initialState && state != sequenceTerminalState && state != sequenceExpiredState && state != sequenceDeadlineState
 }
 
@@ -276,11 +385,11 @@ func (s *sequenceState) currentState() fsm.State {
 	return s.fsm.MustState()
 }
 
-func (s *sequenceState) addPartial(rule string, kevt *kevent.Kevent, outOfOrder bool) {
+func (s *sequenceState) addPartial(rule string, kevt *kevent.Kevent, by any, outOfOrder bool) {
 	i := s.idxs[rule]
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if len(s.partials[i]) > maxOutstandingPartials {
+	if len(s.partials[i]) >= s.maxPartials {
 		partialBreaches.Add(s.name, 1)
 		log.Warnf("max partials encountered in sequence %s slot [%d]. "+
 			"Dropping incoming partial", s.name, s.idxs[rule])
@@ -289,7 +398,7 @@ func (s *sequenceState) addPartial(rule string, kevt *kevent.Kevent, outOfOrder
 	key := kevt.PartialKey()
 	if key != 0 {
 		for _, p := range s.partials[i] {
-			if key == p.PartialKey() {
+			if key == p.key {
 				log.Debugf("%s event tuple already in sequence state", kevt.Name)
 				return
 			}
@@ -301,8 +410,318 @@ func (s *sequenceState) addPartial(rule string, kevt *kevent.Kevent, outOfOrder
 	}
 	log.Debugf("adding partial to slot [%d] for rule %q: %s", i, rule, kevt)
 	partialsPerSequence.Add(s.name, 1)
-	s.partials[i] = append(s.partials[i], kevt)
+	p := &Partial{Kevent: kevt, By: by, key: key}
+	// out-of-order partials are evaluated again once the upstream
+	// expressions match, and the partial of the last expression
+	// completes the sequence right away, so they retain the event
+	if s.compactor != nil && !outOfOrder && (s.absenceState != nil || i != s.slots[len(s.slots)-1]) {
+		s.compactor.compact(p)
+	}
+	s.partials[i] = append(s.partials[i], p)
 	sort.Slice(s.partials[i], func(n, m int) bool { return s.partials[i][n].Timestamp.Before(s.partials[i][m].Timestamp) })
+	if !outOfOrder && s.isLastSlot(i) {
+		s.scheduleAbsenceDeadline(p)
+	}
+}
+
+// isLastSlot determines if the slot is the last non-negated
+// expression of the sequence ending with absence expressions.
+func (s *sequenceState) isLastSlot(i uint16) bool {
+	return s.absenceState != nil && len(s.slots) > 0 && i == s.slots[len(s.slots)-1]
+}
+
+// joins determines if the partial is joined by the given key.
+// Partials of sequences without join fields share the nil key.
+func joins(p *Partial, by any) bool {
+	return by == nil || compareSeqJoin(p.By, by)
+}
+
+// findAbsence returns the position of the absence deadline
+// armed for the join key or -1 if the key has no deadline.
+func (s *sequenceState) findAbsence(by any) int {
+	for n, a := range s.absences {
+		if (a.by == nil && by == nil) || compareSeqJoin(a.by, by) {
+			return n
+		}
+	}
+	return -1
+}
+
+// scheduleAbsenceDeadline arms the max span deadline for the join
+// key of the partial that reached the trailing absence expressions,
+// unless the key is already awaiting its deadline. The max span is
+// measured from the earliest partial of the key in the first slot.
+// The caller must hold the partials lock.
+func (s *sequenceState) scheduleAbsenceDeadline(p *Partial) {
+	by := p.By
+	if s.findAbsence(by) >= 0 {
+		return
+	}
+	start := p.Timestamp
+	for _, first := range s.partials[s.slots[0]] {
+		if joins(first, by) {
+			if first.Timestamp.Before(start) {
+				start = first.Timestamp
+			}
+			break
+		}
+	}
+	a := &absence{by: by, deadline: start.Add(s.maxSpan)}
+	span := a.deadline.Sub(p.Timestamp)
+	switch {
+	case p.Timestamp.IsZero():
+		span = s.maxSpan
+	case span < 0:
+		span = 0
+	}
+	s.absences = append(s.absences, a)
+	if s.eventTime {
+		return
+	}
+	log.Debugf("scheduling absence deadline of %v for key %v in sequence %s", span, by, s.name)
+	a.timer = time.AfterFunc(span, func() {
+		if s.onAbsenceDeadline != nil {
+			log.Infof("max span of %v reached for absence rule %s", s.maxSpan, s.absenceState)
+			s.onAbsenceDeadline(a.by)
+		}
+	})
+}
+
+// pruneAbsences disarms the deadlines of the join keys
+// which no longer have partials in the last slot, e.g.
+// when the partials were retracted or garbage collected.
+// The caller must hold the partials lock.
+func (s *sequenceState) pruneAbsences() {
+	if len(s.absences) == 0 {
+		return
+	}
+	last := s.slots[len(s.slots)-1]
+	absences := s.absences[:0]
+	for _, a := range s.absences {
+		var pending bool
+		for _, p := range s.partials[last] {
+			if joins(p, a.by) {
+				pending = true
+				break
+			}
+		}
+		if !pending {
+			a.stop()
+			continue
+		}
+		absences = append(absences, a)
+	}
+	s.absences = absences
+}
+
+// dueAbsences returns the join keys whose
+// absence deadlines elapsed by the given time.
+func (s *sequenceState) dueAbsences(now time.Time) []any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]any, 0)
+	for _, a := range s.absences {
+		if !a.deadline.After(now) {
+			keys = append(keys, a.by)
+		}
+	}
+	return keys
+}
+
+// popAbsence disarms the absence deadline of the join key
+// and removes the partials joined by the key from all slots.
+// It returns the earliest joined partial of each slot and
+// the boolean value indicating whether every slot had the
+// joined partial. Once all partials are consumed, the state
+// machine transitions back to the initial state.
+func (s *sequenceState) popAbsence(by any) ([]*Partial, bool) {
+	s.mu.Lock()
+	n := s.findAbsence(by)
+	if n < 0 {
+		s.mu.Unlock()
+		return nil, false
+	}
+	s.absences[n].stop()
+	s.absences = append(s.absences[:n], s.absences[n+1:]...)
+
+	evts := make([]*Partial, 0, len(s.slots))
+	empty := true
+	for _, i := range s.slots {
+		var matched bool
+		partials := make([]*Partial, 0, len(s.partials[i]))
+		for _, p := range s.partials[i] {
+			if joins(p, by) && !p.ContainsMeta(kevent.RuleSequenceOutOfOrderKey) {
+				if !matched {
+					evts = append(evts, p)
+					matched = true
+				}
+				partialsPerSequence.Add(s.name, -1)
+				continue
+			}
+			partials = append(partials, p)
+		}
+		s.partials[i] = partials
+		if len(partials) > 0 {
+			empty = false
+		}
+	}
+	s.mu.Unlock()
+
+	if empty {
+		s.mrm.Lock()
+		s.matchedRules = make(map[uint16]bool)
+		s.mrm.Unlock()
+		if s.currentState() == s.absenceState {
+			if err := s.fsm.Fire(resetTransition); err != nil {
+				log.Warnf("unable to transition to initial state: %v", err)
+			}
+		}
+	}
+
+	return evts, len(evts) == len(s.slots)
+}
+
+// retract removes the partials of the closest upstream expression
+// which are joined with the event matching the absence expression.
+// Only partials that occurred before the event are retracted. If the
+// downstream expression already matched, the absence expression is
+// satisfied and no partials are removed. When all partials of the
+// upstream expression are retracted, the sequence is reset.
+func (s *sequenceState) retract(idx uint16, kevt *kevent.Kevent, by any) {
+	up, down := s.upstream(idx), s.downstream(idx)
+	s.mrm.RLock()
+	matched := down != 0 && s.matchedRules[down]
+	s.mrm.RUnlock()
+	if matched {
+		return
+	}
+
+	s.mu.Lock()
+	slots := []uint16{up}
+	// the trailing absence expression settles the sequence
+	// for the joined partials, so they are retracted from
+	// all slots
+	trailing := down == 0 && s.absenceState != nil
+	if trailing {
+		slots = s.slots
+	}
+	var retracted bool
+	for _, i := range slots {
+		partials := make([]*Partial, 0, len(s.partials[i]))
+		for _, p := range s.partials[i] {
+			if p.Timestamp.Before(kevt.Timestamp) && joins(p, by) {
+				log.Debugf("retracting partial from slot [%d] in sequence %s: %s", i, s.name, p)
+				partialsPerSequence.Add(s.name, -1)
+				continue
+			}
+			partials = append(partials, p)
+		}
+		if len(partials) < len(s.partials[i]) {
+			retracted = true
+		}
+		s.partials[i] = partials
+	}
+	s.pruneAbsences()
+	exhausted := true
+	for _, i := range slots {
+		if len(s.partials[i]) > 0 {
+			exhausted = false
+		}
+	}
+	s.mu.Unlock()
+
+	if !retracted || !exhausted {
+		return
+	}
+
+	log.Infof("absence expression matched in %q sequence. All partials retracted", s.name)
+	state := s.currentState()
+	if span, ok := s.spanDeadlines[state]; ok {
+		span.Stop()
+	}
+	partialRetractions.Add(s.name, 1)
+	// transitions to deadline state and
+	// from there back to initial state
+	if err := s.cancelTransition(state); err != nil {
+		log.Warnf("cancel transition failed: %v", err)
+	}
+	if err := s.fsm.Fire(resetTransition); err != nil {
+		log.Warnf("unable to transition to initial state: %v", err)
+	}
+}
+
+// terminate removes partials joined with the event that matched the
+// until expression. Unconstrained sequences discard all partials. If
+// the slot the sequence is waiting on is exhausted, the state machine
+// transitions back to the initial state.
+func (s *sequenceState) terminate(by any) bool {
+	s.mu.Lock()
+	var n int
+	for idx, partials := range s.partials {
+		retained := make([]*Partial, 0, len(partials))
+		for _, p := range partials {
+			if joins(p, by) {
+				log.Debugf("terminating partial from slot [%d] in sequence %s: %s", idx, s.name, p)
+				n++
+				continue
+			}
+			retained = append(retained, p)
+		}
+		s.partials[idx] = retained
+	}
+	s.pruneAbsences()
+	state := s.currentState()
+	exhausted := !s.isInitialState() && state != sequenceTerminalState &&
+		len(s.partials[s.upstream(s.idxs[state])]) == 0
+	s.mu.Unlock()
+
+	if n == 0 {
+		return false
+	}
+	partialsPerSequence.Add(s.name, -int64(n))
+	partialTerminations.Add(s.name, int64(n))
+	if !exhausted {
+		return true
+	}
+
+	log.Infof("until expression matched in %q sequence. All partials terminated", s.name)
+	if span, ok := s.spanDeadlines[state]; ok {
+		span.Stop()
+	}
+	if err := s.cancelTransition(state); err != nil {
+		log.Warnf("cancel transition failed: %v", err)
+	}
+	if err := s.fsm.Fire(resetTransition); err != nil {
+		log.Warnf("unable to transition to initial state: %v", err)
+	}
+	return true
+}
+
+// collectMatches stores the events of all partials
+// that are joined between adjacent sequence slots.
+func (s *sequenceState) collectMatches() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	setMatch := func(idx uint16, e *Partial) {
+		s.mmu.Lock()
+		defer s.mmu.Unlock()
+		if s.matches[idx] == nil {
+			s.matches[idx] = e
+		}
+	}
+
+	for n := 0; n < len(s.slots)-1; n++ {
+		i, j := s.slots[n], s.slots[n+1]
+		for _, outer := range s.partials[i] {
+			for _, inner := range s.partials[j] {
+				if compareSeqJoin(outer.By, inner.By) {
+					setMatch(i, outer)
+					setMatch(j, inner)
+				}
+			}
+		}
+	}
 }
 
 // gc prunes the sequence partial if it remained
@@ -313,6 +732,9 @@ func (s *sequenceState) gc() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	dur := s.maxSpan
+	if dur == 0 {
+		dur = s.lifetime
+	}
 	if dur == 0 {
 		dur = maxSequencePartialLifetime
 	}
@@ -328,6 +750,7 @@ func (s *sequenceState) gc() {
 			}
 		}
 	}
+	s.pruneAbsences()
 }
 
 // meetsTemporalDistance determines if the temporal occurrence of the
@@ -350,9 +773,42 @@ func (s *sequenceState) meetsTemporalDistance(rule string, kevt *kevent.Kevent,
 	return (isAfter && isBefore) || (kevt.Timestamp == s.partials[i][len(s.partials[i])-1].Timestamp && isBefore)
 }
 
+// meetsMaxSpan determines if the event occurred within the max span
+// of any upstream partial joined with the event. The max span deadline
+// is driven by the wall clock, so this check enforces the max span on
+// synthetic events evaluated in dry-run mode, whose timestamps are not
+// in sync with the wall clock.
+func (s *sequenceState) meetsMaxSpan(i int, kevt *kevent.Kevent, by any) bool {
+	if s.maxSpan == 0 || i == 0 || kevt.Timestamp.IsZero() {
+		return true
+	}
+	up := s.upstream(uint16(i + 1))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.partials[up]) == 0 {
+		return true
+	}
+	for _, p := range s.partials[up] {
+		if !joins(p, by) {
+			continue
+		}
+		if p.Timestamp.IsZero() || kevt.Timestamp.Sub(p.Timestamp) <= s.maxSpan {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *sequenceState) clear() {
-	s.partials = make(map[uint16][]*kevent.Kevent)
-	s.matches = make(map[uint16]*kevent.Kevent)
+	for _, a := range s.absences {
+		a.stop()
+	}
+	s.absences = nil
+	for _, span := range s.spanDeadlines {
+		span.Stop()
+	}
+	s.partials = make(map[uint16][]*Partial)
+	s.matches = make(map[uint16]*Partial)
 	s.matchedRules = make(map[uint16]bool)
 	s.spanDeadlines = make(map[fsm.State]*time.Timer)
 	partialsPerSequence.Delete(s.name)
@@ -438,6 +894,10 @@ func (s *sequenceState) next(i int) bool {
 	s.mrm.RLock()
 	defer s.mrm.RUnlock()
 	for n := 0; n < i; n++ {
+		// absence expressions never match
+		if s.negated[uint16(n+1)] {
+			continue
+		}
 		next = s.matchedRules[uint16(n+1)]
 		if !next {
 			break
@@ -487,7 +947,7 @@ func (s *sequenceState) expire(e *kevent.Kevent) bool {
 	defer s.mrm.RUnlock()
 	for _, idx := range s.idxs {
 		for i := len(s.partials[idx]) - 1; i >= 0; i-- {
-			if len(s.partials[idx]) > 0 && !canExpire(s.partials[idx][i], e) {
+			if len(s.partials[idx]) > 0 && !canExpire(s.partials[idx][i].Kevent, e) {
 				continue
 			}
 			// if downstream rule didn't match, and the prev condition
@@ -506,6 +966,7 @@ func (s *sequenceState) expire(e *kevent.Kevent) bool {
 					s.partials[idx][:i],
 					s.partials[idx][i+1:]...)
 				partialsPerSequence.Add(s.name, -1)
+				s.pruneAbsences()
 
 				if len(s.partials[idx]) == 0 {
 					log.Infof("%q sequence expired. All partials retracted", s.name)
@@ -532,8 +993,8 @@ func newFilterGroup(g config.FilterGroup, filters []*compiledFilter) *filterGrou
 	return &filterGroup{group: g, filters: filters}
 }
 
-func newCompiledFilter(f Filter, filterConfig *config.FilterConfig, ss *sequenceState) *compiledFilter {
-	return &compiledFilter{config: filterConfig, filter: f, ss: ss}
+func newCompiledFilter(f Filter, filterConfig *config.FilterConfig, ss *sequenceState, ts *thresholdState) *compiledFilter {
+	return &compiledFilter{config: filterConfig, filter: f, ss: ss, ts: ts}
 }
 
 // isScoped determines if this filter is scoped, i.e. it has the event name or category
@@ -548,11 +1009,12 @@ func (f compiledFilter) isScoped() bool {
 }
 
 // run execute the filter with either simple or sequence expressions.
-func (f compiledFilter) run(kevt *kevent.Kevent, i int, rawMatch bool) bool {
+// The join value is returned if the sequence expression matches.
+func (f compiledFilter) run(kevt *kevent.Kevent, i int, rawMatch bool) (bool, any) {
 	if f.ss != nil {
 		return f.filter.RunSequence(kevt, uint16(i), f.ss.partials, rawMatch)
 	}
-	return f.filter.Run(kevt)
+	return f.filter.Run(kevt), nil
 }
 
 type filterGroups []*filterGroup
@@ -571,12 +1033,14 @@ func (r *Rules) isGroupMapped(scopeHash, groupHash uint32) bool {
 // NewRules produces a fresh rules engine instance.
 func NewRules(psnap ps.Snapshotter, config *config.Config) *Rules {
 	rules := &Rules{
-		groups:    make(map[uint32]filterGroups),
-		matches:   make([]*ruleMatch, 0),
-		sequences: make([]*sequenceState, 0),
-		psnap:     psnap,
-		config:    config,
-		scavenger: time.NewTicker(sequenceGcInterval),
+		groups:     make(map[uint32]filterGroups),
+		matches:    make([]*ruleMatch, 0),
+		sequences:  make([]*sequenceState, 0),
+		thresholds: make([]*thresholdState, 0),
+		psnap:      psnap,
+		config:     config,
+		scavenger:  time.NewTicker(sequenceGcInterval),
+		quit:       make(chan struct{}),
 	}
 
 	go rules.gcSequences()
@@ -584,17 +1048,57 @@ func NewRules(psnap ps.Snapshotter, config *config.Config) *Rules {
 	return rules
 }
 
-// Compile loads macros and rule groups from all
-// indicated resources and creates the rules for
+// EnableDryRun prevents the execution of rule actions, such as
+// emitting alerts or killing processes. Matched events are still
+// tagged with the rule metadata. This is useful for evaluating
+// rules against synthetic events. In dry-run mode, all rules are
+// evaluated for every event, and sequence max spans are measured
+// by event timestamps instead of the wall clock. The dry-run mode
+// must be enabled before the rules are compiled.
+func (r *Rules) EnableDryRun() { r.dryRun = true }
+
+// OnMatch registers the function that is invoked for every rule
+// match, regardless of whether rule actions are executed.
+func (r *Rules) OnMatch(fn func(ctx *config.ActionContext)) { r.onMatch = fn }
+
+// Close stops the garbage collection of sequence partials and
+// disarms pending sequence deadlines.
+func (r *Rules) Close() {
+	r.scavenger.Stop()
+	close(r.quit)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, seq := range r.sequences {
+		seq.clearLocked()
+	}
+}
+
+// Compile loads macros, lookup tables and rule groups
+// from all indicated resources and creates the rules for
 // each filter group. It also sets up the state
 // machine transitions for sequence rules.
 func (r *Rules) Compile() (*config.RulesCompileResult, error) {
 	if err := r.config.Filters.LoadMacros(); err != nil {
 		return nil, err
 	}
+	if err := lists.Load(
+		lists.WithPaths(r.config.Filters.Lists.FromPaths),
+		lists.WithURLs(r.config.Filters.Lists.FromURLs),
+		lists.WithRefresh(r.config.Filters.Lists.RefreshInterval),
+	); err != nil {
+		return nil, err
+	}
 	if err := r.config.Filters.LoadGroups(); err != nil {
 		return nil, err
 	}
+	if err := r.config.Filters.LoadExceptions(); err != nil {
+		return nil, err
+	}
+	if r.config.Filters.Risk.Enabled && r.risk == nil {
+		r.risk = newRiskScorer(r.config.Filters.Risk, emitRiskAlert)
+	}
+	var span time.Duration
+	r.recorded = make(map[uint32]bool)
 	for _, group := range r.config.GetRuleGroups() {
 		if group.IsDisabled() {
 			log.Warnf("rule group [%s] disabled", group.Name)
@@ -608,7 +1112,9 @@ func (r *Rules) Compile() (*config.RulesCompileResult, error) {
 		// sequence rules we have to configure the FSM states and
 		// transitions
 		for _, rule := range group.Rules {
-			fltr := New(rule.Condition, r.config, WithPSnapshotter(r.psnap))
+			fltr := New(rule.Condition, r.config,
+				WithPSnapshotter(r.psnap),
+				WithExceptions(r.config.Filters.GetExceptions(rule, group)...))
 			err := fltr.Compile()
 			if err != nil {
 				return nil, ErrInvalidFilter(rule.Name, group.Name, err)
@@ -633,12 +1139,63 @@ func (r *Rules) Compile() (*config.RulesCompileResult, error) {
 						rule.Name, field, d.Since, d.Fields, field)
 				}
 			}
+			// set up the state for count-based rules
+			var ts *thresholdState
+			if rule.HasThreshold() {
+				if fltr.IsSequence() {
+					return nil, ErrThresholdSequence(rule.Name)
+				}
+				ts, err = newThresholdState(rule.Name, rule.Threshold, r.psnap)
+				if err != nil {
+					return nil, err
+				}
+				r.thresholds = append(r.thresholds, ts)
+			}
+			// set up alert throttling for noisy rules
+			if rule.HasSuppress() {
+				s, err := newSuppressor(rule.Name, rule.Suppress, emitSuppressionSummary)
+				if err != nil {
+					return nil, err
+				}
+				if r.suppressors == nil {
+					r.suppressors = make(map[*config.FilterConfig]*suppressor)
+				}
+				r.suppressors[rule] = s
+			}
+			if len(rule.Action) > 0 {
+				actions, err := action.Decode(rule)
+				if err != nil {
+					return nil, ErrInvalidAction(rule.Name, err)
+				}
+				for _, act := range actions {
+					if act.Name() == "tag" && fltr.IsSequence() && r.config.Filters.Sequences.CompactPartials {
+						return nil, ErrTagCompactedPartials(rule.Name)
+					}
+				}
+				if r.actions == nil {
+					r.actions = make(map[*config.FilterConfig][]action.Action)
+				}
+				r.actions[rule] = actions
+			}
 			filtersCount.Add(1)
-			f := newCompiledFilter(fltr, rule, configureFSM(group, fltr))
+			f := newCompiledFilter(fltr, rule, configureFSM(group, fltr), ts)
 			if fltr.IsSequence() && f.ss != nil {
 				// store the sequences in rules
 				// for more convenient tracking
 				r.sequences = append(r.sequences, f.ss)
+				f.ss.maxPartials = r.config.Filters.Sequences.GetMaxPartials()
+				// partials of unbounded sequences live
+				// as long as the largest permitted span
+				f.ss.lifetime = r.config.Filters.Sequences.MaxSpan
+				if r.config.Filters.Sequences.CompactPartials {
+					f.ss.compactor = newPartialCompactor(fltr, rule, r.psnap)
+				}
+				// sequences ending with absence expressions
+				// fire when the max span deadline is reached
+				if fltr.GetSequence().HasTrailingAbsence() {
+					f.ss.onAbsenceDeadline = r.matchAbsence(f, group)
+					f.ss.eventTime = r.dryRun
+				}
 			}
 			filters = append(filters, f)
 		}
@@ -665,10 +1222,17 @@ func (r *Rules) Compile() (*config.RulesCompileResult, error) {
 					f.config.Name, g.group.Name)
 				continue
 			}
+			recordSpan := recordSpan(r.actions[f.config])
+			if recordSpan > span {
+				span = recordSpan
+			}
 			for name, values := range f.filter.GetStringFields() {
 				for _, v := range values {
 					if name == fields.KevtName || name == fields.KevtCategory {
 						hash := hashers.FnvUint32([]byte(v))
+						if recordSpan > 0 {
+							r.recorded[hash] = true
+						}
 						if r.isGroupMapped(hash, g.group.Hash()) {
 							continue
 						}
@@ -679,6 +1243,15 @@ func (r *Rules) Compile() (*config.RulesCompileResult, error) {
 		}
 	}
 
+	// discard events recorded for the previous ruleset.
+	// Actions don't run in dry-run mode, so events are
+	// not retained
+	if r.dryRun {
+		span = 0
+		r.recorded = nil
+	}
+	action.ResetRecorder(span)
+
 	if len(r.groups) == 0 {
 		return nil, nil
 	}
@@ -686,6 +1259,18 @@ func (r *Rules) Compile() (*config.RulesCompileResult, error) {
 	return r.buildCompileResult(), nil
 }
 
+// recordSpan returns the largest time span of
+// events the rule actions need to be retained.
+func recordSpan(acts []action.Action) time.Duration {
+	var span time.Duration
+	for _, act := range acts {
+		if rec, ok := act.(action.Recording); ok && rec.RecordSpan() > span {
+			span = rec.RecordSpan()
+		}
+	}
+	return span
+}
+
 func configureFSM(group config.FilterGroup, f Filter) *sequenceState {
 	if !f.IsSequence() {
 		return nil
@@ -697,25 +1282,47 @@ func configureFSM(group config.FilterGroup, f Filter) *sequenceState {
 	}
 	initialState := expressions[0].Expr.String()
 	seqState := newSequenceState(group.Name, initialState, seq.MaxSpan)
-	// setup finite state machine states. The last rule
-	// in the sequence transitions to the terminal state
-	// if all rules match
 	for i, expr := range expressions {
-		n := expr.Expr.String()
-		seqState.idxs[n] = uint16(i + 1)
-		if i >= len(expressions)-1 {
-			seqState.fsm.
-				Configure(n).
-				Permit(matchTransition, sequenceTerminalState).
-				Permit(cancelTransition, sequenceDeadlineState).
-				Permit(expireTransition, sequenceExpiredState)
+		seqState.idxs[expr.Expr.String()] = uint16(i + 1)
+		if expr.Negated {
+			seqState.negated[uint16(i+1)] = true
 		} else {
-			seqState.fsm.
-				Configure(n).
-				Permit(matchTransition, expressions[i+1].Expr.String()).
-				Permit(cancelTransition, sequenceDeadlineState).
-				Permit(expireTransition, sequenceExpiredState)
+			seqState.slots = append(seqState.slots, uint16(i+1))
+		}
+	}
+	// setup finite state machine states. The last rule
+	// in the sequence transitions to the terminal state
+	// if all rules match. Absence expressions have no
+	// states, except when they appear at the end of the
+	// sequence. In that case, the last rule transitions
+	// to the absence state which awaits the max span
+	// deadline
+	if seq.HasTrailingAbsence() {
+		// slot indices start at 1, so the last slot
+		// points to the first trailing absence expression
+		last := seqState.slots[len(seqState.slots)-1]
+		seqState.absenceState = fsm.State(expressions[last].Expr.String())
+		seqState.fsm.
+			Configure(seqState.absenceState).
+			Permit(cancelTransition, sequenceDeadlineState).
+			Permit(expireTransition, sequenceExpiredState).
+			Permit(resetTransition, initialState)
+	}
+	for n, i := range seqState.slots {
+		var next fsm.State
+		switch {
+		case n < len(seqState.slots)-1:
+			next = expressions[seqState.slots[n+1]-1].Expr.String()
+		case seqState.absenceState != nil:
+			next = seqState.absenceState
+		default:
+			next = sequenceTerminalState
 		}
+		seqState.fsm.
+			Configure(expressions[i-1].Expr.String()).
+			Permit(matchTransition, next).
+			Permit(cancelTransition, sequenceDeadlineState).
+			Permit(expireTransition, sequenceExpiredState)
 	}
 	// configure reset transitions that are triggered
 	// when the final state is reached of when a deadline
@@ -732,6 +1339,110 @@ func configureFSM(group config.FilterGroup, f Filter) *sequenceState {
 	return seqState
 }
 
+// partialCompactor reduces partials of long-running sequences to
+// the state required to join them with downstream partials. The
+// sequence expression is evaluated when the event arrives, so the
+// partial only needs the join value, the values of the fields
+// bound by downstream expressions, and the values of the fields
+// referenced in the rule output and action templates. The process
+// state, the parameters, and the callstack of the event are dropped,
+// so partials can outlive the processes that generated them without
+// pinning their state in memory.
+type partialCompactor struct {
+	fields    []fields.Field
+	accessors []accessor
+	psnap     ps.Snapshotter
+}
+
+func newPartialCompactor(f Filter, c *config.FilterConfig, psnap ps.Snapshotter) *partialCompactor {
+	compactor := &partialCompactor{accessors: getAccessors(), psnap: psnap}
+	seen := make(map[fields.Field]bool)
+	add := func(field fields.Field) {
+		if field == "" || seen[field] {
+			return
+		}
+		seen[field] = true
+		compactor.fields = append(compactor.fields, field)
+	}
+	if seq := f.GetSequence(); seq != nil {
+		for _, expr := range seq.Expressions {
+			for _, field := range expr.BoundFields {
+				add(field.Field())
+			}
+		}
+	}
+	// templates may be declared in any action, so
+	// all strings of the rule definition are scanned
+	templates := []string{c.Output}
+	for _, act := range c.Action {
+		templates = append(templates, fmt.Sprintf("%v", act))
+	}
+	for _, tmpl := range templates {
+		for _, m := range fieldsReplRegexp.FindAllStringSubmatch(tmpl, -1) {
+			add(fields.Lookup(m[2]))
+		}
+	}
+	// suppression keys are built from the captured values
+	if c.Suppress != nil {
+		for _, f := range c.Suppress.By {
+			if m := suppressFieldRegexp.FindStringSubmatch(f); m != nil {
+				add(fields.Lookup(m[2]))
+			}
+		}
+	}
+	return compactor
+}
+
+// compact captures the field values from the partial
+// event and replaces the event with its header.
+func (c *partialCompactor) compact(p *Partial) {
+	e := p.Kevent
+	p.values = make(map[fields.Field]any, len(c.fields))
+	for _, field := range c.fields {
+		for _, accessor := range c.accessors {
+			v, err := accessor.get(field, e)
+			if err != nil || v == nil {
+				continue
+			}
+			p.values[field] = v
+			break
+		}
+	}
+	evt := &kevent.Kevent{
+		Seq:         e.Seq,
+		PID:         e.PID,
+		Tid:         e.Tid,
+		CPU:         e.CPU,
+		Type:        e.Type,
+		Timestamp:   e.Timestamp,
+		Name:        e.Name,
+		Category:    e.Category,
+		Description: e.Description,
+		Host:        e.Host,
+		Metadata:    make(map[kevent.MetadataKey]any),
+	}
+	// the process identifier is required to
+	// expire partials when the process terminates
+	if kpar, ok := e.Kparams[kparams.ProcessID]; ok {
+		evt.Kparams = kevent.Kparams{kparams.ProcessID: kpar}
+	}
+	p.Kevent = evt
+	p.compacted = true
+}
+
+// restore returns the copy of the compacted partial
+// event with the process state resolved from the
+// snapshotter. The process state is absent if the
+// process terminated in the meantime.
+func (c *partialCompactor) restore(p *Partial) *kevent.Kevent {
+	evt := *p.Kevent
+	evt.Metadata = make(map[kevent.MetadataKey]any)
+	if c.psnap != nil {
+		_, evt.PS = c.psnap.Find(evt.PID)
+	}
+	return &evt
+}
+
 func (r *Rules) buildCompileResult() *config.RulesCompileResult {
 	rs := &config.RulesCompileResult{}
 
@@ -814,6 +1525,10 @@ func (r *Rules) ProcessEvent(evt *kevent.Kevent) (bool, error) {
 	if !r.hasGroups() {
 		return true, nil
 	}
+	// retain the event for capture actions
+	if r.recorded[evt.Type.Hash()] || r.recorded[evt.Category.Hash()] {
+		action.Record(evt)
+	}
 	if evt.IsTerminateProcess() {
 		// expire all sequences if the
 		// process referenced in any
@@ -832,14 +1547,27 @@ func (r *Rules) ProcessEvent(evt *kevent.Kevent) (bool, error) {
 			time.AfterFunc(time.Second*2, expire(seq))
 		}
 	}
+	if r.dryRun {
+		r.ExpireAbsences(evt.Timestamp)
+	}
 	return r.runRules(r.findGroups(evt), evt), nil
 }
 
 func (r *Rules) gcSequences() {
 	for {
-		<-r.scavenger.C
-		for _, seq := range r.sequences {
-			seq.gc()
+		select {
+		case <-r.scavenger.C:
+			for _, seq := range r.sequences {
+				seq.gc()
+			}
+			for _, ts := range r.thresholds {
+				ts.gc()
+			}
+			if r.risk != nil {
+				r.risk.gc(time.Now())
+			}
+		case <-r.quit:
+			return
 		}
 	}
 }
@@ -849,7 +1577,27 @@ func (r *Rules) runSequence(kevt *kevent.Kevent, f *compiledFilter) bool {
 	if seq == nil {
 		return false
 	}
+	// the until expression discards in-flight
+	// partials joined with the current event
+	if seq.Until != nil && seq.Until.IsEvaluable(kevt) {
+		if ok, by := f.filter.RunUntil(kevt); ok {
+			f.ss.terminate(by)
+			return false
+		}
+	}
 	for i, expr := range seq.Expressions {
+		// absence expressions retract upstream partials
+		// if they match while the sequence is waiting
+		// for the downstream expression
+		if expr.Negated {
+			if !f.ss.next(i) || !expr.IsEvaluable(kevt) {
+				continue
+			}
+			if matches, by := f.run(kevt, i, false); matches {
+				f.ss.retract(uint16(i+1), kevt, by)
+			}
+			continue
+		}
 		// only try to evaluate the expression
 		// if upstream expressions have matched
 		if !f.ss.next(i) {
@@ -861,8 +1609,11 @@ func (r *Rules) runSequence(kevt *kevent.Kevent, f *compiledFilter) bool {
 			// If this sequence expression can evaluate
 			// against the current event, mark it as
 			// out-of-order and store in partials list
-			if seq.Expressions[i].IsEvaluable(kevt) && f.run(kevt, i, true) {
-				f.ss.addPartial(expr.Expr.String(), kevt, true)
+			if !seq.Expressions[i].IsEvaluable(kevt) {
+				continue
+			}
+			if matches, _ := f.run(kevt, i, true); matches {
+				f.ss.addPartial(expr.Expr.String(), kevt, nil, true)
 			}
 			continue
 		}
@@ -872,7 +1623,7 @@ func (r *Rules) runSequence(kevt *kevent.Kevent, f *compiledFilter) bool {
 			continue
 		}
 		rule := expr.Expr.String()
-		matches := f.run(kevt, i, false)
+		matches, by := f.run(kevt, i, false)
 		meetsDistance := f.ss.meetsTemporalDistance(rule, kevt, true)
 		log.Debugf("sequence expression [%s] = %t, temporal distance = %t event = %s",
 			rule,
@@ -880,8 +1631,8 @@ func (r *Rules) runSequence(kevt *kevent.Kevent, f *compiledFilter) bool {
 			meetsDistance,
 			kevt)
 		// append the partial and transition state machine
-		if matches && meetsDistance {
-			f.ss.addPartial(rule, kevt, false)
+		if matches && meetsDistance && (!r.dryRun || f.ss.meetsMaxSpan(i, kevt, by)) {
+			f.ss.addPartial(rule, kevt, by, false)
 			err := f.ss.matchTransition(rule, kevt)
 			if err != nil {
 				matchTransitionErrors.Add(1)
@@ -898,30 +1649,42 @@ func (r *Rules) runSequence(kevt *kevent.Kevent, f *compiledFilter) bool {
 	// collect all events involved in the rule match
 	isTerminal := f.ss.isTerminalState()
 	if isTerminal {
-		f.ss.mu.RLock()
-		defer f.ss.mu.RUnlock()
-		nseqs := uint16(len(f.ss.partials))
-
-		setMatch := func(idx uint16, e *kevent.Kevent) {
-			f.ss.mmu.Lock()
-			defer f.ss.mmu.Unlock()
-			if f.ss.matches[idx] == nil {
-				f.ss.matches[idx] = e
-			}
+		f.ss.collectMatches()
+	}
+	return isTerminal
+}
+
+// matchAbsence returns the function that fires the sequence
+// rule for the join key when none of the trailing absence
+// expressions matched the key partials within the max span.
+func (r *Rules) matchAbsence(f *compiledFilter, g config.FilterGroup) func(any) {
+	return func(by any) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		evts, ok := f.ss.popAbsence(by)
+		if !ok {
+			return
+		}
+		r.appendSequenceMatch(f, g, evts)
+		if err := r.processActions(); err != nil {
+			log.Errorf("unable to execute rule action: %v", err)
 		}
+	}
+}
 
-		for i := uint16(1); i < nseqs+1; i++ {
-			for _, outer := range f.ss.partials[i] {
-				for _, inner := range f.ss.partials[i+1] {
-					if compareSeqJoin(outer.SequenceBy(), inner.SequenceBy()) {
-						setMatch(i, outer)
-						setMatch(i+1, inner)
-					}
-				}
-			}
+// ExpireAbsences fires the sequences whose trailing absence
+// deadlines elapsed by the given time without waiting for the
+// wall clock timers. In dry-run mode, the deadlines are expired
+// by timestamps of incoming events.
+func (r *Rules) ExpireAbsences(now time.Time) {
+	for _, seq := range r.sequences {
+		if seq.onAbsenceDeadline == nil {
+			continue
+		}
+		for _, by := range seq.dueAbsences(now) {
+			seq.onAbsenceDeadline(by)
 		}
 	}
-	return isTerminal
 }
 
 func (r *Rules) evaluateOutOfOrderPartials(i int, f *compiledFilter) {
@@ -939,13 +1702,17 @@ func (r *Rules) evaluateOutOfOrderPartials(i int, f *compiledFilter) {
 				continue
 			}
 
-			matches := f.run(partial, int(n)-1, false)
+			matches, by := f.run(partial.Kevent, int(n)-1, false)
 			rule := partial.GetMetaAsString(kevent.RuleExpressionKey)
-			meetsDistance := f.ss.meetsTemporalDistance(rule, partial, false)
+			meetsDistance := f.ss.meetsTemporalDistance(rule, partial.Kevent, false)
 
 			if matches && meetsDistance {
+				partial.By = by
 				partial.RemoveMeta(kevent.RuleSequenceOutOfOrderKey)
-				err := f.ss.matchTransition(rule, partial)
+				if f.ss.isLastSlot(n) {
+					f.ss.scheduleAbsenceDeadline(partial)
+				}
+				err := f.ss.matchTransition(rule, partial.Kevent)
 				if err != nil {
 					matchTransitionErrors.Add(1)
 					log.Warnf("out of order match transition failure: %v", err)
@@ -961,30 +1728,39 @@ func (r *Rules) triggerSequencesInGroup(e *kevent.Kevent, g *filterGroup) {
 			continue
 		}
 		if r.runSequence(e, f) {
-			r.appendMatch(f.config, g.group, f.ss.events()...)
+			r.appendSequenceMatch(f, g.group, f.ss.events())
 			f.ss.clearLocked()
 		}
 	}
 }
 
 func (r *Rules) runRules(groups filterGroups, kevt *kevent.Kevent) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched bool
 	for _, g := range groups {
 		for i, f := range g.filters {
 			var match bool
 			if f.ss != nil {
 				match = r.runSequence(kevt, f)
 			} else {
-				match = f.run(kevt, i, false)
+				match, _ = f.run(kevt, i, false)
 				if match {
 					// transition sequence states since a match
 					// in a simple rule could trigger multiple
 					// matches in sequence rules
 					r.triggerSequencesInGroup(kevt, g)
 				}
+				// count-based rules only fire when the
+				// number of matches in the window reaches
+				// the threshold
+				if match && f.ts != nil {
+					match = f.ts.add(kevt)
+				}
 			}
 			if match {
 				if f.ss != nil {
-					r.appendMatch(f.config, g.group, f.ss.events()...)
+					r.appendSequenceMatch(f, g.group, f.ss.events())
 					f.ss.clearLocked()
 				} else {
 					r.appendMatch(f.config, g.group, kevt)
@@ -993,11 +1769,16 @@ func (r *Rules) runRules(groups filterGroups, kevt *kevent.Kevent) bool {
 				if err != nil {
 					log.Errorf("unable to execute rule action: %v", err)
 				}
-				return true
+				// the dry-run mode reports all
+				// rules matching the event
+				if !r.dryRun {
+					return true
+				}
+				matched = true
 			}
 		}
 	}
-	return false
+	return matched
 }
 
 // processActions executes rule actions
@@ -1014,37 +1795,71 @@ func (r *Rules) processActions() error {
 		f, g, evts := m.ctx.Filter, m.ctx.Group, m.ctx.Events
 		filterMatches.Add(f.Name, 1)
 		log.Debugf("rule [%s] in group [%s] matched", f.Name, g.Name)
-		err := action.Emit(m.ctx, f.Name, InterpolateFields(f.Output, evts), f.Severity, g.Tags)
-		if err != nil {
-			return ErrRuleAction(f.Name, err)
+		if r.onMatch != nil {
+			r.onMatch(m.ctx)
+		}
+		if r.dryRun {
+			continue
+		}
+		if r.risk != nil {
+			r.risk.add(m.ctx)
+		}
+		if s, ok := r.suppressors[f]; !ok || s.allow(m.ctx, m.values) {
+			err := action.Emit(m.ctx, f.Name, interpolateFields(f.Output, evts, m.values, nil), f.Severity, g.Tags)
+			if err != nil {
+				return ErrRuleAction(f.Name, err)
+			}
 		}
 
-		actions, err := f.DecodeActions()
-		if err != nil {
-			return err
-		}
-		for _, act := range actions {
-			switch act := act.(type) {
-			case config.KillAction:
-				field := act.Pid
-				if field == "" {
-					field = "ps.pid"
-				}
-				pid := act.PidToInt(InterpolateFields("%"+field, evts))
-				log.Infof("executing kill action: pid=%d rule=%s", pid, f.Name)
-				if err := action.Kill(pid); err != nil {
-					return ErrRuleAction(f.Name, err)
-				}
+		interpolate := func(s string, escape ...func(string) string) string {
+			if len(escape) > 0 {
+				return interpolateFields(s, evts, m.values, escape[0])
+			}
+			return interpolateFields(s, evts, m.values, nil)
+		}
+		for _, act := range r.actions[f] {
+			if err := action.Run(act, m.ctx, interpolate); err != nil {
+				return ErrRuleAction(f.Name, err)
 			}
 		}
 	}
 	return nil
 }
 
+// emitSuppressionSummary sends the alert reporting the
+// number of suppressed alerts within the rule window.
+func emitSuppressionSummary(ctx *config.ActionContext, key string, n int) {
+	f, g := ctx.Filter, ctx.Group
+	text := fmt.Sprintf("%d more alert(s) suppressed in the last %v", n, f.Suppress.Window)
+	if key != "" {
+		text += fmt.Sprintf(" for %s", key)
+	}
+	if err := action.Emit(ctx, f.Name, text, f.Severity, g.Tags); err != nil {
+		log.Warnf("unable to emit suppression summary for rule %s: %v", f.Name, err)
+	}
+}
+
+// emitRiskAlert sends the alert for the entity
+// that crossed the risk threshold.
+func emitRiskAlert(ctx *config.ActionContext, a riskAlert) {
+	// the risk alert refers to the scored entity
+	// rather than the entity of the rule match
+	c := *ctx
+	c.Key = a.kind + ":" + a.name
+	ctx = &c
+	tags := append([]string{"risk"}, a.tactics...)
+	if err := action.Emit(ctx, a.title(), a.text(), "critical", tags); err != nil {
+		log.Warnf("unable to emit risk alert for %s %s: %v", a.kind, a.name, err)
+	}
+}
+
 func (r *Rules) appendMatch(f *config.FilterConfig, g config.FilterGroup, evts ...*kevent.Kevent) {
 	for _, evt := range evts {
 		evt.AddMeta(kevent.RuleNameKey, f.Name)
 		evt.AddMeta(kevent.RuleGroupKey, g.Name)
+		if f.Severity != "" {
+			evt.AddMeta(kevent.RuleSeverityKey, f.Severity)
+		}
 		for k, v := range g.Labels {
 			evt.AddMeta(kevent.MetadataKey(k), v)
 		}
@@ -1054,9 +1869,45 @@ func (r *Rules) appendMatch(f *config.FilterConfig, g config.FilterGroup, evts .
 		Filter: f,
 		Group:  g,
 	}
+	if len(evts) > 0 {
+		ctx.Key = entityKey(evts[0])
+	}
 	r.matches = append(r.matches, &ruleMatch{ctx: ctx})
 }
 
+// entityKey returns the key of the process that generated the event.
+// The process start time is part of the key to tell apart processes
+// with reused identifiers.
+func entityKey(evt *kevent.Kevent) string {
+	if evt.PS == nil {
+		return fmt.Sprintf("%d", evt.PID)
+	}
+	return fmt.Sprintf("%d:%d", evt.PS.PID, evt.PS.StartTime.UnixNano())
+}
+
+// appendSequenceMatch appends the match of the sequence rule. The
+// events of compacted partials are restored along with the field
+// values captured when the partials were compacted.
+func (r *Rules) appendSequenceMatch(f *compiledFilter, g config.FilterGroup, partials []*Partial) {
+	evts := make([]*kevent.Kevent, 0, len(partials))
+	values := make([]map[fields.Field]any, 0, len(partials))
+	for _, p := range partials {
+		evts = append(evts, f.ss.event(p))
+		values = append(values, p.values)
+	}
+	r.appendMatch(f.config, g, evts...)
+	m := r.matches[len(r.matches)-1]
+	m.values = values
+	// partials joined by the same value
+	// refer to the same entity
+	for _, p := range partials {
+		if p.By != nil {
+			m.ctx.Key = fmt.Sprintf("%v", p.By)
+			break
+		}
+	}
+}
+
 func (r *Rules) clearMatches() {
 	r.matches = make([]*ruleMatch, 0)
 }
@@ -19,6 +19,7 @@
 package filter
 
 import (
+	"expvar"
 	"github.com/rabbitstack/fibratus/pkg/fs"
 	"github.com/rabbitstack/fibratus/pkg/ps"
 	"github.com/rabbitstack/fibratus/pkg/sys"
@@ -33,6 +34,7 @@ import (
 
 	"github.com/rabbitstack/fibratus/pkg/alertsender"
 	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/filter/fields"
 	"github.com/rabbitstack/fibratus/pkg/kevent"
 	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
 	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
@@ -218,12 +220,12 @@ func TestSequenceState(t *testing.T) {
 	assert.True(t, ss.isInitialState())
 	assert.Equal(t, "kevt.name = CreateProcess AND ps.name = cmd.exe", ss.initialState)
 
-	ss.addPartial("kevt.name = CreateProcess AND ps.name = cmd.exe", kevt1, false)
+	ss.addPartial("kevt.name = CreateProcess AND ps.name = cmd.exe", kevt1, nil, false)
 	require.NoError(t, ss.matchTransition("kevt.name = CreateProcess AND ps.name = cmd.exe", kevt1))
 	assert.False(t, ss.isInitialState())
 	assert.Equal(t, "kevt.name = CreateFile AND file.name ICONTAINS temp", ss.currentState())
 
-	ss.addPartial("kevt.name = CreateFile AND file.name ICONTAINS temp", kevt2, false)
+	ss.addPartial("kevt.name = CreateFile AND file.name ICONTAINS temp", kevt2, nil, false)
 	require.NoError(t, ss.matchTransition("kevt.name = CreateFile AND file.name ICONTAINS temp", kevt2))
 
 	assert.Len(t, ss.partials[1], 1)
@@ -252,7 +254,7 @@ func TestSequenceState(t *testing.T) {
 	ss.clear()
 	assert.True(t, ss.isInitialState())
 	require.NoError(t, ss.matchTransition("kevt.name = CreateProcess AND ps.name = cmd.exe", kevt1))
-	ss.addPartial("kevt.name = CreateProcess AND ps.name = cmd.exe", kevt1, false)
+	ss.addPartial("kevt.name = CreateProcess AND ps.name = cmd.exe", kevt1, nil, false)
 	require.False(t, ss.inDeadline.Load())
 
 	// test expiration
@@ -1151,6 +1153,777 @@ func TestKillAction(t *testing.T) {
 	require.False(t, sys.IsProcessRunning(pi.Process))
 }
 
+func TestThresholdRule(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/threshold_rule.yml"))
+	compileRules(t, rules)
+
+	newFileEvent := func(pid uint32, filename string) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateFile,
+			Timestamp: time.Now(),
+			Name:      "CreateFile",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.File,
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+			},
+			Kparams: kevent.Kparams{
+				kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: filename},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	require.False(t, wrapProcessEvent(newFileEvent(1234, "C:\\Users\\a.docx.locked"), rules.ProcessEvent))
+	// the same file name is not counted twice
+	require.False(t, wrapProcessEvent(newFileEvent(1234, "C:\\Users\\a.docx.locked"), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newFileEvent(1234, "C:\\Users\\b.docx.locked"), rules.ProcessEvent))
+	// different process lands in a separate group
+	require.False(t, wrapProcessEvent(newFileEvent(5678, "C:\\Users\\c.docx.locked"), rules.ProcessEvent))
+
+	ts := rules.thresholds[0]
+	assert.Len(t, ts.groups, 2)
+
+	require.True(t, wrapProcessEvent(newFileEvent(1234, "C:\\Users\\c.docx.locked"), rules.ProcessEvent))
+	// the group is reset after the threshold is reached
+	assert.Len(t, ts.groups, 1)
+	require.False(t, wrapProcessEvent(newFileEvent(1234, "C:\\Users\\d.docx.locked"), rules.ProcessEvent))
+}
+
+func TestThresholdRuleWindow(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/threshold_rule.yml"))
+	compileRules(t, rules)
+
+	newRegEvent := func(ts time.Time) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.RegSetValue,
+			Timestamp: ts,
+			Name:      "RegSetValue",
+			Tid:       2484,
+			PID:       859,
+			Category:  ktypes.Registry,
+			Kparams: kevent.Kparams{
+				kparams.RegKeyName: {Name: kparams.RegKeyName, Type: kparams.UnicodeString, Value: "HKEY_CURRENT_USER\\Software\\Microsoft\\Windows\\CurrentVersion\\Run\\Updater"},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	now := time.Now()
+	require.False(t, wrapProcessEvent(newRegEvent(now), rules.ProcessEvent))
+	// the first match slides out of the window
+	require.False(t, wrapProcessEvent(newRegEvent(now.Add(time.Millisecond*200)), rules.ProcessEvent))
+	require.True(t, wrapProcessEvent(newRegEvent(now.Add(time.Millisecond*250)), rules.ProcessEvent))
+}
+
+func TestThresholdGC(t *testing.T) {
+	ts, err := newThresholdState("test", &config.ThresholdConfig{Count: 10, Window: time.Millisecond * 50}, nil)
+	require.NoError(t, err)
+
+	kevt := &kevent.Kevent{
+		Type:      ktypes.CreateFile,
+		Timestamp: time.Now(),
+		Name:      "CreateFile",
+		Category:  ktypes.File,
+	}
+	require.False(t, ts.add(kevt))
+	assert.Len(t, ts.groups, 1)
+
+	time.Sleep(time.Millisecond * 100)
+	ts.gc()
+	assert.Len(t, ts.groups, 0)
+}
+
+func TestThresholdInvalidField(t *testing.T) {
+	_, err := newThresholdState("test", &config.ThresholdConfig{Count: 10, Window: time.Minute, By: []string{"ps.foo"}}, nil)
+	require.Error(t, err)
+}
+
+func TestThresholdInvalidCount(t *testing.T) {
+	for _, count := range []int{0, -1} {
+		_, err := newThresholdState("test", &config.ThresholdConfig{Count: count, Window: time.Minute}, nil)
+		require.EqualError(t, err, ErrThresholdCount("test", count).Error())
+	}
+}
+
+func TestSequenceAbsenceRule(t *testing.T) {
+	now := time.Now()
+	kevt1 := &kevent.Kevent{
+		Type:      ktypes.CreateProcess,
+		Timestamp: now,
+		Name:      "CreateProcess",
+		Tid:       2484,
+		PID:       859,
+		Category:  ktypes.Process,
+		PS: &types.PS{
+			PID:  859,
+			Name: "cmd.exe",
+		},
+		Kparams: kevent.Kparams{
+			kparams.ProcessID: {Name: kparams.ProcessID, Type: kparams.Uint32, Value: uint32(4143)},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+	kevt2 := &kevent.Kevent{
+		Type:      ktypes.CreateFile,
+		Timestamp: now.Add(time.Millisecond * 5),
+		Name:      "CreateFile",
+		Tid:       2484,
+		PID:       859,
+		Category:  ktypes.File,
+		PS: &types.PS{
+			PID:  859,
+			Name: "cmd.exe",
+		},
+		Kparams: kevent.Kparams{
+			kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\allowed.txt"},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+	kevt3 := &kevent.Kevent{
+		Type:      ktypes.CreateFile,
+		Timestamp: now.Add(time.Millisecond * 10),
+		Name:      "CreateFile",
+		Tid:       2484,
+		PID:       859,
+		Category:  ktypes.File,
+		PS: &types.PS{
+			PID:  859,
+			Name: "cmd.exe",
+		},
+		Kparams: kevent.Kparams{
+			kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\temp.txt"},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/sequence_rule_absence.yml"))
+	compileRules(t, rules)
+
+	// the absence expression matches in between and retracts the partial
+	require.False(t, wrapProcessEvent(kevt1, rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(kevt2, rules.ProcessEvent))
+	ss := rules.sequences[0]
+	assert.Len(t, ss.partials[1], 0)
+	assert.True(t, ss.isInitialState())
+	require.False(t, wrapProcessEvent(kevt3, rules.ProcessEvent))
+
+	rules = NewRules(psnap, newConfig("_fixtures/sequence_rule_absence.yml"))
+	compileRules(t, rules)
+
+	require.False(t, wrapProcessEvent(kevt1, rules.ProcessEvent))
+	require.True(t, wrapProcessEvent(kevt3, rules.ProcessEvent))
+}
+
+func TestSequenceTrailingAbsenceRule(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/sequence_rule_trailing_absence.yml"))
+	compileRules(t, rules)
+
+	const rule = "Command shell spawned and never loaded a module"
+
+	newProcEvent := func(pid uint32) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateProcess,
+			Timestamp: time.Now(),
+			Name:      "CreateProcess",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.Process,
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+			},
+			Kparams: kevent.Kparams{
+				kparams.ProcessID: {Name: kparams.ProcessID, Type: kparams.Uint32, Value: uint32(4143)},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	// the image is loaded within the max span, so the rule doesn't fire
+	require.False(t, wrapProcessEvent(newProcEvent(859), rules.ProcessEvent))
+	kevt := &kevent.Kevent{
+		Type:      ktypes.LoadImage,
+		Timestamp: time.Now().Add(time.Millisecond),
+		Name:      "LoadImage",
+		Tid:       2484,
+		PID:       859,
+		Category:  ktypes.Image,
+		PS: &types.PS{
+			PID:  859,
+			Name: "cmd.exe",
+		},
+		Kparams: kevent.Kparams{
+			kparams.ImageFilename: {Name: kparams.ImageFilename, Type: kparams.UnicodeString, Value: "C:\\Windows\\System32\\kernel32.dll"},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+	require.False(t, wrapProcessEvent(kevt, rules.ProcessEvent))
+	time.Sleep(time.Millisecond * 300)
+	assert.Nil(t, filterMatches.Get(rule))
+
+	// no image is loaded within the max span
+	require.False(t, wrapProcessEvent(newProcEvent(1024), rules.ProcessEvent))
+	time.Sleep(time.Millisecond * 300)
+	require.NotNil(t, filterMatches.Get(rule))
+	assert.Equal(t, int64(1), filterMatches.Get(rule).(*expvar.Int).Value())
+	assert.True(t, rules.sequences[0].isInitialState())
+}
+
+func TestSequenceTrailingAbsenceRuleMultipleKeys(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/sequence_rule_trailing_absence_keys.yml"))
+	compileRules(t, rules)
+
+	const rule = "Command shell spawned and never loaded a module within a minute"
+
+	now := time.Now()
+	newEvent := func(typ ktypes.Ktype, pid uint32, ts time.Time) *kevent.Kevent {
+		kevt := &kevent.Kevent{
+			Type:      typ,
+			Timestamp: ts,
+			Name:      typ.String(),
+			Tid:       2484,
+			PID:       pid,
+			Category:  typ.Category(),
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+		if typ == ktypes.LoadImage {
+			kevt.Kparams = kevent.Kparams{
+				kparams.ImageFilename: {Name: kparams.ImageFilename, Type: kparams.UnicodeString, Value: "C:\\Windows\\System32\\kernel32.dll"},
+			}
+		} else {
+			kevt.Kparams = kevent.Kparams{
+				kparams.ProcessID: {Name: kparams.ProcessID, Type: kparams.Uint32, Value: pid},
+			}
+		}
+		return kevt
+	}
+
+	seq := rules.sequences[0]
+
+	require.False(t, wrapProcessEvent(newEvent(ktypes.CreateProcess, 1024, now), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newEvent(ktypes.CreateProcess, 2048, now.Add(time.Second)), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newEvent(ktypes.CreateProcess, 4096, now.Add(time.Second*30)), rules.ProcessEvent))
+	// each key awaits its own deadline
+	require.Len(t, seq.absences, 3)
+
+	// the image is loaded by the second process, so only its partial is retracted
+	require.False(t, wrapProcessEvent(newEvent(ktypes.LoadImage, 2048, now.Add(time.Second*2)), rules.ProcessEvent))
+	require.Len(t, seq.partials[1], 2)
+	require.Len(t, seq.absences, 2)
+	assert.Nil(t, filterMatches.Get(rule))
+
+	// the max span of the first process elapses
+	rules.ExpireAbsences(now.Add(time.Minute + time.Second))
+	require.NotNil(t, filterMatches.Get(rule))
+	assert.Equal(t, int64(1), filterMatches.Get(rule).(*expvar.Int).Value())
+	require.Len(t, seq.partials[1], 1)
+	assert.Equal(t, uint32(4096), seq.partials[1][0].PID)
+	assert.False(t, seq.isInitialState())
+
+	// the partial of the last process is retained and
+	// is still matched by the downstream events
+	require.False(t, wrapProcessEvent(newEvent(ktypes.CreateProcess, 8192, now.Add(time.Second*40)), rules.ProcessEvent))
+	require.Len(t, seq.absences, 2)
+
+	rules.ExpireAbsences(now.Add(time.Minute + time.Second*45))
+	assert.Equal(t, int64(3), filterMatches.Get(rule).(*expvar.Int).Value())
+	assert.Len(t, seq.partials[1], 0)
+	assert.Len(t, seq.absences, 0)
+	assert.True(t, seq.isInitialState())
+}
+
+func TestSequenceUntilRule(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/sequence_rule_until.yml"))
+	compileRules(t, rules)
+
+	now := time.Now()
+	newProcEvent := func(pid uint32) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateProcess,
+			Timestamp: now,
+			Name:      "CreateProcess",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.Process,
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+			},
+			Kparams: kevent.Kparams{
+				kparams.ProcessID: {Name: kparams.ProcessID, Type: kparams.Uint32, Value: uint32(4143)},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+	newFileEvent := func(pid uint32) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateFile,
+			Timestamp: now.Add(time.Millisecond * 10),
+			Name:      "CreateFile",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.File,
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+			},
+			Kparams: kevent.Kparams{
+				kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\temp.txt"},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+	kevt := &kevent.Kevent{
+		Type:      ktypes.TerminateProcess,
+		Timestamp: now.Add(time.Millisecond * 5),
+		Name:      "TerminateProcess",
+		Tid:       2484,
+		PID:       859,
+		Category:  ktypes.Process,
+		PS: &types.PS{
+			PID:  859,
+			Name: "cmd.exe",
+		},
+		Kparams: kevent.Kparams{
+			kparams.ProcessID: {Name: kparams.ProcessID, Type: kparams.Uint32, Value: uint32(859)},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+
+	// the until expression terminates the only partial
+	require.False(t, wrapProcessEvent(newProcEvent(859), rules.ProcessEvent))
+	ss := rules.sequences[0]
+	require.Len(t, ss.partials[1], 1)
+	// the join value is kept in the partial, not in the shared event
+	assert.Equal(t, uint32(859), ss.partials[1][0].By)
+	assert.False(t, ss.partials[1][0].ContainsMeta(kevent.RuleSequenceByKey))
+	require.False(t, wrapProcessEvent(kevt, rules.ProcessEvent))
+	assert.False(t, kevt.ContainsMeta(kevent.RuleSequenceByKey))
+	assert.Len(t, ss.partials[1], 0)
+	assert.True(t, ss.isInitialState())
+	require.False(t, wrapProcessEvent(newFileEvent(859), rules.ProcessEvent))
+
+	// partials for other join keys survive the termination
+	require.False(t, wrapProcessEvent(newProcEvent(859), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newProcEvent(1024), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(kevt, rules.ProcessEvent))
+	assert.Len(t, ss.partials[1], 1)
+	assert.False(t, ss.isInitialState())
+	var key string
+	rules.OnMatch(func(ctx *config.ActionContext) { key = ctx.Key })
+	require.True(t, wrapProcessEvent(newFileEvent(1024), rules.ProcessEvent))
+	// the alert is keyed by the join value
+	assert.Equal(t, "1024", key)
+}
+
+func TestSequenceCompactPartials(t *testing.T) {
+	require.NoError(t, alertsender.LoadAll([]alertsender.Config{{Type: alertsender.Noop}}))
+	psnap := new(ps.SnapshotterMock)
+	psnap.On("Find", uint32(1024)).Return(true, &types.PS{PID: 1024, Name: "cmd.exe", Exe: "C:\\Windows\\System32\\cmd.exe"})
+	c := newConfig("_fixtures/sequence_rule_compact.yml")
+	c.Filters.Sequences = config.Sequences{MaxPartials: 2, CompactPartials: true}
+	rules := NewRules(psnap, c)
+	compileRules(t, rules)
+
+	now := time.Now()
+	newProcEvent := func(pid uint32) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateProcess,
+			Timestamp: now,
+			Name:      "CreateProcess",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.Process,
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+				Exe:  "C:\\Windows\\System32\\cmd.exe",
+			},
+			Kparams: kevent.Kparams{
+				kparams.ProcessID:   {Name: kparams.ProcessID, Type: kparams.Uint32, Value: pid + 1},
+				kparams.ProcessName: {Name: kparams.ProcessName, Type: kparams.AnsiString, Value: "notepad.exe"},
+			},
+			Callstack: kevent.Callstack{{Addr: 0x7ffb5c1d0396, Symbol: "CreateProcessW"}},
+			Metadata:  make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	kevt := newProcEvent(859)
+	require.False(t, wrapProcessEvent(kevt, rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newProcEvent(1024), rules.ProcessEvent))
+	// the partial limit is reached
+	require.False(t, wrapProcessEvent(newProcEvent(2048), rules.ProcessEvent))
+
+	ss := rules.sequences[0]
+	require.Len(t, ss.partials[1], 2)
+	assert.Equal(t, int64(1), partialBreaches.Get(ss.name).(*expvar.Int).Value())
+
+	// the partial retains the event header, the join value, and the
+	// values of the fields referenced in the output, but the original
+	// event remains intact
+	p := ss.partials[1][0]
+	assert.True(t, p.compacted)
+	assert.Nil(t, p.PS)
+	assert.Nil(t, p.Callstack)
+	assert.Equal(t, uint32(859), p.PID)
+	assert.Equal(t, kevt.PS.UUID(), p.By)
+	require.Len(t, p.Kparams, 1)
+	assert.Equal(t, uint32(860), p.Kparams.MustGetPid())
+	assert.Equal(t, "C:\\Windows\\System32\\cmd.exe", p.values[fields.PsExe])
+	assert.NotNil(t, kevt.PS)
+	assert.Len(t, kevt.Callstack, 1)
+	assert.Len(t, kevt.Kparams, 2)
+	// the metadata is not shared with the original event
+	p.AddMeta("foo", "bar")
+	assert.False(t, kevt.ContainsMeta("foo"))
+
+	emitAlert = nil
+	kevt = &kevent.Kevent{
+		Type:      ktypes.CreateFile,
+		Timestamp: now.Add(time.Millisecond * 10),
+		Name:      "CreateFile",
+		Tid:       2484,
+		PID:       1024,
+		Category:  ktypes.File,
+		PS: &types.PS{
+			PID:  1024,
+			Name: "cmd.exe",
+		},
+		Kparams: kevent.Kparams{
+			kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\temp.txt"},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+	require.True(t, wrapProcessEvent(kevt, rules.ProcessEvent))
+	require.NotNil(t, emitAlert)
+	assert.Equal(t, "C:\\Windows\\System32\\cmd.exe created C:\\Windows\\temp.txt", emitAlert.Text)
+	// the event completing the sequence is never compacted
+	assert.Len(t, kevt.Kparams, 1)
+	assert.Equal(t, "Spawned process created a temp file", kevt.GetMetaAsString(kevent.RuleNameKey))
+	psnap.AssertExpectations(t)
+}
+
+func TestSequenceCompactPartialsSuppress(t *testing.T) {
+	require.NoError(t, alertsender.LoadAll([]alertsender.Config{{Type: alertsender.Noop}}))
+	psnap := new(ps.SnapshotterMock)
+	psnap.On("Find", uint32(859)).Return(true, &types.PS{PID: 859, Name: "cmd.exe"})
+	psnap.On("Find", uint32(1024)).Return(true, &types.PS{PID: 1024, Name: "cmd.exe"})
+	c := newConfig("_fixtures/sequence_rule_compact_suppress.yml")
+	c.Filters.Sequences = config.Sequences{CompactPartials: true}
+	rules := NewRules(psnap, c)
+	compileRules(t, rules)
+
+	now := time.Now()
+	newProcEvent := func(pid uint32, child string) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateProcess,
+			Timestamp: now,
+			Name:      "CreateProcess",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.Process,
+			PS:        &types.PS{PID: pid, Name: "cmd.exe"},
+			Kparams: kevent.Kparams{
+				kparams.ProcessID:   {Name: kparams.ProcessID, Type: kparams.Uint32, Value: pid + 1},
+				kparams.ProcessName: {Name: kparams.ProcessName, Type: kparams.AnsiString, Value: child},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+	newFileEvent := func(pid uint32) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateFile,
+			Timestamp: now.Add(time.Millisecond * 10),
+			Name:      "CreateFile",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.File,
+			PS:        &types.PS{PID: pid, Name: "cmd.exe"},
+			Kparams: kevent.Kparams{
+				kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\temp.txt"},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	require.False(t, wrapProcessEvent(newProcEvent(859, "notepad.exe"), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newProcEvent(1024, "calc.exe"), rules.ProcessEvent))
+	ss := rules.sequences[0]
+	require.Len(t, ss.partials[1], 2)
+	// the suppression field is captured in compacted partials
+	p := ss.partials[1][0]
+	require.True(t, p.compacted)
+	assert.Len(t, p.Kparams, 1)
+	assert.Equal(t, "notepad.exe", p.values[fields.PsChildName])
+
+	// distinct child processes open their own windows
+	emitAlert = nil
+	require.True(t, wrapProcessEvent(newFileEvent(859), rules.ProcessEvent))
+	require.NotNil(t, emitAlert)
+	emitAlert = nil
+	require.True(t, wrapProcessEvent(newFileEvent(1024), rules.ProcessEvent))
+	require.NotNil(t, emitAlert)
+
+	const rule = "Spawned process created a temp file"
+	assert.Equal(t, int64(2), suppressionKeysCount.Get(rule).(*expvar.Int).Value())
+	assert.Nil(t, suppressedAlerts.Get(rule))
+}
+
+func TestSuppressRule(t *testing.T) {
+	require.NoError(t, alertsender.LoadAll([]alertsender.Config{{Type: alertsender.Noop}}))
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/suppress_rule.yml"))
+	compileRules(t, rules)
+
+	const rule = "Command shell spawned"
+
+	newProcEvent := func(exe string) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateProcess,
+			Timestamp: time.Now(),
+			Name:      "CreateProcess",
+			Tid:       2484,
+			PID:       859,
+			Category:  ktypes.Process,
+			PS: &types.PS{
+				PID:  859,
+				Name: "cmd.exe",
+				Exe:  exe,
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	emitAlert = nil
+	require.True(t, wrapProcessEvent(newProcEvent("C:\\Windows\\System32\\cmd.exe"), rules.ProcessEvent))
+	require.NotNil(t, emitAlert)
+	assert.Equal(t, rule, emitAlert.Title)
+
+	// subsequent alerts with the same key are suppressed
+	emitAlert = nil
+	require.True(t, wrapProcessEvent(newProcEvent("C:\\Windows\\System32\\cmd.exe"), rules.ProcessEvent))
+	require.True(t, wrapProcessEvent(newProcEvent("C:\\Windows\\System32\\cmd.exe"), rules.ProcessEvent))
+	assert.Nil(t, emitAlert)
+	assert.Equal(t, int64(2), suppressedAlerts.Get(rule).(*expvar.Int).Value())
+
+	// different key opens its own window
+	require.True(t, wrapProcessEvent(newProcEvent("C:\\Temp\\cmd.exe"), rules.ProcessEvent))
+	require.NotNil(t, emitAlert)
+	emitAlert = nil
+
+	// the summary is emitted when the window ends
+	time.Sleep(time.Millisecond * 500)
+	require.NotNil(t, emitAlert)
+	assert.Equal(t, rule, emitAlert.Title)
+	assert.Equal(t, "2 more alert(s) suppressed in the last 300ms for C:\\Windows\\System32\\cmd.exe", emitAlert.Text)
+	assert.Equal(t, int64(1), suppressionSummaries.Get(rule).(*expvar.Int).Value())
+	assert.Equal(t, int64(0), suppressionKeysCount.Get(rule).(*expvar.Int).Value())
+
+	// the new window is opened after the summary
+	emitAlert = nil
+	require.True(t, wrapProcessEvent(newProcEvent("C:\\Windows\\System32\\cmd.exe"), rules.ProcessEvent))
+	require.NotNil(t, emitAlert)
+	emitAlert = nil
+}
+
+func TestSuppressInvalidField(t *testing.T) {
+	_, err := newSuppressor("rule", &config.SuppressConfig{By: []string{"ps.exe", "2.ps.nme"}, Window: time.Minute}, nil)
+	require.EqualError(t, err, ErrSuppressField("rule", "2.ps.nme").Error())
+	_, err = newSuppressor("rule", &config.SuppressConfig{By: []string{"2.ps.exe"}}, nil)
+	require.EqualError(t, err, ErrSuppressWindow("rule").Error())
+	s, err := newSuppressor("rule", &config.SuppressConfig{By: []string{"2.ps.exe"}, Window: time.Minute}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.max)
+	_, err = newSuppressor("rule", &config.SuppressConfig{Window: time.Minute, Max: -1}, nil)
+	require.EqualError(t, err, ErrSuppressMax("rule", -1).Error())
+}
+
+func TestRuleExceptions(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	c := newConfig("_fixtures/exceptions_rule.yml")
+	c.Filters.Exceptions.FromPaths = []string{"_fixtures/exceptions/exceptions.yml"}
+	rules := NewRules(psnap, c)
+	compileRules(t, rules)
+
+	now := time.Now()
+	newProcEvent := func(pid uint32, exe string) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateProcess,
+			Timestamp: now,
+			Name:      "CreateProcess",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.Process,
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+				Exe:  exe,
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+	newFileEvent := func(pid uint32, exe string) *kevent.Kevent {
+		return &kevent.Kevent{
+			Type:      ktypes.CreateFile,
+			Timestamp: now.Add(time.Millisecond * 10),
+			Name:      "CreateFile",
+			Tid:       2484,
+			PID:       pid,
+			Category:  ktypes.File,
+			PS: &types.PS{
+				PID:  pid,
+				Name: "cmd.exe",
+				Exe:  exe,
+			},
+			Kparams: kevent.Kparams{
+				kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\temp.txt"},
+			},
+			Metadata: make(map[kevent.MetadataKey]any),
+		}
+	}
+
+	// the rule exception applies only to the simple rule
+	require.True(t, wrapProcessEvent(newProcEvent(859, "C:\\Windows\\System32\\cmd.exe"), rules.ProcessEvent))
+	require.True(t, wrapProcessEvent(newFileEvent(859, "C:\\Windows\\System32\\cmd.exe"), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newProcEvent(1024, "C:\\Program Files\\cmd.exe"), rules.ProcessEvent))
+	require.True(t, wrapProcessEvent(newFileEvent(1024, "C:\\Program Files\\cmd.exe"), rules.ProcessEvent))
+
+	// the group exception applies to all rules in the group
+	require.False(t, wrapProcessEvent(newProcEvent(2048, "C:\\Tools\\cmd.exe"), rules.ProcessEvent))
+	require.False(t, wrapProcessEvent(newFileEvent(2048, "C:\\Tools\\cmd.exe"), rules.ProcessEvent))
+}
+
+func TestRuleExceptionsUnknownTarget(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	c := newConfig("_fixtures/simple_matches.yml")
+	c.Filters.Exceptions.FromPaths = []string{"_fixtures/exceptions/exceptions.yml"}
+	rules := NewRules(psnap, c)
+	_, err := rules.Compile()
+	require.EqualError(t, err, `"Trusted command shell" exception targets unknown "Command shell spawned" rule`)
+}
+
+func TestTagAction(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/tag_action.yml"))
+	compileRules(t, rules)
+
+	require.NoError(t, alertsender.LoadAll([]alertsender.Config{{Type: alertsender.None}}))
+
+	e := &kevent.Kevent{
+		Type:      ktypes.CreateProcess,
+		Timestamp: time.Now(),
+		Name:      "CreateProcess",
+		Tid:       2484,
+		PID:       859,
+		Category:  ktypes.Process,
+		PS: &types.PS{
+			Name: "cmd.exe",
+		},
+		Kparams: kevent.Kparams{
+			kparams.ProcessID:   {Name: kparams.ProcessID, Type: kparams.PID, Value: uint32(1234)},
+			kparams.ProcessName: {Name: kparams.ProcessName, Type: kparams.UnicodeString, Value: "calc.exe"},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+
+	require.True(t, wrapProcessEvent(e, rules.ProcessEvent))
+	assert.Equal(t, "isolate", e.GetMetaAsString("triage"))
+	assert.Equal(t, "cmd.exe", e.GetMetaAsString("parent"))
+}
+
+func TestInvalidAction(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	rules := NewRules(psnap, newConfig("_fixtures/invalid_action.yml"))
+	_, err := rules.Compile()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid action in "Run script on calc.exe process" rule`)
+}
+
+func TestTagActionCompactPartials(t *testing.T) {
+	psnap := new(ps.SnapshotterMock)
+	c := newConfig("_fixtures/sequence_rule_compact_tag.yml")
+	c.Filters.Sequences = config.Sequences{CompactPartials: true}
+	_, err := NewRules(psnap, c).Compile()
+	require.EqualError(t, err, ErrTagCompactedPartials("Spawned process created a temp file").Error())
+
+	// partials are retained intact
+	compileRules(t, NewRules(psnap, newConfig("_fixtures/sequence_rule_compact_tag.yml")))
+}
+
+func TestRiskScoring(t *testing.T) {
+	var alerts []riskAlert
+	r := newRiskScorer(config.Risk{Enabled: true, ProcessDepth: 1, Entities: []string{"process", "user"}}, func(ctx *config.ActionContext, a riskAlert) {
+		alerts = append(alerts, a)
+	})
+
+	now := time.Now()
+	parent := &types.PS{PID: 1020, Name: "winword.exe", Username: "admin", Domain: "ACME"}
+	child := &types.PS{PID: 2040, Name: "powershell.exe", Username: "admin", Domain: "ACME", Parent: parent}
+
+	match := func(rule, severity, tactic string, ps *types.PS, ts time.Time) {
+		labels := map[string]string{"tactic.id": tactic}
+		if tactic == "TA0006" {
+			labels["tactic.name"] = "Credential Access"
+		}
+		r.add(&config.ActionContext{
+			Events: []*kevent.Kevent{{Timestamp: ts, PS: ps}},
+			Filter: &config.FilterConfig{Name: rule, Severity: severity},
+			Group:  config.FilterGroup{Labels: labels},
+		})
+	}
+
+	match("Office spawned PowerShell", "high", "TA0002", child, now)
+	assert.Equal(t, float64(40), r.score("process", "powershell.exe (2040)", now))
+	assert.Equal(t, float64(40), r.score("process", "winword.exe (1020)", now))
+	assert.Equal(t, float64(40), r.score("user", "ACME\\admin", now))
+	assert.Empty(t, alerts)
+
+	// the score is halved after the half-life elapses
+	assert.Equal(t, float64(20), r.score("process", "powershell.exe (2040)", now.Add(time.Hour)))
+
+	match("PowerShell created a scheduled task", "critical", "TA0003", child, now.Add(time.Hour))
+	require.Len(t, alerts, 3)
+	assert.Equal(t, "process", alerts[0].kind)
+	assert.Equal(t, "powershell.exe (2040)", alerts[0].name)
+	assert.Equal(t, float64(100), alerts[0].score)
+	assert.Equal(t, []string{"Office spawned PowerShell", "PowerShell created a scheduled task"}, alerts[0].rules)
+	assert.Equal(t, "Elevated risk for process powershell.exe (2040)", alerts[0].title())
+
+	// the alert is not repeated while the entity exceeds the threshold
+	match("PowerShell created a scheduled task", "critical", "TA0003", child, now.Add(time.Hour))
+	require.Len(t, alerts, 3)
+
+	// the entity implicated in enough distinct tactics raises the alert
+	// even if the score doesn't cross the threshold
+	other := &types.PS{PID: 3010, Name: "rundll32.exe", SID: "S-1-5-18"}
+	match("Rundll32 discovery", "low", "TA0007", other, now)
+	match("Rundll32 credential access", "low", "TA0006", other, now)
+	match("Rundll32 outbound connection", "low", "TA0011", other, now)
+	require.Len(t, alerts, 5)
+	assert.Equal(t, "rundll32.exe (3010)", alerts[3].name)
+	// tactic ids are emitted as tags while display names only appear in the alert text
+	assert.Equal(t, []string{"TA0006", "TA0007", "TA0011"}, alerts[3].tactics)
+	assert.Contains(t, alerts[3].text(), "across 3 tactic(s): Credential Access (TA0006), TA0007, TA0011")
+	assert.Equal(t, "S-1-5-18", alerts[4].name)
+
+	// entities with negligible scores are discarded
+	r.gc(now.Add(time.Hour * 24))
+	assert.Len(t, r.entities, 0)
+}
+
 func BenchmarkRunRules(b *testing.B) {
 	b.ReportAllocs()
 	psnap := new(ps.SnapshotterMock)
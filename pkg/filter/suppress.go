@@ -0,0 +1,180 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"expvar"
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/filter/fields"
+	log "github.com/sirupsen/logrus"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSuppressionKeys determines the maximum number of deduplication keys per rule
+	maxSuppressionKeys = 10000
+)
+
+var (
+	suppressedAlerts       = expvar.NewMap("suppress.alerts.suppressed")
+	suppressionSummaries   = expvar.NewMap("suppress.summaries.emitted")
+	suppressionKeysCount   = expvar.NewMap("suppress.keys.count")
+	suppressionKeyBreaches = expvar.NewMap("suppress.key.breaches")
+
+	// ErrSuppressField is raised when the suppression references an unknown field
+	ErrSuppressField = func(rule, field string) error {
+		return fmt.Errorf("suppression in %q rule references an unknown %q field", rule, field)
+	}
+	// ErrSuppressWindow is raised when the suppression window is not specified
+	ErrSuppressWindow = func(rule string) error {
+		return fmt.Errorf("suppression in %q rule requires a non-zero window", rule)
+	}
+	// ErrSuppressMax is raised when the maximum number of alerts is negative
+	ErrSuppressMax = func(rule string, max int) error {
+		return fmt.Errorf("suppression in %q rule requires a non-negative max, but got %d", rule, max)
+	}
+
+	suppressFieldRegexp = regexp.MustCompile(`^([1-9]\.)?(.+)$`)
+)
+
+// suppressor throttles alerts of the rule. Every distinct deduplication
+// key opens its own window when the first alert is emitted. Alerts exceeding
+// the maximum number within the window are suppressed. When the window ends,
+// the summary alert reporting the number of suppressed alerts is emitted.
+type suppressor struct {
+	name   string
+	max    int
+	window time.Duration
+	by     []string
+
+	keys map[string]*suppressionKey
+	// mu guards the keys map
+	mu sync.Mutex
+
+	// summarize is called when the window ends
+	// with some alerts suppressed
+	summarize func(ctx *config.ActionContext, key string, n int)
+}
+
+// suppressionKey keeps the state of the deduplication key window.
+type suppressionKey struct {
+	// count is the number of emitted alerts
+	count int
+	// suppressed is the number of suppressed alerts
+	suppressed int
+	// ctx is the action context of the last suppressed alert
+	ctx *config.ActionContext
+}
+
+func newSuppressor(name string, c *config.SuppressConfig, summarize func(*config.ActionContext, string, int)) (*suppressor, error) {
+	if c.Window == 0 {
+		return nil, ErrSuppressWindow(name)
+	}
+	if c.Max < 0 {
+		return nil, ErrSuppressMax(name, c.Max)
+	}
+	s := &suppressor{
+		name:      name,
+		max:       c.Max,
+		window:    c.Window,
+		by:        make([]string, 0, len(c.By)),
+		keys:      make(map[string]*suppressionKey),
+		summarize: summarize,
+	}
+	// the max is optional and defaults to one alert per window
+	if s.max == 0 {
+		s.max = 1
+	}
+	for _, f := range c.By {
+		m := suppressFieldRegexp.FindStringSubmatch(f)
+		if m == nil || fields.Lookup(m[2]) == fields.None {
+			return nil, ErrSuppressField(name, f)
+		}
+		s.by = append(s.by, "%"+f)
+	}
+	return s, nil
+}
+
+// key builds the deduplication key by interpolating the field
+// modifiers with values from rule events. The values captured
+// from compacted partials take precedence.
+func (s *suppressor) key(ctx *config.ActionContext, values []map[fields.Field]any) string {
+	if len(s.by) == 0 {
+		return ""
+	}
+	return interpolateFields(strings.Join(s.by, "|"), ctx.Events, values, nil)
+}
+
+// allow determines whether the alert for the given action context
+// can be emitted. If the maximum number of alerts for the key is
+// reached within the window, the alert is suppressed.
+func (s *suppressor) allow(ctx *config.ActionContext, values []map[fields.Field]any) bool {
+	key := s.key(ctx, values)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.keys[key]
+	if !ok {
+		if len(s.keys) >= maxSuppressionKeys {
+			suppressionKeyBreaches.Add(s.name, 1)
+			log.Warnf("max deduplication keys reached in rule %s. "+
+				"Alert won't be suppressed", s.name)
+			return true
+		}
+		k = &suppressionKey{}
+		s.keys[key] = k
+		suppressionKeysCount.Add(s.name, 1)
+		time.AfterFunc(s.window, func() { s.expire(key) })
+	}
+
+	if k.count < s.max {
+		k.count++
+		return true
+	}
+
+	log.Debugf("suppressing alert with key [%s] for rule %s", key, s.name)
+	k.suppressed++
+	k.ctx = ctx
+	suppressedAlerts.Add(s.name, 1)
+
+	return false
+}
+
+// expire closes the window of the deduplication key and
+// emits the summary if any alerts were suppressed.
+func (s *suppressor) expire(key string) {
+	s.mu.Lock()
+	k, ok := s.keys[key]
+	if ok {
+		delete(s.keys, key)
+		suppressionKeysCount.Add(s.name, -1)
+	}
+	s.mu.Unlock()
+
+	if !ok || k.suppressed == 0 || s.summarize == nil {
+		return
+	}
+	suppressionSummaries.Add(s.name, 1)
+	s.summarize(k.ctx, key, k.suppressed)
+}
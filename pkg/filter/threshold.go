@@ -0,0 +1,228 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"expvar"
+	"fmt"
+	"github.com/rabbitstack/fibratus/pkg/config"
+	"github.com/rabbitstack/fibratus/pkg/filter/fields"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/ps"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxThresholdGroups determines the maximum number of groups per threshold rule
+	maxThresholdGroups = 10000
+)
+
+var (
+	thresholdGroupsCount   = expvar.NewMap("threshold.groups.count")
+	thresholdGroupBreaches = expvar.NewMap("threshold.group.breaches")
+	thresholdEvictions     = expvar.NewMap("threshold.group.evictions")
+
+	// ErrThresholdSequence is raised when the threshold is declared in sequence rules
+	ErrThresholdSequence = func(rule string) error {
+		return fmt.Errorf("threshold is not supported in %q sequence rule", rule)
+	}
+	// ErrThresholdField is raised when the threshold references an unknown field
+	ErrThresholdField = func(rule, field string) error {
+		return fmt.Errorf("threshold in %q rule references an unknown %q field", rule, field)
+	}
+	// ErrThresholdWindow is raised when the threshold window is not specified
+	ErrThresholdWindow = func(rule string) error {
+		return fmt.Errorf("threshold in %q rule requires a non-zero window", rule)
+	}
+	// ErrThresholdCount is raised when the threshold count is not positive
+	ErrThresholdCount = func(rule string, count int) error {
+		return fmt.Errorf("threshold in %q rule requires the count of at least 1, but got %d", rule, count)
+	}
+)
+
+// thresholdState tracks the matches of count-based rules.
+// Each group, identified by the values of the `by` fields,
+// maintains a sliding window of match timestamps, or the
+// unique values of the distinct field along with the time
+// they were last seen. When the number of matches within the
+// window reaches the count, the rule fires and the group
+// window is reset.
+type thresholdState struct {
+	name     string
+	count    int
+	window   time.Duration
+	distinct fields.Field
+	by       []fields.Field
+
+	accessors []accessor
+
+	groups map[string]*thresholdGroup
+	// mu guards the groups map
+	mu sync.Mutex
+}
+
+// thresholdGroup stores the matches in the sliding window.
+type thresholdGroup struct {
+	// hits are timestamps of all the matches in the window
+	hits []time.Time
+	// values maps distinct field values to the timestamp of their last occurrence
+	values map[string]time.Time
+	// last is the timestamp of the most recent match
+	last time.Time
+}
+
+func newThresholdState(name string, c *config.ThresholdConfig, psnap ps.Snapshotter) (*thresholdState, error) {
+	if c.Window == 0 {
+		return nil, ErrThresholdWindow(name)
+	}
+	if c.Count < 1 {
+		return nil, ErrThresholdCount(name, c.Count)
+	}
+	ts := &thresholdState{
+		name:      name,
+		count:     c.Count,
+		window:    c.Window,
+		by:        make([]fields.Field, 0, len(c.By)),
+		accessors: getAccessors(),
+		groups:    make(map[string]*thresholdGroup),
+	}
+	if c.Distinct != "" {
+		if fields.Lookup(c.Distinct) == fields.None {
+			return nil, ErrThresholdField(name, c.Distinct)
+		}
+		ts.distinct = fields.Field(c.Distinct)
+	}
+	for _, f := range c.By {
+		if fields.Lookup(f) == fields.None {
+			return nil, ErrThresholdField(name, f)
+		}
+		ts.by = append(ts.by, fields.Field(f))
+	}
+	// make the process accessor aware of the snapshotter
+	for i, accessor := range ts.accessors {
+		if _, ok := accessor.(*psAccessor); ok {
+			ts.accessors[i] = newPSAccessor(psnap)
+		}
+	}
+	return ts, nil
+}
+
+// groupKey builds the group identifier from the values of the `by` fields.
+func (t *thresholdState) groupKey(kevt *kevent.Kevent) string {
+	if len(t.by) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for i, f := range t.by {
+		if i > 0 {
+			sb.WriteByte('|')
+		}
+		if v := getValue(t.accessors, f, kevt); v != nil {
+			sb.WriteString(fmt.Sprintf("%v", v))
+		}
+	}
+	return sb.String()
+}
+
+// add records the match in the event group and determines
+// whether the count is reached within the time window. If
+// the threshold is reached, the group is cleared to avoid
+// firing the rule on every subsequent match.
+func (t *thresholdState) add(kevt *kevent.Kevent) bool {
+	key := t.groupKey(kevt)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	g, ok := t.groups[key]
+	if !ok {
+		if len(t.groups) >= maxThresholdGroups {
+			thresholdGroupBreaches.Add(t.name, 1)
+			log.Warnf("max groups reached in threshold rule %s. "+
+				"Dropping incoming match", t.name)
+			return false
+		}
+		g = &thresholdGroup{hits: make([]time.Time, 0), values: make(map[string]time.Time)}
+		t.groups[key] = g
+		thresholdGroupsCount.Add(t.name, 1)
+	}
+
+	ts := kevt.Timestamp
+	if ts.After(g.last) {
+		g.last = ts
+	}
+	g.slide(g.last.Add(-t.window))
+
+	var n int
+	if !t.distinct.IsEmpty() {
+		v := getValue(t.accessors, t.distinct, kevt)
+		if v == nil {
+			return false
+		}
+		g.values[fmt.Sprintf("%v", v)] = ts
+		n = len(g.values)
+	} else {
+		g.hits = append(g.hits, ts)
+		n = len(g.hits)
+	}
+
+	if n < t.count {
+		return false
+	}
+
+	log.Debugf("threshold of %d matches reached in group [%s] for rule %s", t.count, key, t.name)
+	delete(t.groups, key)
+	thresholdGroupsCount.Add(t.name, -1)
+
+	return true
+}
+
+// slide discards all matches which occurred before the window start.
+func (g *thresholdGroup) slide(start time.Time) {
+	hits := g.hits[:0]
+	for _, hit := range g.hits {
+		if !hit.Before(start) {
+			hits = append(hits, hit)
+		}
+	}
+	g.hits = hits
+	for v, ts := range g.values {
+		if ts.Before(start) {
+			delete(g.values, v)
+		}
+	}
+}
+
+// gc removes the groups which didn't observe
+// any matches for the duration of the window.
+func (t *thresholdState) gc() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, g := range t.groups {
+		if time.Since(g.last) > t.window {
+			log.Debugf("garbage collecting threshold group [%s] in rule %s", key, t.name)
+			delete(t.groups, key)
+			thresholdGroupsCount.Add(t.name, -1)
+			thresholdEvictions.Add(t.name, 1)
+		}
+	}
+}
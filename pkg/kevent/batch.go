@@ -18,9 +18,21 @@
 
 package kevent
 
+import "sync/atomic"
+
 // Batch contains a sequence of kernel events.
 type Batch struct {
 	Events []*Kevent
+	// shared is not nil for batches that
+	// reference events of a parent batch
+	shared *sharedEvents
+}
+
+// sharedEvents keeps track of batches
+// referencing events of the parent batch.
+type sharedEvents struct {
+	refs atomic.Int32
+	evts []*Kevent
 }
 
 // NewBatch produces a new batch from the group of events.
@@ -31,8 +43,37 @@ func NewBatch(evts ...*Kevent) *Batch {
 // Len returns the length of the batch.
 func (b *Batch) Len() int64 { return int64(len(b.Events)) }
 
+// Share derives a batch for each of the given event subsets. The subsets
+// must only contain events of this batch. The derived batches share the
+// events of this batch, and events are returned to the pool after the last
+// derived batch is released. If no subsets are given, the events are released
+// immediately. This batch must not be used after the call.
+func (b *Batch) Share(subsets ...[]*Kevent) []*Batch {
+	if len(subsets) == 0 {
+		b.Release()
+		return nil
+	}
+	shared := &sharedEvents{evts: b.Events}
+	shared.refs.Store(int32(len(subsets)))
+	batches := make([]*Batch, len(subsets))
+	for i, evts := range subsets {
+		batches[i] = &Batch{Events: evts, shared: shared}
+	}
+	return batches
+}
+
 // Release releases all events from the batch and returns them to the pool.
+// If the batch shares events with other batches, the events are returned to
+// the pool when all batches sharing the events are released.
 func (b *Batch) Release() {
+	if b.shared != nil {
+		if b.shared.refs.Add(-1) == 0 {
+			for _, e := range b.shared.evts {
+				e.Release()
+			}
+		}
+		return
+	}
 	for _, e := range b.Events {
 		e.Release()
 	}
@@ -56,3 +97,28 @@ func (b *Batch) MarshalJSON() []byte {
 	buf = append(buf, ']')
 	return buf
 }
+
+// MarshalECS serializes the batch of events to the JSON array
+// of documents that conform to the Elastic Common Schema.
+func (b *Batch) MarshalECS() []byte {
+	buf := make([]byte, 0)
+	buf = append(buf, '[')
+	for i, kevt := range b.Events {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, kevt.MarshalECS()...)
+	}
+	buf = append(buf, ']')
+	return buf
+}
+
+// MarshalProtobuf serializes the batch of events to
+// the EventBatch Protocol Buffers message.
+func (b *Batch) MarshalProtobuf() []byte {
+	buf := make(pbBuffer, 0)
+	for _, kevt := range b.Events {
+		buf = buf.bytes(1, kevt.appendProtobuf(make(pbBuffer, 0, 512)))
+	}
+	return buf
+}
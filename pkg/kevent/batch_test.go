@@ -251,3 +251,23 @@ func TestBatchMarshalJSON(t *testing.T) {
 	assert.Equal(t, uint32(459), kevts[1].PID)
 	assert.Equal(t, uint32(829), kevts[2].PID)
 }
+
+func TestBatchShare(t *testing.T) {
+	kevt1 := &Kevent{Type: ktypes.CreateFile, PID: 859, Name: "CreateFile"}
+	kevt2 := &Kevent{Type: ktypes.CreateProcess, PID: 459, Name: "CreateProcess"}
+
+	b := NewBatch(kevt1, kevt2)
+	batches := b.Share([]*Kevent{kevt1, kevt2}, []*Kevent{kevt2})
+	require.Len(t, batches, 2)
+	require.Equal(t, int64(2), batches[0].Len())
+	require.Equal(t, int64(1), batches[1].Len())
+
+	// events are retained until all batches are released
+	batches[0].Release()
+	require.Equal(t, uint32(459), batches[1].Events[0].PID)
+	require.Equal(t, "CreateFile", kevt1.Name)
+
+	batches[1].Release()
+	require.Equal(t, "", kevt1.Name)
+	require.Equal(t, uint32(0), kevt2.PID)
+}
@@ -52,6 +52,8 @@ const (
 	RuleNameKey MetadataKey = "rule.name"
 	// RuleGroupKey identifies the group to which the triggered rule pertains
 	RuleGroupKey MetadataKey = "rule.group"
+	// RuleSeverityKey represents the severity of the triggered rule
+	RuleSeverityKey MetadataKey = "rule.severity"
 	// RuleSequenceByKey represents the join field value in sequence rules
 	RuleSequenceByKey MetadataKey = "rule.seq.by"
 	// RuleExpressionKey represents the rule filter expression
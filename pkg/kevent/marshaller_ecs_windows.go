@@ -0,0 +1,357 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kevent
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+)
+
+// ecsVersion is the Elastic Common Schema version the serialized events conform to
+const ecsVersion = "8.11.0"
+
+// ecsCategories maps event categories to ECS event categories
+var ecsCategories = map[ktypes.Category][]string{
+	ktypes.Registry: {"registry"},
+	ktypes.File:     {"file"},
+	ktypes.Net:      {"network"},
+	ktypes.Process:  {"process"},
+	ktypes.Thread:   {"process"},
+	ktypes.Image:    {"library"},
+	ktypes.Handle:   {"process"},
+	ktypes.Driver:   {"driver"},
+	ktypes.Mem:      {"process"},
+}
+
+// ecsTypes maps event names to ECS event types
+var ecsTypes = map[string][]string{
+	"CreateProcess":      {"start"},
+	"TerminateProcess":   {"end"},
+	"OpenProcess":        {"access"},
+	"CreateThread":       {"start"},
+	"TerminateThread":    {"end"},
+	"OpenThread":         {"access"},
+	"SetThreadContext":   {"change"},
+	"ReadFile":           {"access"},
+	"WriteFile":          {"change"},
+	"CreateFile":         {"access"},
+	"DeleteFile":         {"deletion"},
+	"RenameFile":         {"change"},
+	"SetFileInformation": {"change"},
+	"EnumDirectory":      {"access"},
+	"MapViewFile":        {"access"},
+	"RegCreateKey":       {"creation"},
+	"RegOpenKey":         {"access"},
+	"RegSetValue":        {"change"},
+	"RegQueryValue":      {"access"},
+	"RegQueryKey":        {"access"},
+	"RegDeleteKey":       {"deletion"},
+	"RegDeleteValue":     {"deletion"},
+	"Accept":             {"connection", "start"},
+	"Connect":            {"connection", "start"},
+	"Reconnect":          {"connection", "start"},
+	"Disconnect":         {"connection", "end"},
+	"Send":               {"connection"},
+	"Recv":               {"connection"},
+	"Retransmit":         {"connection"},
+	"QueryDns":           {"protocol"},
+	"ReplyDNS":           {"protocol"},
+	"LoadImage":          {"start"},
+	"UnloadImage":        {"end"},
+}
+
+// ecsObject writes JSON object fields taking care of field delimiters.
+type ecsObject struct {
+	js *jsonStream
+	n  int
+}
+
+func newECSObject(js *jsonStream) *ecsObject {
+	js.writeObjectStart()
+	return &ecsObject{js: js}
+}
+
+func (o *ecsObject) field(name string) *jsonStream {
+	if o.n > 0 {
+		o.js.writeMore()
+	}
+	o.n++
+	return o.js.writeObjectField(name)
+}
+
+// object starts a nested object under the given field name.
+func (o *ecsObject) object(name string) *ecsObject {
+	o.field(name)
+	return newECSObject(o.js)
+}
+
+func (o *ecsObject) str(name, value string) {
+	if value == "" {
+		return
+	}
+	o.field(name).writeEscapeString(value)
+}
+
+func (o *ecsObject) strs(name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	o.field(name).writeArrayStart()
+	for i, v := range values {
+		o.js.writeEscapeString(v)
+		if o.js.shouldWriteMore(i, len(values)) {
+			o.js.writeMore()
+		}
+	}
+	o.js.writeArrayEnd()
+}
+
+func (o *ecsObject) end() { o.js.writeObjectEnd() }
+
+// MarshalECS produces a JSON payload for this kevent that conforms to
+// the Elastic Common Schema (ECS). Event parameters, process state,
+// and rule metadata are mapped to the corresponding ECS field sets.
+// The original event parameters and the callstack are retained under
+// the fibratus custom field set.
+func (e *Kevent) MarshalECS() []byte {
+	if e == nil {
+		return []byte{}
+	}
+
+	js := newJSONStream()
+	doc := newECSObject(js)
+
+	timestamp := make([]byte, 0)
+	timestamp = e.Timestamp.AppendFormat(timestamp, time.RFC3339Nano)
+	doc.field("@timestamp").writeString(string(timestamp))
+
+	ecs := doc.object("ecs")
+	ecs.str("version", ecsVersion)
+	ecs.end()
+
+	doc.str("message", e.Description)
+
+	ruleName := e.GetMetaAsString(RuleNameKey)
+
+	evt := doc.object("event")
+	if ruleName != "" {
+		evt.str("kind", "alert")
+	} else {
+		evt.str("kind", "event")
+	}
+	evt.strs("category", ecsCategories[e.Category])
+	typ := ecsTypes[e.Name]
+	if e.IsCreateFile() && e.Kparams.Contains(kparams.FileOperation) && e.IsCreateDisposition() {
+		typ = []string{"creation"}
+	}
+	if typ == nil {
+		typ = []string{"info"}
+	}
+	evt.strs("type", typ)
+	evt.str("action", e.Name)
+	if e.IsSuccess() {
+		evt.str("outcome", "success")
+	} else {
+		evt.str("outcome", "failure")
+	}
+	evt.field("sequence").writeUint64(e.Seq)
+	evt.str("provider", "fibratus")
+	evt.str("dataset", "fibratus."+string(e.Category))
+	evt.end()
+
+	host := doc.object("host")
+	host.str("hostname", e.Host)
+	host.str("name", e.Host)
+	host.end()
+
+	e.writeECSProcess(doc)
+
+	switch e.Category {
+	case ktypes.File:
+		e.writeECSFile(doc, "file")
+	case ktypes.Image:
+		e.writeECSFile(doc, "dll")
+	case ktypes.Registry:
+		e.writeECSRegistry(doc)
+	case ktypes.Net:
+		e.writeECSNetwork(doc)
+	}
+
+	if ruleName != "" {
+		rule := doc.object("rule")
+		rule.str("name", ruleName)
+		rule.str("ruleset", e.GetMetaAsString(RuleGroupKey))
+		rule.end()
+	}
+
+	if len(e.Metadata) > 0 {
+		keys := make([]string, 0, len(e.Metadata))
+		for k := range e.Metadata {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		labels := doc.object("labels")
+		for _, k := range keys {
+			// ECS labels can't contain dots in field names
+			labels.field(strings.ReplaceAll(k, ".", "_")).writeEscapeString(fmt.Sprintf("%v", e.Metadata[MetadataKey(k)]))
+		}
+		labels.end()
+	}
+
+	fibratus := doc.object("fibratus")
+	fibratus.field("cpu").writeUint8(e.CPU)
+	fibratus.field("kparams").writeKparams(e)
+	if !e.Callstack.IsEmpty() {
+		fibratus.strs("callstack", strings.Split(e.Callstack.String(), "|"))
+	}
+	fibratus.end()
+
+	doc.end()
+
+	return js.flush()
+}
+
+func (e *Kevent) writeECSProcess(doc *ecsObject) {
+	proc := doc.object("process")
+	proc.field("pid").writeUint32(e.PID)
+	thread := proc.object("thread")
+	thread.field("id").writeUint32(e.Tid)
+	thread.end()
+
+	ps := e.PS
+	if ps == nil {
+		proc.end()
+		return
+	}
+	proc.str("name", ps.Name)
+	proc.str("executable", ps.Exe)
+	proc.str("command_line", ps.Cmdline)
+	proc.strs("args", ps.Args)
+	proc.str("working_directory", ps.Cwd)
+	if !ps.StartTime.IsZero() {
+		proc.field("start").writeString(ps.StartTime.Format(time.RFC3339Nano))
+	}
+	if parent := ps.Parent; parent != nil || ps.Ppid != 0 {
+		p := proc.object("parent")
+		p.field("pid").writeUint32(ps.Ppid)
+		if parent != nil {
+			p.str("name", parent.Name)
+			p.str("executable", parent.Exe)
+			p.str("command_line", parent.Cmdline)
+		}
+		p.end()
+	}
+	proc.end()
+
+	if ps.SID != "" || ps.Username != "" {
+		user := doc.object("user")
+		user.str("id", ps.SID)
+		user.str("name", ps.Username)
+		user.str("domain", ps.Domain)
+		user.end()
+	}
+}
+
+func (e *Kevent) writeECSFile(doc *ecsObject, name string) {
+	path := e.GetParamAsString(kparams.FileName)
+	if path == "" {
+		return
+	}
+	file := doc.object(name)
+	file.str("path", path)
+	file.str("name", filepath.Base(path))
+	file.str("directory", filepath.Dir(path))
+	if ext := filepath.Ext(path); ext != "" {
+		file.str("extension", strings.TrimPrefix(ext, "."))
+	}
+	file.end()
+}
+
+func (e *Kevent) writeECSRegistry(doc *ecsObject) {
+	path := e.GetParamAsString(kparams.RegKeyName)
+	if path == "" {
+		return
+	}
+	reg := doc.object("registry")
+	reg.str("path", path)
+	hive, key, _ := strings.Cut(path, `\`)
+	reg.str("hive", hive)
+	switch e.Type {
+	case ktypes.RegSetValue, ktypes.RegQueryValue, ktypes.RegDeleteValue:
+		// the key path contains the value name
+		// as the last path component
+		if i := strings.LastIndexByte(key, '\\'); i >= 0 {
+			reg.str("key", key[:i])
+			reg.str("value", key[i+1:])
+		} else {
+			reg.str("value", key)
+		}
+		if e.Kparams.Contains(kparams.RegValue) {
+			data := reg.object("data")
+			data.str("type", e.GetParamAsString(kparams.RegValueType))
+			data.strs("strings", []string{e.GetParamAsString(kparams.RegValue)})
+			data.end()
+		}
+	default:
+		reg.str("key", key)
+	}
+	reg.end()
+}
+
+func (e *Kevent) writeECSNetwork(doc *ecsObject) {
+	if e.Kparams.Contains(kparams.NetSIP) {
+		src := doc.object("source")
+		src.str("ip", e.GetParamAsString(kparams.NetSIP))
+		if port, err := e.Kparams.GetUint16(kparams.NetSport); err == nil {
+			src.field("port").writeUint16(port)
+		}
+		src.end()
+	}
+	if e.Kparams.Contains(kparams.NetDIP) {
+		dst := doc.object("destination")
+		dst.str("ip", e.GetParamAsString(kparams.NetDIP))
+		if port, err := e.Kparams.GetUint16(kparams.NetDport); err == nil {
+			dst.field("port").writeUint16(port)
+		}
+		dst.end()
+	}
+	network := doc.object("network")
+	if e.IsDNS() {
+		network.str("protocol", "dns")
+	} else if e.IsNetworkUDP() {
+		network.str("transport", "udp")
+	} else {
+		network.str("transport", "tcp")
+	}
+	if ip, err := e.Kparams.GetIP(kparams.NetDIP); err == nil {
+		if ip.To4() != nil {
+			network.str("type", "ipv4")
+		} else if len(ip) == net.IPv6len {
+			network.str("type", "ipv6")
+		}
+	}
+	network.end()
+}
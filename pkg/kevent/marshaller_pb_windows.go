@@ -0,0 +1,277 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kevent
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// pbBuffer encodes Protocol Buffers messages described in kevent.proto.
+// Fields with zero values are omitted as mandated by proto3 semantics.
+type pbBuffer []byte
+
+func (b pbBuffer) uint(num protowire.Number, v uint64) pbBuffer {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func (b pbBuffer) sint(num protowire.Number, v int64) pbBuffer {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeZigZag(v))
+}
+
+func (b pbBuffer) double(num protowire.Number, v float64) pbBuffer {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func (b pbBuffer) bool(num protowire.Number, v bool) pbBuffer {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(v))
+}
+
+func (b pbBuffer) str(num protowire.Number, s string) pbBuffer {
+	if s == "" {
+		return b
+	}
+	return b.bytes(num, []byte(s))
+}
+
+func (b pbBuffer) strs(num protowire.Number, values []string) pbBuffer {
+	for _, s := range values {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+	return b
+}
+
+func (b pbBuffer) bytes(num protowire.Number, v []byte) pbBuffer {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func (b pbBuffer) timestamp(num protowire.Number, t time.Time) pbBuffer {
+	if t.IsZero() {
+		return b
+	}
+	var ts pbBuffer
+	ts = ts.uint(1, uint64(t.Unix()))
+	ts = ts.uint(2, uint64(t.Nanosecond()))
+	return b.bytes(num, ts)
+}
+
+// stringMap encodes the map<string, string> field.
+func (b pbBuffer) stringMap(num protowire.Number, m map[string]string) pbBuffer {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var entry pbBuffer
+		entry = entry.bytes(1, []byte(k))
+		entry = entry.bytes(2, []byte(m[k]))
+		b = b.bytes(num, entry)
+	}
+	return b
+}
+
+// MarshalProtobuf produces the Protocol Buffers payload for this kevent.
+// The payload is the Event message as defined in the kevent.proto file.
+func (e *Kevent) MarshalProtobuf() []byte {
+	if e == nil {
+		return []byte{}
+	}
+	return e.appendProtobuf(make(pbBuffer, 0, 512))
+}
+
+func (e *Kevent) appendProtobuf(b pbBuffer) pbBuffer {
+	b = b.uint(1, e.Seq)
+	b = b.uint(2, uint64(e.PID))
+	b = b.uint(3, uint64(e.Tid))
+	b = b.uint(4, uint64(e.CPU))
+	b = b.str(5, e.Name)
+	b = b.str(6, string(e.Category))
+	b = b.str(7, e.Description)
+	b = b.str(8, e.Host)
+	b = b.timestamp(9, e.Timestamp)
+
+	pars := make([]*Kparam, 0, len(e.Kparams))
+	for _, kpar := range e.Kparams {
+		pars = append(pars, kpar)
+	}
+	sort.Slice(pars, func(i, j int) bool { return pars[i].Name < pars[j].Name })
+	for _, kpar := range pars {
+		var entry pbBuffer
+		entry = entry.bytes(1, []byte(kpar.Name))
+		entry = entry.bytes(2, e.marshalProtobufParam(kpar))
+		b = b.bytes(10, entry)
+	}
+
+	if len(e.Metadata) > 0 {
+		meta := make(map[string]string, len(e.Metadata))
+		for k, v := range e.Metadata {
+			meta[k.String()] = fmt.Sprintf("%v", v)
+		}
+		b = b.stringMap(11, meta)
+	}
+
+	if e.PS != nil {
+		b = b.bytes(12, marshalProtobufPS(e.PS, true))
+	}
+
+	for _, frame := range e.Callstack {
+		var f pbBuffer
+		f = f.uint(1, frame.Addr.Uint64())
+		f = f.uint(2, frame.Offset)
+		f = f.str(3, frame.Symbol)
+		f = f.str(4, frame.Module)
+		b = b.bytes(13, f)
+	}
+
+	return b
+}
+
+// marshalProtobufParam encodes the Param message.
+func (e *Kevent) marshalProtobufParam(kpar *Kparam) pbBuffer {
+	var b pbBuffer
+	switch kpar.Type {
+	case kparams.Int64:
+		return b.sint(1, kpar.Value.(int64))
+	case kparams.Int32:
+		return b.sint(1, int64(kpar.Value.(int32)))
+	case kparams.Int16:
+		return b.sint(1, int64(kpar.Value.(int16)))
+	case kparams.Int8:
+		return b.sint(1, int64(kpar.Value.(int8)))
+	case kparams.Uint64:
+		return b.uintValue(kpar.Value.(uint64))
+	case kparams.Uint32, kparams.PID, kparams.TID:
+		return b.uintValue(uint64(kpar.Value.(uint32)))
+	case kparams.Uint16, kparams.Port:
+		return b.uintValue(uint64(kpar.Value.(uint16)))
+	case kparams.Uint8:
+		return b.uintValue(uint64(kpar.Value.(uint8)))
+	case kparams.Float:
+		return b.double(3, float64(kpar.Value.(float32)))
+	case kparams.Double:
+		return b.double(3, kpar.Value.(float64))
+	case kparams.Bool:
+		return b.bool(4, kpar.Value.(bool))
+	case kparams.IPv4, kparams.IPv6:
+		return b.bytes(5, []byte(kpar.Value.(net.IP).String()))
+	case kparams.Time:
+		return b.bytes(5, []byte(kpar.Value.(time.Time).String()))
+	case kparams.Slice:
+		if slice, ok := kpar.Value.([]string); ok {
+			var list pbBuffer
+			return b.bytes(6, list.strs(1, slice))
+		}
+	}
+	return b.bytes(5, []byte(e.GetParamAsString(kpar.Name)))
+}
+
+// uintValue encodes the uint oneof member. Unlike regular scalar
+// fields, oneof members are encoded even if they have zero values.
+func (b pbBuffer) uintValue(v uint64) pbBuffer {
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// marshalProtobufPS encodes the Process message. Process resources
+// are only encoded for the process that generated the event, and
+// are governed by the same flags as in the JSON serializer.
+func marshalProtobufPS(ps *pstypes.PS, resources bool) pbBuffer {
+	var b pbBuffer
+	b = b.uint(1, uint64(ps.PID))
+	b = b.uint(2, uint64(ps.Ppid))
+	b = b.str(3, ps.Name)
+	b = b.str(4, ps.Cmdline)
+	b = b.str(5, ps.Exe)
+	b = b.str(6, ps.Cwd)
+	b = b.str(7, ps.SID)
+	b = b.strs(8, ps.Args)
+	b = b.uint(9, uint64(ps.SessionID))
+	b = b.str(10, ps.Username)
+	b = b.str(11, ps.Domain)
+	b = b.timestamp(12, ps.StartTime)
+	if !resources {
+		return b
+	}
+	if ps.Parent != nil {
+		b = b.bytes(13, marshalProtobufPS(ps.Parent, false))
+	}
+	if SerializeEnvs {
+		b = b.stringMap(14, ps.Envs)
+	}
+	if SerializeThreads {
+		ps.RLock()
+		tids := make([]uint32, 0, len(ps.Threads))
+		for tid := range ps.Threads {
+			tids = append(tids, tid)
+		}
+		sort.Slice(tids, func(i, j int) bool { return tids[i] < tids[j] })
+		for _, tid := range tids {
+			thread := ps.Threads[tid]
+			var t pbBuffer
+			t = t.uint(1, uint64(thread.Tid))
+			t = t.uint(2, uint64(thread.IOPrio))
+			t = t.uint(3, uint64(thread.BasePrio))
+			t = t.uint(4, uint64(thread.PagePrio))
+			t = t.uint(5, thread.Entrypoint.Uint64())
+			t = t.uint(6, thread.UstackBase.Uint64())
+			t = t.uint(7, thread.UstackLimit.Uint64())
+			t = t.uint(8, thread.KstackBase.Uint64())
+			t = t.uint(9, thread.KstackLimit.Uint64())
+			b = b.bytes(15, t)
+		}
+		ps.RUnlock()
+	}
+	if SerializeImages {
+		for _, m := range ps.Modules {
+			var mod pbBuffer
+			mod = mod.str(1, m.Name)
+			mod = mod.uint(2, m.Size)
+			mod = mod.uint(3, m.BaseAddress.Uint64())
+			b = b.bytes(16, mod)
+		}
+	}
+	if SerializeHandles {
+		for _, handle := range ps.Handles {
+			var h pbBuffer
+			h = h.uint(1, uint64(handle.Num))
+			h = h.str(2, handle.Type)
+			h = h.str(3, handle.Name)
+			h = h.uint(4, handle.Object)
+			b = b.bytes(17, h)
+		}
+	}
+	return b
+}
@@ -21,8 +21,13 @@ package kevent
 import (
 	"encoding/json"
 	kcapver "github.com/rabbitstack/fibratus/pkg/kcap/version"
+	"github.com/rabbitstack/fibratus/pkg/kevent/pb"
 	"github.com/rabbitstack/fibratus/pkg/util/va"
 	"golang.org/x/sys/windows"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"net"
 	"os"
 	"testing"
 	"time"
@@ -594,3 +599,400 @@ func BenchmarkUnmarshal(b *testing.B) {
 		}
 	}
 }
+
+func TestKeventMarshalECS(t *testing.T) {
+	kevt := &Kevent{
+		Type:        ktypes.ConnectTCPv4,
+		Tid:         2484,
+		PID:         859,
+		CPU:         1,
+		Seq:         2,
+		Name:        "Connect",
+		Timestamp:   time.Date(2023, 5, 3, 15, 4, 5, 0, time.UTC),
+		Category:    ktypes.Net,
+		Host:        "archrabbit",
+		Description: "Connects a socket to the remote peer",
+		Kparams: Kparams{
+			kparams.NetDIP:   {Name: kparams.NetDIP, Type: kparams.IPv4, Value: net.ParseIP("216.58.201.174")},
+			kparams.NetSIP:   {Name: kparams.NetSIP, Type: kparams.IPv4, Value: net.ParseIP("192.168.1.8")},
+			kparams.NetDport: {Name: kparams.NetDport, Type: kparams.Port, Value: uint16(443)},
+			kparams.NetSport: {Name: kparams.NetSport, Type: kparams.Port, Value: uint16(53742)},
+		},
+		Metadata: map[MetadataKey]any{RuleNameKey: "Suspicious connection", RuleGroupKey: "command and control"},
+		PS: &pstypes.PS{
+			PID:      859,
+			Ppid:     6304,
+			Name:     "rundll32.exe",
+			Exe:      `C:\Windows\System32\rundll32.exe`,
+			Cmdline:  `C:\Windows\System32\rundll32.exe payload.dll,Start`,
+			Args:     []string{"payload.dll,Start"},
+			SID:      `S-1-5-18`,
+			Username: "SYSTEM",
+			Domain:   "NT AUTHORITY",
+			Parent: &pstypes.PS{
+				Name: "explorer.exe",
+				Exe:  `C:\Windows\explorer.exe`,
+			},
+		},
+	}
+	kevt.Callstack.PushFrame(Frame{Addr: 0x7ffb5c1d0396, Offset: 0x61, Symbol: "connect", Module: "C:\\WINDOWS\\System32\\ws2_32.dll"})
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(kevt.MarshalECS(), &doc))
+
+	field := func(path ...string) any {
+		var v any = doc
+		for _, p := range path {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil
+			}
+			v = m[p]
+		}
+		return v
+	}
+
+	assert.Equal(t, "2023-05-03T15:04:05Z", field("@timestamp"))
+	assert.Equal(t, ecsVersion, field("ecs", "version"))
+	assert.Equal(t, "alert", field("event", "kind"))
+	assert.Equal(t, []any{"network"}, field("event", "category"))
+	assert.Equal(t, []any{"connection", "start"}, field("event", "type"))
+	assert.Equal(t, "Connect", field("event", "action"))
+	assert.Equal(t, float64(2), field("event", "sequence"))
+	assert.Equal(t, "archrabbit", field("host", "name"))
+
+	assert.Equal(t, float64(859), field("process", "pid"))
+	assert.Equal(t, float64(2484), field("process", "thread", "id"))
+	assert.Equal(t, "rundll32.exe", field("process", "name"))
+	assert.Equal(t, `C:\Windows\System32\rundll32.exe payload.dll,Start`, field("process", "command_line"))
+	assert.Equal(t, float64(6304), field("process", "parent", "pid"))
+	assert.Equal(t, "explorer.exe", field("process", "parent", "name"))
+	assert.Equal(t, "S-1-5-18", field("user", "id"))
+	assert.Equal(t, "NT AUTHORITY", field("user", "domain"))
+
+	assert.Equal(t, "192.168.1.8", field("source", "ip"))
+	assert.Equal(t, float64(53742), field("source", "port"))
+	assert.Equal(t, "216.58.201.174", field("destination", "ip"))
+	assert.Equal(t, float64(443), field("destination", "port"))
+	assert.Equal(t, "tcp", field("network", "transport"))
+	assert.Equal(t, "ipv4", field("network", "type"))
+
+	assert.Equal(t, "Suspicious connection", field("rule", "name"))
+	assert.Equal(t, "command and control", field("rule", "ruleset"))
+	assert.Equal(t, "Suspicious connection", field("labels", "rule_name"))
+
+	assert.Equal(t, "216.58.201.174", field("fibratus", "kparams", "dip"))
+	assert.Len(t, field("fibratus", "callstack"), 1)
+}
+
+func TestKeventMarshalECSFile(t *testing.T) {
+	kevt := &Kevent{
+		Type:     ktypes.CreateFile,
+		Name:     "CreateFile",
+		Category: ktypes.File,
+		Kparams: Kparams{
+			kparams.FileName:      {Name: kparams.FileName, Type: kparams.UnicodeString, Value: `C:\Windows\system32\user32.dll`},
+			kparams.FileOperation: {Name: kparams.FileOperation, Type: kparams.Enum, Value: uint32(windows.FILE_CREATE)},
+		},
+		Metadata: make(map[MetadataKey]any),
+	}
+
+	var doc struct {
+		Event struct {
+			Kind string   `json:"kind"`
+			Type []string `json:"type"`
+		} `json:"event"`
+		File struct {
+			Path      string `json:"path"`
+			Name      string `json:"name"`
+			Directory string `json:"directory"`
+			Extension string `json:"extension"`
+		} `json:"file"`
+	}
+	require.NoError(t, json.Unmarshal(kevt.MarshalECS(), &doc))
+
+	assert.Equal(t, "event", doc.Event.Kind)
+	assert.Equal(t, []string{"creation"}, doc.Event.Type)
+	assert.Equal(t, `C:\Windows\system32\user32.dll`, doc.File.Path)
+	assert.Equal(t, "user32.dll", doc.File.Name)
+	assert.Equal(t, `C:\Windows\system32`, doc.File.Directory)
+	assert.Equal(t, "dll", doc.File.Extension)
+}
+
+// decodeProtobuf decodes top-level fields of the protobuf message. Varint
+// values are stored as uint64 and length-delimited values as byte slices.
+func decodeProtobuf(t *testing.T, b []byte) map[protowire.Number][]any {
+	fields := make(map[protowire.Number][]any)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			require.True(t, n > 0)
+			fields[num] = append(fields[num], v)
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			require.True(t, n > 0)
+			fields[num] = append(fields[num], v)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			require.True(t, n > 0)
+			b = b[n:]
+		}
+	}
+	return fields
+}
+
+func TestKeventMarshalProtobuf(t *testing.T) {
+	kevt := &Kevent{
+		Type:      ktypes.CreateFile,
+		Tid:       2484,
+		PID:       859,
+		CPU:       1,
+		Seq:       2,
+		Name:      "CreateFile",
+		Timestamp: time.Unix(1683126245, 323),
+		Category:  ktypes.File,
+		Host:      "archrabbit",
+		Kparams: Kparams{
+			kparams.FileName:    {Name: kparams.FileName, Type: kparams.UnicodeString, Value: `C:\Windows\system32\user32.dll`},
+			kparams.BasePrio:    {Name: kparams.BasePrio, Type: kparams.Int8, Value: int8(-2)},
+			kparams.NetDIPNames: {Name: kparams.NetDIPNames, Type: kparams.Slice, Value: []string{"dns.google.", "github.com."}},
+		},
+		Metadata: map[MetadataKey]any{RuleNameKey: "Suspicious DLL"},
+		PS: &pstypes.PS{
+			PID:    859,
+			Name:   "rundll32.exe",
+			Parent: &pstypes.PS{Name: "explorer.exe"},
+			Handles: []htypes.Handle{
+				{Num: windows.Handle(0x1c4), Name: `C:\Users\bunny`, Type: "File", Object: 357488883434455544},
+			},
+		},
+	}
+	kevt.Callstack.PushFrame(Frame{Addr: 0x7ffb5c1d0396, Offset: 0x61, Symbol: "CreateFileW", Module: "C:\\WINDOWS\\System32\\KERNELBASE.dll"})
+
+	evt := decodeProtobuf(t, kevt.MarshalProtobuf())
+	assert.Equal(t, []any{uint64(2)}, evt[1])
+	assert.Equal(t, []any{uint64(859)}, evt[2])
+	assert.Equal(t, []any{uint64(2484)}, evt[3])
+	assert.Equal(t, []any{[]byte("CreateFile")}, evt[5])
+	assert.Equal(t, []any{[]byte("file")}, evt[6])
+
+	ts := decodeProtobuf(t, evt[9][0].([]byte))
+	assert.Equal(t, []any{uint64(1683126245)}, ts[1])
+	assert.Equal(t, []any{uint64(323)}, ts[2])
+
+	// params are sorted by name
+	require.Len(t, evt[10], 3)
+	param := decodeProtobuf(t, evt[10][0].([]byte))
+	assert.Equal(t, []any{[]byte(kparams.BasePrio)}, param[1])
+	val := decodeProtobuf(t, param[2][0].([]byte))
+	assert.Equal(t, int64(-2), protowire.DecodeZigZag(val[1][0].(uint64)))
+	param = decodeProtobuf(t, evt[10][1].([]byte))
+	assert.Equal(t, []any{[]byte(kparams.NetDIPNames)}, param[1])
+	val = decodeProtobuf(t, param[2][0].([]byte))
+	list := decodeProtobuf(t, val[6][0].([]byte))
+	assert.Equal(t, []any{[]byte("dns.google."), []byte("github.com.")}, list[1])
+
+	require.Len(t, evt[11], 1)
+	meta := decodeProtobuf(t, evt[11][0].([]byte))
+	assert.Equal(t, []any{[]byte("rule.name")}, meta[1])
+	assert.Equal(t, []any{[]byte("Suspicious DLL")}, meta[2])
+
+	ps := decodeProtobuf(t, evt[12][0].([]byte))
+	assert.Equal(t, []any{[]byte("rundll32.exe")}, ps[3])
+	parent := decodeProtobuf(t, ps[13][0].([]byte))
+	assert.Equal(t, []any{[]byte("explorer.exe")}, parent[3])
+	require.Len(t, ps[17], 1)
+	handle := decodeProtobuf(t, ps[17][0].([]byte))
+	assert.Equal(t, []any{uint64(0x1c4)}, handle[1])
+	assert.Equal(t, []any{[]byte("File")}, handle[2])
+
+	require.Len(t, evt[13], 1)
+	frame := decodeProtobuf(t, evt[13][0].([]byte))
+	assert.Equal(t, []any{uint64(0x7ffb5c1d0396)}, frame[1])
+	assert.Equal(t, []any{[]byte("CreateFileW")}, frame[3])
+}
+
+// requireNoUnknownFields asserts the message and all of its nested messages were
+// fully decoded. Fields with the number or the wire type not matching the schema
+// are retained as unknown fields.
+func requireNoUnknownFields(t *testing.T, m protoreflect.Message) {
+	require.Empty(t, m.GetUnknown(), "unknown fields in %s", m.Descriptor().FullName())
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					requireNoUnknownFields(t, v.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := 0; i < v.List().Len(); i++ {
+					requireNoUnknownFields(t, v.List().Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			requireNoUnknownFields(t, v.Message())
+		}
+		return true
+	})
+}
+
+func TestKeventMarshalProtobufRoundTrip(t *testing.T) {
+	started := time.Date(2023, 5, 3, 15, 4, 5, 0, time.UTC)
+	kevt := &Kevent{
+		Type:        ktypes.CreateFile,
+		Tid:         2484,
+		PID:         859,
+		CPU:         1,
+		Seq:         2,
+		Name:        "CreateFile",
+		Description: "Creates or opens a file",
+		Timestamp:   time.Unix(1683126245, 323),
+		Category:    ktypes.File,
+		Host:        "archrabbit",
+		Kparams: Kparams{
+			kparams.FileName:    {Name: kparams.FileName, Type: kparams.UnicodeString, Value: `C:\Windows\system32\user32.dll`},
+			kparams.BasePrio:    {Name: kparams.BasePrio, Type: kparams.Int8, Value: int8(-2)},
+			kparams.FileObject:  {Name: kparams.FileObject, Type: kparams.Uint64, Value: uint64(18446738026482168384)},
+			kparams.NetDport:    {Name: kparams.NetDport, Type: kparams.Port, Value: uint16(0)},
+			kparams.NetDIP:      {Name: kparams.NetDIP, Type: kparams.IPv4, Value: net.ParseIP("216.58.201.174")},
+			kparams.NetDIPNames: {Name: kparams.NetDIPNames, Type: kparams.Slice, Value: []string{"dns.google.", "github.com."}},
+			kparams.FileIsDLL:   {Name: kparams.FileIsDLL, Type: kparams.Bool, Value: true},
+			"entropy":           {Name: "entropy", Type: kparams.Double, Value: 7.25},
+		},
+		Metadata: map[MetadataKey]any{RuleNameKey: "Suspicious DLL", RuleGroupKey: "defense evasion"},
+		PS: &pstypes.PS{
+			PID:       859,
+			Ppid:      2484,
+			Name:      "rundll32.exe",
+			Cmdline:   `C:\Windows\system32\rundll32.exe shell32.dll,Control_RunDLL`,
+			Exe:       `C:\Windows\system32\rundll32.exe`,
+			Cwd:       `C:\Windows\system32`,
+			SID:       "S-1-5-18",
+			Args:      []string{"shell32.dll,Control_RunDLL"},
+			SessionID: 1,
+			Username:  "SYSTEM",
+			Domain:    "NT AUTHORITY",
+			StartTime: started,
+			Envs:      map[string]string{"ProgramFiles": `C:\Program Files`},
+			Parent:    &pstypes.PS{PID: 2484, Name: "explorer.exe", Envs: map[string]string{"TEMP": `C:\Temp`}},
+			Threads: map[uint32]pstypes.Thread{
+				3453: {Tid: 3453, IOPrio: 2, BasePrio: 8, PagePrio: 5, Entrypoint: va.Address(140720254731104), UstackBase: va.Address(86638592), UstackLimit: va.Address(86630400), KstackBase: va.Address(18446677035730165760), KstackLimit: va.Address(18446677035730137088)},
+			},
+			Modules: []pstypes.Module{
+				{Name: `C:\Windows\System32\kernel32.dll`, Size: 12354, BaseAddress: va.Address(4294066175)},
+			},
+			Handles: []htypes.Handle{
+				{Num: windows.Handle(0x1c4), Name: `C:\Users\bunny`, Type: "File", Object: 357488883434455544},
+			},
+		},
+	}
+	kevt.Callstack.PushFrame(Frame{Addr: 0x7ffb5c1d0396, Offset: 0x61, Symbol: "CreateFileW", Module: "C:\\WINDOWS\\System32\\KERNELBASE.dll"})
+
+	var evt pb.Event
+	require.NoError(t, proto.Unmarshal(kevt.MarshalProtobuf(), &evt))
+	requireNoUnknownFields(t, evt.ProtoReflect())
+
+	assert.Equal(t, uint64(2), evt.Seq)
+	assert.Equal(t, uint32(859), evt.Pid)
+	assert.Equal(t, uint32(2484), evt.Tid)
+	assert.Equal(t, uint32(1), evt.Cpu)
+	assert.Equal(t, "CreateFile", evt.Name)
+	assert.Equal(t, "file", evt.Category)
+	assert.Equal(t, "Creates or opens a file", evt.Description)
+	assert.Equal(t, "archrabbit", evt.Host)
+	assert.True(t, kevt.Timestamp.Equal(evt.Timestamp.AsTime()))
+
+	require.Len(t, evt.Params, 8)
+	assert.Equal(t, `C:\Windows\system32\user32.dll`, evt.Params[kparams.FileName].GetString_())
+	assert.Equal(t, int64(-2), evt.Params[kparams.BasePrio].GetInt())
+	assert.Equal(t, uint64(18446738026482168384), evt.Params[kparams.FileObject].GetUint())
+	// zero oneof values must be distinguishable from unset values
+	assert.IsType(t, &pb.Param_Uint{}, evt.Params[kparams.NetDport].Value)
+	assert.Equal(t, "216.58.201.174", evt.Params[kparams.NetDIP].GetString_())
+	assert.Equal(t, []string{"dns.google.", "github.com."}, evt.Params[kparams.NetDIPNames].GetStrings().GetValues())
+	assert.True(t, evt.Params[kparams.FileIsDLL].GetBool())
+	assert.Equal(t, 7.25, evt.Params["entropy"].GetDouble())
+
+	assert.Equal(t, map[string]string{"rule.name": "Suspicious DLL", "rule.group": "defense evasion"}, evt.Meta)
+
+	ps := evt.Ps
+	require.NotNil(t, ps)
+	assert.Equal(t, uint32(859), ps.Pid)
+	assert.Equal(t, uint32(2484), ps.Ppid)
+	assert.Equal(t, "rundll32.exe", ps.Name)
+	assert.Equal(t, kevt.PS.Cmdline, ps.Cmdline)
+	assert.Equal(t, kevt.PS.Exe, ps.Exe)
+	assert.Equal(t, kevt.PS.Cwd, ps.Cwd)
+	assert.Equal(t, "S-1-5-18", ps.Sid)
+	assert.Equal(t, []string{"shell32.dll,Control_RunDLL"}, ps.Args)
+	assert.Equal(t, uint32(1), ps.SessionId)
+	assert.Equal(t, "SYSTEM", ps.Username)
+	assert.Equal(t, "NT AUTHORITY", ps.Domain)
+	assert.True(t, started.Equal(ps.StartTime.AsTime()))
+	assert.Equal(t, kevt.PS.Envs, ps.Envs)
+
+	// only the identity fields of the parent are encoded
+	require.NotNil(t, ps.Parent)
+	assert.Equal(t, uint32(2484), ps.Parent.Pid)
+	assert.Equal(t, "explorer.exe", ps.Parent.Name)
+	assert.Empty(t, ps.Parent.Envs)
+
+	require.Len(t, ps.Threads, 1)
+	thread := ps.Threads[0]
+	assert.Equal(t, uint32(3453), thread.Tid)
+	assert.Equal(t, uint32(2), thread.IoPrio)
+	assert.Equal(t, uint32(8), thread.BasePrio)
+	assert.Equal(t, uint32(5), thread.PagePrio)
+	assert.Equal(t, uint64(140720254731104), thread.Entrypoint)
+	assert.Equal(t, uint64(86638592), thread.UstackBase)
+	assert.Equal(t, uint64(86630400), thread.UstackLimit)
+	assert.Equal(t, uint64(18446677035730165760), thread.KstackBase)
+	assert.Equal(t, uint64(18446677035730137088), thread.KstackLimit)
+
+	require.Len(t, ps.Modules, 1)
+	assert.Equal(t, `C:\Windows\System32\kernel32.dll`, ps.Modules[0].Name)
+	assert.Equal(t, uint64(12354), ps.Modules[0].Size)
+	assert.Equal(t, uint64(4294066175), ps.Modules[0].BaseAddress)
+
+	require.Len(t, ps.Handles, 1)
+	assert.Equal(t, uint64(0x1c4), ps.Handles[0].Id)
+	assert.Equal(t, "File", ps.Handles[0].Type)
+	assert.Equal(t, `C:\Users\bunny`, ps.Handles[0].Name)
+	assert.Equal(t, uint64(357488883434455544), ps.Handles[0].Object)
+
+	require.Len(t, evt.Callstack, 1)
+	frame := evt.Callstack[0]
+	assert.Equal(t, uint64(0x7ffb5c1d0396), frame.Address)
+	assert.Equal(t, uint64(0x61), frame.Offset)
+	assert.Equal(t, "CreateFileW", frame.Symbol)
+	assert.Equal(t, "C:\\WINDOWS\\System32\\KERNELBASE.dll", frame.Module)
+}
+
+func TestBatchMarshalProtobuf(t *testing.T) {
+	b := NewBatch(&Kevent{Seq: 1, Name: "CreateFile"}, &Kevent{Seq: 2, Name: "WriteFile"})
+	batch := decodeProtobuf(t, b.MarshalProtobuf())
+	require.Len(t, batch[1], 2)
+	evt := decodeProtobuf(t, batch[1][1].([]byte))
+	assert.Equal(t, []any{uint64(2)}, evt[1])
+	assert.Equal(t, []any{[]byte("WriteFile")}, evt[5])
+
+	var eb pb.EventBatch
+	require.NoError(t, proto.Unmarshal(b.MarshalProtobuf(), &eb))
+	requireNoUnknownFields(t, eb.ProtoReflect())
+	require.Len(t, eb.Events, 2)
+	assert.Equal(t, "CreateFile", eb.Events[0].Name)
+	assert.Equal(t, uint64(2), eb.Events[1].Seq)
+
+	var docs []map[string]any
+	require.NoError(t, json.Unmarshal(NewBatch(&Kevent{Seq: 1}, &Kevent{Seq: 2}).MarshalECS(), &docs))
+	require.Len(t, docs, 2)
+}
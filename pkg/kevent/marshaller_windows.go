@@ -421,8 +421,6 @@ func (e *Kevent) UnmarshalRaw(b []byte, ver kcapver.Version) error {
 	return nil
 }
 
-var js = newJSONStream()
-
 func writePsResources() bool {
 	return SerializeHandles || SerializeThreads || SerializeImages || SerializePE
 }
@@ -433,6 +431,8 @@ func (e *Kevent) MarshalJSON() []byte {
 		return []byte{}
 	}
 
+	js := newJSONStream()
+
 	// start of JSON
 	js.writeObjectStart()
 
@@ -450,70 +450,7 @@ func (e *Kevent) MarshalJSON() []byte {
 	timestamp = e.Timestamp.AppendFormat(timestamp, time.RFC3339Nano)
 	js.writeObjectField("timestamp").writeString(string(timestamp)).writeMore()
 
-	// start kparams
-	js.writeObjectField("kparams")
-	js.writeObjectStart()
-
-	pars := make([]*Kparam, 0, len(e.Kparams))
-	for _, kpar := range e.Kparams {
-		pars = append(pars, kpar)
-	}
-	sort.Slice(pars, func(i, j int) bool { return pars[i].Name < pars[j].Name })
-
-	for i, kpar := range pars {
-		writeMore := js.shouldWriteMore(i, len(pars))
-		js.writeObjectField(kpar.Name)
-		switch kpar.Type {
-		case kparams.Int64:
-			js.writeInt64(kpar.Value.(int64))
-		case kparams.Uint64:
-			js.writeUint64(kpar.Value.(uint64))
-		case kparams.Int32:
-			js.writeInt32(kpar.Value.(int32))
-		case kparams.Uint32:
-			js.writeUint32(kpar.Value.(uint32))
-		case kparams.Int16:
-			js.writeInt16(kpar.Value.(int16))
-		case kparams.Uint16, kparams.Port:
-			js.writeUint16(kpar.Value.(uint16))
-		case kparams.Int8:
-			js.writeInt8(kpar.Value.(int8))
-		case kparams.Uint8:
-			js.writeUint8(kpar.Value.(uint8))
-		case kparams.Float:
-			js.writeFloat32(kpar.Value.(float32))
-		case kparams.Double:
-			js.writeFloat64(kpar.Value.(float64))
-		case kparams.PID, kparams.TID:
-			js.writeUint32(kpar.Value.(uint32))
-		case kparams.IPv4, kparams.IPv6:
-			js.writeString(kpar.Value.(net.IP).String())
-		case kparams.Bool:
-			js.writeBool(kpar.Value.(bool))
-		case kparams.Time:
-			js.writeString(kpar.Value.(time.Time).String())
-		case kparams.Slice:
-			switch slice := kpar.Value.(type) {
-			case []string:
-				js.writeArrayStart()
-				for i, s := range slice {
-					writeMore := js.shouldWriteMore(i, len(slice))
-					js.writeEscapeString(s)
-					if writeMore {
-						js.writeMore()
-					}
-				}
-				js.writeArrayEnd()
-			}
-		default:
-			js.writeEscapeString(e.GetParamAsString(kpar.Name))
-		}
-		if writeMore {
-			js.writeMore()
-		}
-	}
-	// end kparams
-	js.writeObjectEnd().writeMore()
+	js.writeObjectField("kparams").writeKparams(e).writeMore()
 
 	// start metadata
 	js.writeObjectField("meta")
@@ -787,3 +724,68 @@ func (e *Kevent) MarshalJSON() []byte {
 
 	return js.flush()
 }
+
+// writeKparams writes the JSON object with event parameters sorted by name.
+func (js *jsonStream) writeKparams(e *Kevent) *jsonStream {
+	js.writeObjectStart()
+
+	pars := make([]*Kparam, 0, len(e.Kparams))
+	for _, kpar := range e.Kparams {
+		pars = append(pars, kpar)
+	}
+	sort.Slice(pars, func(i, j int) bool { return pars[i].Name < pars[j].Name })
+
+	for i, kpar := range pars {
+		writeMore := js.shouldWriteMore(i, len(pars))
+		js.writeObjectField(kpar.Name)
+		switch kpar.Type {
+		case kparams.Int64:
+			js.writeInt64(kpar.Value.(int64))
+		case kparams.Uint64:
+			js.writeUint64(kpar.Value.(uint64))
+		case kparams.Int32:
+			js.writeInt32(kpar.Value.(int32))
+		case kparams.Uint32:
+			js.writeUint32(kpar.Value.(uint32))
+		case kparams.Int16:
+			js.writeInt16(kpar.Value.(int16))
+		case kparams.Uint16, kparams.Port:
+			js.writeUint16(kpar.Value.(uint16))
+		case kparams.Int8:
+			js.writeInt8(kpar.Value.(int8))
+		case kparams.Uint8:
+			js.writeUint8(kpar.Value.(uint8))
+		case kparams.Float:
+			js.writeFloat32(kpar.Value.(float32))
+		case kparams.Double:
+			js.writeFloat64(kpar.Value.(float64))
+		case kparams.PID, kparams.TID:
+			js.writeUint32(kpar.Value.(uint32))
+		case kparams.IPv4, kparams.IPv6:
+			js.writeString(kpar.Value.(net.IP).String())
+		case kparams.Bool:
+			js.writeBool(kpar.Value.(bool))
+		case kparams.Time:
+			js.writeString(kpar.Value.(time.Time).String())
+		case kparams.Slice:
+			switch slice := kpar.Value.(type) {
+			case []string:
+				js.writeArrayStart()
+				for i, s := range slice {
+					writeMore := js.shouldWriteMore(i, len(slice))
+					js.writeEscapeString(s)
+					if writeMore {
+						js.writeMore()
+					}
+				}
+				js.writeArrayEnd()
+			}
+		default:
+			js.writeEscapeString(e.GetParamAsString(kpar.Name))
+		}
+		if writeMore {
+			js.writeMore()
+		}
+	}
+	return js.writeObjectEnd()
+}
@@ -0,0 +1,23 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pb contains the Go code generated from the kevent.proto schema. It is used to
+// verify the hand-written Protocol Buffers encoder of the kevent package against the schema.
+package pb
+
+//go:generate protoc -I.. --go_out=../../.. --go_opt=module=github.com/rabbitstack/fibratus ../kevent.proto
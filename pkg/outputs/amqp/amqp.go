@@ -45,6 +45,9 @@ func initAMQP(config outputs.Config) (outputs.OutputGroup, error) {
 	if !ok {
 		return outputs.Fail(outputs.ErrInvalidConfig(outputs.AMQP, config.Output))
 	}
+	if err := cfg.Serializer.Validate(); err != nil {
+		return outputs.Fail(err)
+	}
 
 	q := &rabbitmq{client: newClient(cfg)}
 
@@ -67,8 +70,7 @@ func (q *rabbitmq) Close() error {
 }
 
 func (q *rabbitmq) Publish(batch *kevent.Batch) error {
-	body := batch.MarshalJSON()
-	defer batch.Release()
+	body := q.client.config.Serializer.MarshalBatch(batch)
 
 	err := q.client.publish(body)
 	if err != nil {
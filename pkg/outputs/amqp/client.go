@@ -125,7 +125,7 @@ func (c *client) publish(body []byte) error {
 func (c *client) msg(body []byte) amqp.Publishing {
 	return amqp.Publishing{
 		Body:         body,
-		ContentType:  "text/json",
+		ContentType:  c.config.contentType(),
 		Headers:      c.config.amqpHeaders(),
 		DeliveryMode: c.config.deliveryMode(),
 	}
@@ -25,6 +25,8 @@ import (
 // Client represents the minimal interface all output implementors have to satisfy.
 type Client interface {
 	Close() error
+	// Publish sends the batch to the output. The batch is owned by the
+	// caller and must not be retained or released after Publish returns.
 	Publish(*kevent.Batch) error
 	Connect() error
 }
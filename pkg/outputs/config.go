@@ -27,6 +27,8 @@ import (
 type Config struct {
 	Type   Type
 	Output interface{}
+	// Filter is the optional filter expression that decides which events are routed to the output.
+	Filter string
 }
 
 // TLSConfig stores the client TLS parameters.
@@ -41,8 +41,19 @@ const (
 	esTemplateName        = "output.elasticsearch.template-name"
 	esTemplateConfig      = "output.elasticsearch.template-config"
 	esGzipCompression     = "output.elasticsearch.gzip-compression"
+	esSerializer          = "output.elasticsearch.serializer"
+	esDataStream          = "output.elasticsearch.data-stream"
+	esDataStreamName      = "output.elasticsearch.data-stream-name"
+	esILMPolicyName       = "output.elasticsearch.ilm-policy-name"
+	esILMPolicyConfig     = "output.elasticsearch.ilm-policy-config"
+	esILMRolloverMaxAge   = "output.elasticsearch.ilm-rollover-max-age"
+	esILMRolloverMaxSize  = "output.elasticsearch.ilm-rollover-max-size"
+	esILMDeleteAfter      = "output.elasticsearch.ilm-delete-after"
 )
 
+// defaultDataStreamName is the data stream name following the type-dataset-namespace naming scheme
+const defaultDataStreamName = "logs-fibratus-default"
+
 // Config contains the options for tweaking the output behaviour.
 type Config struct {
 	outputs.TLSConfig
@@ -78,6 +89,31 @@ type Config struct {
 	TemplateConfig string `mapstructure:"template-config"`
 	// GzipCompression specifies if gzip compression is enabled.
 	GzipCompression bool `mapstructure:"gzip-compression"`
+	// Serializer indicates the serializer for indexed documents. It can be one of json or ecs.
+	Serializer outputs.Serializer `mapstructure:"serializer"`
+	// DataStream enables the data stream mode. Events are appended to the data stream backed by the
+	// composable index template and the ILM policy instead of the time-based indices.
+	DataStream bool `mapstructure:"data-stream"`
+	// DataStreamName is the name of the data stream where events are written.
+	DataStreamName string `mapstructure:"data-stream-name"`
+	// ILMPolicyName is the name of the ILM policy attached to the data stream. Empty name disables the ILM policy.
+	ILMPolicyName string `mapstructure:"ilm-policy-name"`
+	// ILMPolicyConfig contains the full JSON body of the ILM policy.
+	ILMPolicyConfig string `mapstructure:"ilm-policy-config"`
+	// ILMRolloverMaxAge is the maximum age of the backing index before it is rolled over.
+	ILMRolloverMaxAge string `mapstructure:"ilm-rollover-max-age"`
+	// ILMRolloverMaxSize is the maximum primary shard size of the backing index before it is rolled over.
+	ILMRolloverMaxSize string `mapstructure:"ilm-rollover-max-size"`
+	// ILMDeleteAfter is the age after the rollover at which backing indices are deleted. Empty value retains backing indices forever.
+	ILMDeleteAfter string `mapstructure:"ilm-delete-after"`
+}
+
+// dataStreamName returns the data stream name or the default name if it is not specified.
+func (c Config) dataStreamName() string {
+	if c.DataStreamName == "" {
+		return defaultDataStreamName
+	}
+	return c.DataStreamName
 }
 
 // AddFlags registers persistent flags.
@@ -98,4 +134,12 @@ func AddFlags(flags *pflag.FlagSet) {
 	flags.String(esIndexName, "fibratus", "Represents the target index for kernel events. It allows time specifiers to create indices per time frame")
 	flags.String(esTemplateConfig, "", "Contains the full JSON body of the index template")
 	flags.Bool(esGzipCompression, false, "Specifies if gzip compression is enabled")
+	flags.String(esSerializer, string(outputs.JSON), "Indicates the document serializer type (json, ecs)")
+	flags.Bool(esDataStream, false, "Enables the data stream mode. Events are appended to the data stream backed by the composable index template and the ILM policy")
+	flags.String(esDataStreamName, defaultDataStreamName, "Specifies the name of the data stream where events are written")
+	flags.String(esILMPolicyName, "fibratus", "Specifies the name of the ILM policy attached to the data stream. Empty name disables the ILM policy")
+	flags.String(esILMPolicyConfig, "", "Contains the full JSON body of the ILM policy")
+	flags.String(esILMRolloverMaxAge, "1d", "Specifies the maximum age of the backing index before it is rolled over")
+	flags.String(esILMRolloverMaxSize, "50gb", "Specifies the maximum primary shard size of the backing index before it is rolled over")
+	flags.String(esILMDeleteAfter, "30d", "Specifies the age after the rollover at which backing indices are deleted. Empty value retains backing indices forever")
 }
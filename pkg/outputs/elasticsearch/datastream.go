@@ -0,0 +1,216 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/olivere/elastic/v7"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+)
+
+// minDataStreamVersion is the minimal Elasticsearch version required by the data stream mode
+var minDataStreamVersion, _ = version.NewVersion("7.13")
+
+// dataStreamTemplatePriority takes precedence over the built-in logs-*-* index template
+const dataStreamTemplatePriority = 200
+
+// putDataStreamResources installs the ILM policy and the composable index template
+// that back the data stream. Existing resources are left untouched.
+func (i index) putDataStreamResources() error {
+	ctx := context.Background()
+	if err := i.putILMPolicy(ctx); err != nil {
+		return err
+	}
+	return i.putIndexTemplate(ctx)
+}
+
+// putILMPolicy creates the ILM policy if it doesn't exist.
+func (i index) putILMPolicy(ctx context.Context) error {
+	name := i.config.ILMPolicyName
+	if name == "" {
+		return nil
+	}
+	_, err := i.client.XPackIlmGetLifecycle().Policy(name).Do(ctx)
+	if err == nil {
+		return nil
+	}
+	if !elastic.IsNotFound(err) {
+		return fmt.Errorf("unable to check the existence of the %q ILM policy: %v", name, err)
+	}
+	body := i.config.ILMPolicyConfig
+	if body == "" {
+		b, err := json.Marshal(i.ilmPolicy())
+		if err != nil {
+			return err
+		}
+		body = string(b)
+	}
+	_, err = i.client.XPackIlmPutLifecycle().Policy(name).BodyString(body).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to create the %q ILM policy: %v", name, err)
+	}
+	return nil
+}
+
+// ilmPolicy builds the default ILM policy. Backing indices are rolled over
+// in the hot phase and optionally deleted after the configured age.
+func (i index) ilmPolicy() map[string]any {
+	rollover := make(map[string]any)
+	if i.config.ILMRolloverMaxAge != "" {
+		rollover["max_age"] = i.config.ILMRolloverMaxAge
+	}
+	if i.config.ILMRolloverMaxSize != "" {
+		rollover["max_primary_shard_size"] = i.config.ILMRolloverMaxSize
+	}
+	hot := map[string]any{}
+	if len(rollover) > 0 {
+		hot["rollover"] = rollover
+	}
+	phases := map[string]any{
+		"hot": map[string]any{"actions": hot},
+	}
+	if i.config.ILMDeleteAfter != "" {
+		phases["delete"] = map[string]any{
+			"min_age": i.config.ILMDeleteAfter,
+			"actions": map[string]any{"delete": map[string]any{}},
+		}
+	}
+	return map[string]any{"policy": map[string]any{"phases": phases}}
+}
+
+// putIndexTemplate creates the composable index template if it doesn't exist.
+func (i index) putIndexTemplate(ctx context.Context) error {
+	name := i.config.TemplateName
+	if name == "" {
+		return nil
+	}
+	path := "/_index_template/" + name
+	resp, err := i.client.PerformRequest(ctx, elastic.PerformRequestOptions{
+		Method:       http.MethodHead,
+		Path:         path,
+		IgnoreErrors: []int{http.StatusNotFound},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to check the existence of the %q index template: %v", name, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body := i.config.TemplateConfig
+	if body == "" {
+		tmpl, err := i.composableTemplate()
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(tmpl)
+		if err != nil {
+			return err
+		}
+		body = string(b)
+	}
+	_, err = i.client.PerformRequest(ctx, elastic.PerformRequestOptions{
+		Method: http.MethodPut,
+		Path:   path,
+		Body:   body,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create the %q index template: %v", name, err)
+	}
+	return nil
+}
+
+// composableTemplate derives the composable index template from the
+// settings and field mappings of the legacy index template. The template
+// enables the data stream and attaches the ILM policy to backing indices.
+func (i index) composableTemplate() (map[string]any, error) {
+	b, err := i.renderTemplate(i.config.dataStreamName())
+	if err != nil {
+		return nil, err
+	}
+	var legacy struct {
+		Settings map[string]any `json:"settings"`
+		Mappings map[string]any `json:"mappings"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &legacy); err != nil {
+		return nil, fmt.Errorf("invalid index template: %v", err)
+	}
+	if legacy.Settings == nil {
+		legacy.Settings = make(map[string]any)
+	}
+	if legacy.Mappings == nil {
+		legacy.Mappings = make(map[string]any)
+	}
+	if i.config.ILMPolicyName != "" {
+		settings, ok := legacy.Settings["index"].(map[string]any)
+		if !ok {
+			settings = make(map[string]any)
+			legacy.Settings["index"] = settings
+		}
+		settings["lifecycle"] = map[string]any{"name": i.config.ILMPolicyName}
+	}
+	// data streams require the @timestamp field
+	props, ok := legacy.Mappings["properties"].(map[string]any)
+	if !ok {
+		props = make(map[string]any)
+		legacy.Mappings["properties"] = props
+	}
+	if _, ok := props["@timestamp"]; !ok {
+		props["@timestamp"] = map[string]any{"type": "date"}
+	}
+	return map[string]any{
+		"index_patterns": []string{i.config.dataStreamName()},
+		"data_stream":    map[string]any{},
+		"priority":       dataStreamTemplatePriority,
+		"template": map[string]any{
+			"settings": legacy.Settings,
+			"mappings": legacy.Mappings,
+		},
+	}, nil
+}
+
+// newBulkCreateRequest builds the bulk request for appending the document to
+// the data stream. Data streams only accept the create operation type. The
+// @timestamp field is injected into documents that lack it.
+func newBulkCreateRequest(dataStream string, doc []byte, serializer outputs.Serializer, ts time.Time) *elastic.BulkIndexRequest {
+	if serializer != outputs.ECS {
+		doc = withTimestamp(doc, ts)
+	}
+	return elastic.NewBulkIndexRequest().OpType("create").Index(dataStream).Doc(json.RawMessage(doc))
+}
+
+// withTimestamp prepends the @timestamp field to the JSON document.
+func withTimestamp(doc []byte, ts time.Time) []byte {
+	if len(doc) < 2 || doc[0] != '{' {
+		return doc
+	}
+	b := make([]byte, 0, len(doc)+48)
+	b = append(b, `{"@timestamp":"`...)
+	b = ts.UTC().AppendFormat(b, time.RFC3339Nano)
+	b = append(b, '"')
+	if doc[1] != '}' {
+		b = append(b, ',')
+	}
+	return append(b, doc[1:]...)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package elasticsearch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCluster mimics the Elasticsearch endpoints involved
+// in the data stream setup and bulk ingestion.
+type fakeCluster struct {
+	mu        sync.Mutex
+	version   string
+	ilmPolicy map[string]any
+	template  map[string]any
+	bulk      [][]byte
+	// bulkResponse produces the response for the bulk request
+	bulkResponse func(lines [][]byte) elastic.BulkResponse
+}
+
+func (c *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case r.URL.Path == "/_ilm/policy/fibratus" && r.Method == http.MethodGet:
+		if c.ilmPolicy == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"fibratus":{"version":1,"policy":{}}}`))
+	case r.URL.Path == "/_ilm/policy/fibratus" && r.Method == http.MethodPut:
+		_ = json.NewDecoder(r.Body).Decode(&c.ilmPolicy)
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	case r.URL.Path == "/_index_template/fibratus" && r.Method == http.MethodHead:
+		if c.template == nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.URL.Path == "/_index_template/fibratus" && r.Method == http.MethodPut:
+		_ = json.NewDecoder(r.Body).Decode(&c.template)
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	case r.URL.Path == "/_bulk":
+		var lines [][]byte
+		s := bufio.NewScanner(r.Body)
+		s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for s.Scan() {
+			lines = append(lines, append([]byte(nil), s.Bytes()...))
+		}
+		c.bulk = append(c.bulk, lines...)
+		resp := c.bulkResponse(lines)
+		b, _ := json.Marshal(&resp)
+		_, _ = w.Write(b)
+	default:
+		ping := elastic.PingResult{Name: "es"}
+		ping.Version.Number = c.version
+		b, _ := json.Marshal(&ping)
+		_, _ = w.Write(b)
+	}
+}
+
+func dataStreamConfig(url string) Config {
+	return Config{
+		Servers:            []string{url},
+		FlushPeriod:        time.Millisecond * 100,
+		TemplateName:       "fibratus",
+		DataStream:         true,
+		ILMPolicyName:      "fibratus",
+		ILMRolloverMaxAge:  "1d",
+		ILMRolloverMaxSize: "50gb",
+		ILMDeleteAfter:     "30d",
+		Serializer:         outputs.JSON,
+	}
+}
+
+func TestElasticsearchConnectDataStream(t *testing.T) {
+	cluster := &fakeCluster{version: "8.11.0"}
+	srv := httptest.NewServer(cluster)
+	defer srv.Close()
+
+	cfg := dataStreamConfig(srv.URL)
+	es := &elasticsearch{config: cfg, index: index{config: cfg}}
+	require.NoError(t, es.Connect())
+	defer es.Close()
+
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+
+	require.NotNil(t, cluster.ilmPolicy)
+	phases := cluster.ilmPolicy["policy"].(map[string]any)["phases"].(map[string]any)
+	rollover := phases["hot"].(map[string]any)["actions"].(map[string]any)["rollover"].(map[string]any)
+	assert.Equal(t, "1d", rollover["max_age"])
+	assert.Equal(t, "50gb", rollover["max_primary_shard_size"])
+	assert.Equal(t, "30d", phases["delete"].(map[string]any)["min_age"])
+
+	require.NotNil(t, cluster.template)
+	assert.Equal(t, []any{"logs-fibratus-default"}, cluster.template["index_patterns"])
+	assert.Contains(t, cluster.template, "data_stream")
+	tmpl := cluster.template["template"].(map[string]any)
+	settings := tmpl["settings"].(map[string]any)["index"].(map[string]any)
+	assert.Equal(t, map[string]any{"name": "fibratus"}, settings["lifecycle"])
+	props := tmpl["mappings"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "date"}, props["@timestamp"])
+	assert.Contains(t, props, "kparams")
+}
+
+func TestElasticsearchConnectDataStreamUnsupportedVersion(t *testing.T) {
+	srv := httptest.NewServer(&fakeCluster{version: "7.9.3"})
+	defer srv.Close()
+
+	cfg := dataStreamConfig(srv.URL)
+	es := &elasticsearch{config: cfg, index: index{config: cfg}}
+	require.Error(t, es.Connect())
+}
+
+func TestElasticsearchPublishDataStream(t *testing.T) {
+	cluster := &fakeCluster{
+		version:   "8.11.0",
+		ilmPolicy: map[string]any{},
+		template:  map[string]any{},
+		// reject the second document in the bulk
+		bulkResponse: func(lines [][]byte) elastic.BulkResponse {
+			resp := elastic.BulkResponse{Took: 1}
+			for i := 0; i < len(lines)/2; i++ {
+				item := &elastic.BulkResponseItem{Index: ".ds-logs-fibratus-default-000001", Status: http.StatusCreated}
+				if i == 1 {
+					resp.Errors = true
+					item.Status = http.StatusBadRequest
+					item.Error = &elastic.ErrorDetails{Type: "mapper_parsing_exception", Reason: "failed to parse field [kparams.dip]"}
+				}
+				resp.Items = append(resp.Items, map[string]*elastic.BulkResponseItem{"create": item})
+			}
+			return resp
+		},
+	}
+	srv := httptest.NewServer(cluster)
+	defer srv.Close()
+
+	cfg := dataStreamConfig(srv.URL)
+	es := &elasticsearch{config: cfg, index: index{config: cfg}}
+	require.NoError(t, es.Connect())
+	defer es.Close()
+
+	committed, failed := committedDocs.Value(), failedDocs.Value()
+
+	require.NoError(t, es.Publish(getBatch()))
+	require.NoError(t, es.bulkProcessor.Flush())
+
+	assert.Equal(t, committed+2, committedDocs.Value())
+	assert.Equal(t, failed+1, failedDocs.Value())
+
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+	require.Len(t, cluster.bulk, 6)
+	assert.JSONEq(t, `{"create":{"_index":"logs-fibratus-default"}}`, string(cluster.bulk[0]))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(cluster.bulk[1], &doc))
+	assert.Equal(t, "2018-05-03T15:04:05.323Z", doc["@timestamp"])
+	assert.Equal(t, "CreateFile", doc["name"])
+}
+
+func TestWithTimestamp(t *testing.T) {
+	ts, _ := time.Parse(time.RFC3339, "2018-05-03T15:04:05.323Z")
+	assert.Equal(t, `{"@timestamp":"2018-05-03T15:04:05.323Z","seq":1}`, string(withTimestamp([]byte(`{"seq":1}`), ts)))
+	assert.Equal(t, `{"@timestamp":"2018-05-03T15:04:05.323Z"}`, string(withTimestamp([]byte(`{}`), ts)))
+	assert.True(t, bytes.Equal([]byte(`[]`), withTimestamp([]byte(`[]`), ts)))
+}
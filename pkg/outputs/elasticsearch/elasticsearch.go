@@ -66,6 +66,13 @@ func initElastic(config outputs.Config) (outputs.OutputGroup, error) {
 	if !ok {
 		return outputs.Fail(outputs.ErrInvalidConfig(outputs.Elasticsearch, config.Output))
 	}
+	// Elasticsearch only accepts JSON documents
+	if cfg.Serializer == outputs.Protobuf {
+		return outputs.Fail(fmt.Errorf("%s serializer is not supported by Elasticsearch output", cfg.Serializer))
+	}
+	if err := cfg.Serializer.Validate(); err != nil {
+		return outputs.Fail(err)
+	}
 
 	es := &elasticsearch{config: cfg, index: index{config: cfg}}
 
@@ -125,27 +132,15 @@ func (e *elasticsearch) Connect() error {
 	if v.LessThan(minElasticVersion) {
 		return fmt.Errorf("required at least Elasticsearch %s but found version %s", minElasticVersion.String(), ver)
 	}
+	if e.config.DataStream && v.LessThan(minDataStreamVersion) {
+		return fmt.Errorf("data streams require at least Elasticsearch %s but found version %s", minDataStreamVersion.String(), ver)
+	}
 
 	e.client = client
 	e.index.client = client
 
 	bulkProcessor, err := client.BulkProcessor().
-		After(func(executionId int64, requests []elastic.BulkableRequest, response *elastic.BulkResponse, err error) {
-			if err != nil {
-				log.Errorf("failed to execute bulk: %s", err)
-				return
-			}
-
-			if response.Errors {
-				log.Errorf("failed to insert %d documents", len(response.Failed()))
-				for i, fail := range response.Failed() {
-					failedDocs.Add(1)
-					log.Errorf("failed to insert document %d: %v", i, fail.Error)
-				}
-				return
-			}
-			committedDocs.Add(int64(len(requests)))
-		}).
+		After(afterBulk).
 		FlushInterval(e.config.FlushPeriod).
 		Workers(e.config.BulkWorkers).
 		Do(context.Background())
@@ -153,7 +148,11 @@ func (e *elasticsearch) Connect() error {
 		return fmt.Errorf("couldn't create Elasticsearch bulk processor: %v", err)
 	}
 
-	err = e.index.putTemplate()
+	if e.config.DataStream {
+		err = e.index.putDataStreamResources()
+	} else {
+		err = e.index.putTemplate()
+	}
 	if err != nil {
 		return err
 	}
@@ -176,17 +175,64 @@ func (e *elasticsearch) Publish(batch *kevent.Batch) error {
 		// create the bulk index request for each event in the batch.
 		// We already have a valid JSON body, so just pass the raw
 		// JSON message as request document
-		e.bulkProcessor.Add(newBulkIndexRequest(indexName, kevt))
+		doc := e.config.Serializer.Marshal(kevt)
+		var req *elastic.BulkIndexRequest
+		if e.config.DataStream {
+			req = newBulkCreateRequest(indexName, doc, e.config.Serializer, kevt.Timestamp)
+		} else {
+			req = newBulkIndexRequest(indexName, doc)
+		}
+		e.bulkProcessor.Add(&bulkRequest{BulkIndexRequest: req, seq: kevt.Seq, name: kevt.Name})
 		totalBulkedDocs.Add(1)
 	}
-	batch.Release()
 
 	return nil
 }
 
-func newBulkIndexRequest(indexName string, kevt *kevent.Kevent) *elastic.BulkIndexRequest {
-	kjson := kevt.MarshalJSON()
-	return elastic.NewBulkIndexRequest().Index(indexName).Doc(json.RawMessage(kjson))
+func newBulkIndexRequest(indexName string, doc []byte) *elastic.BulkIndexRequest {
+	return elastic.NewBulkIndexRequest().Index(indexName).Doc(json.RawMessage(doc))
+}
+
+// bulkRequest keeps track of the event the bulk request was created
+// from, so document failures can be reported for each event.
+type bulkRequest struct {
+	*elastic.BulkIndexRequest
+	seq  uint64
+	name string
+}
+
+// afterBulk accounts for committed and failed documents once the bulk
+// is executed. Documents rejected by Elasticsearch don't fail the rest
+// of the bulk. Instead, each failure is reported along with the event
+// that produced the document.
+func afterBulk(_ int64, requests []elastic.BulkableRequest, response *elastic.BulkResponse, err error) {
+	if err != nil {
+		log.Errorf("failed to execute bulk: %s", err)
+		return
+	}
+
+	var failed int
+	for i, item := range response.Items {
+		for _, res := range item {
+			if res.Status >= 200 && res.Status <= 299 {
+				continue
+			}
+			failed++
+			failedDocs.Add(1)
+			reason := fmt.Sprintf("status %d", res.Status)
+			if res.Error != nil {
+				reason = fmt.Sprintf("%s: %s", res.Error.Type, res.Error.Reason)
+			}
+			if i < len(requests) {
+				if r, ok := requests[i].(*bulkRequest); ok {
+					log.Errorf("failed to insert %s event (seq: %d) into %s: %s", r.name, r.seq, res.Index, reason)
+					continue
+				}
+			}
+			log.Errorf("failed to insert document %d into %s: %s", i, res.Index, reason)
+		}
+	}
+	committedDocs.Add(int64(len(requests) - failed))
 }
 
 func (e *elasticsearch) Close() error {
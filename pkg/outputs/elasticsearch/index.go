@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"github.com/olivere/elastic/v7"
 	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
 	"html/template"
 	"strings"
 	"time"
@@ -49,9 +50,8 @@ func (i index) putTemplate() error {
 	if i.config.TemplateConfig != "" {
 		b.WriteString(i.config.TemplateConfig)
 	} else {
-		// expand the Go template
-		tmpl := template.Must(template.New("template").Parse(indexTemplate))
-		err := tmpl.Execute(&b, templateInfo{IndexPattern: indexPattern + "*"})
+		var err error
+		b, err = i.renderTemplate(indexPattern + "*")
 		if err != nil {
 			return err
 		}
@@ -75,9 +75,24 @@ func (i index) putTemplate() error {
 	return nil
 }
 
+// renderTemplate expands the Go template of the legacy index template. ECS
+// documents have a different layout, so they require their own field mappings.
+func (i index) renderTemplate(indexPattern string) (bytes.Buffer, error) {
+	var b bytes.Buffer
+	tmpl := template.Must(template.New("template").Parse(indexTemplate))
+	if i.config.Serializer == outputs.ECS {
+		tmpl = template.Must(template.New("template").Parse(ecsIndexTemplate))
+	}
+	err := tmpl.Execute(&b, templateInfo{IndexPattern: indexPattern})
+	return b, err
+}
+
 // getName creates an index name by replacing specifiers to create time frame indices. If no time specifiers are
 // used this method returns a fixed index name.
 func (i index) getName(kevt *kevent.Kevent) string {
+	if i.config.DataStream {
+		return i.config.dataStreamName()
+	}
 	indexName := i.config.IndexName
 	if !strings.Contains(indexName, "%") {
 		return indexName
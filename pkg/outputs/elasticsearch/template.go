@@ -81,3 +81,155 @@ const indexTemplate = `
 	}
 }
 `
+
+// ecsIndexTemplate is the index template for documents produced by the ECS serializer. Field mappings
+// follow the Elastic Common Schema, so the documents are ready to be consumed by Kibana dashboards and
+// detection rules.
+const ecsIndexTemplate = `
+{
+	"index_patterns": [ "{{ .IndexPattern }}" ],
+	"settings": {
+		"index": {
+			"refresh_interval": "5s",
+			"number_of_shards": 1,
+			"number_of_replicas": 1
+		}
+	},
+	"mappings": {
+		"dynamic_templates": [
+			{
+				"strings_as_keyword": {
+					"match_mapping_type": "string",
+					"mapping": { "type": "keyword", "ignore_above": 1024 }
+				}
+			}
+		],
+		"properties": {
+			"@timestamp": { "type": "date" },
+			"message": { "type": "match_only_text" },
+			"ecs": { "properties": { "version": { "type": "keyword" } } },
+
+			"event": {
+				"properties": {
+					"kind": { "type": "keyword" },
+					"category": { "type": "keyword" },
+					"type": { "type": "keyword" },
+					"action": { "type": "keyword" },
+					"outcome": { "type": "keyword" },
+					"sequence": { "type": "long" },
+					"provider": { "type": "keyword" },
+					"dataset": { "type": "keyword" }
+				}
+			},
+
+			"host": {
+				"properties": {
+					"hostname": { "type": "keyword" },
+					"name": { "type": "keyword" }
+				}
+			},
+
+			"process": {
+				"properties": {
+					"pid": { "type": "long" },
+					"name": { "type": "keyword" },
+					"executable": { "type": "keyword" },
+					"command_line": { "type": "wildcard" },
+					"args": { "type": "keyword" },
+					"working_directory": { "type": "keyword" },
+					"start": { "type": "date" },
+					"thread": { "properties": { "id": { "type": "long" } } },
+					"parent": {
+						"properties": {
+							"pid": { "type": "long" },
+							"name": { "type": "keyword" },
+							"executable": { "type": "keyword" },
+							"command_line": { "type": "wildcard" }
+						}
+					}
+				}
+			},
+
+			"user": {
+				"properties": {
+					"id": { "type": "keyword" },
+					"name": { "type": "keyword" },
+					"domain": { "type": "keyword" }
+				}
+			},
+
+			"file": {
+				"properties": {
+					"path": { "type": "keyword" },
+					"name": { "type": "keyword" },
+					"directory": { "type": "keyword" },
+					"extension": { "type": "keyword" }
+				}
+			},
+
+			"dll": {
+				"properties": {
+					"path": { "type": "keyword" },
+					"name": { "type": "keyword" },
+					"directory": { "type": "keyword" },
+					"extension": { "type": "keyword" }
+				}
+			},
+
+			"registry": {
+				"properties": {
+					"path": { "type": "keyword" },
+					"hive": { "type": "keyword" },
+					"key": { "type": "keyword" },
+					"value": { "type": "keyword" },
+					"data": {
+						"properties": {
+							"type": { "type": "keyword" },
+							"strings": { "type": "wildcard" }
+						}
+					}
+				}
+			},
+
+			"source": {
+				"properties": {
+					"ip": { "type": "ip" },
+					"port": { "type": "long" }
+				}
+			},
+
+			"destination": {
+				"properties": {
+					"ip": { "type": "ip" },
+					"port": { "type": "long" }
+				}
+			},
+
+			"network": {
+				"properties": {
+					"transport": { "type": "keyword" },
+					"type": { "type": "keyword" },
+					"protocol": { "type": "keyword" }
+				}
+			},
+
+			"rule": {
+				"properties": {
+					"name": { "type": "keyword" },
+					"ruleset": { "type": "keyword" }
+				}
+			},
+
+			"labels": { "type": "object" },
+
+			"fibratus": {
+				"properties": {
+					"cpu": { "type": "short" },
+					"kparams": { "type": "object" },
+					"callstack": { "type": "keyword" }
+				}
+			}
+		}
+	}
+}
+`
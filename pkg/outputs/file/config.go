@@ -0,0 +1,155 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/spf13/pflag"
+)
+
+const (
+	fileEnabled          = "output.file.enabled"
+	filePath             = "output.file.path"
+	fileFormat           = "output.file.format"
+	fileSerializer       = "output.file.serializer"
+	fileTemplate         = "output.file.template"
+	fileMaxSize          = "output.file.max-size"
+	fileRotationInterval = "output.file.rotation-interval"
+	fileMaxBackups       = "output.file.max-backups"
+	fileCompression      = "output.file.compression"
+	fileFsync            = "output.file.fsync"
+	fileFsyncInterval    = "output.file.fsync-interval"
+)
+
+const (
+	// formatJSON writes each event as a JSON document terminated by the new line (NDJSON)
+	formatJSON = "json"
+	// formatTemplate renders each event line from the template
+	formatTemplate = "template"
+
+	// compressionNone leaves rotated files uncompressed
+	compressionNone = "none"
+	// compressionGzip compresses rotated files with gzip
+	compressionGzip = "gzip"
+	// compressionZstd compresses rotated files with zstd
+	compressionZstd = "zstd"
+
+	// fsyncBatch flushes the file to stable storage after each published batch
+	fsyncBatch = "batch"
+	// fsyncInterval flushes the file to stable storage periodically
+	fsyncInterval = "interval"
+	// fsyncNever leaves flushing the file to the operating system
+	fsyncNever = "never"
+)
+
+// defaultTemplate represents the default template used to render event lines
+const defaultTemplate = "{{ .Seq }} {{ .Timestamp }} - {{ .CPU }} {{ .Process }} ({{ .Pid }}) - {{ .Type }} ({{ .Kparams }})"
+
+// Config contains the options that influence the behaviour of the file output.
+type Config struct {
+	// Enabled indicates if the file output is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// Path is the location of the file where events are written.
+	Path string `mapstructure:"path"`
+	// Format determines how events are written. It can be one of json or template.
+	Format string `mapstructure:"format"`
+	// Serializer indicates the serializer of JSON documents when the json format is used.
+	Serializer outputs.Serializer `mapstructure:"serializer"`
+	// Template is the template for rendering event lines.
+	Template string `mapstructure:"template"`
+	// MaxSize is the maximum size in megabytes of the file before it gets rotated.
+	MaxSize int `mapstructure:"max-size"`
+	// RotationInterval specifies the interval after which the file is rotated regardless of its size.
+	RotationInterval time.Duration `mapstructure:"rotation-interval"`
+	// MaxBackups is the maximum number of rotated files to retain.
+	MaxBackups int `mapstructure:"max-backups"`
+	// Compression is the compression algorithm applied to rotated files. It can be one of none, gzip, or zstd.
+	Compression string `mapstructure:"compression"`
+	// Fsync determines when the file is flushed to stable storage. It can be one of batch, interval, or never.
+	Fsync string `mapstructure:"fsync"`
+	// FsyncInterval specifies how often the file is flushed to stable storage when the interval policy is used.
+	FsyncInterval time.Duration `mapstructure:"fsync-interval"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(fileEnabled, false, "Indicates if the file output is enabled")
+	flags.String(filePath, filepath.Join(os.Getenv("PROGRAMFILES"), "fibratus", "events", "fibratus.json"), "Specifies the location of the file where events are written")
+	flags.String(fileFormat, formatJSON, "Determines how events are written (json, template)")
+	flags.String(fileSerializer, string(outputs.JSON), "Indicates the event serializer type when the json format is used (json, ecs)")
+	flags.String(fileTemplate, "", "Specifies the template for rendering event lines")
+	flags.Int(fileMaxSize, 100, "Specifies the maximum size in megabytes of the file before it gets rotated")
+	flags.Duration(fileRotationInterval, time.Hour*24, "Specifies the interval after which the file is rotated regardless of its size. Zero disables time-based rotation")
+	flags.Int(fileMaxBackups, 10, "Specifies the maximum number of rotated files to retain. Zero retains all rotated files")
+	flags.String(fileCompression, compressionNone, "Specifies the compression algorithm applied to rotated files (none, gzip, zstd)")
+	flags.String(fileFsync, fsyncInterval, "Determines when the file is flushed to stable storage (batch, interval, never)")
+	flags.Duration(fileFsyncInterval, time.Second, "Specifies how often the file is flushed to stable storage when the interval policy is used")
+}
+
+func (c Config) withDefaults() Config {
+	if c.Format == "" {
+		c.Format = formatJSON
+	}
+	if c.Serializer == "" {
+		c.Serializer = outputs.JSON
+	}
+	if c.Compression == "" {
+		c.Compression = compressionNone
+	}
+	if c.Fsync == "" {
+		c.Fsync = fsyncNever
+	}
+	if c.Fsync == fsyncInterval && c.FsyncInterval <= 0 {
+		c.FsyncInterval = time.Second
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	if c.Path == "" {
+		return fmt.Errorf("file path is empty")
+	}
+	switch c.Format {
+	case formatJSON, formatTemplate:
+	default:
+		return fmt.Errorf("invalid format: %s", c.Format)
+	}
+	if c.Serializer == outputs.Protobuf {
+		return fmt.Errorf("%s serializer is not supported by file output", c.Serializer)
+	}
+	if err := c.Serializer.Validate(); err != nil {
+		return err
+	}
+	switch c.Compression {
+	case compressionNone, compressionGzip, compressionZstd:
+	default:
+		return fmt.Errorf("invalid compression: %s", c.Compression)
+	}
+	switch c.Fsync {
+	case fsyncBatch, fsyncInterval, fsyncNever:
+	default:
+		return fmt.Errorf("invalid fsync policy: %s", c.Fsync)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// fileErrors counts event write errors
+	fileErrors = expvar.NewInt("output.file.publish.errors")
+	// fileEvents counts the total number of written events
+	fileEvents = expvar.NewInt("output.file.publish.events")
+	// fsyncErrors counts the number of failed file flushes to stable storage
+	fsyncErrors = expvar.NewInt("output.file.fsync.errors")
+)
+
+type file struct {
+	config    Config
+	formatter *kevent.Formatter
+
+	mu      sync.Mutex
+	rotator *rotator
+	stop    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+func init() {
+	outputs.Register(outputs.File, initFile)
+}
+
+func initFile(config outputs.Config) (outputs.OutputGroup, error) {
+	cfg, ok := config.Output.(Config)
+	if !ok {
+		return outputs.Fail(outputs.ErrInvalidConfig(outputs.File, config.Output))
+	}
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return outputs.Fail(err)
+	}
+	f := &file{config: cfg, stop: make(chan struct{})}
+	if cfg.Format == formatTemplate {
+		tmpl := cfg.Template
+		if tmpl == "" {
+			tmpl = defaultTemplate
+		}
+		var err error
+		f.formatter, err = kevent.NewFormatter(tmpl)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+	}
+	return outputs.Success(f), nil
+}
+
+func (f *file) Connect() error {
+	var err error
+	f.rotator, err = newRotator(f.config.Path, f.config.MaxSize, f.config.RotationInterval, f.config.MaxBackups, f.config.Compression)
+	if err != nil {
+		return err
+	}
+	if f.config.Fsync == fsyncInterval {
+		f.wg.Add(1)
+		go f.syncLoop()
+	}
+	return nil
+}
+
+func (f *file) Close() error {
+	f.once.Do(func() { close(f.stop) })
+	f.wg.Wait()
+	if f.rotator == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.config.Fsync != fsyncNever {
+		if err := f.rotator.flush(); err == nil {
+			_ = f.rotator.sync()
+		}
+	}
+	err := f.rotator.close()
+	f.rotator.wait()
+	return err
+}
+
+func (f *file) Publish(batch *kevent.Batch) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, kevt := range batch.Events {
+		var buf []byte
+		switch f.config.Format {
+		case formatTemplate:
+			buf = f.formatter.Format(kevt)
+		default:
+			buf = f.config.Serializer.Marshal(kevt)
+		}
+		if err := f.rotator.write(append(buf, '\n')); err != nil {
+			fileErrors.Add(1)
+			return err
+		}
+		fileEvents.Add(1)
+	}
+	if err := f.rotator.flush(); err != nil {
+		fileErrors.Add(1)
+		return err
+	}
+	if f.config.Fsync == fsyncBatch {
+		if err := f.rotator.sync(); err != nil {
+			fsyncErrors.Add(1)
+			return err
+		}
+	}
+	return nil
+}
+
+// syncLoop periodically commits the file contents to stable storage.
+func (f *file) syncLoop() {
+	defer f.wg.Done()
+	tick := time.NewTicker(f.config.FsyncInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			f.mu.Lock()
+			if err := f.rotator.sync(); err != nil {
+				fsyncErrors.Add(1)
+				log.Warnf("unable to sync %s: %v", f.config.Path, err)
+			}
+			f.mu.Unlock()
+		case <-f.stop:
+			return
+		}
+	}
+}
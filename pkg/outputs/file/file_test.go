@@ -0,0 +1,119 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKevent(seq uint64) *kevent.Kevent {
+	return &kevent.Kevent{
+		Type:      ktypes.CreateFile,
+		Tid:       2484,
+		PID:       859,
+		Seq:       seq,
+		Name:      "CreateFile",
+		Category:  ktypes.File,
+		Host:      "archrabbit",
+		Timestamp: time.Now(),
+		Kparams: kevent.Kparams{
+			kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: `C:\Windows\system32\kernel32.dll`},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+}
+
+func newFile(t *testing.T, config Config) *file {
+	out, err := initFile(outputs.Config{Type: outputs.File, Output: config})
+	require.NoError(t, err)
+	require.Len(t, out.Clients, 1)
+	return out.Clients[0].(*file)
+}
+
+func TestPublishNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events", "fibratus.json")
+	f := newFile(t, Config{Path: path, Fsync: fsyncBatch})
+	require.NoError(t, f.Connect())
+
+	require.NoError(t, f.Publish(kevent.NewBatch(newKevent(1), newKevent(2))))
+	require.NoError(t, f.Publish(kevent.NewBatch(newKevent(3))))
+	require.NoError(t, f.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	require.Len(t, lines, 3)
+	for i, line := range lines {
+		var evt map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &evt))
+		assert.Equal(t, float64(i+1), evt["seq"])
+	}
+}
+
+func TestPublishTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fibratus.log")
+	f := newFile(t, Config{Path: path, Format: formatTemplate, Template: "{{ .Seq }} {{ .Type }} ({{ .Kparams }})", Fsync: fsyncInterval})
+	require.NoError(t, f.Connect())
+
+	require.NoError(t, f.Publish(kevent.NewBatch(newKevent(1), newKevent(2))))
+	require.NoError(t, f.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1 CreateFile (file_name➜ C:\\Windows\\system32\\kernel32.dll)\n2 CreateFile (file_name➜ C:\\Windows\\system32\\kernel32.dll)\n", string(b))
+}
+
+func TestPublishECS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fibratus.json")
+	f := newFile(t, Config{Path: path, Serializer: outputs.ECS, Fsync: fsyncBatch})
+	require.NoError(t, f.Connect())
+
+	require.NoError(t, f.Publish(kevent.NewBatch(newKevent(1))))
+	require.NoError(t, f.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(b, &doc))
+	assert.Contains(t, doc, "@timestamp")
+	assert.Contains(t, doc, "ecs")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := initFile(outputs.Config{Type: outputs.File, Output: Config{}})
+	require.Error(t, err)
+	_, err = initFile(outputs.Config{Type: outputs.File, Output: Config{Path: "fibratus.json", Compression: "lzma"}})
+	require.Error(t, err)
+	_, err = initFile(outputs.Config{Type: outputs.File, Output: Config{Path: "fibratus.json", Fsync: "always"}})
+	require.Error(t, err)
+	_, err = initFile(outputs.Config{Type: outputs.File, Output: Config{Path: "fibratus.json", Serializer: outputs.Protobuf}})
+	require.Error(t, err)
+}
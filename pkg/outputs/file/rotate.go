@@ -0,0 +1,302 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"expvar"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// backupTimeFormat is the timestamp layout appended to rotated file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+var (
+	// fileRotations counts the number of file rotations
+	fileRotations = expvar.NewInt("output.file.rotations")
+	// compressErrors counts the number of rotated files that failed to compress
+	compressErrors = expvar.NewInt("output.file.compress.errors")
+)
+
+// rotator is the file writer that rotates the underlying file when it grows over the maximum size
+// or when the rotation interval elapses. Rotated files are renamed by appending the rotation
+// timestamp to the file name, and optionally compressed in the background. When the number of
+// rotated files exceeds the maximum number of backups, the oldest ones are removed.
+type rotator struct {
+	path        string
+	maxSize     int64
+	interval    time.Duration
+	maxBackups  int
+	compression string
+
+	f        *os.File
+	w        *bufio.Writer
+	size     int64
+	openedAt time.Time
+
+	// mill serializes compression and removal of rotated files
+	mill sync.Mutex
+	wg   sync.WaitGroup
+}
+
+func newRotator(path string, maxSize int, interval time.Duration, maxBackups int, compression string) (*rotator, error) {
+	r := &rotator{
+		path:        path,
+		maxSize:     int64(maxSize) * 1024 * 1024,
+		interval:    interval,
+		maxBackups:  maxBackups,
+		compression: compression,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the file in append mode or creates it if it doesn't exist.
+func (r *rotator) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f = f
+	r.size = fi.Size()
+	r.openedAt = time.Now()
+	if r.w == nil {
+		r.w = bufio.NewWriterSize(f, 64*1024)
+	} else {
+		r.w.Reset(f)
+	}
+	return nil
+}
+
+// write writes the buffer to the file. If the buffer doesn't fit into
+// the file or the rotation interval has elapsed, the file is rotated
+// before writing.
+func (r *rotator) write(b []byte) error {
+	// reopen the file if the previous rotation failed
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	if r.shouldRotate(int64(len(b))) {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.w.Write(b)
+	r.size += int64(n)
+	return err
+}
+
+func (r *rotator) shouldRotate(n int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.maxSize > 0 && r.size+n > r.maxSize {
+		return true
+	}
+	return r.interval > 0 && time.Since(r.openedAt) >= r.interval
+}
+
+// rotate closes the current file, renames it to the backup name and opens a new file.
+func (r *rotator) rotate() error {
+	if err := r.close(); err != nil {
+		return err
+	}
+	backup := r.backupName(time.Now())
+	if err := os.Rename(r.path, backup); err != nil {
+		return err
+	}
+	fileRotations.Add(1)
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.wg.Add(1)
+	go r.millRun(backup)
+	return nil
+}
+
+// flush writes buffered data to the file.
+func (r *rotator) flush() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.w.Flush()
+}
+
+// sync commits the file contents to stable storage.
+func (r *rotator) sync() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Sync()
+}
+
+// close flushes buffered data and closes the file.
+func (r *rotator) close() error {
+	if r.f == nil {
+		return nil
+	}
+	if err := r.w.Flush(); err != nil {
+		return err
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// wait blocks until all background compressions are finished.
+func (r *rotator) wait() { r.wg.Wait() }
+
+// backupName returns the name of the rotated file. For example,
+// fibratus.json is rotated to fibratus-2023-05-03T15-04-05.323.json.
+func (r *rotator) backupName(t time.Time) string {
+	dir, prefix, ext := r.nameParts()
+	return filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+}
+
+func (r *rotator) nameParts() (string, string, string) {
+	dir, name := filepath.Split(r.path)
+	ext := filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// millRun compresses the rotated file and removes
+// the oldest backups that exceed the retention limit.
+func (r *rotator) millRun(backup string) {
+	defer r.wg.Done()
+	r.mill.Lock()
+	defer r.mill.Unlock()
+	if r.compression != compressionNone {
+		if err := compress(backup, r.compression); err != nil {
+			compressErrors.Add(1)
+			log.Warnf("unable to compress %s: %v", backup, err)
+		}
+	}
+	if r.maxBackups <= 0 {
+		return
+	}
+	backups, err := r.backups()
+	if err != nil {
+		log.Warnf("unable to list rotated files: %v", err)
+		return
+	}
+	if len(backups) <= r.maxBackups {
+		return
+	}
+	for _, backup := range backups[:len(backups)-r.maxBackups] {
+		if err := os.Remove(backup); err != nil {
+			log.Warnf("unable to remove rotated file %s: %v", backup, err)
+		}
+	}
+}
+
+// backups returns rotated files sorted from the oldest to the newest.
+func (r *rotator) backups() ([]string, error) {
+	dir, prefix, ext := r.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]string, 0)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(strings.TrimSuffix(ts, ".gz"), ".zst")
+		ts = strings.TrimSuffix(ts, ext)
+		if _, err := time.Parse(backupTimeFormat, ts); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	// the timestamp layout sorts lexicographically
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// compress compresses the file with the given algorithm and removes the original file.
+func compress(path, algo string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	ext := ".gz"
+	if algo == compressionZstd {
+		ext = ".zst"
+	}
+	dst, err := os.OpenFile(path+ext, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	var zw io.WriteCloser
+	switch algo {
+	case compressionZstd:
+		zw, err = zstd.NewWriter(dst)
+		if err != nil {
+			_ = dst.Close()
+			return err
+		}
+	default:
+		zw = gzip.NewWriter(dst)
+	}
+
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = dst.Close()
+	} else {
+		_ = dst.Close()
+	}
+	if err != nil {
+		_ = os.Remove(path + ext)
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
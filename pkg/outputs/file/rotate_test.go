@@ -0,0 +1,146 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fibratus.json")
+	r, err := newRotator(path, 1, 0, 0, compressionNone)
+	require.NoError(t, err)
+	r.maxSize = 10
+
+	require.NoError(t, r.write([]byte("abcdef\n")))
+	require.NoError(t, r.write([]byte("ghijkl\n")))
+	require.NoError(t, r.write([]byte("mn\n")))
+	require.NoError(t, r.close())
+	r.wait()
+
+	backups, err := r.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	b, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef\n", string(b))
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ghijkl\nmn\n", string(b))
+}
+
+func TestRotateByInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fibratus.json")
+	r, err := newRotator(path, 0, time.Minute, 0, compressionNone)
+	require.NoError(t, err)
+
+	require.NoError(t, r.write([]byte("abcdef\n")))
+	r.openedAt = time.Now().Add(-time.Hour)
+	require.NoError(t, r.write([]byte("ghijkl\n")))
+	require.NoError(t, r.close())
+	r.wait()
+
+	backups, err := r.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+}
+
+func TestRotateMaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fibratus.json")
+	r, err := newRotator(path, 1, 0, 2, compressionNone)
+	require.NoError(t, err)
+	r.maxSize = 4
+
+	for _, s := range []string{"aaa\n", "bbb\n", "ccc\n", "ddd\n", "eee\n"} {
+		require.NoError(t, r.write([]byte(s)))
+		// rotated files get distinct timestamps
+		time.Sleep(time.Millisecond * 5)
+	}
+	require.NoError(t, r.close())
+	r.wait()
+
+	backups, err := r.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+
+	b, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "ccc\n", string(b))
+	b, err = os.ReadFile(backups[1])
+	require.NoError(t, err)
+	assert.Equal(t, "ddd\n", string(b))
+}
+
+func TestRotateCompress(t *testing.T) {
+	var tests = []struct {
+		compression string
+		ext         string
+		reader      func(io.Reader) (io.Reader, error)
+	}{
+		{
+			compressionGzip,
+			".gz",
+			func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			compressionZstd,
+			".zst",
+			func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fibratus.json")
+			r, err := newRotator(path, 1, 0, 0, tt.compression)
+			require.NoError(t, err)
+			r.maxSize = 10
+
+			require.NoError(t, r.write([]byte("abcdef\n")))
+			require.NoError(t, r.write([]byte("ghijkl\n")))
+			require.NoError(t, r.close())
+			r.wait()
+
+			backups, err := r.backups()
+			require.NoError(t, err)
+			require.Len(t, backups, 1)
+			require.True(t, strings.HasSuffix(backups[0], ".json"+tt.ext), backups[0])
+
+			f, err := os.Open(backups[0])
+			require.NoError(t, err)
+			defer f.Close()
+			zr, err := tt.reader(f)
+			require.NoError(t, err)
+			b, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			assert.Equal(t, "abcdef\n", string(b))
+		})
+	}
+}
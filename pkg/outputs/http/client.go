@@ -22,13 +22,40 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/rabbitstack/fibratus/pkg/outputs"
 	"github.com/rabbitstack/fibratus/pkg/util/tls"
 )
 
-// newHTTPClient builds a fresh stdlib HTTP client. The HTTP proxy and TLS config is set
-// accordingly if enabled in the HTTP output preferences.
+// ClientConfig contains the options for building the HTTP client. It
+// is shared by all outputs that deliver events over HTTP.
+type ClientConfig struct {
+	outputs.TLSConfig
+	// Timeout represents the timeout for the HTTP requests.
+	Timeout time.Duration
+	// ProxyURL specifies the HTTP proxy URL.
+	ProxyURL string
+	// ProxyUsername is the username for proxy authentication.
+	ProxyUsername string
+	// ProxyPassword is the password for proxy authentication.
+	ProxyPassword string
+}
+
+// newHTTPClient builds the HTTP client from the HTTP output preferences.
 func newHTTPClient(config Config) (*http.Client, error) {
+	return NewClient(ClientConfig{
+		TLSConfig:     config.TLSConfig,
+		Timeout:       config.Timeout,
+		ProxyURL:      config.ProxyURL,
+		ProxyUsername: config.ProxyUsername,
+		ProxyPassword: config.ProxyPassword,
+	})
+}
+
+// NewClient builds a fresh stdlib HTTP client. The HTTP proxy and TLS config is set
+// accordingly if enabled in the output preferences.
+func NewClient(config ClientConfig) (*http.Client, error) {
 	tlsConfig, err := tls.MakeConfig(config.TLSCert, config.TLSKey, config.TLSCA, config.TLSInsecureSkipVerify)
 	if err != nil {
 		return nil, fmt.Errorf("invalid TLS config: %v", err)
@@ -81,6 +81,6 @@ func AddFlags(flags *pflag.FlagSet) {
 	flags.String(httpUsername, "", "Username for the basic HTTP authentication")
 	flags.String(httpPassword, "", "Password for the basic HTTP authentication")
 	flags.Bool(httpEnableGzip, false, "Indicates whether the gzip compression is enabled")
-	flags.String(httpSerializer, string(outputs.JSON), "Indicates the event serializer type")
+	flags.String(httpSerializer, string(outputs.JSON), "Indicates the event serializer type (json, ecs, protobuf)")
 	outputs.AddTLSFlags(flags, outputs.HTTP)
 }
@@ -36,9 +36,6 @@ import (
 // userAgentHeader represents the value of the User-Agent header
 var userAgentHeader = version.ProductToken()
 
-// defaultContentType represents the default content type for HTTP requests
-const defaultContentType = "application/json"
-
 type _http struct {
 	client *http.Client
 	config Config
@@ -54,6 +51,9 @@ func initHTTP(config outputs.Config) (outputs.OutputGroup, error) {
 	if !ok {
 		return outputs.Fail(outputs.ErrInvalidConfig(outputs.HTTP, config.Output))
 	}
+	if err := cfg.Serializer.Validate(); err != nil {
+		return outputs.Fail(err)
+	}
 
 	clients := make([]outputs.Client, len(cfg.Endpoints))
 	for i, endpoint := range cfg.Endpoints {
@@ -80,12 +80,7 @@ func (h *_http) Connect() error { return nil }
 func (h *_http) Close() error   { return nil }
 
 func (h *_http) Publish(batch *kevent.Batch) error {
-	var buf []byte
-	defer batch.Release()
-	switch h.config.Serializer {
-	case outputs.JSON:
-		buf = batch.MarshalJSON()
-	}
+	buf := h.config.Serializer.MarshalBatch(batch)
 
 	if h.config.EnableGzip {
 		var bb bytes.Buffer
@@ -137,7 +132,7 @@ func (h *_http) setBasicAuth(req *http.Request) {
 // setHeaders populates required and optional request headers.
 func (h *_http) setHeaders(req *http.Request) {
 	req.Header.Set("User-Agent", userAgentHeader)
-	req.Header.Set("Content-Type", defaultContentType)
+	req.Header.Set("Content-Type", h.config.Serializer.ContentType())
 	if h.config.EnableGzip {
 		req.Header.Set("Content-Encoding", "gzip")
 	}
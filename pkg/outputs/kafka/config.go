@@ -0,0 +1,173 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/rabbitstack/fibratus/pkg/util/tls"
+	"github.com/spf13/pflag"
+)
+
+const (
+	kafkaEnabled       = "output.kafka.enabled"
+	kafkaBrokers       = "output.kafka.brokers"
+	kafkaTopic         = "output.kafka.topic"
+	kafkaPartitionKey  = "output.kafka.partition-key"
+	kafkaCompression   = "output.kafka.compression"
+	kafkaAcks          = "output.kafka.acks"
+	kafkaTimeout       = "output.kafka.timeout"
+	kafkaClientID      = "output.kafka.client-id"
+	kafkaSASLMechanism = "output.kafka.sasl-mechanism"
+	kafkaUsername      = "output.kafka.username"
+	kafkaPassword      = "output.kafka.password"
+	kafkaSerializer    = "output.kafka.serializer"
+)
+
+// Config contains the options that influence the behaviour of the Kafka output.
+type Config struct {
+	outputs.TLSConfig
+	// Enabled indicates if the Kafka output is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// Brokers contains the list of Kafka bootstrap brokers.
+	Brokers []string `mapstructure:"brokers"`
+	// Topic is the topic where events are produced. It may contain event field placeholders.
+	Topic string `mapstructure:"topic"`
+	// PartitionKey is the message key that determines the partition of the event. It may contain event field placeholders.
+	PartitionKey string `mapstructure:"partition-key"`
+	// Compression is the compression codec for produced messages.
+	Compression string `mapstructure:"compression"`
+	// Acks determines the level of acknowledgement reliability required from brokers.
+	Acks string `mapstructure:"acks"`
+	// Timeout specifies the connection and produce timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// ClientID is the client identifier sent to brokers.
+	ClientID string `mapstructure:"client-id"`
+	// SASLMechanism is the SASL authentication mechanism. Empty value disables SASL authentication.
+	SASLMechanism string `mapstructure:"sasl-mechanism"`
+	// Username is the SASL username.
+	Username string `mapstructure:"username"`
+	// Password is the SASL password.
+	Password string `mapstructure:"password"`
+	// Serializer indicates the serializer for the message value.
+	Serializer outputs.Serializer `mapstructure:"serializer"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(kafkaEnabled, false, "Indicates if the Kafka output is enabled")
+	flags.StringSlice(kafkaBrokers, []string{"localhost:9092"}, "Contains the list of Kafka bootstrap brokers")
+	flags.String(kafkaTopic, "fibratus", "Specifies the topic where events are produced. Event fields such as %kevt.category can be used to derive the topic name")
+	flags.String(kafkaPartitionKey, "", "Specifies the message key that determines the partition of the event. Event fields such as %kevt.host can be used to derive the key")
+	flags.String(kafkaCompression, "none", "Specifies the compression codec for produced messages (none, gzip, snappy, lz4, zstd)")
+	flags.String(kafkaAcks, "leader", "Determines the level of acknowledgement reliability required from brokers (none, leader, all)")
+	flags.Duration(kafkaTimeout, time.Second*10, "Specifies the connection and produce timeout")
+	flags.String(kafkaClientID, "fibratus", "Represents the client identifier sent to brokers")
+	flags.String(kafkaSASLMechanism, "", "Specifies the SASL authentication mechanism (plain, scram-sha-256, scram-sha-512)")
+	flags.String(kafkaUsername, "", "The SASL username")
+	flags.String(kafkaPassword, "", "The SASL password")
+	flags.String(kafkaSerializer, string(outputs.JSON), "Indicates the event serializer type (json, ecs, protobuf)")
+	outputs.AddTLSFlags(flags, outputs.Kafka)
+}
+
+// saramaConfig translates the output config to the Kafka client config.
+func (c Config) saramaConfig() (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	if c.ClientID != "" {
+		config.ClientID = c.ClientID
+	}
+	if c.Timeout > 0 {
+		config.Net.DialTimeout = c.Timeout
+		config.Net.ReadTimeout = c.Timeout
+		config.Net.WriteTimeout = c.Timeout
+		config.Producer.Timeout = c.Timeout
+	}
+	// required by the sync producer
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
+
+	switch strings.ToLower(c.Acks) {
+	case "none", "0":
+		config.Producer.RequiredAcks = sarama.NoResponse
+	case "", "leader", "1":
+		config.Producer.RequiredAcks = sarama.WaitForLocal
+	case "all", "-1":
+		config.Producer.RequiredAcks = sarama.WaitForAll
+	default:
+		return nil, fmt.Errorf("invalid acks: %s", c.Acks)
+	}
+
+	switch strings.ToLower(c.Compression) {
+	case "", "none":
+		config.Producer.Compression = sarama.CompressionNone
+	case "gzip":
+		config.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		config.Producer.Compression = sarama.CompressionSnappy
+	case "lz4":
+		config.Producer.Compression = sarama.CompressionLZ4
+	case "zstd":
+		config.Producer.Compression = sarama.CompressionZSTD
+	default:
+		return nil, fmt.Errorf("invalid compression codec: %s", c.Compression)
+	}
+
+	if c.PartitionKey != "" {
+		config.Producer.Partitioner = sarama.NewHashPartitioner
+	} else {
+		config.Producer.Partitioner = sarama.NewRoundRobinPartitioner
+	}
+
+	if c.SASLMechanism != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.User = c.Username
+		config.Net.SASL.Password = c.Password
+		switch strings.ToLower(c.SASLMechanism) {
+		case "plain":
+			config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		case "scram-sha-256":
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: sha256Hash} }
+		case "scram-sha-512":
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: sha512Hash} }
+		default:
+			return nil, fmt.Errorf("invalid SASL mechanism: %s", c.SASLMechanism)
+		}
+	}
+
+	tlsConfig, err := tls.MakeConfig(c.TLSCert, c.TLSKey, c.TLSCA, c.TLSInsecureSkipVerify)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS config: %v", err)
+	}
+	if tlsConfig != nil {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"errors"
+	"expvar"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+)
+
+var (
+	// kafkaErrors counts Kafka delivery errors
+	kafkaErrors = expvar.NewInt("output.kafka.publish.errors")
+	// kafkaMessages counts the total number of produced messages
+	kafkaMessages = expvar.NewInt("output.kafka.publish.messages")
+)
+
+type kafka struct {
+	config   Config
+	sconfig  *sarama.Config
+	producer sarama.SyncProducer
+}
+
+func init() {
+	outputs.Register(outputs.Kafka, initKafka)
+}
+
+func initKafka(config outputs.Config) (outputs.OutputGroup, error) {
+	cfg, ok := config.Output.(Config)
+	if !ok {
+		return outputs.Fail(outputs.ErrInvalidConfig(outputs.Kafka, config.Output))
+	}
+	if len(cfg.Brokers) == 0 {
+		return outputs.Fail(errors.New("no Kafka brokers specified"))
+	}
+	if err := cfg.Serializer.Validate(); err != nil {
+		return outputs.Fail(err)
+	}
+	sconfig, err := cfg.saramaConfig()
+	if err != nil {
+		return outputs.Fail(err)
+	}
+	return outputs.Success(&kafka{config: cfg, sconfig: sconfig}), nil
+}
+
+func (k *kafka) Connect() error {
+	var err error
+	k.producer, err = sarama.NewSyncProducer(k.config.Brokers, k.sconfig)
+	return err
+}
+
+func (k *kafka) Close() error {
+	if k.producer == nil {
+		return nil
+	}
+	return k.producer.Close()
+}
+
+// Publish produces a message for each event in the batch. The
+// topic and the partition key are derived from event fields.
+func (k *kafka) Publish(batch *kevent.Batch) error {
+	msgs := make([]*sarama.ProducerMessage, 0, len(batch.Events))
+	for _, kevt := range batch.Events {
+		msg := &sarama.ProducerMessage{
+			Topic: expand(k.config.Topic, kevt),
+			Value: sarama.ByteEncoder(k.config.Serializer.Marshal(kevt)),
+		}
+		if k.config.PartitionKey != "" {
+			msg.Key = sarama.StringEncoder(expand(k.config.PartitionKey, kevt))
+		}
+		msgs = append(msgs, msg)
+	}
+	err := k.producer.SendMessages(msgs)
+	if err != nil {
+		var perrs sarama.ProducerErrors
+		if errors.As(err, &perrs) {
+			kafkaErrors.Add(int64(len(perrs)))
+			kafkaMessages.Add(int64(len(msgs) - len(perrs)))
+		} else {
+			kafkaErrors.Add(int64(len(msgs)))
+		}
+		return err
+	}
+	kafkaMessages.Add(int64(len(msgs)))
+	return nil
+}
+
+// expand replaces event field placeholders in the topic or partition key template.
+func expand(s string, kevt *kevent.Kevent) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var psName, psSID string
+	if kevt.PS != nil {
+		psName, psSID = kevt.PS.Name, kevt.PS.SID
+	}
+	return strings.NewReplacer(
+		"%kevt.category", string(kevt.Category),
+		"%kevt.name", kevt.Name,
+		"%kevt.host", kevt.Host,
+		"%kevt.pid", strconv.Itoa(int(kevt.PID)),
+		"%kevt.tid", strconv.Itoa(int(kevt.Tid)),
+		"%ps.name", psName,
+		"%ps.sid", psSID,
+	).Replace(s)
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBroker(t *testing.T, topics ...string) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	metadata := sarama.NewMockMetadataResponse(t).
+		SetBroker(broker.Addr(), broker.BrokerID()).
+		SetController(broker.BrokerID())
+	for _, topic := range topics {
+		metadata.SetLeader(topic, 0, broker.BrokerID())
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadata,
+		"ProduceRequest":  sarama.NewMockProduceResponse(t),
+	})
+	return broker
+}
+
+func newKafka(t *testing.T, config Config) *kafka {
+	out, err := initKafka(outputs.Config{Type: outputs.Kafka, Output: config})
+	require.NoError(t, err)
+	require.Len(t, out.Clients, 1)
+	k := out.Clients[0].(*kafka)
+	k.sconfig.Producer.Retry.Max = 0
+	k.sconfig.Metadata.Retry.Max = 0
+	return k
+}
+
+func getBatch() *kevent.Batch {
+	kevt1 := &kevent.Kevent{
+		Type:      ktypes.CreateFile,
+		Tid:       2484,
+		PID:       859,
+		Name:      "CreateFile",
+		Category:  ktypes.File,
+		Host:      "archrabbit",
+		Timestamp: time.Now(),
+		Kparams: kevent.Kparams{
+			kparams.FileName: {Name: kparams.FileName, Type: kparams.UnicodeString, Value: "C:\\Windows\\system32\\user32.dll"},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+		PS:       &pstypes.PS{PID: 859, Name: "cmd.exe", SID: "S-1-5-18"},
+	}
+	kevt2 := &kevent.Kevent{
+		Type:      ktypes.ConnectTCPv4,
+		Tid:       2484,
+		PID:       1023,
+		Name:      "Connect",
+		Category:  ktypes.Net,
+		Host:      "archrabbit",
+		Timestamp: time.Now(),
+		Kparams: kevent.Kparams{
+			kparams.NetDport: {Name: kparams.NetDport, Type: kparams.Uint16, Value: uint16(443)},
+		},
+		Metadata: make(map[kevent.MetadataKey]any),
+	}
+	return kevent.NewBatch(kevt1, kevt2)
+}
+
+func TestPublishKafkaOutput(t *testing.T) {
+	broker := newBroker(t, "fibratus-file", "fibratus-net")
+	defer broker.Close()
+
+	k := newKafka(t, Config{
+		Brokers:      []string{broker.Addr()},
+		Topic:        "fibratus-%kevt.category",
+		PartitionKey: "%kevt.host",
+		Acks:         "all",
+		Compression:  "gzip",
+		Timeout:      time.Second * 5,
+	})
+	require.NoError(t, k.Connect())
+	defer k.Close()
+
+	require.NoError(t, k.Publish(getBatch()))
+
+	var produced int
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produced++
+			assert.Equal(t, sarama.WaitForAll, req.RequiredAcks)
+		}
+	}
+	assert.True(t, produced > 0)
+}
+
+func TestPublishKafkaUnknownTopic(t *testing.T) {
+	broker := newBroker(t, "fibratus-file")
+	defer broker.Close()
+
+	k := newKafka(t, Config{
+		Brokers: []string{broker.Addr()},
+		Topic:   "fibratus-%kevt.category",
+		Timeout: time.Second * 5,
+	})
+	require.NoError(t, k.Connect())
+	defer k.Close()
+
+	// the topic for network events doesn't exist
+	require.Error(t, k.Publish(getBatch()))
+}
+
+func TestExpand(t *testing.T) {
+	b := getBatch()
+	assert.Equal(t, "fibratus", expand("fibratus", b.Events[0]))
+	assert.Equal(t, "fibratus-file", expand("fibratus-%kevt.category", b.Events[0]))
+	assert.Equal(t, "archrabbit-859-cmd.exe", expand("%kevt.host-%kevt.pid-%ps.name", b.Events[0]))
+	assert.Equal(t, "Connect-", expand("%kevt.name-%ps.name", b.Events[1]))
+}
+
+func TestSaramaConfig(t *testing.T) {
+	var tests = []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{Acks: "none", Compression: "snappy"}, true},
+		{Config{Acks: "all", Compression: "zstd"}, true},
+		{Config{Acks: "some"}, false},
+		{Config{Compression: "brotli"}, false},
+		{Config{SASLMechanism: "plain", Username: "fibratus", Password: "secret"}, true},
+		{Config{SASLMechanism: "scram-sha-512", Username: "fibratus", Password: "secret"}, true},
+		{Config{SASLMechanism: "kerberos", Username: "fibratus", Password: "secret"}, false},
+		{Config{TLSConfig: outputs.TLSConfig{TLSCA: "_fixtures/ca.crt"}}, false},
+	}
+
+	for _, tt := range tests {
+		c, err := tt.config.saramaConfig()
+		if tt.valid {
+			require.NoError(t, err)
+			require.NotNil(t, c)
+		} else {
+			require.Error(t, err)
+		}
+	}
+
+	c, err := Config{SASLMechanism: "scram-sha-256", Username: "fibratus", Password: "secret", PartitionKey: "%kevt.pid"}.saramaConfig()
+	require.NoError(t, err)
+	assert.True(t, c.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA256), c.Net.SASL.Mechanism)
+	assert.NotNil(t, c.Net.SASL.SCRAMClientGeneratorFunc)
+	assert.Equal(t, sarama.WaitForLocal, c.Producer.RequiredAcks)
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/spf13/pflag"
+)
+
+const (
+	otlpEnabled             = "output.otlp.enabled"
+	otlpProtocol            = "output.otlp.protocol"
+	otlpEndpoint            = "output.otlp.endpoint"
+	otlpHeaders             = "output.otlp.headers"
+	otlpTimeout             = "output.otlp.timeout"
+	otlpCompression         = "output.otlp.compression"
+	otlpInsecure            = "output.otlp.insecure"
+	otlpServiceName         = "output.otlp.service-name"
+	otlpSerializer          = "output.otlp.serializer"
+	otlpTemplate            = "output.otlp.template"
+	otlpBatchSize           = "output.otlp.batch-size"
+	otlpMaxRetries          = "output.otlp.max-retries"
+	otlpRetryInitialBackoff = "output.otlp.retry-initial-backoff"
+	otlpRetryMaxBackoff     = "output.otlp.retry-max-backoff"
+)
+
+const (
+	// protoGRPC exports log records via the gRPC transport
+	protoGRPC = "grpc"
+	// protoHTTP exports log records via the HTTP transport with protobuf-encoded payloads
+	protoHTTP = "http"
+
+	// compressionNone disables the payload compression
+	compressionNone = "none"
+	// compressionGzip compresses payloads with gzip
+	compressionGzip = "gzip"
+)
+
+const (
+	defaultGRPCEndpoint = "localhost:4317"
+	defaultHTTPEndpoint = "http://localhost:4318/v1/logs"
+	defaultLogsPath     = "/v1/logs"
+)
+
+// defaultTemplate represents the default template used to render the log record body
+const defaultTemplate = "{{ .Seq }} {{ .Timestamp }} - {{ .CPU }} {{ .Process }} ({{ .Pid }}) - {{ .Type }} ({{ .Kparams }})"
+
+// Config contains the options that influence the behaviour of the OTLP output.
+type Config struct {
+	outputs.TLSConfig
+	// Enabled indicates if the OTLP output is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// Protocol is the OTLP transport protocol. It can be one of grpc or http.
+	Protocol string `mapstructure:"protocol"`
+	// Endpoint is the collector address. For the gRPC protocol, it is given as host:port. For the
+	// HTTP protocol, it is the full URL of the logs receiver.
+	Endpoint string `mapstructure:"endpoint"`
+	// Headers represents a list of arbitrary headers or gRPC metadata included in each export request.
+	Headers map[string]string `mapstructure:"headers"`
+	// Timeout specifies the deadline of a single export request.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Compression is the payload compression algorithm. It can be one of none or gzip.
+	Compression string `mapstructure:"compression"`
+	// Insecure disables the transport security for the gRPC protocol.
+	Insecure bool `mapstructure:"insecure"`
+	// ServiceName is the value of the service.name resource attribute.
+	ServiceName string `mapstructure:"service-name"`
+	// Serializer indicates the serializer of the log record body. If set, it takes precedence over the template.
+	Serializer outputs.Serializer `mapstructure:"serializer"`
+	// Template is the template for rendering the log record body.
+	Template string `mapstructure:"template"`
+	// BatchSize determines the maximum number of log records sent in a single export request.
+	BatchSize int `mapstructure:"batch-size"`
+	// MaxRetries is the maximum number of retries of the failed export request.
+	MaxRetries int `mapstructure:"max-retries"`
+	// RetryInitialBackoff is the initial interval to wait before retrying the export request.
+	RetryInitialBackoff time.Duration `mapstructure:"retry-initial-backoff"`
+	// RetryMaxBackoff is the upper bound on the interval between retries.
+	RetryMaxBackoff time.Duration `mapstructure:"retry-max-backoff"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(otlpEnabled, false, "Indicates if the OTLP output is enabled")
+	flags.String(otlpProtocol, protoGRPC, "Specifies the OTLP transport protocol (grpc, http)")
+	flags.String(otlpEndpoint, "", "Represents the collector endpoint. Defaults to localhost:4317 for gRPC or http://localhost:4318/v1/logs for HTTP protocol")
+	flags.StringToString(otlpHeaders, map[string]string{}, "Represents a list of arbitrary headers or gRPC metadata included in each export request")
+	flags.Duration(otlpTimeout, time.Second*10, "Specifies the deadline of a single export request")
+	flags.String(otlpCompression, compressionNone, "Specifies the payload compression algorithm (none, gzip)")
+	flags.Bool(otlpInsecure, false, "Disables the transport security for the gRPC protocol")
+	flags.String(otlpServiceName, "fibratus", "Specifies the value of the service.name resource attribute")
+	flags.String(otlpSerializer, "", "Indicates the event serializer type of the log record body (json, ecs). If set, it takes precedence over the template")
+	flags.String(otlpTemplate, "", "Specifies the template for rendering the log record body")
+	flags.Int(otlpBatchSize, 512, "Determines the maximum number of log records sent in a single export request")
+	flags.Int(otlpMaxRetries, 5, "Specifies the maximum number of retries of the failed export request")
+	flags.Duration(otlpRetryInitialBackoff, time.Second, "Specifies the initial interval to wait before retrying the export request")
+	flags.Duration(otlpRetryMaxBackoff, time.Second*30, "Specifies the upper bound on the interval between retries")
+	outputs.AddTLSFlags(flags, outputs.OTLP)
+}
+
+// withDefaults fills in the default values of unset options.
+func (c Config) withDefaults() Config {
+	if c.Protocol == "" {
+		c.Protocol = protoGRPC
+	}
+	if c.Endpoint == "" {
+		if c.Protocol == protoHTTP {
+			c.Endpoint = defaultHTTPEndpoint
+		} else {
+			c.Endpoint = defaultGRPCEndpoint
+		}
+	}
+	if c.Compression == "" {
+		c.Compression = compressionNone
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = "fibratus"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Second * 10
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 512
+	}
+	if c.RetryInitialBackoff <= 0 {
+		c.RetryInitialBackoff = time.Second
+	}
+	if c.RetryMaxBackoff < c.RetryInitialBackoff {
+		c.RetryMaxBackoff = c.RetryInitialBackoff
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	switch c.Protocol {
+	case protoGRPC, protoHTTP:
+	default:
+		return fmt.Errorf("invalid protocol: %s", c.Protocol)
+	}
+	switch c.Compression {
+	case compressionNone, compressionGzip:
+	default:
+		return fmt.Errorf("invalid compression: %s", c.Compression)
+	}
+	if c.Protocol == protoHTTP {
+		u, err := url.Parse(c.Endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid endpoint scheme: %s", c.Endpoint)
+		}
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("invalid max retries: %d", c.MaxRetries)
+	}
+	if c.Serializer == outputs.Protobuf {
+		return fmt.Errorf("%s serializer is not supported by OTLP output", c.Serializer)
+	}
+	return c.Serializer.Validate()
+}
+
+// logsURL returns the URL of the HTTP logs receiver. If the
+// endpoint is given without the path, the path defaults to
+// the OTLP logs signal path.
+func (c Config) logsURL() string {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return c.Endpoint
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultLogsPath
+	}
+	return u.String()
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcgzip "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// protobufContentType is the content type of OTLP/HTTP binary protobuf payloads
+const protobufContentType = "application/x-protobuf"
+
+// maxErrorBodySize limits the size of the error response body read from the collector
+const maxErrorBodySize = 4096
+
+// exporter ships export requests to the collector.
+type exporter interface {
+	// export sends the request and returns the collector response. Errors the
+	// collector signals as transient are wrapped in the retryableError.
+	export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error)
+	// close releases the resources held by the exporter.
+	close() error
+}
+
+// retryableError indicates the export request can be retried. The
+// throttle delay, if present, is the interval requested by the collector
+// to wait before the next attempt.
+type retryableError struct {
+	err      error
+	throttle time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// grpcExporter exports log records via the gRPC transport.
+type grpcExporter struct {
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+	md     metadata.MD
+	opts   []grpc.CallOption
+}
+
+func newGRPCExporter(config Config, tlsConfig *tls.Config) (*grpcExporter, error) {
+	creds := insecure.NewCredentials()
+	if !config.Insecure {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{InsecureSkipVerify: config.TLSInsecureSkipVerify}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.Dial(config.Endpoint,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent(version.ProductToken()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	e := &grpcExporter{
+		conn:   conn,
+		client: collogspb.NewLogsServiceClient(conn),
+		md:     metadata.New(config.Headers),
+	}
+	if config.Compression == compressionGzip {
+		e.opts = append(e.opts, grpc.UseCompressor(grpcgzip.Name))
+	}
+	return e, nil
+}
+
+func (e *grpcExporter) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	if e.md.Len() > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, e.md)
+	}
+	resp, err := e.client.Export(ctx, req, e.opts...)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp, nil
+}
+
+func (e *grpcExporter) close() error { return e.conn.Close() }
+
+// grpcError classifies the status error according to the OTLP
+// specification. Resource exhaustion is only retried when the
+// collector attaches the retry information to the status.
+func grpcError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	var throttle time.Duration
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			throttle = info.RetryDelay.AsDuration()
+		}
+	}
+	switch st.Code() {
+	case codes.Canceled, codes.DeadlineExceeded, codes.Aborted,
+		codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return &retryableError{err: err, throttle: throttle}
+	case codes.ResourceExhausted:
+		if throttle > 0 {
+			return &retryableError{err: err, throttle: throttle}
+		}
+	}
+	return err
+}
+
+// httpExporter exports log records via the HTTP transport.
+type httpExporter struct {
+	client *http.Client
+	config Config
+	url    string
+}
+
+func newHTTPExporter(config Config, tlsConfig *tls.Config) *httpExporter {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	} else if config.TLSInsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &httpExporter{
+		client: &http.Client{Transport: transport},
+		config: config,
+		url:    config.logsURL(),
+	}
+}
+
+func (e *httpExporter) export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if e.config.Compression == compressionGzip {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(body); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("User-Agent", version.ProductToken())
+	r.Header.Set("Content-Type", protobufContentType)
+	if e.config.Compression == compressionGzip {
+		r.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range e.config.Headers {
+		r.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(r)
+	if err != nil {
+		// transport errors are transient
+		return nil, &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		var res collogspb.ExportLogsServiceResponse
+		if len(b) > 0 {
+			if err := proto.Unmarshal(b, &res); err != nil {
+				return nil, fmt.Errorf("unable to decode export response: %v", err)
+			}
+		}
+		return &res, nil
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	err = fmt.Errorf("otlp export failed with %d status code: %s", resp.StatusCode, string(b))
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, &retryableError{err: err, throttle: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+	return nil, err
+}
+
+func (e *httpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// retryAfter parses the Retry-After header value that can be
+// given either in delay seconds or as the HTTP date.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// scopeName is the name of the instrumentation scope that emits log records
+const scopeName = "github.com/rabbitstack/fibratus"
+
+// severities maps rule severities to OTLP severity numbers
+var severities = map[string]logspb.SeverityNumber{
+	"low":      logspb.SeverityNumber_SEVERITY_NUMBER_INFO4,
+	"medium":   logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	"high":     logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	"critical": logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+// severity derives the severity number and text of the log record from
+// rule metadata. Events that didn't trigger any rule are reported with
+// the informational severity. Rule matches without an explicit severity
+// are promoted to the warning level.
+func severity(kevt *kevent.Kevent) (logspb.SeverityNumber, string) {
+	if kevt.GetMetaAsString(kevent.RuleNameKey) == "" {
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO"
+	}
+	sev := strings.ToLower(kevt.GetMetaAsString(kevent.RuleSeverityKey))
+	if num, ok := severities[sev]; ok {
+		return num, strings.ToUpper(sev)
+	}
+	return logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "WARN"
+}
+
+// logsBuilder converts events to OTLP log records.
+type logsBuilder struct {
+	config Config
+	body   func(*kevent.Kevent) string
+}
+
+// build produces the export request from the given events. Log records
+// are grouped in resources by the host name that originated the event.
+func (b *logsBuilder) build(evts []*kevent.Kevent) *collogspb.ExportLogsServiceRequest {
+	var (
+		req       = &collogspb.ExportLogsServiceRequest{}
+		resources = make(map[string]*logspb.ScopeLogs)
+		observed  = uint64(time.Now().UnixNano())
+	)
+	for _, kevt := range evts {
+		scope, ok := resources[kevt.Host]
+		if !ok {
+			scope = &logspb.ScopeLogs{
+				Scope: &commonpb.InstrumentationScope{
+					Name:    scopeName,
+					Version: version.Get(),
+				},
+			}
+			resources[kevt.Host] = scope
+			req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+				Resource:  b.resource(kevt.Host),
+				ScopeLogs: []*logspb.ScopeLogs{scope},
+			})
+		}
+		scope.LogRecords = append(scope.LogRecords, b.record(kevt, observed))
+	}
+	return req
+}
+
+func (b *logsBuilder) resource(host string) *resourcepb.Resource {
+	attrs := []*commonpb.KeyValue{
+		strAttr("service.name", b.config.ServiceName),
+		strAttr("service.version", version.Get()),
+		strAttr("os.type", "windows"),
+	}
+	if host != "" {
+		attrs = append(attrs, strAttr("host.name", host))
+	}
+	return &resourcepb.Resource{Attributes: attrs}
+}
+
+func (b *logsBuilder) record(kevt *kevent.Kevent, observed uint64) *logspb.LogRecord {
+	num, text := severity(kevt)
+	return &logspb.LogRecord{
+		TimeUnixNano:         uint64(kevt.Timestamp.UnixNano()),
+		ObservedTimeUnixNano: observed,
+		SeverityNumber:       num,
+		SeverityText:         text,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: b.body(kevt)}},
+		Attributes:           attributes(kevt),
+	}
+}
+
+// attributes builds the log record attributes from the event, its
+// parameters, process state, and metadata.
+func attributes(kevt *kevent.Kevent) []*commonpb.KeyValue {
+	attrs := []*commonpb.KeyValue{
+		strAttr("event.name", kevt.Name),
+		strAttr("event.category", string(kevt.Category)),
+		intAttr("event.sequence", int64(kevt.Seq)),
+		intAttr("event.cpu", int64(kevt.CPU)),
+		intAttr("process.pid", int64(kevt.PID)),
+		intAttr("thread.id", int64(kevt.Tid)),
+	}
+	if kevt.Description != "" {
+		attrs = append(attrs, strAttr("event.description", kevt.Description))
+	}
+
+	pars := make([]*kevent.Kparam, 0, len(kevt.Kparams))
+	for _, kpar := range kevt.Kparams {
+		pars = append(pars, kpar)
+	}
+	sort.Slice(pars, func(i, j int) bool { return pars[i].Name < pars[j].Name })
+	for _, kpar := range pars {
+		attrs = append(attrs, &commonpb.KeyValue{Key: "kparams." + kpar.Name, Value: paramValue(kevt, kpar)})
+	}
+
+	if ps := kevt.PS; ps != nil {
+		attrs = append(attrs,
+			intAttr("process.parent_pid", int64(ps.Ppid)),
+			strAttr("process.executable.name", ps.Name),
+			strAttr("process.executable.path", ps.Exe),
+			strAttr("process.command_line", ps.Cmdline),
+			strAttr("process.working_directory", ps.Cwd),
+			strAttr("process.owner", ps.Username),
+			strAttr("process.owner.domain", ps.Domain),
+			strAttr("process.owner.sid", ps.SID),
+			intAttr("process.session_id", int64(ps.SessionID)),
+		)
+		if parent := ps.Parent; parent != nil {
+			attrs = append(attrs,
+				strAttr("process.parent.executable.name", parent.Name),
+				strAttr("process.parent.executable.path", parent.Exe),
+				strAttr("process.parent.command_line", parent.Cmdline),
+			)
+		}
+	}
+
+	keys := make([]string, 0, len(kevt.Metadata))
+	for k := range kevt.Metadata {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, strAttr(k, fmt.Sprintf("%v", kevt.Metadata[kevent.MetadataKey(k)])))
+	}
+
+	return attrs
+}
+
+// paramValue converts the event parameter to the attribute value
+// preserving the parameter type where the OTLP data model permits.
+func paramValue(kevt *kevent.Kevent, kpar *kevent.Kparam) *commonpb.AnyValue {
+	switch kpar.Type {
+	case kparams.Int64:
+		return intValue(kpar.Value.(int64))
+	case kparams.Uint64:
+		return intValue(int64(kpar.Value.(uint64)))
+	case kparams.Int32:
+		return intValue(int64(kpar.Value.(int32)))
+	case kparams.Uint32, kparams.PID, kparams.TID:
+		return intValue(int64(kpar.Value.(uint32)))
+	case kparams.Int16:
+		return intValue(int64(kpar.Value.(int16)))
+	case kparams.Uint16, kparams.Port:
+		return intValue(int64(kpar.Value.(uint16)))
+	case kparams.Int8:
+		return intValue(int64(kpar.Value.(int8)))
+	case kparams.Uint8:
+		return intValue(int64(kpar.Value.(uint8)))
+	case kparams.Float:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(kpar.Value.(float32))}}
+	case kparams.Double:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: kpar.Value.(float64)}}
+	case kparams.Bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: kpar.Value.(bool)}}
+	case kparams.IPv4, kparams.IPv6:
+		return strValue(kpar.Value.(net.IP).String())
+	case kparams.Slice:
+		if slice, ok := kpar.Value.([]string); ok {
+			values := make([]*commonpb.AnyValue, len(slice))
+			for i, s := range slice {
+				values[i] = strValue(s)
+			}
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+		}
+	}
+	return strValue(kevt.GetParamAsString(kpar.Name))
+}
+
+func strValue(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+func intValue(n int64) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: n}}
+}
+
+func strAttr(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: strValue(value)}
+}
+
+func intAttr(key string, value int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: intValue(value)}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func newKevent(host string) *kevent.Kevent {
+	ts, _ := time.Parse(time.RFC3339, "2023-05-03T15:04:05.323Z")
+	return &kevent.Kevent{
+		Type:        ktypes.ConnectTCPv4,
+		Tid:         2484,
+		PID:         859,
+		Seq:         2,
+		Name:        "Connect",
+		Category:    ktypes.Net,
+		Host:        host,
+		Description: "Connects a socket to the remote peer",
+		Timestamp:   ts,
+		Kparams: kevent.Kparams{
+			kparams.NetDport: {Name: kparams.NetDport, Type: kparams.Uint16, Value: uint16(443)},
+			kparams.NetDIP:   {Name: kparams.NetDIP, Type: kparams.AnsiString, Value: "216.58.201.174"},
+		},
+		Metadata: map[kevent.MetadataKey]any{
+			kevent.RuleNameKey:     "Suspicious connection | C2",
+			kevent.RuleGroupKey:    "command and control",
+			kevent.RuleSeverityKey: "high",
+		},
+		PS: &pstypes.PS{
+			PID:      859,
+			Ppid:     4,
+			Name:     "rundll32.exe",
+			Exe:      `C:\Windows\System32\rundll32.exe`,
+			Cmdline:  `C:\Windows\System32\rundll32.exe shell32.dll,Control_RunDLL`,
+			SID:      "S-1-5-18",
+			Username: "SYSTEM",
+			Domain:   "NT AUTHORITY",
+		},
+	}
+}
+
+func attrsToMap(attrs []*commonpb.KeyValue) map[string]*commonpb.AnyValue {
+	m := make(map[string]*commonpb.AnyValue, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Key] = attr.Value
+	}
+	return m
+}
+
+func TestBuildLogs(t *testing.T) {
+	b := &logsBuilder{
+		config: Config{ServiceName: "edr"},
+		body:   func(kevt *kevent.Kevent) string { return kevt.Name },
+	}
+	evt1 := newKevent("archrabbit")
+	evt2 := newKevent("archrabbit")
+	evt2.Metadata = map[kevent.MetadataKey]any{}
+	evt3 := newKevent("sandbox")
+
+	req := b.build([]*kevent.Kevent{evt1, evt2, evt3})
+	require.Len(t, req.ResourceLogs, 2)
+
+	res := attrsToMap(req.ResourceLogs[0].Resource.Attributes)
+	assert.Equal(t, "archrabbit", res["host.name"].GetStringValue())
+	assert.Equal(t, "edr", res["service.name"].GetStringValue())
+	assert.Equal(t, "sandbox", attrsToMap(req.ResourceLogs[1].Resource.Attributes)["host.name"].GetStringValue())
+
+	require.Len(t, req.ResourceLogs[0].ScopeLogs, 1)
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 2)
+
+	rec := records[0]
+	assert.Equal(t, uint64(evt1.Timestamp.UnixNano()), rec.TimeUnixNano)
+	assert.NotZero(t, rec.ObservedTimeUnixNano)
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, rec.SeverityNumber)
+	assert.Equal(t, "HIGH", rec.SeverityText)
+	assert.Equal(t, "Connect", rec.Body.GetStringValue())
+
+	attrs := attrsToMap(rec.Attributes)
+	assert.Equal(t, "Connect", attrs["event.name"].GetStringValue())
+	assert.Equal(t, "net", attrs["event.category"].GetStringValue())
+	assert.Equal(t, int64(2), attrs["event.sequence"].GetIntValue())
+	assert.Equal(t, int64(859), attrs["process.pid"].GetIntValue())
+	assert.Equal(t, int64(2484), attrs["thread.id"].GetIntValue())
+	assert.Equal(t, int64(443), attrs["kparams.dport"].GetIntValue())
+	assert.Equal(t, "216.58.201.174", attrs["kparams.dip"].GetStringValue())
+	assert.Equal(t, "rundll32.exe", attrs["process.executable.name"].GetStringValue())
+	assert.Equal(t, `C:\Windows\System32\rundll32.exe`, attrs["process.executable.path"].GetStringValue())
+	assert.Equal(t, int64(4), attrs["process.parent_pid"].GetIntValue())
+	assert.Equal(t, "SYSTEM", attrs["process.owner"].GetStringValue())
+	assert.Equal(t, "Suspicious connection | C2", attrs["rule.name"].GetStringValue())
+	assert.Equal(t, "command and control", attrs["rule.group"].GetStringValue())
+
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, records[1].SeverityNumber)
+	assert.Equal(t, "INFO", records[1].SeverityText)
+}
+
+func TestSeverity(t *testing.T) {
+	var tests = []struct {
+		meta map[kevent.MetadataKey]any
+		num  logspb.SeverityNumber
+		text string
+	}{
+		{map[kevent.MetadataKey]any{}, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO"},
+		{map[kevent.MetadataKey]any{kevent.RuleNameKey: "r"}, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "WARN"},
+		{map[kevent.MetadataKey]any{kevent.RuleNameKey: "r", kevent.RuleSeverityKey: "low"}, logspb.SeverityNumber_SEVERITY_NUMBER_INFO4, "LOW"},
+		{map[kevent.MetadataKey]any{kevent.RuleNameKey: "r", kevent.RuleSeverityKey: "medium"}, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "MEDIUM"},
+		{map[kevent.MetadataKey]any{kevent.RuleNameKey: "r", kevent.RuleSeverityKey: "Critical"}, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, "CRITICAL"},
+	}
+
+	for _, tt := range tests {
+		num, text := severity(&kevent.Kevent{Metadata: tt.meta})
+		assert.Equal(t, tt.num, num)
+		assert.Equal(t, tt.text, text)
+	}
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	tlsutil "github.com/rabbitstack/fibratus/pkg/util/tls"
+	log "github.com/sirupsen/logrus"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+)
+
+var (
+	// otlpErrors counts export requests that failed after exhausting all retries
+	otlpErrors = expvar.NewInt("output.otlp.publish.errors")
+	// otlpRecords counts the number of log records accepted by the collector
+	otlpRecords = expvar.NewInt("output.otlp.records.exported")
+	// otlpRejectedRecords counts the number of log records rejected by the collector
+	otlpRejectedRecords = expvar.NewInt("output.otlp.records.rejected")
+	// otlpRetries counts the number of export retries
+	otlpRetries = expvar.NewInt("output.otlp.retries")
+)
+
+// errClosed signals the output was closed while waiting to retry the export request
+var errClosed = errors.New("otlp output closed")
+
+type otlp struct {
+	config    Config
+	tlsConfig *tls.Config
+	builder   *logsBuilder
+	exporter  exporter
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+func init() {
+	outputs.Register(outputs.OTLP, initOTLP)
+}
+
+func initOTLP(config outputs.Config) (outputs.OutputGroup, error) {
+	cfg, ok := config.Output.(Config)
+	if !ok {
+		return outputs.Fail(outputs.ErrInvalidConfig(outputs.OTLP, config.Output))
+	}
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return outputs.Fail(err)
+	}
+	tmpl := cfg.Template
+	if tmpl == "" {
+		tmpl = defaultTemplate
+	}
+	formatter, err := kevent.NewFormatter(tmpl)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+	tlsConfig, err := tlsutil.MakeConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSCA, cfg.TLSInsecureSkipVerify)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+	body := func(kevt *kevent.Kevent) string { return string(formatter.Format(kevt)) }
+	if cfg.Serializer != "" {
+		body = func(kevt *kevent.Kevent) string { return string(cfg.Serializer.Marshal(kevt)) }
+	}
+	o := &otlp{
+		config:    cfg,
+		tlsConfig: tlsConfig,
+		builder: &logsBuilder{
+			config: cfg,
+			body:   body,
+		},
+		stop: make(chan struct{}),
+	}
+	return outputs.Success(o), nil
+}
+
+func (o *otlp) Connect() error {
+	switch o.config.Protocol {
+	case protoHTTP:
+		o.exporter = newHTTPExporter(o.config, o.tlsConfig)
+	default:
+		var err error
+		o.exporter, err = newGRPCExporter(o.config, o.tlsConfig)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *otlp) Close() error {
+	var err error
+	o.closeOnce.Do(func() {
+		close(o.stop)
+		if o.exporter != nil {
+			err = o.exporter.close()
+		}
+	})
+	return err
+}
+
+// Publish converts the events in the batch to log records and exports
+// them in requests of up to the configured batch size. The call blocks
+// while the collector is throttling or temporarily unavailable, which
+// in turn applies the backpressure to the event aggregator.
+func (o *otlp) Publish(batch *kevent.Batch) error {
+	evts := batch.Events
+	for len(evts) > 0 {
+		n := o.config.BatchSize
+		if n > len(evts) {
+			n = len(evts)
+		}
+		if err := o.send(o.builder.build(evts[:n]), n); err != nil {
+			otlpErrors.Add(1)
+			return err
+		}
+		evts = evts[n:]
+	}
+	return nil
+}
+
+// send exports the request retrying transient failures with exponential
+// backoff. The throttle delay requested by the collector takes precedence
+// over the computed backoff interval.
+func (o *otlp) send(req *collogspb.ExportLogsServiceRequest, n int) error {
+	backoff := o.config.RetryInitialBackoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), o.config.Timeout)
+		resp, err := o.exporter.export(ctx, req)
+		cancel()
+		if err == nil {
+			o.handlePartialSuccess(resp, n)
+			return nil
+		}
+		var rerr *retryableError
+		if !errors.As(err, &rerr) || attempt >= o.config.MaxRetries {
+			return err
+		}
+		delay := backoff
+		if rerr.throttle > 0 {
+			delay = rerr.throttle
+		}
+		log.Warnf("otlp export failed: %v. Retrying in %v", err, delay)
+		otlpRetries.Add(1)
+		select {
+		case <-time.After(delay):
+		case <-o.stop:
+			return errClosed
+		}
+		backoff *= 2
+		if backoff > o.config.RetryMaxBackoff {
+			backoff = o.config.RetryMaxBackoff
+		}
+	}
+}
+
+// handlePartialSuccess accounts for log records rejected by the
+// collector. Rejected records are not retried as per specification.
+func (o *otlp) handlePartialSuccess(resp *collogspb.ExportLogsServiceResponse, n int) {
+	partial := resp.GetPartialSuccess()
+	rejected := int(partial.GetRejectedLogRecords())
+	if rejected > 0 || partial.GetErrorMessage() != "" {
+		log.Warnf("otlp collector rejected %d log record(s): %s", rejected, partial.GetErrorMessage())
+	}
+	otlpRejectedRecords.Add(int64(rejected))
+	otlpRecords.Add(int64(n - rejected))
+}
@@ -0,0 +1,260 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// receiver is the in-process OTLP logs receiver. The errs
+// slice contains errors returned on consecutive exports.
+type receiver struct {
+	collogspb.UnimplementedLogsServiceServer
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	md       metadata.MD
+	errs     []error
+	calls    int32
+}
+
+func (r *receiver) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := atomic.AddInt32(&r.calls, 1)
+	if int(n) <= len(r.errs) {
+		return nil, r.errs[n-1]
+	}
+	r.md, _ = metadata.FromIncomingContext(ctx)
+	r.requests = append(r.requests, req)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func (r *receiver) records() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for _, req := range r.requests {
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				n += len(sl.LogRecords)
+			}
+		}
+	}
+	return n
+}
+
+func startGRPCReceiver(t *testing.T, r *receiver) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(srv, r)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+	return l.Addr().String()
+}
+
+func newOTLP(t *testing.T, config Config) *otlp {
+	out, err := initOTLP(outputs.Config{Type: outputs.OTLP, Output: config})
+	require.NoError(t, err)
+	require.Len(t, out.Clients, 1)
+	o := out.Clients[0].(*otlp)
+	require.NoError(t, o.Connect())
+	t.Cleanup(func() { _ = o.Close() })
+	return o
+}
+
+func TestPublishGRPC(t *testing.T) {
+	r := &receiver{}
+	addr := startGRPCReceiver(t, r)
+
+	o := newOTLP(t, Config{
+		Protocol:    protoGRPC,
+		Endpoint:    addr,
+		Insecure:    true,
+		Compression: compressionGzip,
+		Headers:     map[string]string{"api-key": "secret"},
+		BatchSize:   2,
+	})
+
+	require.NoError(t, o.Publish(kevent.NewBatch(newKevent("archrabbit"), newKevent("archrabbit"), newKevent("archrabbit"))))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// three events are split in two requests
+	require.Len(t, r.requests, 2)
+	assert.Equal(t, []string{"secret"}, r.md.Get("api-key"))
+	rec := r.requests[0].ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.Contains(t, rec.Body.GetStringValue(), "Connect")
+	assert.Equal(t, "216.58.201.174", attrsToMap(rec.Attributes)["kparams.dip"].GetStringValue())
+}
+
+func TestPublishGRPCRetry(t *testing.T) {
+	st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Millisecond * 10)})
+	require.NoError(t, err)
+	r := &receiver{errs: []error{status.Error(codes.Unavailable, "collector unavailable"), st.Err()}}
+	addr := startGRPCReceiver(t, r)
+
+	o := newOTLP(t, Config{Endpoint: addr, Insecure: true, MaxRetries: 3, RetryInitialBackoff: time.Millisecond})
+
+	require.NoError(t, o.Publish(kevent.NewBatch(newKevent("archrabbit"))))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&r.calls))
+	assert.Equal(t, 1, r.records())
+}
+
+func TestPublishGRPCNonRetryable(t *testing.T) {
+	r := &receiver{errs: []error{status.Error(codes.InvalidArgument, "bad request")}}
+	addr := startGRPCReceiver(t, r)
+
+	o := newOTLP(t, Config{Endpoint: addr, Insecure: true, MaxRetries: 3, RetryInitialBackoff: time.Millisecond})
+
+	require.Error(t, o.Publish(kevent.NewBatch(newKevent("archrabbit"))))
+	// resource exhaustion without retry info is not retried
+	r.mu.Lock()
+	r.errs = []error{status.Error(codes.ResourceExhausted, "quota exceeded")}
+	atomic.StoreInt32(&r.calls, 0)
+	r.mu.Unlock()
+	require.Error(t, o.Publish(kevent.NewBatch(newKevent("archrabbit"))))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&r.calls))
+}
+
+func TestPublishHTTP(t *testing.T) {
+	var (
+		calls int32
+		reqs  = make(chan *collogspb.ExportLogsServiceRequest, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, protobufContentType, r.Header.Get("Content-Type"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "secret", r.Header.Get("Api-Key"))
+		gz, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, err := io.ReadAll(gz)
+		if !assert.NoError(t, err) {
+			return
+		}
+		var req collogspb.ExportLogsServiceRequest
+		if !assert.NoError(t, proto.Unmarshal(b, &req)) {
+			return
+		}
+		reqs <- &req
+
+		resp, _ := proto.Marshal(&collogspb.ExportLogsServiceResponse{
+			PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1, ErrorMessage: "too old"},
+		})
+		w.Header().Set("Content-Type", protobufContentType)
+		_, _ = w.Write(resp)
+	}))
+	defer srv.Close()
+
+	o := newOTLP(t, Config{
+		Protocol:            protoHTTP,
+		Endpoint:            srv.URL,
+		Compression:         compressionGzip,
+		Headers:             map[string]string{"api-key": "secret"},
+		MaxRetries:          1,
+		RetryInitialBackoff: time.Millisecond,
+	})
+
+	rejected := otlpRejectedRecords.Value()
+	require.NoError(t, o.Publish(kevent.NewBatch(newKevent("archrabbit"), newKevent("sandbox"))))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, rejected+1, otlpRejectedRecords.Value())
+
+	req := <-reqs
+	require.Len(t, req.ResourceLogs, 2)
+	assert.Equal(t, "sandbox", attrsToMap(req.ResourceLogs[1].Resource.Attributes)["host.name"].GetStringValue())
+}
+
+func TestPublishHTTPRetriesExhausted(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	o := newOTLP(t, Config{Protocol: protoHTTP, Endpoint: srv.URL, MaxRetries: 2, RetryInitialBackoff: time.Millisecond})
+
+	require.Error(t, o.Publish(kevent.NewBatch(newKevent("archrabbit"))))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), retryAfter(""))
+	assert.Equal(t, time.Second*3, retryAfter("3"))
+	assert.True(t, retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)) > time.Second*50)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := initOTLP(outputs.Config{Type: outputs.OTLP, Output: Config{Protocol: "thrift"}})
+	require.Error(t, err)
+	_, err = initOTLP(outputs.Config{Type: outputs.OTLP, Output: Config{Compression: "zstd"}})
+	require.Error(t, err)
+	_, err = initOTLP(outputs.Config{Type: outputs.OTLP, Output: Config{Protocol: protoHTTP, Endpoint: "localhost:4318"}})
+	require.Error(t, err)
+	_, err = initOTLP(outputs.Config{Type: outputs.OTLP, Output: Config{Serializer: outputs.Protobuf}})
+	require.Error(t, err)
+}
+
+func TestSerializerBody(t *testing.T) {
+	out, err := initOTLP(outputs.Config{Type: outputs.OTLP, Output: Config{Serializer: outputs.ECS, Template: "{{ .Type }}"}})
+	require.NoError(t, err)
+	o := out.Clients[0].(*otlp)
+	assert.Contains(t, o.builder.body(newKevent("archrabbit")), `"@timestamp"`)
+
+	out, err = initOTLP(outputs.Config{Type: outputs.OTLP, Output: Config{Template: "{{ .Type }}"}})
+	require.NoError(t, err)
+	o = out.Clients[0].(*otlp)
+	assert.Equal(t, "Connect", o.builder.body(newKevent("archrabbit")))
+}
+
+func TestLogsURL(t *testing.T) {
+	assert.Equal(t, "http://collector:4318/v1/logs", Config{Endpoint: "http://collector:4318"}.logsURL())
+	assert.Equal(t, "https://collector/custom/logs", Config{Endpoint: "https://collector/custom/logs"}.logsURL())
+}
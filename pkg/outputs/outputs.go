@@ -51,6 +51,16 @@ const (
 	Eventlog
 	// Null is the null output.
 	Null
+	// Kafka denotes the Kafka output.
+	Kafka
+	// Syslog denotes the syslog output.
+	Syslog
+	// File denotes the rotating file output.
+	File
+	// OTLP denotes the OpenTelemetry logs output.
+	OTLP
+	// Splunk denotes the Splunk HTTP Event Collector output.
+	Splunk
 	// Unknown is an undefined output type.
 	Unknown
 )
@@ -70,6 +80,16 @@ func (t Type) String() string {
 		return "eventlog"
 	case Null:
 		return "null"
+	case Kafka:
+		return "kafka"
+	case Syslog:
+		return "syslog"
+	case File:
+		return "file"
+	case OTLP:
+		return "otlp"
+	case Splunk:
+		return "splunk"
 	default:
 		return "unknown"
 	}
@@ -90,6 +110,16 @@ func TypeFromString(s string) Type {
 		return Eventlog
 	case "null":
 		return Null
+	case "kafka":
+		return Kafka
+	case "syslog":
+		return Syslog
+	case "file":
+		return File
+	case "otlp":
+		return OTLP
+	case "splunk":
+		return Splunk
 	default:
 		return Unknown
 	}
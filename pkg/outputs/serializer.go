@@ -18,10 +18,69 @@
 
 package outputs
 
+import (
+	"fmt"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+)
+
 // Serializer is the type definition for the output serializers.
 type Serializer string
 
 const (
 	// JSON represents the JSON serializer type.
 	JSON Serializer = "json"
+	// ECS represents the serializer that produces JSON documents conforming to the Elastic Common Schema.
+	ECS Serializer = "ecs"
+	// Protobuf represents the Protocol Buffers serializer type. The schema is published in the kevent.proto file.
+	Protobuf Serializer = "protobuf"
 )
+
+// ErrUnknownSerializer signals an unknown serializer type
+var ErrUnknownSerializer = func(s Serializer) error {
+	return fmt.Errorf("unknown serializer %q. Choose between json|ecs|protobuf", s)
+}
+
+// Validate returns an error if the serializer is unknown. The empty
+// serializer is valid and falls back to the JSON serializer.
+func (s Serializer) Validate() error {
+	switch s {
+	case "", JSON, ECS, Protobuf:
+		return nil
+	default:
+		return ErrUnknownSerializer(s)
+	}
+}
+
+// Marshal serializes a single event.
+func (s Serializer) Marshal(kevt *kevent.Kevent) []byte {
+	switch s {
+	case ECS:
+		return kevt.MarshalECS()
+	case Protobuf:
+		return kevt.MarshalProtobuf()
+	default:
+		return kevt.MarshalJSON()
+	}
+}
+
+// MarshalBatch serializes the batch of events. JSON serializers produce the JSON array,
+// while the Protocol Buffers serializer produces the EventBatch message.
+func (s Serializer) MarshalBatch(batch *kevent.Batch) []byte {
+	switch s {
+	case ECS:
+		return batch.MarshalECS()
+	case Protobuf:
+		return batch.MarshalProtobuf()
+	default:
+		return batch.MarshalJSON()
+	}
+}
+
+// ContentType returns the media type of the serialized payload.
+func (s Serializer) ContentType() string {
+	if s == Protobuf {
+		return "application/x-protobuf"
+	}
+	return "application/json"
+}
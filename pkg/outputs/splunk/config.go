@@ -0,0 +1,174 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package splunk
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/spf13/pflag"
+)
+
+const (
+	splunkEnabled         = "output.splunk.enabled"
+	splunkEndpoint        = "output.splunk.endpoint"
+	splunkToken           = "output.splunk.token"
+	splunkIndex           = "output.splunk.index"
+	splunkRuleIndex       = "output.splunk.rule-index"
+	splunkSource          = "output.splunk.source"
+	splunkSourcetype      = "output.splunk.sourcetype"
+	splunkSerializer      = "output.splunk.serializer"
+	splunkEnableGzip      = "output.splunk.enable-gzip"
+	splunkTimeout         = "output.splunk.timeout"
+	splunkAck             = "output.splunk.ack"
+	splunkAckTimeout      = "output.splunk.ack-timeout"
+	splunkAckPollInterval = "output.splunk.ack-poll-interval"
+	splunkChannel         = "output.splunk.channel"
+	splunkProxyURL        = "output.splunk.proxy-url"
+	splunkProxyUsername   = "output.splunk.proxy-username"
+	splunkProxyPassword   = "output.splunk.proxy-password"
+)
+
+const (
+	// eventPath is the path of the HEC JSON event endpoint
+	eventPath = "/services/collector/event"
+	// ackPath is the path of the HEC indexer acknowledgement endpoint
+	ackPath = "/services/collector/ack"
+)
+
+// Config contains the options that influence the behaviour of the Splunk output.
+type Config struct {
+	outputs.TLSConfig
+	// Enabled indicates if the Splunk output is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint is the HTTP Event Collector URL. If the URL lacks the path, the JSON event endpoint path is used.
+	Endpoint string `mapstructure:"endpoint"`
+	// Token is the HTTP Event Collector token.
+	Token string `mapstructure:"token"`
+	// Index is the name of the index where events are stored. If empty, the default index of the token is used.
+	Index string `mapstructure:"index"`
+	// RuleIndex is the name of the index where events that triggered a rule are stored.
+	RuleIndex string `mapstructure:"rule-index"`
+	// Source is the source value assigned to events.
+	Source string `mapstructure:"source"`
+	// Sourcetype is the sourcetype value assigned to events.
+	Sourcetype string `mapstructure:"sourcetype"`
+	// Serializer indicates the serializer for the event payload.
+	Serializer outputs.Serializer `mapstructure:"serializer"`
+	// EnableGzip specifies whether the gzip compression is enabled.
+	EnableGzip bool `mapstructure:"enable-gzip"`
+	// Timeout represents the timeout for the HTTP requests.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Ack enables the indexer acknowledgement.
+	Ack bool `mapstructure:"ack"`
+	// AckTimeout specifies the maximum time to wait for the indexer acknowledgement.
+	AckTimeout time.Duration `mapstructure:"ack-timeout"`
+	// AckPollInterval specifies how often the acknowledgement status is queried.
+	AckPollInterval time.Duration `mapstructure:"ack-poll-interval"`
+	// Channel is the channel identifier used for indexer acknowledgement. If empty, a random channel identifier is generated.
+	Channel string `mapstructure:"channel"`
+	// ProxyURL specifies the HTTP proxy URL.
+	ProxyURL string `mapstructure:"proxy-url"`
+	// ProxyUsername is the username for proxy authentication.
+	ProxyUsername string `mapstructure:"proxy-username"`
+	// ProxyPassword is the password for proxy authentication.
+	ProxyPassword string `mapstructure:"proxy-password"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(splunkEnabled, false, "Indicates if the Splunk output is enabled")
+	flags.String(splunkEndpoint, "https://localhost:8088", "Represents the HTTP Event Collector URL")
+	flags.String(splunkToken, "", "Specifies the HTTP Event Collector token")
+	flags.String(splunkIndex, "", "Specifies the index where events are stored. If empty, the default index of the token is used")
+	flags.String(splunkRuleIndex, "", "Specifies the index where events that triggered a rule are stored. If empty, such events are stored in the regular index")
+	flags.String(splunkSource, "fibratus", "Specifies the source value assigned to events")
+	flags.String(splunkSourcetype, "fibratus", "Specifies the sourcetype value assigned to events")
+	flags.String(splunkSerializer, string(outputs.JSON), "Indicates the event serializer type (json, ecs)")
+	flags.Bool(splunkEnableGzip, false, "Indicates whether the gzip compression is enabled")
+	flags.Duration(splunkTimeout, time.Second*5, "Represents the timeout for the HTTP requests")
+	flags.Bool(splunkAck, false, "Enables the indexer acknowledgement")
+	flags.Duration(splunkAckTimeout, time.Second*30, "Specifies the maximum time to wait for the indexer acknowledgement")
+	flags.Duration(splunkAckPollInterval, time.Second, "Specifies how often the acknowledgement status is queried")
+	flags.String(splunkChannel, "", "Specifies the channel identifier used for indexer acknowledgement")
+	flags.String(splunkProxyURL, "", "Specifies the HTTP proxy URL. It overrides the HTTP proxy URL as indicated by the environment variables")
+	flags.String(splunkProxyUsername, "", "The username for HTTP proxy authentication")
+	flags.String(splunkProxyPassword, "", "The password for HTTP proxy authentication")
+	outputs.AddTLSFlags(flags, outputs.Splunk)
+}
+
+// withDefaults fills in the default values of unset options.
+func (c Config) withDefaults() Config {
+	if c.Endpoint == "" {
+		c.Endpoint = "https://localhost:8088"
+	}
+	if c.Source == "" {
+		c.Source = "fibratus"
+	}
+	if c.Sourcetype == "" {
+		c.Sourcetype = "fibratus"
+	}
+	if c.Serializer == "" {
+		c.Serializer = outputs.JSON
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Second * 5
+	}
+	if c.AckTimeout == 0 {
+		c.AckTimeout = time.Second * 30
+	}
+	if c.AckPollInterval == 0 {
+		c.AckPollInterval = time.Second
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	if c.Token == "" {
+		return fmt.Errorf("HTTP Event Collector token is required")
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid endpoint scheme: %s", c.Endpoint)
+	}
+	if c.Serializer == outputs.Protobuf {
+		return fmt.Errorf("%s serializer is not supported by Splunk output", c.Serializer)
+	}
+	return c.Serializer.Validate()
+}
+
+// urls returns the URLs of the event and acknowledgement endpoints.
+func (c Config) urls() (string, string) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return c.Endpoint, c.Endpoint
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = eventPath
+	}
+	event := u.String()
+	u.Path = ackPath
+	u.RawQuery = ""
+	return event, u.String()
+}
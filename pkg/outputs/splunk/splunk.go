@@ -0,0 +1,296 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package splunk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	httpout "github.com/rabbitstack/fibratus/pkg/outputs/http"
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+)
+
+var (
+	// splunkErrors counts failed HEC requests
+	splunkErrors = expvar.NewInt("output.splunk.publish.errors")
+	// splunkEvents counts the number of events accepted by the HTTP Event Collector
+	splunkEvents = expvar.NewInt("output.splunk.publish.events")
+	// splunkAckTimeouts counts the number of batches not acknowledged by indexers in time
+	splunkAckTimeouts = expvar.NewInt("output.splunk.ack.timeouts")
+)
+
+// errAckTimeout signals the batch was not acknowledged by indexers within the timeout
+var errAckTimeout = func(id int64, timeout time.Duration) error {
+	return fmt.Errorf("batch with ack id %d not acknowledged after %v", id, timeout)
+}
+
+// errAckDisabled signals the indexer acknowledgement is not enabled for the HEC token
+var errAckDisabled = errors.New("HEC response lacks the ack id. Is indexer acknowledgement enabled for the token?")
+
+// errClosed signals the output was closed while waiting for the indexer acknowledgement
+var errClosed = errors.New("splunk output closed")
+
+// hecEvent is the HEC event envelope.
+type hecEvent struct {
+	Time       json.Number       `json:"time"`
+	Host       string            `json:"host,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	Sourcetype string            `json:"sourcetype,omitempty"`
+	Index      string            `json:"index,omitempty"`
+	Event      json.RawMessage   `json:"event"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+// hecResponse is the response returned by the HEC endpoints.
+type hecResponse struct {
+	Text  string `json:"text"`
+	Code  int    `json:"code"`
+	AckID *int64 `json:"ackId"`
+}
+
+// ackRequest queries the status of the given ack identifiers.
+type ackRequest struct {
+	Acks []int64 `json:"acks"`
+}
+
+// ackResponse maps ack identifiers to their indexing status.
+type ackResponse struct {
+	Acks map[string]bool `json:"acks"`
+}
+
+type splunk struct {
+	client    *http.Client
+	config    Config
+	eventURL  string
+	ackURL    string
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+func init() {
+	outputs.Register(outputs.Splunk, initSplunk)
+}
+
+func initSplunk(config outputs.Config) (outputs.OutputGroup, error) {
+	cfg, ok := config.Output.(Config)
+	if !ok {
+		return outputs.Fail(outputs.ErrInvalidConfig(outputs.Splunk, config.Output))
+	}
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return outputs.Fail(err)
+	}
+	if cfg.Ack && cfg.Channel == "" {
+		cfg.Channel = uuid.NewString()
+	}
+	client, err := httpout.NewClient(httpout.ClientConfig{
+		TLSConfig:     cfg.TLSConfig,
+		Timeout:       cfg.Timeout,
+		ProxyURL:      cfg.ProxyURL,
+		ProxyUsername: cfg.ProxyUsername,
+		ProxyPassword: cfg.ProxyPassword,
+	})
+	if err != nil {
+		return outputs.Fail(err)
+	}
+	eventURL, ackURL := cfg.urls()
+	s := &splunk{
+		client:   client,
+		config:   cfg,
+		eventURL: eventURL,
+		ackURL:   ackURL,
+		stop:     make(chan struct{}),
+	}
+	return outputs.Success(s), nil
+}
+
+func (s *splunk) Connect() error { return nil }
+
+func (s *splunk) Close() error {
+	s.closeOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// Publish sends all events in the batch in a single HEC request. If
+// the indexer acknowledgement is enabled, the call blocks until indexers
+// confirm the batch was durably stored.
+func (s *splunk) Publish(batch *kevent.Batch) error {
+	body, err := s.encode(batch)
+	if err != nil {
+		return err
+	}
+	resp, err := s.post(s.eventURL, body, s.config.EnableGzip)
+	if err != nil {
+		splunkErrors.Add(1)
+		return err
+	}
+	if s.config.Ack {
+		if resp.AckID == nil {
+			splunkErrors.Add(1)
+			return errAckDisabled
+		}
+		if err := s.waitAck(*resp.AckID); err != nil {
+			return err
+		}
+	}
+	splunkEvents.Add(batch.Len())
+	return nil
+}
+
+// encode builds the request body from the stacked HEC event envelopes.
+func (s *splunk) encode(batch *kevent.Batch) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, kevt := range batch.Events {
+		if err := enc.Encode(s.envelope(kevt)); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// envelope wraps the event into the HEC envelope. Events that
+// triggered a rule are routed to the rule index, if configured, and
+// carry the rule name, group and severity as indexed fields.
+func (s *splunk) envelope(kevt *kevent.Kevent) hecEvent {
+	evt := hecEvent{
+		Time:       json.Number(strconv.FormatFloat(float64(kevt.Timestamp.UnixMilli())/1e3, 'f', 3, 64)),
+		Host:       kevt.Host,
+		Source:     s.config.Source,
+		Sourcetype: s.config.Sourcetype,
+		Index:      s.config.Index,
+		Event:      s.config.Serializer.Marshal(kevt),
+	}
+	rule := kevt.GetMetaAsString(kevent.RuleNameKey)
+	if rule == "" {
+		return evt
+	}
+	if s.config.RuleIndex != "" {
+		evt.Index = s.config.RuleIndex
+	}
+	evt.Fields = map[string]string{kevent.RuleNameKey.String(): rule}
+	for _, k := range []kevent.MetadataKey{kevent.RuleGroupKey, kevent.RuleSeverityKey} {
+		if v := kevt.GetMetaAsString(k); v != "" {
+			evt.Fields[k.String()] = v
+		}
+	}
+	return evt
+}
+
+// waitAck polls the acknowledgement endpoint until indexers confirm the
+// ack identifier, the ack timeout expires, or the output is closed.
+func (s *splunk) waitAck(id int64) error {
+	body, err := json.Marshal(ackRequest{Acks: []int64{id}})
+	if err != nil {
+		return err
+	}
+	key := strconv.FormatInt(id, 10)
+	deadline := time.NewTimer(s.config.AckTimeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(s.config.AckPollInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+		case <-deadline.C:
+			splunkAckTimeouts.Add(1)
+			return errAckTimeout(id, s.config.AckTimeout)
+		case <-s.stop:
+			return errClosed
+		}
+		var resp ackResponse
+		if err := s.do(s.ackURL, body, false, &resp); err != nil {
+			splunkErrors.Add(1)
+			return err
+		}
+		if resp.Acks[key] {
+			return nil
+		}
+	}
+}
+
+func (s *splunk) post(url string, body []byte, compress bool) (*hecResponse, error) {
+	var resp hecResponse
+	if err := s.do(url, body, compress, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do sends the HEC request and decodes the response into the given value.
+func (s *splunk) do(url string, body []byte, compress bool, v any) error {
+	if compress {
+		var bb bytes.Buffer
+		gz := gzip.NewWriter(&bb)
+		if _, err := gz.Write(body); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		body = bb.Bytes()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Splunk "+s.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.ProductToken())
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if s.config.Channel != "" {
+		req.Header.Set("X-Splunk-Request-Channel", s.config.Channel)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var hr hecResponse
+		if err := json.Unmarshal(b, &hr); err == nil && hr.Text != "" {
+			return fmt.Errorf("HEC request failed with %d status code: %s (code %d)", resp.StatusCode, hr.Text, hr.Code)
+		}
+		return fmt.Errorf("HEC request failed with %d status code: %s", resp.StatusCode, string(b))
+	}
+	return json.Unmarshal(b, v)
+}
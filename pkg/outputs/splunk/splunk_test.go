@@ -0,0 +1,234 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package splunk
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKevent(rule string) *kevent.Kevent {
+	ts, _ := time.Parse(time.RFC3339, "2023-05-03T15:04:05.323Z")
+	kevt := &kevent.Kevent{
+		Type:        ktypes.ConnectTCPv4,
+		Tid:         2484,
+		PID:         859,
+		Seq:         2,
+		Name:        "Connect",
+		Category:    ktypes.Net,
+		Host:        "archrabbit",
+		Description: "Connects a socket to the remote peer",
+		Timestamp:   ts,
+		Kparams: kevent.Kparams{
+			kparams.NetDport: {Name: kparams.NetDport, Type: kparams.Uint16, Value: uint16(443)},
+			kparams.NetDIP:   {Name: kparams.NetDIP, Type: kparams.AnsiString, Value: "216.58.201.174"},
+		},
+		Metadata: map[kevent.MetadataKey]any{},
+		PS: &pstypes.PS{
+			PID: 859,
+			Exe: `C:\Windows\System32\rundll32.exe`,
+		},
+	}
+	if rule != "" {
+		kevt.AddMeta(kevent.RuleNameKey, rule)
+		kevt.AddMeta(kevent.RuleGroupKey, "command and control")
+		kevt.AddMeta(kevent.RuleSeverityKey, "high")
+	}
+	return kevt
+}
+
+func newSplunk(t *testing.T, config Config) *splunk {
+	out, err := initSplunk(outputs.Config{Type: outputs.Splunk, Output: config})
+	require.NoError(t, err)
+	require.Len(t, out.Clients, 1)
+	s := out.Clients[0].(*splunk)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func decodeEvents(t *testing.T, r *http.Request) []hecEvent {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		body = gz
+	}
+	var evts []hecEvent
+	dec := json.NewDecoder(body)
+	for dec.More() {
+		var evt hecEvent
+		if !assert.NoError(t, dec.Decode(&evt)) {
+			return nil
+		}
+		evts = append(evts, evt)
+	}
+	return evts
+}
+
+func TestPublish(t *testing.T) {
+	evts := make(chan []hecEvent, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(eventPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Splunk 0b2f4e1c", r.Header.Get("Authorization"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		evts <- decodeEvents(t, r)
+		_, _ = w.Write([]byte(`{"text":"Success","code":0}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := newSplunk(t, Config{
+		Endpoint:   srv.URL,
+		Token:      "0b2f4e1c",
+		Index:      "edr",
+		RuleIndex:  "alerts",
+		Sourcetype: "fibratus:event",
+		EnableGzip: true,
+	})
+	require.NoError(t, s.Publish(kevent.NewBatch(newKevent(""), newKevent("Suspicious connection"))))
+
+	var published []hecEvent
+	select {
+	case published = <-evts:
+	case <-time.After(time.Second * 2):
+		t.Fatal("events not received")
+	}
+	require.Len(t, published, 2)
+
+	evt := published[0]
+	assert.Equal(t, json.Number("1683126245.323"), evt.Time)
+	assert.Equal(t, "archrabbit", evt.Host)
+	assert.Equal(t, "fibratus", evt.Source)
+	assert.Equal(t, "fibratus:event", evt.Sourcetype)
+	assert.Equal(t, "edr", evt.Index)
+	assert.Empty(t, evt.Fields)
+	var kevt map[string]any
+	require.NoError(t, json.Unmarshal(evt.Event, &kevt))
+	assert.Equal(t, "Connect", kevt["name"])
+
+	evt = published[1]
+	assert.Equal(t, "alerts", evt.Index)
+	assert.Equal(t, "Suspicious connection", evt.Fields["rule.name"])
+	assert.Equal(t, "command and control", evt.Fields["rule.group"])
+	assert.Equal(t, "high", evt.Fields["rule.severity"])
+}
+
+func TestPublishWithAck(t *testing.T) {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc(eventPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "7b0e7f3a-5d13-4d43-9a4e-7a4c3b3b1d11", r.Header.Get("X-Splunk-Request-Channel"))
+		_, _ = w.Write([]byte(`{"text":"Success","code":0,"ackId":7}`))
+	})
+	mux.HandleFunc(ackPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "7b0e7f3a-5d13-4d43-9a4e-7a4c3b3b1d11", r.Header.Get("X-Splunk-Request-Channel"))
+		var req ackRequest
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+			return
+		}
+		assert.Equal(t, []int64{7}, req.Acks)
+		// the batch is acknowledged on the second poll
+		if atomic.AddInt32(&polls, 1) < 2 {
+			_, _ = w.Write([]byte(`{"acks":{"7":false}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"acks":{"7":true}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := newSplunk(t, Config{
+		Endpoint:        srv.URL,
+		Token:           "0b2f4e1c",
+		Ack:             true,
+		AckPollInterval: time.Millisecond * 10,
+		Channel:         "7b0e7f3a-5d13-4d43-9a4e-7a4c3b3b1d11",
+	})
+	require.NoError(t, s.Publish(kevent.NewBatch(newKevent(""))))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&polls))
+}
+
+func TestPublishAckTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(eventPath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"text":"Success","code":0,"ackId":3}`))
+	})
+	mux.HandleFunc(ackPath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"acks":{"3":false}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := newSplunk(t, Config{
+		Endpoint:        srv.URL,
+		Token:           "0b2f4e1c",
+		Ack:             true,
+		AckTimeout:      time.Millisecond * 100,
+		AckPollInterval: time.Millisecond * 10,
+	})
+	assert.NotEmpty(t, s.config.Channel)
+	require.Error(t, s.Publish(kevent.NewBatch(newKevent(""))))
+}
+
+func TestPublishError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"text":"Invalid token","code":4}`))
+	}))
+	defer srv.Close()
+
+	s := newSplunk(t, Config{Endpoint: srv.URL, Token: "0b2f4e1c"})
+	err := s.Publish(kevent.NewBatch(newKevent("")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid token")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := initSplunk(outputs.Config{Type: outputs.Splunk, Output: Config{}})
+	require.Error(t, err)
+	_, err = initSplunk(outputs.Config{Type: outputs.Splunk, Output: Config{Token: "0b2f4e1c", Endpoint: "localhost:8088"}})
+	require.Error(t, err)
+	_, err = initSplunk(outputs.Config{Type: outputs.Splunk, Output: Config{Token: "0b2f4e1c", Serializer: outputs.Protobuf}})
+	require.Error(t, err)
+}
+
+func TestURLs(t *testing.T) {
+	event, ack := Config{Endpoint: "https://splunk:8088"}.urls()
+	assert.Equal(t, "https://splunk:8088/services/collector/event", event)
+	assert.Equal(t, "https://splunk:8088/services/collector/ack", ack)
+	event, ack = Config{Endpoint: "https://splunk:8088/services/collector/event/1.0"}.urls()
+	assert.Equal(t, "https://splunk:8088/services/collector/event/1.0", event)
+	assert.Equal(t, "https://splunk:8088/services/collector/ack", ack)
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/spf13/pflag"
+)
+
+const (
+	syslogEnabled    = "output.syslog.enabled"
+	syslogNetwork    = "output.syslog.network"
+	syslogAddress    = "output.syslog.address"
+	syslogFormat     = "output.syslog.format"
+	syslogFraming    = "output.syslog.framing"
+	syslogBody       = "output.syslog.body"
+	syslogSerializer = "output.syslog.serializer"
+	syslogTemplate   = "output.syslog.template"
+	syslogFacility   = "output.syslog.facility"
+	syslogSeverity   = "output.syslog.severity"
+	syslogAppName    = "output.syslog.app-name"
+	syslogHostname   = "output.syslog.hostname"
+	syslogTimeout    = "output.syslog.timeout"
+)
+
+const (
+	// rfc5424 is the modern syslog protocol format
+	rfc5424 = "rfc5424"
+	// rfc3164 is the legacy BSD syslog protocol format
+	rfc3164 = "rfc3164"
+
+	// octetCounting prefixes each message with its length
+	octetCounting = "octet-counting"
+	// newline terminates each message with the line feed character
+	newline = "newline"
+
+	// bodyTemplate renders the message body from the template
+	bodyTemplate = "template"
+	// bodyJSON renders the message body as JSON
+	bodyJSON = "json"
+	// bodyCEF renders the message body in ArcSight Common Event Format
+	bodyCEF = "cef"
+	// bodyLEEF renders the message body in IBM QRadar Log Event Extended Format
+	bodyLEEF = "leef"
+)
+
+// defaultTemplate represents the default template used to render the message body
+const defaultTemplate = "{{ .Seq }} {{ .Timestamp }} - {{ .CPU }} {{ .Process }} ({{ .Pid }}) - {{ .Type }} ({{ .Kparams }})"
+
+// Config contains the options that influence the behaviour of the syslog output.
+type Config struct {
+	outputs.TLSConfig
+	// Enabled indicates if the syslog output is enabled.
+	Enabled bool `mapstructure:"enabled"`
+	// Network is the transport protocol for delivering messages. It can be one of udp, tcp, or tls.
+	Network string `mapstructure:"network"`
+	// Address is the syslog server address.
+	Address string `mapstructure:"address"`
+	// Format is the syslog protocol format. It can be one of rfc5424 or rfc3164.
+	Format string `mapstructure:"format"`
+	// Framing determines how messages are delimited on stream transports. It can be one of octet-counting or newline.
+	Framing string `mapstructure:"framing"`
+	// Body determines the format of the message body. It can be one of template, json, cef, or leef.
+	Body string `mapstructure:"body"`
+	// Serializer indicates the serializer of the message body when the json body is used.
+	Serializer outputs.Serializer `mapstructure:"serializer"`
+	// Template is the template for rendering the message body.
+	Template string `mapstructure:"template"`
+	// Facility is the syslog facility.
+	Facility string `mapstructure:"facility"`
+	// Severity is the syslog severity.
+	Severity string `mapstructure:"severity"`
+	// AppName is the application name reported in syslog messages.
+	AppName string `mapstructure:"app-name"`
+	// Hostname overrides the host name reported in syslog messages.
+	Hostname string `mapstructure:"hostname"`
+	// Timeout specifies the connection and write timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// AddFlags registers persistent flags.
+func AddFlags(flags *pflag.FlagSet) {
+	flags.Bool(syslogEnabled, false, "Indicates if the syslog output is enabled")
+	flags.String(syslogNetwork, "udp", "Specifies the transport protocol for delivering messages (udp, tcp, tls)")
+	flags.String(syslogAddress, "localhost:514", "Represents the syslog server address")
+	flags.String(syslogFormat, rfc5424, "Specifies the syslog protocol format (rfc5424, rfc3164)")
+	flags.String(syslogFraming, octetCounting, "Determines how messages are delimited on TCP and TLS transports (octet-counting, newline)")
+	flags.String(syslogBody, bodyTemplate, "Determines the format of the message body (template, json, cef, leef)")
+	flags.String(syslogSerializer, string(outputs.JSON), "Indicates the event serializer type when the json body is used (json, ecs)")
+	flags.String(syslogTemplate, "", "Specifies the template for rendering the message body")
+	flags.String(syslogFacility, "local0", "Specifies the syslog facility")
+	flags.String(syslogSeverity, "info", "Specifies the syslog severity of events. Events that triggered a rule are reported with the warning severity")
+	flags.String(syslogAppName, "fibratus", "Specifies the application name reported in syslog messages")
+	flags.String(syslogHostname, "", "Overrides the host name reported in syslog messages")
+	flags.Duration(syslogTimeout, time.Second*5, "Specifies the connection and write timeout")
+	outputs.AddTLSFlags(flags, outputs.Syslog)
+}
+
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+var severities = map[string]int{
+	"emerg":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"warning": 4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+// withDefaults fills in the default values of unset options.
+func (c Config) withDefaults() Config {
+	if c.Network == "" {
+		c.Network = "udp"
+	}
+	if c.Format == "" {
+		c.Format = rfc5424
+	}
+	if c.Framing == "" {
+		c.Framing = octetCounting
+	}
+	if c.Body == "" {
+		c.Body = bodyTemplate
+	}
+	if c.Serializer == "" {
+		c.Serializer = outputs.JSON
+	}
+	if c.Facility == "" {
+		c.Facility = "local0"
+	}
+	if c.Severity == "" {
+		c.Severity = "info"
+	}
+	if c.AppName == "" {
+		c.AppName = "fibratus"
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	switch c.Network {
+	case "udp", "tcp", "tls":
+	default:
+		return fmt.Errorf("invalid network: %s", c.Network)
+	}
+	switch c.Format {
+	case rfc5424, rfc3164:
+	default:
+		return fmt.Errorf("invalid format: %s", c.Format)
+	}
+	switch c.Framing {
+	case octetCounting, newline:
+	default:
+		return fmt.Errorf("invalid framing: %s", c.Framing)
+	}
+	switch c.Body {
+	case bodyTemplate, bodyJSON, bodyCEF, bodyLEEF:
+	default:
+		return fmt.Errorf("invalid body format: %s", c.Body)
+	}
+	if c.Serializer == outputs.Protobuf {
+		return fmt.Errorf("%s serializer is not supported by syslog output", c.Serializer)
+	}
+	if err := c.Serializer.Validate(); err != nil {
+		return err
+	}
+	if _, ok := facilities[c.Facility]; !ok {
+		return fmt.Errorf("invalid facility: %s", c.Facility)
+	}
+	if _, ok := severities[c.Severity]; !ok {
+		return fmt.Errorf("invalid severity: %s", c.Severity)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/util/version"
+)
+
+const (
+	vendor  = "Fibratus"
+	product = "Fibratus"
+	// rfc5424Time is the timestamp format of RFC 5424 messages
+	rfc5424Time = "2006-01-02T15:04:05.000000Z07:00"
+	// leefTimeFormat is the format of the LEEF devTime attribute
+	leefTimeFormat = "MMM dd yyyy HH:mm:ss.SSS"
+	// leefTime is the Go layout matching leefTimeFormat
+	leefTime = "Jan 02 2006 15:04:05.000"
+)
+
+// message renders syslog messages from events.
+type message struct {
+	config    Config
+	formatter *kevent.Formatter
+	facility  int
+	severity  int
+	pid       int
+}
+
+func newMessage(config Config) (*message, error) {
+	m := &message{
+		config:   config,
+		facility: facilities[config.Facility],
+		severity: severities[config.Severity],
+		pid:      os.Getpid(),
+	}
+	if config.Body == bodyTemplate {
+		tmpl := config.Template
+		if tmpl == "" {
+			tmpl = defaultTemplate
+		}
+		var err error
+		m.formatter, err = kevent.NewFormatter(tmpl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// build renders the syslog message comprising the header and the body.
+func (m *message) build(kevt *kevent.Kevent) []byte {
+	severity := m.severity
+	// events that triggered a rule are reported
+	// with at least the warning severity
+	if isRuleMatch(kevt) && severity > severities["warning"] {
+		severity = severities["warning"]
+	}
+	pri := m.facility*8 + severity
+
+	hostname := m.config.Hostname
+	if hostname == "" {
+		hostname = kevt.Host
+	}
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	var b strings.Builder
+	switch m.config.Format {
+	case rfc3164:
+		fmt.Fprintf(&b, "<%d>%s %s %s[%d]: ", pri, kevt.Timestamp.Format(time.Stamp), hostname, m.config.AppName, m.pid)
+	default:
+		msgID := kevt.Name
+		if msgID == "" {
+			msgID = "-"
+		}
+		fmt.Fprintf(&b, "<%d>1 %s %s %s %d %s - ", pri, kevt.Timestamp.Format(rfc5424Time), hostname, m.config.AppName, m.pid, msgID)
+	}
+	b.Write(m.body(kevt))
+
+	return []byte(b.String())
+}
+
+func (m *message) body(kevt *kevent.Kevent) []byte {
+	switch m.config.Body {
+	case bodyJSON:
+		return m.config.Serializer.Marshal(kevt)
+	case bodyCEF:
+		return []byte(cef(kevt))
+	case bodyLEEF:
+		return []byte(leef(kevt))
+	default:
+		return m.formatter.Format(kevt)
+	}
+}
+
+func isRuleMatch(kevt *kevent.Kevent) bool {
+	return kevt.GetMetaAsString(kevent.RuleNameKey) != ""
+}
+
+// attr is the key/value pair of the CEF extension or the LEEF event attribute.
+type attr struct {
+	key   string
+	value string
+}
+
+// attrs collects the attributes shared by CEF and LEEF bodies. Keys
+// follow the CEF dictionary and are renamed for LEEF when necessary.
+func attrs(kevt *kevent.Kevent) []attr {
+	a := make([]attr, 0)
+	add := func(key, value string) {
+		if value != "" {
+			a = append(a, attr{key, value})
+		}
+	}
+	add("cat", string(kevt.Category))
+	add("act", kevt.Name)
+	add("dvchost", kevt.Host)
+	add("spid", strconv.FormatUint(uint64(kevt.PID), 10))
+	if ps := kevt.PS; ps != nil {
+		add("sproc", ps.Exe)
+		add("suser", ps.SID)
+	}
+	for _, kpar := range []struct{ name, key string }{
+		{kparams.NetSIP, "src"},
+		{kparams.NetDIP, "dst"},
+		{kparams.NetSport, "spt"},
+		{kparams.NetDport, "dpt"},
+		{kparams.FileName, "filePath"},
+	} {
+		if kevt.Kparams.Contains(kpar.name) {
+			add(kpar.key, kevt.GetParamAsString(kpar.name))
+		}
+	}
+	add("ruleName", kevt.GetMetaAsString(kevent.RuleNameKey))
+	add("ruleGroup", kevt.GetMetaAsString(kevent.RuleGroupKey))
+	add("msg", kevt.Kparams.String())
+	return a
+}
+
+// cefSeverity returns the CEF severity in the 0-10 range.
+func cefSeverity(kevt *kevent.Kevent) int {
+	if isRuleMatch(kevt) {
+		return 8
+	}
+	return 3
+}
+
+// cef renders the event in ArcSight Common Event Format.
+func cef(kevt *kevent.Kevent) string {
+	name := kevt.GetMetaAsString(kevent.RuleNameKey)
+	if name == "" {
+		name = kevt.Description
+	}
+	if name == "" {
+		name = kevt.Name
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		cefHeader(vendor),
+		cefHeader(product),
+		cefHeader(version.Get()),
+		cefHeader(kevt.Name),
+		cefHeader(name),
+		cefSeverity(kevt))
+	fmt.Fprintf(&b, "rt=%d", kevt.Timestamp.UnixMilli())
+	for _, a := range attrs(kevt) {
+		switch a.key {
+		case "ruleName":
+			fmt.Fprintf(&b, " cs1Label=ruleName cs1=%s", cefValue(a.value))
+		case "ruleGroup":
+			fmt.Fprintf(&b, " cs2Label=ruleGroup cs2=%s", cefValue(a.value))
+		default:
+			fmt.Fprintf(&b, " %s=%s", a.key, cefValue(a.value))
+		}
+	}
+	return b.String()
+}
+
+// leefKeys maps CEF keys to LEEF predefined attributes.
+var leefKeys = map[string]string{
+	"dvchost": "identHostName",
+	"spid":    "pid",
+	"sproc":   "proc",
+	"suser":   "usrName",
+	"spt":     "srcPort",
+	"dpt":     "dstPort",
+}
+
+// leef renders the event in IBM QRadar Log Event Extended Format.
+func leef(kevt *kevent.Kevent) string {
+	sev := 3
+	if isRuleMatch(kevt) {
+		sev = 8
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "LEEF:1.0|%s|%s|%s|%s|",
+		leefHeader(vendor),
+		leefHeader(product),
+		leefHeader(version.Get()),
+		leefHeader(kevt.Name))
+	fmt.Fprintf(&b, "devTime=%s\tdevTimeFormat=%s\tsev=%d", kevt.Timestamp.Format(leefTime), leefTimeFormat, sev)
+	for _, a := range attrs(kevt) {
+		key := a.key
+		if k, ok := leefKeys[key]; ok {
+			key = k
+		}
+		fmt.Fprintf(&b, "\t%s=%s", key, leefValue(a.value))
+	}
+	return b.String()
+}
+
+var (
+	cefHeaderReplacer  = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueReplacer   = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefHeaderReplacer = strings.NewReplacer(`|`, `\|`, "\r", " ", "\n", " ", "\t", " ")
+	leefValueReplacer  = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+func cefHeader(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return cefHeaderReplacer.Replace(s)
+}
+
+func cefValue(s string) string   { return cefValueReplacer.Replace(s) }
+func leefHeader(s string) string { return leefHeaderReplacer.Replace(s) }
+func leefValue(s string) string  { return leefValueReplacer.Replace(s) }
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/kevent/kparams"
+	"github.com/rabbitstack/fibratus/pkg/kevent/ktypes"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	pstypes "github.com/rabbitstack/fibratus/pkg/ps/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKevent() *kevent.Kevent {
+	ts, _ := time.Parse(time.RFC3339, "2023-05-03T15:04:05.323Z")
+	return &kevent.Kevent{
+		Type:        ktypes.ConnectTCPv4,
+		Tid:         2484,
+		PID:         859,
+		Seq:         2,
+		Name:        "Connect",
+		Category:    ktypes.Net,
+		Host:        "archrabbit",
+		Description: "Connects a socket to the remote peer",
+		Timestamp:   ts,
+		Kparams: kevent.Kparams{
+			kparams.NetDport: {Name: kparams.NetDport, Type: kparams.Uint16, Value: uint16(443)},
+			kparams.NetDIP:   {Name: kparams.NetDIP, Type: kparams.AnsiString, Value: "216.58.201.174"},
+		},
+		Metadata: map[kevent.MetadataKey]any{
+			kevent.RuleNameKey:  "Suspicious connection | C2",
+			kevent.RuleGroupKey: "command and control",
+		},
+		PS: &pstypes.PS{
+			PID: 859,
+			Exe: `C:\Windows\System32\rundll32.exe`,
+			SID: `NT AUTHORITY\SYSTEM`,
+		},
+	}
+}
+
+func TestRFC5424Message(t *testing.T) {
+	m, err := newMessage(Config{Format: rfc5424, Body: bodyTemplate, Template: "{{ .Type }} by {{ .Pid }}", Facility: "local0", Severity: "info", AppName: "fibratus"}.withDefaults())
+	require.NoError(t, err)
+
+	msg := string(m.build(newKevent()))
+	// local0 facility and warning severity
+	// because the event triggered a rule
+	assert.True(t, strings.HasPrefix(msg, "<132>1 2023-05-03T15:04:05.323000Z archrabbit fibratus "), msg)
+	assert.True(t, strings.HasSuffix(msg, " Connect - Connect by 859"), msg)
+}
+
+func TestRFC3164Message(t *testing.T) {
+	m, err := newMessage(Config{Format: rfc3164, Body: bodyTemplate, Template: "{{ .Type }}", Hostname: "edr", Facility: "auth", Severity: "notice", AppName: "fibratus"}.withDefaults())
+	require.NoError(t, err)
+
+	evt := newKevent()
+	evt.Metadata = make(map[kevent.MetadataKey]any)
+
+	msg := string(m.build(evt))
+	assert.True(t, strings.HasPrefix(msg, "<37>May  3 15:04:05 edr fibratus["), msg)
+	assert.True(t, strings.HasSuffix(msg, "]: Connect"), msg)
+}
+
+func TestJSONBody(t *testing.T) {
+	m, err := newMessage(Config{Body: bodyJSON}.withDefaults())
+	require.NoError(t, err)
+	msg := string(m.build(newKevent()))
+	assert.Contains(t, msg, ` - {"`)
+	assert.NotContains(t, msg, `"@timestamp"`)
+
+	m, err = newMessage(Config{Body: bodyJSON, Serializer: outputs.ECS}.withDefaults())
+	require.NoError(t, err)
+	msg = string(m.build(newKevent()))
+	assert.Contains(t, msg, `"@timestamp"`)
+}
+
+func TestCEF(t *testing.T) {
+	s := cef(newKevent())
+	assert.True(t, strings.HasPrefix(s, `CEF:0|Fibratus|Fibratus|`), s)
+	assert.Contains(t, s, `|Connect|Suspicious connection \| C2|8|rt=1683126245323 `)
+	assert.Contains(t, s, ` cat=net act=Connect dvchost=archrabbit spid=859`)
+	assert.Contains(t, s, ` sproc=C:\\Windows\\System32\\rundll32.exe suser=NT AUTHORITY\\SYSTEM`)
+	assert.Contains(t, s, ` dst=216.58.201.174 dpt=443`)
+	assert.Contains(t, s, ` cs1Label=ruleName cs1=Suspicious connection | C2 cs2Label=ruleGroup cs2=command and control`)
+	assert.Contains(t, s, ` msg=dip➜ 216.58.201.174, dport➜ 443`)
+}
+
+func TestLEEF(t *testing.T) {
+	s := leef(newKevent())
+	assert.True(t, strings.HasPrefix(s, `LEEF:1.0|Fibratus|Fibratus|`), s)
+	assert.Contains(t, s, "|Connect|devTime=May 03 2023 15:04:05.323\tdevTimeFormat=MMM dd yyyy HH:mm:ss.SSS\tsev=8")
+	assert.Contains(t, s, "\tidentHostName=archrabbit\tpid=859\tproc=C:\\Windows\\System32\\rundll32.exe\tusrName=NT AUTHORITY\\SYSTEM")
+	assert.Contains(t, s, "\tdst=216.58.201.174\tdstPort=443")
+	assert.Contains(t, s, "\truleName=Suspicious connection | C2\truleGroup=command and control")
+}
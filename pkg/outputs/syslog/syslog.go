@@ -0,0 +1,135 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"crypto/tls"
+	"expvar"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	tlsutil "github.com/rabbitstack/fibratus/pkg/util/tls"
+)
+
+var (
+	// syslogErrors counts syslog delivery errors
+	syslogErrors = expvar.NewInt("output.syslog.publish.errors")
+	// syslogMessages counts the total number of sent messages
+	syslogMessages = expvar.NewInt("output.syslog.publish.messages")
+)
+
+type syslog struct {
+	config    Config
+	tlsConfig *tls.Config
+	msg       *message
+	conn      net.Conn
+}
+
+func init() {
+	outputs.Register(outputs.Syslog, initSyslog)
+}
+
+func initSyslog(config outputs.Config) (outputs.OutputGroup, error) {
+	cfg, ok := config.Output.(Config)
+	if !ok {
+		return outputs.Fail(outputs.ErrInvalidConfig(outputs.Syslog, config.Output))
+	}
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return outputs.Fail(err)
+	}
+	msg, err := newMessage(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+	s := &syslog{config: cfg, msg: msg}
+	if cfg.Network == "tls" {
+		s.tlsConfig, err = tlsutil.MakeConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSCA, cfg.TLSInsecureSkipVerify)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+		if s.tlsConfig == nil {
+			s.tlsConfig = &tls.Config{InsecureSkipVerify: cfg.TLSInsecureSkipVerify}
+		}
+	}
+	return outputs.Success(s), nil
+}
+
+func (s *syslog) Connect() error {
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var err error
+	switch s.config.Network {
+	case "tls":
+		s.conn, err = tls.DialWithDialer(dialer, "tcp", s.config.Address, s.tlsConfig)
+	default:
+		s.conn, err = dialer.Dial(s.config.Network, s.config.Address)
+	}
+	return err
+}
+
+func (s *syslog) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// Publish sends a syslog message for each event in the batch. If
+// writing to the connection fails, the connection is reestablished
+// on the next publish attempt.
+func (s *syslog) Publish(batch *kevent.Batch) error {
+	if s.conn == nil {
+		if err := s.Connect(); err != nil {
+			syslogErrors.Add(1)
+			return err
+		}
+	}
+	for _, kevt := range batch.Events {
+		if s.config.Timeout > 0 {
+			_ = s.conn.SetWriteDeadline(time.Now().Add(s.config.Timeout))
+		}
+		if _, err := s.conn.Write(s.frame(s.msg.build(kevt))); err != nil {
+			syslogErrors.Add(1)
+			_ = s.Close()
+			return err
+		}
+		syslogMessages.Add(1)
+	}
+	return nil
+}
+
+// frame delimits the message on stream transports. Datagram
+// transports carry exactly one message per datagram.
+func (s *syslog) frame(msg []byte) []byte {
+	if s.config.Network == "udp" {
+		return msg
+	}
+	if s.config.Framing == newline {
+		return append(msg, '\n')
+	}
+	buf := make([]byte, 0, len(msg)+8)
+	buf = strconv.AppendInt(buf, int64(len(msg)), 10)
+	buf = append(buf, ' ')
+	return append(buf, msg...)
+}
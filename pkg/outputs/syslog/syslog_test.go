@@ -0,0 +1,147 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitstack/fibratus/pkg/kevent"
+	"github.com/rabbitstack/fibratus/pkg/outputs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSyslog(t *testing.T, config Config) *syslog {
+	out, err := initSyslog(outputs.Config{Type: outputs.Syslog, Output: config})
+	require.NoError(t, err)
+	require.Len(t, out.Clients, 1)
+	return out.Clients[0].(*syslog)
+}
+
+func TestPublishUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	s := newSyslog(t, Config{Network: "udp", Address: pc.LocalAddr().String(), Body: bodyCEF, Timeout: time.Second})
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	require.NoError(t, s.Publish(kevent.NewBatch(newKevent(), newKevent())))
+
+	buf := make([]byte, 4096)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, pc.SetReadDeadline(time.Now().Add(time.Second*2)))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		msg := string(buf[:n])
+		assert.True(t, strings.HasPrefix(msg, "<132>1 "), msg)
+		assert.Contains(t, msg, " - CEF:0|Fibratus|Fibratus|")
+	}
+}
+
+func TestPublishTCPFraming(t *testing.T) {
+	var tests = []struct {
+		framing string
+		read    func(r *bufio.Reader) (string, error)
+	}{
+		{
+			octetCounting,
+			func(r *bufio.Reader) (string, error) {
+				l, err := r.ReadString(' ')
+				if err != nil {
+					return "", err
+				}
+				n, err := strconv.Atoi(strings.TrimSpace(l))
+				if err != nil {
+					return "", err
+				}
+				buf := make([]byte, n)
+				_, err = io.ReadFull(r, buf)
+				return string(buf), err
+			},
+		},
+		{
+			newline,
+			func(r *bufio.Reader) (string, error) {
+				l, err := r.ReadString('\n')
+				return strings.TrimSuffix(l, "\n"), err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.framing, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer l.Close()
+
+			msgs := make(chan string, 2)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					msg, err := tt.read(r)
+					if err != nil {
+						return
+					}
+					msgs <- msg
+				}
+			}()
+
+			s := newSyslog(t, Config{Network: "tcp", Address: l.Addr().String(), Framing: tt.framing, Body: bodyLEEF, Format: rfc3164, Timeout: time.Second})
+			require.NoError(t, s.Connect())
+			defer s.Close()
+
+			require.NoError(t, s.Publish(kevent.NewBatch(newKevent(), newKevent())))
+
+			for i := 0; i < 2; i++ {
+				select {
+				case msg := <-msgs:
+					assert.True(t, strings.HasPrefix(msg, "<132>May  3 15:04:05 archrabbit fibratus["), msg)
+					assert.Contains(t, msg, "]: LEEF:1.0|Fibratus|Fibratus|")
+					assert.True(t, strings.HasSuffix(msg, "ruleGroup=command and control\tmsg=dip➜ 216.58.201.174, dport➜ 443"), msg)
+				case <-time.After(time.Second * 2):
+					t.Fatal("syslog message not received")
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := initSyslog(outputs.Config{Type: outputs.Syslog, Output: Config{Network: "quic"}})
+	require.Error(t, err)
+	_, err = initSyslog(outputs.Config{Type: outputs.Syslog, Output: Config{Body: "gelf"}})
+	require.Error(t, err)
+	_, err = initSyslog(outputs.Config{Type: outputs.Syslog, Output: Config{Facility: "local9"}})
+	require.Error(t, err)
+	_, err = initSyslog(outputs.Config{Type: outputs.Syslog, Output: Config{Body: bodyJSON, Serializer: outputs.Protobuf}})
+	require.Error(t, err)
+}
@@ -65,6 +65,7 @@ var (
 	procNtQueryMutant                        = modntdll.NewProc("NtQueryMutant")
 	procNtQueryObject                        = modntdll.NewProc("NtQueryObject")
 	procNtQueryVolumeInformationFile         = modntdll.NewProc("NtQueryVolumeInformationFile")
+	procNtSuspendProcess                     = modntdll.NewProc("NtSuspendProcess")
 	procNtUnmapViewOfSection                 = modntdll.NewProc("NtUnmapViewOfSection")
 	procRtlNtStatusToDosError                = modntdll.NewProc("RtlNtStatusToDosError")
 	procEnumDeviceDrivers                    = modpsapi.NewProc("EnumDeviceDrivers")
@@ -208,6 +209,14 @@ func NtQueryVolumeInformationFile(handle windows.Handle, ioStatusBlock *windows.
 	return
 }
 
+func NtSuspendProcess(process windows.Handle) (ntstatus error) {
+	r0, _, _ := syscall.Syscall(procNtSuspendProcess.Addr(), 1, uintptr(process), 0, 0)
+	if r0 != 0 {
+		ntstatus = windows.NTStatus(r0)
+	}
+	return
+}
+
 func NtUnmapViewOfSection(process windows.Handle, addr uintptr) (ntstatus error) {
 	r0, _, _ := syscall.Syscall(procNtUnmapViewOfSection.Addr(), 2, uintptr(process), uintptr(addr), 0)
 	if r0 != 0 {
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ahocorasick implements the Aho-Corasick automaton for matching
+// the text against a large set of byte patterns in a single pass.
+package ahocorasick
+
+type edge struct {
+	b    byte
+	next int32
+}
+
+type node struct {
+	edges []edge
+	// fail is the node of the longest proper suffix that is also in the trie
+	fail int32
+	// dict is the nearest node on the fail chain that terminates a pattern
+	dict int32
+	// depth is the length of the path from the root to this node
+	depth int32
+	// patterns contains indices of the patterns terminating in this node
+	patterns []int32
+}
+
+func (n *node) child(b byte) int32 {
+	for _, e := range n.edges {
+		if e.b == b {
+			return e.next
+		}
+	}
+	return -1
+}
+
+// Matcher is the automaton built from the set of patterns. The
+// matcher is immutable once built and is safe for concurrent use.
+type Matcher struct {
+	nodes []node
+	// root contains the transitions from the root node for all bytes
+	root [256]int32
+}
+
+// New builds the automaton for the given patterns.
+func New(patterns []string) *Matcher {
+	m := &Matcher{nodes: []node{{fail: 0, dict: -1}}}
+	for i, pat := range patterns {
+		n := int32(0)
+		for j := 0; j < len(pat); j++ {
+			next := m.nodes[n].child(pat[j])
+			if next < 0 {
+				next = int32(len(m.nodes))
+				m.nodes = append(m.nodes, node{dict: -1, depth: m.nodes[n].depth + 1})
+				m.nodes[n].edges = append(m.nodes[n].edges, edge{b: pat[j], next: next})
+			}
+			n = next
+		}
+		m.nodes[n].patterns = append(m.nodes[n].patterns, int32(i))
+	}
+
+	// compute fail and dictionary links in breadth-first order
+	queue := make([]int32, 0, len(m.nodes))
+	for _, e := range m.nodes[0].edges {
+		m.root[e.b] = e.next
+		if len(m.nodes[0].patterns) > 0 {
+			m.nodes[e.next].dict = 0
+		}
+		queue = append(queue, e.next)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, e := range m.nodes[n].edges {
+			fail := m.nodes[n].fail
+			for {
+				if next := m.nodes[fail].child(e.b); next >= 0 {
+					fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			child := &m.nodes[e.next]
+			child.fail = fail
+			if len(m.nodes[fail].patterns) > 0 {
+				child.dict = fail
+			} else {
+				child.dict = m.nodes[fail].dict
+			}
+			queue = append(queue, e.next)
+		}
+	}
+	return m
+}
+
+// step transitions the automaton from the node on the given byte.
+func (m *Matcher) step(n int32, b byte) int32 {
+	for n != 0 {
+		if next := m.nodes[n].child(b); next >= 0 {
+			return next
+		}
+		n = m.nodes[n].fail
+	}
+	return m.root[b]
+}
+
+// terminal determines if any pattern ends in the node.
+func (m *Matcher) terminal(n int32) bool {
+	return len(m.nodes[n].patterns) > 0 || m.nodes[n].dict >= 0
+}
+
+// Contains determines if any of the patterns occurs in the text.
+func (m *Matcher) Contains(s string) bool {
+	if m.terminal(0) {
+		return true
+	}
+	n := int32(0)
+	for i := 0; i < len(s); i++ {
+		n = m.step(n, s[i])
+		if m.terminal(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPrefix determines if the text begins with any of the patterns.
+func (m *Matcher) HasPrefix(s string) bool {
+	if m.terminal(0) {
+		return true
+	}
+	n := int32(0)
+	for i := 0; i < len(s); i++ {
+		n = m.step(n, s[i])
+		// the automaton fell off the path
+		// spelled by the text prefix
+		if m.nodes[n].depth != int32(i+1) {
+			return false
+		}
+		if len(m.nodes[n].patterns) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSuffix determines if the text ends with any of the patterns.
+func (m *Matcher) HasSuffix(s string) bool {
+	n := int32(0)
+	for i := 0; i < len(s); i++ {
+		n = m.step(n, s[i])
+	}
+	return m.terminal(n)
+}
+
+// Scan invokes the function for each pattern occurrence in the text.
+// The function receives the index of the pattern. Scanning stops when
+// the function returns true, in which case Scan returns true as well.
+func (m *Matcher) Scan(s string, fn func(pattern int) bool) bool {
+	if m.emit(0, fn) {
+		return true
+	}
+	n := int32(0)
+	for i := 0; i < len(s); i++ {
+		n = m.step(n, s[i])
+		if m.emit(n, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Matcher) emit(n int32, fn func(pattern int) bool) bool {
+	for ; n >= 0; n = m.nodes[n].dict {
+		for _, p := range m.nodes[n].patterns {
+			if fn(int(p)) {
+				return true
+			}
+		}
+	}
+	return false
+}
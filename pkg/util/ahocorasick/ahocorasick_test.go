@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ahocorasick
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	patterns := []string{
+		"\\windows\\system32\\",
+		"\\temp\\",
+		"rundll32.exe",
+		"he",
+		"she",
+		"his",
+		"hers",
+		"ž.dll",
+	}
+	m := New(patterns)
+
+	var tests = []string{
+		"",
+		"c:\\windows\\system32\\rundll32.exe",
+		"c:\\users\\admin\\appdata\\local\\temp\\dropper.exe",
+		"ushers",
+		"hishe",
+		"rundll32.ex",
+		"h",
+		"c:\\ž.dll",
+		"rundll32.exe -k",
+		"he said",
+		"xhers",
+	}
+
+	anyOf := func(s string, fn func(string, string) bool) bool {
+		for _, pat := range patterns {
+			if fn(s, pat) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, s := range tests {
+		assert.Equal(t, anyOf(s, strings.Contains), m.Contains(s), "contains %q", s)
+		assert.Equal(t, anyOf(s, strings.HasPrefix), m.HasPrefix(s), "prefix %q", s)
+		assert.Equal(t, anyOf(s, strings.HasSuffix), m.HasSuffix(s), "suffix %q", s)
+	}
+}
+
+func TestMatcherEmptyPattern(t *testing.T) {
+	m := New([]string{"cmd", ""})
+	assert.True(t, m.Contains(""))
+	assert.True(t, m.HasPrefix("powershell"))
+	assert.True(t, m.HasSuffix("powershell"))
+	assert.False(t, New(nil).Contains("powershell"))
+}
+
+func TestMatcherScan(t *testing.T) {
+	m := New([]string{"he", "she", "his", "hers"})
+	var found []int
+	assert.False(t, m.Scan("ushers", func(i int) bool {
+		found = append(found, i)
+		return false
+	}))
+	assert.ElementsMatch(t, []int{0, 1, 3}, found)
+	assert.True(t, m.Scan("ushers", func(i int) bool { return i == 1 }))
+}
+
+func BenchmarkMatcherContains(b *testing.B) {
+	patterns := make([]string, 0, 500)
+	for i := 0; i < 500; i++ {
+		patterns = append(patterns, strings.Repeat(string(rune('a'+i%26)), 1+i%7)+"\\evil.exe")
+	}
+	m := New(patterns)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.Contains("c:\\windows\\system32\\svchost.exe -k rpcss -p")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2021-2022 by Nedim Sabic Sabic
+ * https://www.fibratus.io
+ * All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wildcard
+
+import (
+	"unicode/utf8"
+
+	"github.com/rabbitstack/fibratus/pkg/util/ahocorasick"
+)
+
+// PatternSet matches the text against multiple wildcard patterns at once.
+// Every pattern is anchored by its longest literal segment, and anchors of
+// all patterns are combined into a single Aho-Corasick automaton. Scanning
+// the text with the automaton yields the patterns whose anchors occur in the
+// text, so only those patterns are fully matched. Patterns without literal
+// segments are always matched.
+type PatternSet struct {
+	patterns []Pattern
+	// anchored contains the pattern index for each anchor in the automaton
+	anchored   []int
+	unanchored []int
+	anchors    *ahocorasick.Matcher
+	matchAll   bool
+}
+
+// CompileSet builds the pattern set from the given patterns.
+func CompileSet(patterns []string) *PatternSet {
+	s := &PatternSet{patterns: make([]Pattern, len(patterns))}
+	anchors := make([]string, 0, len(patterns))
+	for i, pattern := range patterns {
+		if pattern == "*" {
+			s.matchAll = true
+		}
+		s.patterns[i] = Compile(pattern)
+		anchor := longestLiteral(pattern)
+		if anchor == "" {
+			s.unanchored = append(s.unanchored, i)
+			continue
+		}
+		anchors = append(anchors, anchor)
+		s.anchored = append(s.anchored, i)
+	}
+	if len(anchors) > 0 {
+		s.anchors = ahocorasick.New(anchors)
+	}
+	return s
+}
+
+// Match determines if the text satisfies any of the patterns
+// in the set. The outcome is the same as matching each pattern
+// individually.
+func (s *PatternSet) Match(name string) bool {
+	if s.matchAll {
+		return true
+	}
+	for _, i := range s.unanchored {
+		if s.patterns[i].Match(name) {
+			return true
+		}
+	}
+	if s.anchors == nil {
+		return false
+	}
+	// the anchor can occur multiple times in the text,
+	// but each candidate pattern is matched only once
+	var buf [8]uint64
+	seen := buf[:]
+	if n := (len(s.anchored) + 63) / 64; n > len(buf) {
+		seen = make([]uint64, n)
+	}
+	return s.anchors.Scan(name, func(i int) bool {
+		if seen[i/64]&(1<<(i%64)) != 0 {
+			return false
+		}
+		seen[i/64] |= 1 << (i % 64)
+		return s.patterns[s.anchored[i]].Match(name)
+	})
+}
+
+// Len returns the number of patterns in the set.
+func (s *PatternSet) Len() int { return len(s.patterns) }
+
+// longestLiteral returns the longest pattern segment that
+// doesn't contain wildcards. Such segment must appear in
+// every text matched by the pattern. The replacement char
+// also matches invalid UTF-8 sequences in the text, so it
+// can't be a part of the literal.
+func longestLiteral(pattern string) string {
+	var literal string
+	start := 0
+	for i := 0; i < len(pattern); {
+		r, n := utf8.DecodeRuneInString(pattern[i:])
+		if r == '*' || r == '?' || r == utf8.RuneError {
+			if i-start > len(literal) {
+				literal = pattern[start:i]
+			}
+			start = i + n
+		}
+		i += n
+	}
+	if len(pattern)-start > len(literal) {
+		literal = pattern[start:]
+	}
+	return literal
+}
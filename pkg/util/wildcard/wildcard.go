@@ -16,6 +16,8 @@
 
 package wildcard
 
+import "unicode/utf8"
+
 // Match -  finds whether the text matches/satisfies the pattern string.
 // supports  '*' and '?' wildcards in the pattern string.
 // unlike path.Match(), considers a path as a flat name space while matching the pattern.
@@ -51,3 +53,67 @@ func deepMatchRune(str, pattern []rune, simple bool) bool {
 	}
 	return len(str) == 0 && len(pattern) == 0
 }
+
+// Pattern is the wildcard pattern prepared for repeated matching.
+type Pattern struct {
+	raw   string
+	runes []rune
+}
+
+// Compile prepares the pattern for matching. Matching the compiled pattern
+// yields the same result as Match, but the pattern is decoded only once and
+// the text is scanned without allocating.
+func Compile(pattern string) Pattern {
+	return Pattern{raw: pattern, runes: []rune(pattern)}
+}
+
+// String returns the original pattern string.
+func (p Pattern) String() string { return p.raw }
+
+// Match finds whether the text satisfies the compiled pattern.
+func (p Pattern) Match(name string) bool {
+	if p.raw == "" {
+		return name == ""
+	}
+	if p.raw == "*" {
+		return true
+	}
+	pattern := p.runes
+	// the position of the last seen star in the pattern
+	// and the text position the star was tried against
+	star, mark := -1, 0
+	i, j := 0, 0
+	for i < len(pattern) || j < len(name) {
+		if i < len(pattern) {
+			switch c := pattern[i]; c {
+			case '*':
+				star, mark = i, j
+				i++
+				continue
+			case '?':
+				if j < len(name) {
+					_, n := utf8.DecodeRuneInString(name[j:])
+					i, j = i+1, j+n
+					continue
+				}
+			default:
+				if j < len(name) {
+					r, n := utf8.DecodeRuneInString(name[j:])
+					if r == c {
+						i, j = i+1, j+n
+						continue
+					}
+				}
+			}
+		}
+		// backtrack and let the last star consume one more rune
+		if star >= 0 && mark < len(name) {
+			_, n := utf8.DecodeRuneInString(name[mark:])
+			mark += n
+			i, j = star+1, mark
+			continue
+		}
+		return false
+	}
+	return true
+}
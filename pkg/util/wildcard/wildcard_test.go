@@ -13,7 +13,9 @@
 package wildcard
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
@@ -25,3 +27,133 @@ func TestMatch(t *testing.T) {
 	assert.True(t, Match("HKEY_USERS\\*\\Environment\\windir", "HKEY_USERS\\S-1-5-21-2271034452-2606270099-984871569-1001\\Environment\\windir"))
 	assert.True(t, Match("C:\\Windows\\SoftwareDistribution\\*", "C:\\Windows\\SoftwareDistribution\\SLS\\7971F918-A847-4430-9279-4A52D1EFE18D\\sls.rar"))
 }
+
+func TestCompiledPatternMatch(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		name    string
+	}{
+		{"C:\\*\\lsass?.dmp", "C:\\Windows\\System32\\lsass2.dmp"},
+		{"C:\\*\\lsass?.dmp", "C:\\Windows\\System32\\lsass.dmp"},
+		{"C:\\ProgramData\\*.dll", "C:\\ProgramData\\Directory\\OneMoreDirectory\\mal.dll"},
+		{"C:\\ProgramData\\*.dll", "C:\\ProgramData\\Directory\\mal.exe"},
+		{"HKEY_USERS\\*\\Environment\\windir", "HKEY_USERS\\S-1-5-21-2271034452-2606270099-984871569-1001\\Environment\\windir"},
+		{"*", ""},
+		{"*", "cmd.exe"},
+		{"", ""},
+		{"", "cmd.exe"},
+		{"?", ""},
+		{"?", "ž"},
+		{"??", "ž"},
+		{"*?", ""},
+		{"**", ""},
+		{"*a*b*c", "xxaxxbxxc"},
+		{"*a*b*c", "xxaxxbxxcx"},
+		{"a*", "a"},
+		{"*a", "ba"},
+		{"*.exe", "cmd.exe.bak"},
+		{"c?d.exe", "cmd.exe"},
+		{"C:\\Users\\*\\Děsktop\\*", "C:\\Users\\admin\\Děsktop\\notes.txt"},
+		{"*\uFFFD*", "a\xffb"},
+		{"a?b", "a\xffb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.name, func(t *testing.T) {
+			assert.Equal(t, Match(tt.pattern, tt.name), Compile(tt.pattern).Match(tt.name))
+		})
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Match("C:\\Users\\*\\AppData\\*\\Temp\\*.exe", "C:\\Users\\admin\\AppData\\Local\\Temp\\svchost.exe")
+	}
+}
+
+func BenchmarkCompiledPatternMatch(b *testing.B) {
+	b.ReportAllocs()
+	p := Compile("C:\\Users\\*\\AppData\\*\\Temp\\*.exe")
+	for i := 0; i < b.N; i++ {
+		p.Match("C:\\Users\\admin\\AppData\\Local\\Temp\\svchost.exe")
+	}
+}
+
+func TestPatternSetMatch(t *testing.T) {
+	patterns := []string{
+		"?:\\Windows\\System32\\*.dll",
+		"*\\AppData\\Local\\Temp\\*.exe",
+		"*\\rundll32.exe *,#*",
+		"HKEY_USERS\\*\\Environment\\windir",
+		"*.s?r",
+		"??",
+		"*a*a*",
+		"*\uFFFD*",
+	}
+	set := CompileSet(patterns)
+	require.Equal(t, len(patterns), set.Len())
+
+	var names = []string{
+		"",
+		"ab",
+		"C:\\Windows\\System32\\kernel32.dll",
+		"C:\\Windows\\System32\\drivers\\etc\\hosts",
+		"C:\\Users\\admin\\AppData\\Local\\Temp\\svchost.exe",
+		"C:\\Windows\\system32\\rundll32.exe shell32.dll,#44",
+		"HKEY_USERS\\S-1-5-21-2271034452-2606270099-984871569-1001\\Environment\\windir",
+		"screensaver.scr",
+		"banana",
+		"cmd.exe",
+		"a\xffb",
+	}
+
+	for _, name := range names {
+		var expected bool
+		for _, pattern := range patterns {
+			if Match(pattern, name) {
+				expected = true
+				break
+			}
+		}
+		assert.Equal(t, expected, set.Match(name), name)
+	}
+
+	assert.True(t, CompileSet([]string{"*.dll", "*"}).Match("cmd.exe"))
+	assert.False(t, CompileSet(nil).Match("cmd.exe"))
+	assert.True(t, CompileSet([]string{""}).Match(""))
+}
+
+func TestLongestLiteral(t *testing.T) {
+	assert.Equal(t, "\\AppData\\Local\\Temp\\", longestLiteral("*\\AppData\\Local\\Temp\\*.exe"))
+	assert.Equal(t, "", longestLiteral("*?*"))
+	assert.Equal(t, "cmd.exe", longestLiteral("cmd.exe"))
+	assert.Equal(t, "bc", longestLiteral("a\xffbc"))
+}
+
+func BenchmarkPatternSetMatch(b *testing.B) {
+	patterns := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		patterns = append(patterns, fmt.Sprintf("?:\\Program Files\\Vendor%d\\*\\updater*.exe", i))
+	}
+	set := CompileSet(patterns)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		set.Match("C:\\Users\\admin\\AppData\\Local\\Temp\\svchost.exe")
+	}
+}
+
+func BenchmarkPatternsMatch(b *testing.B) {
+	patterns := make([]Pattern, 0, 200)
+	for i := 0; i < 200; i++ {
+		patterns = append(patterns, Compile(fmt.Sprintf("?:\\Program Files\\Vendor%d\\*\\updater*.exe", i)))
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, p := range patterns {
+			if p.Match("C:\\Users\\admin\\AppData\\Local\\Temp\\svchost.exe") {
+				break
+			}
+		}
+	}
+}
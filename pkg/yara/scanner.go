@@ -239,8 +239,12 @@ func (s scanner) send(ctx AlertContext) error {
 		return err
 	}
 
-	// fetch the alert sender that is specified in the config
-	sender := alertsender.Find(alertsender.ToType(s.config.AlertVia))
+	// fetch the alert sender that is specified in the config.
+	// The sender is looked up by instance name or sender type
+	sender := alertsender.FindByName(s.config.AlertVia)
+	if sender == nil {
+		sender = alertsender.Find(alertsender.ToType(s.config.AlertVia))
+	}
 	if sender == nil {
 		return fmt.Errorf("%q alert sender is not initialized", s.config.AlertVia)
 	}